@@ -0,0 +1,186 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/clearmatics/autonity/accounts/abi"
+	"github.com/clearmatics/autonity/accounts/abi/bind"
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus"
+	"github.com/clearmatics/autonity/consensus/ethash"
+	"github.com/clearmatics/autonity/contracts/autonity"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/params"
+)
+
+var errNoAutonityContract = errors.New("simulated chain has no autonity contract")
+
+// NewAutonitySimulatedBackendWithDatabase creates a new binding backend based on
+// the given database, simulating an Autonity chain: the Autonity contract built
+// from the given genesis configuration is deployed with the first block and its
+// finalization runs on every following one.
+func NewAutonitySimulatedBackendWithDatabase(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64, contract *params.AutonityContractGenesis, cacher *core.TxSenderCacher) *SimulatedBackend {
+	config := *params.AllEthashProtocolChanges
+	config.Tendermint = &params.TendermintConfig{}
+	config.AutonityContractConfig = contract.AddDefault()
+	if err := config.AutonityContractConfig.Validate(); err != nil {
+		panic(fmt.Errorf("invalid autonity contract: %v", err))
+	}
+	genesis := core.Genesis{Config: &config, GasLimit: gasLimit, Alloc: alloc, Mixhash: types.BFTDigest}
+
+	engine := &autonityEngine{Ethash: ethash.NewFaker()}
+	backend := newSimulatedBackend(database, &genesis, engine, cacher)
+	engine.bind(backend.blockchain)
+	backend.rollback()
+	return backend
+}
+
+// NewAutonitySimulatedBackend creates a new binding backend using a simulated
+// Autonity chain for testing purposes.
+func NewAutonitySimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64, contract *params.AutonityContractGenesis) *SimulatedBackend {
+	return NewAutonitySimulatedBackendWithDatabase(rawdb.NewMemoryDatabase(), alloc, gasLimit, contract, core.NewTxSenderCacher())
+}
+
+// AutonityContract returns a binding to the Autonity contract of the simulated
+// chain, through which tests may act as the operator, e.g. to change the
+// committee or the minimum gas price.
+func (b *SimulatedBackend) AutonityContract() (*bind.BoundContract, error) {
+	contract := b.blockchain.GetAutonityContract()
+	if contract == nil {
+		return nil, errNoAutonityContract
+	}
+	parsed, err := abi.JSON(strings.NewReader(contract.GetContractABI()))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(contract.Address(), parsed, b, b, b), nil
+}
+
+// minGasPrice returns the Autonity contract minimum gas price on top of the
+// current block, zero if there is no contract.
+func (b *SimulatedBackend) minGasPrice() *big.Int {
+	contract := b.blockchain.GetAutonityContract()
+	if contract == nil {
+		return new(big.Int)
+	}
+	statedb, err := b.blockchain.State()
+	if err != nil {
+		return new(big.Int)
+	}
+	price, err := contract.GetMinimumGasPrice(b.blockchain.CurrentBlock(), statedb)
+	if err != nil {
+		return new(big.Int)
+	}
+	return new(big.Int).SetUint64(price)
+}
+
+// checkAutonityRules verifies that a transaction meets the minimum gas price and
+// the submission permissions of the Autonity contract, as a node's pool would.
+func (b *SimulatedBackend) checkAutonityRules(sender common.Address, tx *types.Transaction) error {
+	contract := b.blockchain.GetAutonityContract()
+	if contract == nil {
+		return nil
+	}
+	if tx.GasPrice().Cmp(b.minGasPrice()) < 0 {
+		return core.ErrMinGasPrice
+	}
+	statedb, err := b.blockchain.State()
+	if err != nil {
+		return err
+	}
+	permissions, err := contract.GetPermissions(b.blockchain.CurrentBlock().Header(), statedb)
+	if err != nil {
+		return err
+	}
+	return permissions.Check(sender, tx.To() == nil)
+}
+
+// autonityEngine is a faked proof-of-work engine finalizing blocks the way the
+// Tendermint backend does, so the Autonity contract is deployed and run by the
+// simulated chain and the headers carry the committee it elects. Blocks use the
+// BFT digest for their hashes to cover the committee.
+type autonityEngine struct {
+	*ethash.Ethash
+	contract *autonity.Contract
+}
+
+// bind attaches the engine to the Autonity contract of the simulated chain. The
+// committee of the first block is the genesis one, as in the Tendermint backend.
+func (e *autonityEngine) bind(chain *core.BlockChain) {
+	e.contract = chain.GetAutonityContract()
+	e.contract.SavedCommitteeRetriever = func(number uint64) (types.Committee, error) {
+		parent := chain.GetHeaderByNumber(number - 1)
+		if parent == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		return parent.Committee, nil
+	}
+}
+
+// Finalize implements consensus.Engine, electing the committee of the block
+// without granting any reward.
+func (e *autonityEngine) Finalize(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB, txs []*types.Transaction, uncles []*types.Header) {
+	committee, err := e.committee(chain, header, statedb)
+	if err != nil {
+		log.Error("Failed to retrieve the committee", "number", header.Number, "err", err)
+		return
+	}
+	header.Root = statedb.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = types.CalcUncleHash(nil)
+	header.Committee = committee
+}
+
+// FinalizeAndAssemble implements consensus.Engine, running the finalization of
+// the Autonity contract and assembling the final block.
+func (e *autonityEngine) FinalizeAndAssemble(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	committee, err := e.committee(chain, header, statedb)
+	if err != nil {
+		return nil, err
+	}
+	if header.Number.Uint64() > 1 {
+		if err := e.contract.ApplyFinalize(txs, receipts, header, statedb); err != nil {
+			return nil, err
+		}
+	}
+	header.Root = statedb.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = types.CalcUncleHash(nil)
+	header.MixDigest = types.BFTDigest
+	header.Committee = committee
+
+	return types.NewBlock(header, txs, nil, receipts), nil
+}
+
+// committee deploys the Autonity contract with the first block and returns the
+// committee it elects for the given block.
+func (e *autonityEngine) committee(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) (types.Committee, error) {
+	if header.Number.Uint64() == 1 {
+		if _, err := e.contract.DeployAutonityContract(chain, header, statedb); err != nil {
+			return nil, err
+		}
+	}
+	return e.contract.ContractGetCommittee(chain, header, statedb)
+}
@@ -0,0 +1,355 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/clearmatics/autonity/accounts/abi"
+	"github.com/clearmatics/autonity/accounts/abi/bind"
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/hexutil"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/eth/tracers"
+	"github.com/clearmatics/autonity/p2p/enode"
+	"github.com/clearmatics/autonity/params"
+)
+
+// validatorUser returns a validator of the Autonity contract for the given key.
+func validatorUser(key *ecdsa.PrivateKey) params.User {
+	return params.User{
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+		Enode:   enode.NewV4(&key.PublicKey, net.ParseIP("127.0.0.1"), 30303, 30303).URLv4(),
+		Type:    params.UserValidator,
+		Stake:   1,
+	}
+}
+
+// committeeAddresses returns the sorted members of the head block committee.
+func committeeAddresses(t *testing.T, sim *SimulatedBackend) []common.Address {
+	header, err := sim.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve head header: %v", err)
+	}
+	var addresses []common.Address
+	for _, member := range header.Committee {
+		addresses = append(addresses, member.Address)
+	}
+	sort.Sort(common.Addresses(addresses))
+	return addresses
+}
+
+func TestAutonitySimulatedBackend(t *testing.T) {
+	operatorKey, _ := crypto.GenerateKey()
+	operator := bind.NewKeyedTransactor(operatorKey)
+
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+	}
+	genesis := &params.AutonityContractGenesis{
+		Operator:    operator.From,
+		MinGasPrice: 5,
+		Users:       []params.User{validatorUser(keys[0]), validatorUser(keys[1])},
+	}
+	alloc := core.GenesisAlloc{operator.From: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)}}
+
+	sim := NewAutonitySimulatedBackend(alloc, 10000000, genesis)
+	defer sim.Close()
+	sim.Commit()
+
+	// The contract is deployed with the first block and elects the genesis validators
+	contract, err := sim.AutonityContract()
+	if err != nil {
+		t.Fatalf("failed to bind autonity contract: %v", err)
+	}
+	address := sim.Blockchain().GetAutonityContract().Address()
+	if code, _ := sim.CodeAt(context.Background(), address, nil); len(code) == 0 {
+		t.Fatal("autonity contract not deployed")
+	}
+	want := []common.Address{genesis.Users[0].Address, genesis.Users[1].Address}
+	sort.Sort(common.Addresses(want))
+	if have := committeeAddresses(t, sim); !reflect.DeepEqual(have, want) {
+		t.Fatalf("committee mismatch: have %x, want %x", have, want)
+	}
+	// Transactions under the minimum gas price are refused
+	if price, _ := sim.SuggestGasPrice(context.Background()); price.Uint64() != 5 {
+		t.Fatalf("suggested gas price mismatch: have %v, want 5", price)
+	}
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, operatorKey)
+	if err := sim.SendTransaction(context.Background(), tx); err != core.ErrMinGasPrice {
+		t.Fatalf("error mismatch: have %v, want %v", err, core.ErrMinGasPrice)
+	}
+	// The operator changes the minimum gas price and the committee
+	if _, err := contract.Transact(operator, "setMinimumGasPrice", big.NewInt(10)); err != nil {
+		t.Fatalf("failed to set minimum gas price: %v", err)
+	}
+	sim.Commit()
+
+	var price *big.Int
+	if err := contract.Call(nil, &price, "getMinimumGasPrice"); err != nil {
+		t.Fatalf("failed to retrieve minimum gas price: %v", err)
+	}
+	if price.Uint64() != 10 {
+		t.Fatalf("minimum gas price mismatch: have %v, want 10", price)
+	}
+	if price, _ := sim.SuggestGasPrice(context.Background()); price.Uint64() != 10 {
+		t.Fatalf("suggested gas price mismatch: have %v, want 10", price)
+	}
+	added := validatorUser(keys[2])
+	if _, err := contract.Transact(operator, "addValidator", added.Address, big.NewInt(1), added.Enode); err != nil {
+		t.Fatalf("failed to add validator: %v", err)
+	}
+	sim.Commit()
+
+	want = append(want, added.Address)
+	sort.Sort(common.Addresses(want))
+	if have := committeeAddresses(t, sim); !reflect.DeepEqual(have, want) {
+		t.Fatalf("committee mismatch: have %x, want %x", have, want)
+	}
+}
+
+func TestAutonityTraceFinalize(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	operator := bind.NewKeyedTransactor(key)
+
+	genesis := &params.AutonityContractGenesis{
+		Operator: operator.From,
+		Users:    []params.User{validatorUser(key)},
+	}
+	alloc := core.GenesisAlloc{operator.From: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)}}
+
+	sim := NewAutonitySimulatedBackend(alloc, 10000000, genesis)
+	defer sim.Close()
+	sim.Commit()
+	sim.Commit()
+
+	// Replay the finalize call of the head block on top of its parent state
+	var (
+		chain    = sim.Blockchain()
+		contract = chain.GetAutonityContract()
+		block    = chain.CurrentBlock()
+		parent   = chain.GetBlockByHash(block.ParentHash())
+	)
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		t.Fatalf("failed to retrieve parent state: %v", err)
+	}
+	tracer, err := tracers.NewTracer("callTracer")
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	_, gas, failed, err := contract.TraceFinalize(nil, nil, block.Header(), statedb, tracer)
+	if err != nil || failed {
+		t.Fatalf("failed to trace finalize: err %v, failed %v", err, failed)
+	}
+	if root := statedb.IntermediateRoot(true); root != block.Root() {
+		t.Fatalf("state root mismatch: have %x, want %x", root, block.Root())
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace: %v", err)
+	}
+	var trace struct {
+		Type    string         `json:"type"`
+		From    common.Address `json:"from"`
+		To      common.Address `json:"to"`
+		GasUsed hexutil.Uint64 `json:"gasUsed"`
+		Input   hexutil.Bytes  `json:"input"`
+		Error   string         `json:"error"`
+	}
+	if err := json.Unmarshal(res, &trace); err != nil {
+		t.Fatalf("failed to decode trace: %v", err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(contract.GetContractABI()))
+	if err != nil {
+		t.Fatalf("failed to parse contract ABI: %v", err)
+	}
+	if trace.Type != "CALL" || trace.From != chain.Config().AutonityContractConfig.Deployer || trace.To != contract.Address() {
+		t.Errorf("call mismatch: have %s %x->%x", trace.Type, trace.From, trace.To)
+	}
+	if len(trace.Input) < 4 || !bytes.Equal(trace.Input[:4], parsed.Methods["finalize"].ID()) {
+		t.Errorf("input mismatch: have %x", trace.Input)
+	}
+	if uint64(trace.GasUsed) != gas || trace.Error != "" {
+		t.Errorf("result mismatch: have gas %d error %q, want gas %d", trace.GasUsed, trace.Error, gas)
+	}
+	// The first block deploys the contract instead of finalizing it
+	if _, _, _, err := contract.TraceFinalize(nil, nil, parent.Header(), statedb, tracer); err == nil {
+		t.Errorf("finalize of the first block traced")
+	}
+}
+
+// Tests that the calls made to the Autonity contract on behalf of its deployer
+// are recorded with the blocks they were made in.
+func TestAutonitySystemCalls(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	operator := bind.NewKeyedTransactor(key)
+
+	genesis := &params.AutonityContractGenesis{
+		Operator: operator.From,
+		Users:    []params.User{validatorUser(key)},
+	}
+	alloc := core.GenesisAlloc{operator.From: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)}}
+
+	sim := NewAutonitySimulatedBackend(alloc, 10000000, genesis)
+	defer sim.Close()
+	sim.Commit()
+
+	var (
+		chain    = sim.Blockchain()
+		contract = chain.GetAutonityContract()
+		deployer = chain.Config().AutonityContractConfig.Deployer
+	)
+	// The first block deploys the contract
+	calls := chain.GetSystemCallsByHash(chain.CurrentBlock().Hash())
+	if len(calls) != 1 {
+		t.Fatalf("deployment calls mismatch: have %d, want 1", len(calls))
+	}
+	if call := calls[0]; call.Type != types.SystemCallCreate || call.From != deployer || call.To != contract.Address() || call.Error != "" {
+		t.Errorf("deployment mismatch: have %s %x->%x, error %q", call.Type, call.From, call.To, call.Error)
+	}
+	if diff := calls[0].StateDiff; len(diff) != 2 || diff[1].Address != contract.Address() || diff[1].CodeHashAfter == diff[1].CodeHashBefore || len(diff[1].Storage) == 0 {
+		t.Errorf("deployment state changes mismatch: have %d accounts", len(diff))
+	}
+	// Finalizing a block without fees to redistribute doesn't change the state
+	sim.Commit()
+	if calls := chain.GetSystemCallsByHash(chain.CurrentBlock().Hash()); len(calls) != 0 {
+		t.Fatalf("finalization without fees recorded: %d calls", len(calls))
+	}
+	// Finalizing a block with fees redistributes them to the validator
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, key)
+	if err := sim.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	sim.Commit()
+
+	block := chain.CurrentBlock()
+	calls = chain.GetSystemCallsByHash(block.Hash())
+	if len(calls) != 1 {
+		t.Fatalf("finalization calls mismatch: have %d, want 1", len(calls))
+	}
+	call := calls[0]
+	if call.Type != types.SystemCallCall || call.Method != "finalize" || call.From != deployer || call.To != contract.Address() || call.Error != "" {
+		t.Errorf("finalization mismatch: have %s %s %x->%x, error %q", call.Type, call.Method, call.From, call.To, call.Error)
+	}
+	if call.BlockHash != block.Hash() || call.BlockNumber != block.NumberU64() || call.Hash != types.SystemCallHash(block.NumberU64(), 0) {
+		t.Errorf("derived fields mismatch: have block %x #%d, hash %x", call.BlockHash, call.BlockNumber, call.Hash)
+	}
+	if call.GasUsed == 0 || len(call.Output) == 0 {
+		t.Errorf("result mismatch: have gas %d, output %x", call.GasUsed, call.Output)
+	}
+	fees := big.NewInt(21000)
+	for _, diff := range call.StateDiff {
+		change := new(big.Int).Sub(diff.BalanceAfter, diff.BalanceBefore)
+		switch diff.Address {
+		case contract.Address():
+			if change.Cmp(new(big.Int).Neg(fees)) != 0 {
+				t.Errorf("contract balance change mismatch: have %v, want -%v", change, fees)
+			}
+		case operator.From:
+			if change.Cmp(fees) != 0 {
+				t.Errorf("validator balance change mismatch: have %v, want %v", change, fees)
+			}
+		}
+	}
+	if len(call.StateDiff) != 2 {
+		t.Errorf("finalization state changes mismatch: have %d accounts, want 2", len(call.StateDiff))
+	}
+}
+
+func TestAutonityChainEvents(t *testing.T) {
+	operatorKey, _ := crypto.GenerateKey()
+	operator := bind.NewKeyedTransactor(operatorKey)
+
+	genesis := &params.AutonityContractGenesis{
+		Operator: operator.From,
+		Users:    []params.User{validatorUser(operatorKey)},
+	}
+	alloc := core.GenesisAlloc{operator.From: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)}}
+
+	sim := NewAutonitySimulatedBackend(alloc, 10000000, genesis)
+	defer sim.Close()
+	sim.Commit()
+
+	var (
+		chain        = sim.Blockchain()
+		committees   = make(chan core.CommitteeEvent, 10)
+		gasPrices    = make(chan core.MinGasPriceEvent, 10)
+		stakes       = make(chan core.StakeEvent, 10)
+		committeeSub = chain.SubscribeCommitteeEvent(committees)
+		gasPriceSub  = chain.SubscribeMinGasPriceEvent(gasPrices)
+		stakeSub     = chain.SubscribeStakeEvent(stakes)
+	)
+	defer committeeSub.Unsubscribe()
+	defer gasPriceSub.Unsubscribe()
+	defer stakeSub.Unsubscribe()
+
+	contract, err := sim.AutonityContract()
+	if err != nil {
+		t.Fatalf("failed to bind autonity contract: %v", err)
+	}
+	// Changing the minimum gas price posts the new price
+	if _, err := contract.Transact(operator, "setMinimumGasPrice", big.NewInt(10)); err != nil {
+		t.Fatalf("failed to set minimum gas price: %v", err)
+	}
+	sim.Commit()
+
+	select {
+	case ev := <-gasPrices:
+		if ev.Price.Cmp(big.NewInt(10)) != 0 || ev.Block.Hash() != chain.CurrentBlock().Hash() {
+			t.Errorf("gas price event mismatch: have %v in #%d", ev.Price, ev.Block.NumberU64())
+		}
+	default:
+		t.Fatal("no gas price event")
+	}
+	if len(committees) != 0 || len(stakes) != 0 {
+		t.Errorf("unexpected events: %d committee, %d stake", len(committees), len(stakes))
+	}
+
+	// Adding a validator posts the new committee and the stake of the validator
+	key, _ := crypto.GenerateKey()
+	added := validatorUser(key)
+	if _, err := contract.Transact(operator, "addValidator", added.Address, big.NewInt(3), added.Enode); err != nil {
+		t.Fatalf("failed to add validator: %v", err)
+	}
+	sim.Commit()
+
+	select {
+	case ev := <-committees:
+		var addresses []common.Address
+		for _, member := range ev.Committee {
+			addresses = append(addresses, member.Address)
+		}
+		want := []common.Address{operator.From, added.Address}
+		sort.Sort(common.Addresses(want))
+		if !reflect.DeepEqual(addresses, want) {
+			t.Errorf("committee mismatch: have %x, want %x", addresses, want)
+		}
+	default:
+		t.Fatal("no committee event")
+	}
+	select {
+	case ev := <-stakes:
+		if ev.Account != added.Address || ev.Stake == nil || ev.Stake.Cmp(big.NewInt(3)) != 0 {
+			t.Errorf("stake event mismatch: have %x %v", ev.Account, ev.Stake)
+		}
+	default:
+		t.Fatal("no stake event")
+	}
+	if len(gasPrices) != 0 {
+		t.Errorf("unexpected gas price events: %d", len(gasPrices))
+	}
+}
@@ -39,6 +39,7 @@ const (
 	MimetypeDataWithValidator = "data/validator"
 	MimetypeTypedData         = "data/typed"
 	MimetypeTextPlain         = "text/plain"
+	MimetypeTendermint        = "application/x-tendermint-message"
 )
 
 // Wallet represents a software or hardware wallet that might contain one or more
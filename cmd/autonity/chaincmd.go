@@ -36,6 +36,7 @@ import (
 	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/eth/downloader"
+	"github.com/clearmatics/autonity/ethdb"
 	"github.com/clearmatics/autonity/event"
 	"github.com/clearmatics/autonity/log"
 	"github.com/clearmatics/autonity/trie"
@@ -71,6 +72,7 @@ It expects the genesis file as argument.`,
 			utils.GCModeFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
+			utils.ImportTrustedFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -78,7 +80,13 @@ The import command imports blocks from an RLP-encoded form. The form can be one
 with several RLP-encoded blocks, or several files can be used.
 
 If only one file is used, import error will result in failure. If several files are used,
-processing will proceed even if an individual RLP-file import failure occurs.`,
+processing will proceed even if an individual RLP-file import failure occurs.
+
+Archives exported with --archive are imported likewise. With --trusted, the blocks
+of an archive are only checked to be sealed by a quorum of their committee, as
+recorded in the archive, and written without execution up to the last state held
+by the archive. The later blocks are then executed. Blocks already present are
+skipped, so an interrupted import resumes where it stopped.`,
 	}
 	exportCommand = cli.Command{
 		Action:    utils.MigrateFlags(exportChain),
@@ -89,6 +97,8 @@ processing will proceed even if an individual RLP-file import failure occurs.`,
 			utils.DataDirFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
+			utils.ExportArchiveFlag,
+			utils.ExportStateIntervalFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -96,7 +106,30 @@ Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
-be gzipped.`,
+be gzipped.
+
+With --archive, the file is overwritten by an archive for disaster
+recovery, holding the blocks along with their receipts, the committees
+governing them and the states of every --stateinterval blocks and of the
+last block.`,
+	}
+	verifyChainCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifyChain),
+		Name:      "verify-chain",
+		Usage:     "Verify the committed seals and committees of the entire chain",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The verify-chain command checks that every block of the canonical chain was
+proposed by a member of the committee elected by its parent and sealed by a
+quorum of it, that every committee is well formed, and that the bodies and
+receipts match their headers. Transactions aren't executed.`,
 	}
 	importPreimagesCommand = cli.Command{
 		Action:    utils.MigrateFlags(importPreimages),
@@ -154,6 +187,23 @@ The first argument must be the directory containing the blockchain to download f
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 Remove blockchain and state databases`,
+	}
+	migratedbCommand = cli.Command{
+		Action:    utils.MigrateFlags(migrateDB),
+		Name:      "migratedb",
+		Usage:     "Migrate blockchain and state databases to another database engine",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.DBEngineFlag,
+			utils.CacheFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The migratedb command copies the full and light node databases into new ones using
+the engine given by --db.engine, and swaps them in. The ancient chain segments kept
+inside the full node database are moved along. The original databases are kept,
+suffixed by their engine, until removed by hand.`,
 	}
 	dumpCommand = cli.Command{
 		Action:    utils.MigrateFlags(dump),
@@ -298,14 +348,15 @@ func importChain(ctx *cli.Context) error {
 	}()
 	// Import the chain
 	start := time.Now()
+	trusted := ctx.GlobalBool(utils.ImportTrustedFlag.Name)
 
 	if len(ctx.Args()) == 1 {
-		if err := utils.ImportChain(chain, ctx.Args().First()); err != nil {
+		if err := utils.ImportChain(chain, ctx.Args().First(), trusted); err != nil {
 			log.Error("Import error", "err", err)
 		}
 	} else {
 		for _, arg := range ctx.Args() {
-			if err := utils.ImportChain(chain, arg); err != nil {
+			if err := utils.ImportChain(chain, arg, trusted); err != nil {
 				log.Error("Import error", "file", arg, "err", err)
 			}
 		}
@@ -314,17 +365,7 @@ func importChain(ctx *cli.Context) error {
 	fmt.Printf("Import done in %v.\n\n", time.Since(start))
 
 	// Output pre-compaction stats mostly to see the import trashing
-	stats, err := db.Stat("leveldb.stats")
-	if err != nil {
-		utils.Fatalf("Failed to read database stats: %v", err)
-	}
-	fmt.Println(stats)
-
-	ioStats, err := db.Stat("leveldb.iostats")
-	if err != nil {
-		utils.Fatalf("Failed to read database iostats: %v", err)
-	}
-	fmt.Println(ioStats)
+	showDatabaseStats(db)
 
 	// Print the memory statistics used by the importing
 	mem := new(runtime.MemStats)
@@ -342,23 +383,28 @@ func importChain(ctx *cli.Context) error {
 	// Compact the entire database to more accurately measure disk io and print the stats
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = db.Compact(nil, nil); err != nil {
+	if err := db.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 
-	stats, err = db.Stat("leveldb.stats")
-	if err != nil {
-		utils.Fatalf("Failed to read database stats: %v", err)
-	}
-	fmt.Println(stats)
+	showDatabaseStats(db)
+	return nil
+}
 
-	ioStats, err = db.Stat("leveldb.iostats")
-	if err != nil {
-		utils.Fatalf("Failed to read database iostats: %v", err)
+// showDatabaseStats prints the internal stats of the database, whichever engine
+// backs it.
+func showDatabaseStats(db ethdb.Database) {
+	var shown bool
+	for _, property := range []string{"leveldb.stats", "leveldb.iostats", "pebble.stats"} {
+		if stats, err := db.Stat(property); err == nil {
+			fmt.Println(stats)
+			shown = true
+		}
+	}
+	if !shown {
+		utils.Fatalf("Failed to read database stats")
 	}
-	fmt.Println(ioStats)
-	return nil
 }
 
 func exportChain(ctx *cli.Context) error {
@@ -373,7 +419,18 @@ func exportChain(ctx *cli.Context) error {
 
 	var err error
 	fp := ctx.Args().First()
-	if len(ctx.Args()) < 3 {
+	if ctx.GlobalBool(utils.ExportArchiveFlag.Name) {
+		first, last := uint64(0), chain.CurrentBlock().NumberU64()
+		if len(ctx.Args()) >= 3 {
+			if first, err = strconv.ParseUint(ctx.Args().Get(1), 10, 64); err != nil {
+				utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+			}
+			if last, err = strconv.ParseUint(ctx.Args().Get(2), 10, 64); err != nil {
+				utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+			}
+		}
+		err = utils.ExportArchive(chain, fp, first, last, ctx.GlobalUint64(utils.ExportStateIntervalFlag.Name))
+	} else if len(ctx.Args()) < 3 {
 		err = utils.ExportChain(chain, fp)
 	} else {
 		// This can be improved to allow for numbers larger than 9223372036854775807
@@ -395,6 +452,22 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
+// verifyChain checks the committed seals and committees of the whole chain.
+func verifyChain(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	start := time.Now()
+	if err := utils.VerifyChain(chainDb); err != nil {
+		utils.Fatalf("Chain verification failed: %v", err)
+	}
+	fmt.Printf("Verification done in %v\n", time.Since(start))
+	return nil
+}
+
 // importPreimages imports preimage data from the specified file.
 func importPreimages(ctx *cli.Context) error {
 	if len(ctx.Args()) < 1 {
@@ -447,13 +520,13 @@ func copyDb(ctx *cli.Context) error {
 	syncMode := *utils.GlobalTextMarshaler(ctx, utils.SyncModeFlag.Name).(*downloader.SyncMode)
 
 	var syncBloom *trie.SyncBloom
-	if syncMode == downloader.FastSync {
+	if syncMode == downloader.FastSync || syncMode == downloader.BFTSync {
 		syncBloom = trie.NewSyncBloom(uint64(ctx.GlobalInt(utils.CacheFlag.Name)/2), chainDb)
 	}
 	dl := downloader.New(0, chainDb, syncBloom, new(event.TypeMux), chain, nil, nil)
 
 	// Create a source peer to satisfy downloader requests from
-	db, err := rawdb.NewLevelDBDatabaseWithFreezer(ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name)/2, 256, ctx.Args().Get(1), "")
+	db, err := rawdb.NewDiskDatabaseWithFreezer("", ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name)/2, 256, ctx.Args().Get(1), "")
 	if err != nil {
 		return err
 	}
@@ -520,6 +593,77 @@ func removeDB(ctx *cli.Context) error {
 	return nil
 }
 
+func migrateDB(ctx *cli.Context) error {
+	engine := ctx.GlobalString(utils.DBEngineFlag.Name)
+	if engine == "" {
+		utils.Fatalf("The engine to migrate to must be given with --%s", utils.DBEngineFlag.Name)
+	}
+	stack, _ := makeConfigNode(ctx)
+	cache := ctx.GlobalInt(utils.CacheFlag.Name) / 2
+
+	for _, name := range []string{"chaindata", "lightchaindata"} {
+		if err := migrateDatabase(stack.ResolvePath(name), engine, cache); err != nil {
+			utils.Fatalf("Failed to migrate %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// migrateDatabase copies a database into a new one backed by the given engine,
+// moves the ancient chain segments kept inside it, and swaps the two.
+func migrateDatabase(path string, engine string, cache int) error {
+	current, err := rawdb.DatabaseEngine(path)
+	if err != nil {
+		return err
+	}
+	switch current {
+	case "":
+		log.Info("Database missing", "path", path)
+		return nil
+	case engine:
+		log.Info("Database already migrated", "path", path, "engine", engine)
+		return nil
+	}
+	migrated, backup := path+".migrating", path+"."+current
+	for _, leftover := range []string{migrated, backup} {
+		if common.FileExist(leftover) {
+			return fmt.Errorf("%s already exists, remove it first", leftover)
+		}
+	}
+	src, err := rawdb.NewKeyValueStore(current, path, cache, 256, "")
+	if err != nil {
+		return err
+	}
+	dst, err := rawdb.NewKeyValueStore(engine, migrated, cache, 256, "")
+	if err != nil {
+		src.Close()
+		return err
+	}
+	log.Info("Migrating database", "path", path, "from", current, "to", engine)
+	_, err = rawdb.MigrateKeyValueStore(src, dst)
+	src.Close()
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	// Move the ancient chain segments along, then swap the databases
+	if ancient := filepath.Join(path, "ancient"); common.FileExist(ancient) {
+		if err := os.Rename(ancient, filepath.Join(migrated, "ancient")); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(path, backup); err != nil {
+		return err
+	}
+	if err := os.Rename(migrated, path); err != nil {
+		return err
+	}
+	log.Info("Database migrated", "path", path, "engine", engine, "original", backup)
+	return nil
+}
+
 // confirmAndRemoveDB prompts the user for a last confirmation and removes the
 // folder if accepted.
 func confirmAndRemoveDB(database string, kind string) {
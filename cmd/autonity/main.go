@@ -42,6 +42,7 @@ import (
 	"github.com/clearmatics/autonity/log"
 	"github.com/clearmatics/autonity/metrics"
 	"github.com/clearmatics/autonity/node"
+	"github.com/clearmatics/autonity/tracing"
 	"github.com/elastic/gosigar"
 	cli "gopkg.in/urfave/cli.v1"
 )
@@ -66,6 +67,7 @@ var (
 		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
 		utils.AncientFlag,
+		utils.DBEngineFlag,
 		utils.KeyStoreDirFlag,
 		utils.ExternalSignerFlag,
 		utils.NoUSBFlag,
@@ -80,6 +82,7 @@ var (
 		utils.EthashDatasetsOnDiskFlag,
 		utils.TxPoolLocalsFlag,
 		utils.TxPoolNoLocalsFlag,
+		utils.TxPoolNoPriorityFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
 		utils.TxPoolPriceLimitFlag,
@@ -92,6 +95,8 @@ var (
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
+		utils.StateRetentionFlag,
+		utils.PruneBloomSizeFlag,
 		utils.LightServeFlag,
 		utils.LightLegacyServFlag,
 		utils.LightIngressFlag,
@@ -103,11 +108,15 @@ var (
 		utils.UltraLightFractionFlag,
 		utils.UltraLightOnlyAnnounceFlag,
 		utils.WhitelistFlag,
+		utils.BFTCheckpointFlag,
+		utils.PrivateManagerFlag,
+		utils.PrivateIdentityFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
 		utils.CacheGCFlag,
 		utils.CacheNoPrefetchFlag,
+		utils.CacheNoSnapshotFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
@@ -126,6 +135,8 @@ var (
 		utils.MinerLegacyExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerPipelineFlag,
+		utils.MinerPriorityGasFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -182,6 +193,12 @@ var (
 		utils.MetricsInfluxDBPasswordFlag,
 		utils.MetricsInfluxDBTagsFlag,
 	}
+
+	tracingFlags = []cli.Flag{
+		utils.TracingEnabledFlag,
+		utils.TracingFileFlag,
+		utils.TracingEndpointFlag,
+	}
 )
 
 func init() {
@@ -194,12 +211,16 @@ func init() {
 		initCommand,
 		importCommand,
 		exportCommand,
+		verifyChainCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
 		copydbCommand,
 		removedbCommand,
+		migratedbCommand,
 		dumpCommand,
 		inspectCommand,
+		// See snapshot.go:
+		snapshotCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -224,11 +245,13 @@ func init() {
 	app.Flags = append(app.Flags, consoleFlags...)
 	app.Flags = append(app.Flags, debug.Flags...)
 	app.Flags = append(app.Flags, metricsFlags...)
+	app.Flags = append(app.Flags, tracingFlags...)
 
 	app.Before = func(ctx *cli.Context) error {
 		return debug.Setup(ctx, "")
 	}
 	app.After = func(ctx *cli.Context) error {
+		tracing.Stop()
 		debug.Exit()
 		console.Stdin.Close() // Resets terminal mode.
 		return nil
@@ -282,6 +305,9 @@ func prepare(ctx *cli.Context) {
 	// Start metrics export if enabled
 	utils.SetupMetrics(ctx)
 
+	// Start tracing export if enabled
+	utils.SetupTracing(ctx)
+
 	// Start system runtime metrics collection
 	go metrics.CollectProcessMetrics(3 * time.Second)
 }
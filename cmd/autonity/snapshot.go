@@ -0,0 +1,103 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"time"
+
+	"github.com/clearmatics/autonity/cmd/utils"
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state/pruner"
+	"github.com/clearmatics/autonity/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:        "snapshot",
+		Usage:       "A set of commands based on the state of the chain",
+		ArgsUsage:   "",
+		Category:    "BLOCKCHAIN COMMANDS",
+		Description: "",
+		Subcommands: []cli.Command{
+			{
+				Name:      "prune-state",
+				Usage:     "Delete the state which isn't needed anymore under BFT finality",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(pruneState),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.CacheFlag,
+					utils.StateRetentionFlag,
+					utils.PruneBloomSizeFlag,
+				},
+				Description: `
+autonity snapshot prune-state
+
+Deletes the trie nodes and contract codes which aren't reachable from the
+states of the last committed blocks, the number of which is configured by
+--pruning.retention. As committed blocks are final, older states can never be
+needed again. Reachability is tracked by a bloom filter sized by
+--pruning.bloomsize, whose false positives only leave some garbage behind.
+
+The node must be stopped, cleanly so that its recent states were persisted.`,
+			},
+		},
+	}
+)
+
+// pruneState deletes the unreachable state of a stopped node.
+func pruneState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	config := rawdb.ReadChainConfig(chainDb, rawdb.ReadCanonicalHash(chainDb, 0))
+	if config == nil {
+		utils.Fatalf("Chain configuration missing, is the database initialized?")
+	}
+	if config.Tendermint == nil {
+		utils.Fatalf("Failed to prune state: %v", core.ErrNoFinality)
+	}
+	retention := uint64(core.TriesInMemory)
+	if ctx.GlobalIsSet(utils.StateRetentionFlag.Name) {
+		retention = ctx.GlobalUint64(utils.StateRetentionFlag.Name)
+	}
+	roots, err := pruner.RetainedRoots(chainDb, retention)
+	if err != nil {
+		utils.Fatalf("Failed to collect retained states: %v", err)
+	}
+	p, err := pruner.NewPruner(chainDb, ctx.GlobalUint64(utils.PruneBloomSizeFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to create state pruner: %v", err)
+	}
+	start := time.Now()
+	if err := p.Prune(roots, nil); err != nil {
+		utils.Fatalf("Failed to prune state: %v", err)
+	}
+	log.Info("Compacting database to reclaim the pruned space")
+	if err := chainDb.Compact(nil, nil); err != nil {
+		utils.Fatalf("Failed to compact database: %v", err)
+	}
+	log.Info("State pruning complete", "retained", len(roots), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
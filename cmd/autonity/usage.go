@@ -68,6 +68,7 @@ var AppHelpFlagGroups = []flagGroup{
 			configFileFlag,
 			utils.DataDirFlag,
 			utils.AncientFlag,
+			utils.DBEngineFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
 			utils.SmartCardDaemonPathFlag,
@@ -78,10 +79,15 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.SyncModeFlag,
 			utils.ExitWhenSyncedFlag,
 			utils.GCModeFlag,
+			utils.StateRetentionFlag,
+			utils.PruneBloomSizeFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
+			utils.BFTCheckpointFlag,
+			utils.PrivateManagerFlag,
+			utils.PrivateIdentityFlag,
 		},
 	},
 	{
@@ -119,6 +125,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.TxPoolLocalsFlag,
 			utils.TxPoolNoLocalsFlag,
+			utils.TxPoolNoPriorityFlag,
 			utils.TxPoolJournalFlag,
 			utils.TxPoolRejournalFlag,
 			utils.TxPoolPriceLimitFlag,
@@ -138,6 +145,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheTrieFlag,
 			utils.CacheGCFlag,
 			utils.CacheNoPrefetchFlag,
+			utils.CacheNoSnapshotFlag,
 		},
 	},
 	{
@@ -206,6 +214,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerfiyFlag,
+			utils.MinerPipelineFlag,
+			utils.MinerPriorityGasFlag,
 		},
 	},
 	{
@@ -234,6 +244,10 @@ var AppHelpFlagGroups = []flagGroup{
 		Name:  "METRICS AND STATS",
 		Flags: metricsFlags,
 	},
+	{
+		Name:  "TRACING",
+		Flags: tracingFlags,
+	},
 	{
 		Name: "DEPRECATED",
 		Flags: []cli.Flag{
@@ -111,6 +111,10 @@ var (
 		Usage: "File used for writing new 4byte-identifiers submitted via API",
 		Value: "./4byte-custom.json",
 	}
+	autonityNodeFlag = cli.StringFlag{
+		Name:  "autonity.node",
+		Usage: "RPC endpoint of an Autonity node to retrieve the Autonity contract address and upgraded ABI from",
+	}
 	auditLogFlag = cli.StringFlag{
 		Name:  "auditlog",
 		Usage: "File used to emit audit logs. Set to \"\" to disable",
@@ -215,6 +219,7 @@ func init() {
 		rpcPortFlag,
 		signerSecretFlag,
 		customDBFlag,
+		autonityNodeFlag,
 		auditLogFlag,
 		ruleFlag,
 		stdiouiFlag,
@@ -453,9 +458,22 @@ func signer(c *cli.Context) error {
 	embeds, locals := db.Size()
 	log.Info("Loaded 4byte database", "embeds", embeds, "locals", locals, "local", fourByteLocal)
 
+	// Follow the Autonity contract upgrades if a node is configured
+	if endpoint := c.GlobalString(autonityNodeFlag.Name); endpoint != "" {
+		client, err := rpc.Dial(endpoint)
+		if err != nil {
+			utils.Fatalf("Could not connect to the Autonity node: %v", err)
+		}
+		if err := db.FollowAutonityContract(client); err != nil {
+			utils.Fatalf("Could not retrieve the Autonity contract: %v", err)
+		}
+		log.Info("Following the Autonity contract", "node", endpoint)
+	}
+
 	var (
 		api       core.ExternalAPI
 		pwStorage storage.Storage = &storage.NoStorage{}
+		csStorage storage.Storage
 	)
 
 	configDir := c.GlobalString(configdirFlag.Name)
@@ -468,11 +486,13 @@ func signer(c *cli.Context) error {
 		pwkey := crypto.Keccak256([]byte("credentials"), stretchedKey)
 		jskey := crypto.Keccak256([]byte("jsstorage"), stretchedKey)
 		confkey := crypto.Keccak256([]byte("config"), stretchedKey)
+		cskey := crypto.Keccak256([]byte("consensus"), stretchedKey)
 
 		// Initialize the encrypted storages
 		pwStorage = storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "credentials.json"), pwkey)
 		jsStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "jsstorage.json"), jskey)
 		configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confkey)
+		csStorage = storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "consensus.json"), cskey)
 
 		// Do we have a rule-file?
 		if ruleFile := c.GlobalString(ruleFlag.Name); ruleFile != "" {
@@ -509,7 +529,7 @@ func signer(c *cli.Context) error {
 	log.Info("Starting signer", "chainid", chainId, "keystore", ksLoc,
 		"light-kdf", lightKdf, "advanced", advanced)
 	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath)
-	apiImpl := core.NewSignerAPI(am, chainId, nousb, ui, db, advanced, pwStorage)
+	apiImpl := core.NewSignerAPI(am, chainId, nousb, ui, db, advanced, pwStorage, csStorage)
 
 	// Establish the bidirectional communication, by creating a new UI backend and registering
 	// it with the UI.
@@ -541,7 +561,7 @@ func signer(c *cli.Context) error {
 
 		// start http server
 		httpEndpoint := fmt.Sprintf("%s:%d", c.GlobalString(utils.RPCListenAddrFlag.Name), c.Int(rpcPortFlag.Name))
-		listener, _, err := rpc.StartHTTPEndpoint(httpEndpoint, rpcAPI, []string{"account"}, cors, vhosts, rpc.DefaultHTTPTimeouts)
+		listener, _, err := rpc.StartHTTPEndpoint(httpEndpoint, rpcAPI, []string{"account"}, cors, vhosts, rpc.DefaultHTTPTimeouts, nil)
 		if err != nil {
 			utils.Fatalf("Could not start RPC api: %v", err)
 		}
@@ -0,0 +1,563 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus"
+	"github.com/clearmatics/autonity/consensus/tendermint/backend"
+	"github.com/clearmatics/autonity/consensus/tendermint/config"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/rlp"
+)
+
+// Kinds of the archive entries. An archive starts with its header, followed by
+// the blocks in ascending order. A committee entry precedes the first block it
+// governs, at every epoch start and whenever the committee changes. The state
+// of a block may follow it as node entries, closed by a state entry.
+const (
+	archiveHeaderKind uint64 = iota
+	archiveCommitteeKind
+	archiveBlockKind
+	archiveNodesKind
+	archiveStateKind
+)
+
+const (
+	archiveMagic   = "autonity-archive"
+	archiveVersion = 1
+
+	archiveNodesPerEntry = 1024 // Number of state nodes bundled in a single archive entry
+	statsReportLimit     = 8 * time.Second
+)
+
+var (
+	errArchiveGenesis     = errors.New("archive of another network")
+	errArchiveRequired    = errors.New("trusted import requires an archive")
+	errCommitteeMismatch  = errors.New("committee differs from the archived one")
+	errNotCommitteeMember = errors.New("block proposed by a non committee member")
+	errEmptyCommittee     = errors.New("empty committee")
+	errDuplicateMember    = errors.New("duplicate committee member")
+	errNoVotingPower      = errors.New("committee member without voting power")
+)
+
+// archiveEntry is the envelope of every item of an archive.
+type archiveEntry struct {
+	Kind uint64
+	Data rlp.RawValue
+}
+
+type archiveHeader struct {
+	Magic   string
+	Version uint64
+	Genesis common.Hash
+}
+
+type archiveCommittee struct {
+	Number    uint64 // First block governed by the committee
+	Committee types.Committee
+}
+
+type archiveBlock struct {
+	Block    *types.Block
+	Receipts []*types.ReceiptForStorage
+}
+
+type archiveNodes struct {
+	Number uint64
+	Nodes  [][]byte // State trie nodes and contract codes
+}
+
+type archiveState struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+func writeArchiveEntry(w io.Writer, kind uint64, data interface{}) error {
+	blob, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, &archiveEntry{Kind: kind, Data: blob})
+}
+
+// decodeArchiveHeader tells whether an exported item is the header of an
+// archive, as opposed to the first block of a plain export.
+func decodeArchiveHeader(item []byte) (*archiveHeader, bool) {
+	var entry archiveEntry
+	if err := rlp.DecodeBytes(item, &entry); err != nil || entry.Kind != archiveHeaderKind {
+		return nil, false
+	}
+	header := new(archiveHeader)
+	if err := rlp.DecodeBytes(entry.Data, header); err != nil || header.Magic != archiveMagic {
+		return nil, false
+	}
+	return header, true
+}
+
+// ExportArchive exports a range of blocks into an archive, truncating any data
+// already present in the file. The archive records the committees along with
+// the blocks and their receipts, and the state of every interval-th block as
+// well as of the last one (interval 0 only exports the latter).
+func ExportArchive(chain *core.BlockChain, fn string, first uint64, last uint64, interval uint64) error {
+	tendermint := chain.Config().Tendermint
+	if tendermint == nil {
+		return core.ErrNoFinality
+	}
+	if first > last {
+		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
+	}
+	log.Info("Exporting blockchain archive", "file", fn, "first", first, "last", last)
+
+	// Open the file handle and potentially wrap with a gzip stream
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	header := &archiveHeader{Magic: archiveMagic, Version: archiveVersion, Genesis: chain.Genesis().Hash()}
+	if err := writeArchiveEntry(writer, archiveHeaderKind, header); err != nil {
+		return err
+	}
+	var (
+		committee types.Committee
+		start     = time.Now()
+		reported  = time.Now()
+	)
+	for nr := first; nr <= last; nr++ {
+		block := chain.GetBlockByNumber(nr)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: not found", nr)
+		}
+		// Blocks are governed by the committee elected by their parent
+		if nr > 0 {
+			parent := chain.GetHeader(block.ParentHash(), nr-1)
+			if parent == nil {
+				return fmt.Errorf("export failed on #%d: parent not found", nr)
+			}
+			epoch := tendermint.Epoch > 0 && nr%tendermint.Epoch == 0
+			if committee == nil || epoch || !sameCommittee(committee, parent.Committee) {
+				committee = parent.Committee
+				if err := writeArchiveEntry(writer, archiveCommitteeKind, &archiveCommittee{Number: nr, Committee: committee}); err != nil {
+					return err
+				}
+			}
+		}
+		receipts := chain.GetReceiptsByHash(block.Hash())
+		stored := make([]*types.ReceiptForStorage, len(receipts))
+		for i, receipt := range receipts {
+			stored[i] = (*types.ReceiptForStorage)(receipt)
+		}
+		if err := writeArchiveEntry(writer, archiveBlockKind, &archiveBlock{Block: block, Receipts: stored}); err != nil {
+			return err
+		}
+		if nr == last || (interval > 0 && nr%interval == 0) {
+			if err := exportArchiveState(writer, chain, block); err != nil {
+				return fmt.Errorf("export failed on #%d state: %v", nr, err)
+			}
+		}
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Exporting blocks", "exported", nr-first, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	log.Info("Exported blockchain archive", "file", fn, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportArchiveState writes the trie nodes and contract codes of the state of a
+// block. States which were garbage collected are skipped.
+func exportArchiveState(w io.Writer, chain *core.BlockChain, block *types.Block) error {
+	if !chain.HasState(block.Root()) {
+		log.Warn("Skipping unavailable state", "number", block.Number(), "root", block.Root())
+		return nil
+	}
+	db := chain.StateCache()
+	statedb, err := state.New(block.Root(), db)
+	if err != nil {
+		return err
+	}
+	var (
+		it    = state.NewNodeIterator(statedb)
+		nodes [][]byte
+	)
+	for it.Next() {
+		if it.Hash == (common.Hash{}) {
+			continue
+		}
+		blob, err := db.TrieDB().Node(it.Hash)
+		if err != nil {
+			if blob, err = db.ContractCode(common.Hash{}, it.Hash); err != nil {
+				return err
+			}
+		}
+		if nodes = append(nodes, blob); len(nodes) == archiveNodesPerEntry {
+			if err := writeArchiveEntry(w, archiveNodesKind, &archiveNodes{Number: block.NumberU64(), Nodes: nodes}); err != nil {
+				return err
+			}
+			nodes = nil
+		}
+	}
+	if it.Error != nil {
+		return it.Error
+	}
+	if len(nodes) > 0 {
+		if err := writeArchiveEntry(w, archiveNodesKind, &archiveNodes{Number: block.NumberU64(), Nodes: nodes}); err != nil {
+			return err
+		}
+	}
+	return writeArchiveEntry(w, archiveStateKind, &archiveState{Number: block.NumberU64(), Hash: block.Hash()})
+}
+
+// importArchive imports the entries of an archive following its header. A full
+// import runs every block through the consensus engine and the EVM, like plain
+// exports. A trusted import only checks the committed seals against the
+// archived committees and writes the blocks as they are, their state being
+// taken from the archive. The blocks past the last archived state are executed.
+//
+// Blocks already present in the database are skipped, so that an interrupted
+// import can be resumed by running it again.
+func importArchive(chain *core.BlockChain, header *archiveHeader, stream *rlp.Stream, trusted bool, interrupted func() bool) error {
+	if header.Version != archiveVersion {
+		return fmt.Errorf("unsupported archive version %d", header.Version)
+	}
+	if header.Genesis != chain.Genesis().Hash() {
+		return errArchiveGenesis
+	}
+	var policy config.ProposerPolicy
+	if trusted {
+		tendermint := chain.Config().Tendermint
+		if tendermint == nil {
+			return core.ErrNoFinality
+		}
+		policy = config.ProposerPolicy(tendermint.ProposerPolicy)
+	}
+	var (
+		committee types.Committee
+		parent    *types.Header
+		blocks    types.Blocks
+		receipts  []types.Receipts
+	)
+	flush := func() error {
+		if len(blocks) == 0 {
+			return nil
+		}
+		last := blocks[len(blocks)-1].NumberU64()
+		if trusted {
+			if _, err := chain.InsertTrustedChain(blocks, receipts); err != nil {
+				return fmt.Errorf("invalid block %d: %v", last, err)
+			}
+		} else if missing := missingBlocks(chain, blocks); len(missing) > 0 {
+			if _, err := chain.InsertChain(missing); err != nil {
+				return fmt.Errorf("invalid block %d: %v", last, err)
+			}
+		}
+		blocks, receipts = nil, nil
+		return nil
+	}
+	for {
+		if interrupted() {
+			return fmt.Errorf("interrupted")
+		}
+		var entry archiveEntry
+		if err := stream.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		switch entry.Kind {
+		case archiveCommitteeKind:
+			var c archiveCommittee
+			if err := rlp.DecodeBytes(entry.Data, &c); err != nil {
+				return err
+			}
+			if err := verifyCommittee(c.Committee); err != nil {
+				return fmt.Errorf("committee of block %d: %v", c.Number, err)
+			}
+			committee = c.Committee
+
+		case archiveBlockKind:
+			var b archiveBlock
+			if err := rlp.DecodeBytes(entry.Data, &b); err != nil {
+				return err
+			}
+			block, number := b.Block, b.Block.NumberU64()
+			if number == 0 {
+				if block.Hash() != chain.Genesis().Hash() {
+					return errArchiveGenesis
+				}
+				parent = block.Header()
+				continue
+			}
+			if !trusted {
+				if blocks = append(blocks, block); len(blocks) == importBatchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			// Trusted blocks are written without execution, vouched for by the
+			// committee of their parent
+			if parent == nil {
+				if parent = chain.GetHeaderByHash(block.ParentHash()); parent == nil {
+					return fmt.Errorf("block %d: %v", number, consensus.ErrUnknownAncestor)
+				}
+			}
+			if !sameCommittee(parent.Committee, committee) {
+				return fmt.Errorf("block %d: %v", number, errCommitteeMismatch)
+			}
+			rs := make(types.Receipts, len(b.Receipts))
+			for i, receipt := range b.Receipts {
+				rs[i] = (*types.Receipt)(receipt)
+			}
+			if err := verifyCommitted(block.Header(), parent, policy); err != nil {
+				return fmt.Errorf("block %d: %v", number, err)
+			}
+			if err := verifyBody(block, rs); err != nil {
+				return fmt.Errorf("block %d: %v", number, err)
+			}
+			parent = block.Header()
+			if chain.HasFastBlock(block.Hash(), number) {
+				continue
+			}
+			blocks, receipts = append(blocks, block), append(receipts, rs)
+			if len(blocks) == importBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+		case archiveNodesKind:
+			var nodes archiveNodes
+			if err := rlp.DecodeBytes(entry.Data, &nodes); err != nil {
+				return err
+			}
+			if !trusted || nodes.Number <= chain.CurrentBlock().NumberU64() {
+				continue
+			}
+			if err := chain.InsertTrustedState(nodes.Nodes); err != nil {
+				return err
+			}
+
+		case archiveStateKind:
+			var s archiveState
+			if err := rlp.DecodeBytes(entry.Data, &s); err != nil {
+				return err
+			}
+			if !trusted || s.Number <= chain.CurrentBlock().NumberU64() {
+				continue
+			}
+			// The head can only move to a block already written
+			if err := flush(); err != nil {
+				return err
+			}
+			if err := chain.CommitTrustedHead(s.Hash); err != nil {
+				return fmt.Errorf("state of block %d: %v", s.Number, err)
+			}
+
+		default:
+			return fmt.Errorf("unknown archive entry kind %d", entry.Kind)
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if trusted {
+		return executeTrustedChain(chain, interrupted)
+	}
+	return nil
+}
+
+// executeTrustedChain imports the blocks written by a trusted import past the
+// head block, for which the archive held no state.
+func executeTrustedChain(chain *core.BlockChain, interrupted func() bool) error {
+	first, last := chain.CurrentBlock().NumberU64()+1, chain.CurrentFastBlock().NumberU64()
+	if first > last {
+		return nil
+	}
+	log.Info("Executing blocks past the archived state", "first", first, "last", last)
+	for number := first; number <= last; {
+		if interrupted() {
+			return fmt.Errorf("interrupted")
+		}
+		var blocks types.Blocks
+		for ; number <= last && len(blocks) < importBatchSize; number++ {
+			blocks = append(blocks, chain.GetBlockByNumber(number))
+		}
+		if n, err := chain.InsertChain(blocks); err != nil {
+			return fmt.Errorf("invalid block %d: %v", blocks[n].NumberU64(), err)
+		}
+	}
+	return nil
+}
+
+// VerifyChain checks the canonical chain of a database, without executing any
+// transaction: every block must be proposed by a member of the committee of its
+// parent and sealed by a quorum of it, every committee must be well formed,
+// and the bodies and receipts present must match the headers.
+func VerifyChain(db ethdb.Database) error {
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	chainConfig := rawdb.ReadChainConfig(db, genesis)
+	if chainConfig == nil {
+		return errors.New("chain configuration missing")
+	}
+	if chainConfig.Tendermint == nil {
+		return core.ErrNoFinality
+	}
+	policy := config.ProposerPolicy(chainConfig.Tendermint.ProposerPolicy)
+
+	head := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
+	if head == nil {
+		return errors.New("head header missing")
+	}
+	parent := rawdb.ReadHeader(db, genesis, 0)
+	if parent == nil {
+		return errors.New("genesis header missing")
+	}
+	if err := verifyCommittee(parent.Committee); err != nil {
+		return fmt.Errorf("committee of genesis: %v", err)
+	}
+	var (
+		transitions int
+		start       = time.Now()
+		reported    = time.Now()
+	)
+	for number := uint64(1); number <= *head; number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		header := rawdb.ReadHeader(db, hash, number)
+		if header == nil {
+			return fmt.Errorf("block %d: header missing", number)
+		}
+		if err := verifyCommitted(header, parent, policy); err != nil {
+			return fmt.Errorf("block %d: %v", number, err)
+		}
+		if err := verifyCommittee(header.Committee); err != nil {
+			return fmt.Errorf("committee of block %d: %v", number, err)
+		}
+		if !sameCommittee(parent.Committee, header.Committee) {
+			log.Debug("Committee transition", "number", number, "committee", header.Committee)
+			transitions++
+		}
+		// Light databases don't hold the bodies
+		if body := rawdb.ReadBody(db, hash, number); body != nil {
+			block := types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
+			if err := verifyBody(block, rawdb.ReadRawReceipts(db, hash, number)); err != nil {
+				return fmt.Errorf("block %d: %v", number, err)
+			}
+		}
+		parent = header
+
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Verifying chain", "number", number, "head", *head, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	log.Info("Verified chain", "blocks", *head, "transitions", transitions, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// verifyCommitted checks that a header was committed by the committee elected
+// by its parent: proposed by one of the members and sealed by a quorum.
+func verifyCommitted(header *types.Header, parent *types.Header, policy config.ProposerPolicy) error {
+	if header.ParentHash != parent.Hash() || header.Number.Uint64() != parent.Number.Uint64()+1 {
+		return consensus.ErrUnknownAncestor
+	}
+	proposer, err := types.Ecrecover(header)
+	if err != nil {
+		return err
+	}
+	member := false
+	for _, m := range parent.Committee {
+		if m.Address == proposer {
+			member = true
+			break
+		}
+	}
+	if !member {
+		return errNotCommitteeMember
+	}
+	return backend.VerifyCommittedSeals(header, parent.Committee, policy)
+}
+
+// verifyCommittee checks that a committee can govern blocks.
+func verifyCommittee(committee types.Committee) error {
+	if len(committee) == 0 {
+		return errEmptyCommittee
+	}
+	members := make(map[common.Address]struct{}, len(committee))
+	for _, m := range committee {
+		if _, ok := members[m.Address]; ok {
+			return errDuplicateMember
+		}
+		if m.VotingPower == nil || m.VotingPower.Sign() <= 0 {
+			return errNoVotingPower
+		}
+		members[m.Address] = struct{}{}
+	}
+	return nil
+}
+
+// verifyBody checks that the transactions and uncles of a block, and its
+// receipts if known, are the ones committed to by its header.
+func verifyBody(block *types.Block, receipts types.Receipts) error {
+	header := block.Header()
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("uncle root hash mismatch: have %x, want %x", hash, header.UncleHash)
+	}
+	if hash := types.DeriveSha(block.Transactions()); hash != header.TxHash {
+		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
+	}
+	if receipts == nil {
+		return nil
+	}
+	if hash := types.DeriveSha(receipts); hash != header.ReceiptHash {
+		return fmt.Errorf("receipt root hash mismatch: have %x, want %x", hash, header.ReceiptHash)
+	}
+	return nil
+}
+
+func sameCommittee(a, b types.Committee) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Address != b[i].Address || a[i].VotingPower.Cmp(b[i].VotingPower) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/ethash"
+	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/core/vm"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/params"
+)
+
+// archiveTester is a committed chain written straight into a database, each
+// block crediting an account without carrying any transaction.
+type archiveTester struct {
+	genesis *core.Genesis
+	keys    []*ecdsa.PrivateKey
+	db      ethdb.Database
+	blocks  []*types.Block
+}
+
+func newArchiveTester(t *testing.T, n int) *archiveTester {
+	config := *params.TestChainConfig
+	config.Ethash = nil
+	config.Tendermint = &params.TendermintConfig{Epoch: 4}
+	config.AutonityContractConfig = &params.AutonityContractGenesis{}
+
+	tester := &archiveTester{
+		genesis: &core.Genesis{Config: &config, Mixhash: types.BFTDigest, Alloc: core.GenesisAlloc{}},
+		db:      rawdb.NewMemoryDatabase(),
+	}
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		tester.keys = append(tester.keys, key)
+		config.AutonityContractConfig.Users = append(config.AutonityContractConfig.Users, params.User{
+			Address: crypto.PubkeyToAddress(key.PublicKey),
+			Type:    params.UserValidator,
+			Stake:   1,
+		})
+	}
+	parent := tester.genesis.MustCommit(tester.db)
+	statedb, _ := state.New(parent.Root(), state.NewDatabase(tester.db))
+	for i := 1; i <= n; i++ {
+		statedb.AddBalance(common.Address{byte(i)}, big.NewInt(int64(i)))
+		root, _ := statedb.Commit(true)
+		if err := statedb.Database().TrieDB().Commit(root, false); err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Root:       root,
+			Difficulty: big.NewInt(1),
+			Number:     big.NewInt(int64(i)),
+			GasLimit:   parent.GasLimit(),
+			Time:       parent.Time() + 1,
+			MixDigest:  types.BFTDigest,
+			Committee:  parent.Header().Committee,
+			Round:      new(big.Int),
+		}
+		block := tester.seal(types.NewBlock(header, nil, nil, nil), tester.keys)
+
+		rawdb.WriteTd(tester.db, block.Hash(), block.NumberU64(), big.NewInt(int64(i+1)))
+		rawdb.WriteBlock(tester.db, block)
+		rawdb.WriteReceipts(tester.db, block.Hash(), block.NumberU64(), nil)
+		rawdb.WriteCanonicalHash(tester.db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(tester.db, block.Hash())
+		rawdb.WriteHeadFastBlockHash(tester.db, block.Hash())
+		rawdb.WriteHeadHeaderHash(tester.db, block.Hash())
+
+		tester.blocks = append(tester.blocks, block)
+		parent = block
+	}
+	return tester
+}
+
+// seal signs a block proposed by the first key and committed by all the keys.
+func (tester *archiveTester) seal(block *types.Block, keys []*ecdsa.PrivateKey) *types.Block {
+	header := block.Header()
+	header.ProposerSeal, _ = crypto.Sign(crypto.Keccak256(types.SigHash(header).Bytes()), tester.keys[0])
+
+	seal := tendermintCore.PrepareCommittedSeal(header.Hash(), header.Round, header.Number)
+	header.CommittedSeals = nil
+	for _, key := range keys {
+		sig, _ := crypto.Sign(crypto.Keccak256(seal), key)
+		header.CommittedSeals = append(header.CommittedSeals, sig)
+	}
+	return block.WithSeal(header)
+}
+
+// chain opens a blockchain on a database holding the tester genesis.
+func (tester *archiveTester) chain(t *testing.T, db ethdb.Database) *core.BlockChain {
+	chain, err := core.NewBlockChain(db, nil, tester.genesis.Config, ethash.NewFaker(), vm.Config{}, nil, core.NewTxSenderCacher())
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return chain
+}
+
+// export writes an archive of a range of the tester chain.
+func (tester *archiveTester) export(t *testing.T, dir string, first, last, interval uint64) string {
+	chain := tester.chain(t, tester.db)
+	defer chain.Stop()
+
+	fn := filepath.Join(dir, "archive.rlp.gz")
+	if err := ExportArchive(chain, fn, first, last, interval); err != nil {
+		t.Fatalf("failed to export archive: %v", err)
+	}
+	return fn
+}
+
+// Tests that a trusted import restores an archived chain and its state, and is
+// able to resume from an earlier partial import.
+func TestArchiveTrustedImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tester := newArchiveTester(t, 10)
+	db := rawdb.NewMemoryDatabase()
+	tester.genesis.MustCommit(db)
+	chain := tester.chain(t, db)
+	defer chain.Stop()
+
+	if err := ImportChain(chain, tester.export(t, dir, 0, 5, 0), true); err != nil {
+		t.Fatalf("failed to import partial archive: %v", err)
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 5 {
+		t.Fatalf("head mismatch after partial import: have %d, want 5", head)
+	}
+	if err := ImportChain(chain, tester.export(t, dir, 0, 10, 4), true); err != nil {
+		t.Fatalf("failed to resume import: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != tester.blocks[9].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, tester.blocks[9].Hash())
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("head state missing: %v", err)
+	}
+	for i := 1; i <= 10; i++ {
+		if balance := statedb.GetBalance(common.Address{byte(i)}); balance.Int64() != int64(i) {
+			t.Errorf("account %d: balance mismatch: have %v, want %d", i, balance, i)
+		}
+	}
+	if err := VerifyChain(db); err != nil {
+		t.Errorf("imported chain failed verification: %v", err)
+	}
+}
+
+// Tests that blocks not committed by a quorum are refused by trusted imports
+// and reported by the chain verification.
+func TestArchiveForgedSeals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tester := newArchiveTester(t, 6)
+	if err := VerifyChain(tester.db); err != nil {
+		t.Fatalf("failed to verify chain: %v", err)
+	}
+	forged := tester.seal(tester.blocks[2], tester.keys[:1])
+	rawdb.WriteHeader(tester.db, forged.Header())
+
+	if err := VerifyChain(tester.db); err == nil {
+		t.Fatal("forged seals verified")
+	}
+	fn := tester.export(t, dir, 0, 6, 0)
+
+	db := rawdb.NewMemoryDatabase()
+	tester.genesis.MustCommit(db)
+	chain := tester.chain(t, db)
+	defer chain.Stop()
+
+	if err := ImportChain(chain, fn, true); err == nil {
+		t.Fatal("forged seals imported")
+	}
+	if head := chain.CurrentFastBlock().NumberU64(); head > 2 {
+		t.Errorf("blocks past the forged one imported: head %d", head)
+	}
+}
+
+// Tests that plain exports are still imported, but can't be trusted.
+func TestArchiveRequiredForTrust(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tester := newArchiveTester(t, 2)
+	source := tester.chain(t, tester.db)
+	defer source.Stop()
+
+	fn := filepath.Join(dir, "chain.rlp")
+	if err := ExportChain(source, fn); err != nil {
+		t.Fatalf("failed to export chain: %v", err)
+	}
+	db := rawdb.NewMemoryDatabase()
+	tester.genesis.MustCommit(db)
+	chain := tester.chain(t, db)
+	defer chain.Stop()
+
+	if err := ImportChain(chain, fn, true); err != errArchiveRequired {
+		t.Fatalf("error mismatch: have %v, want %v", err, errArchiveRequired)
+	}
+	// The genesis alone is a valid plain export to import
+	genesis := filepath.Join(dir, "genesis.rlp")
+	if err := ExportAppendChain(source, genesis, 0, 0); err != nil {
+		t.Fatalf("failed to export genesis: %v", err)
+	}
+	if err := ImportChain(chain, genesis, false); err != nil {
+		t.Fatalf("failed to import plain export: %v", err)
+	}
+}
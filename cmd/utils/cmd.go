@@ -18,6 +18,8 @@
 package utils
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -85,7 +87,9 @@ func StartNode(stack *node.Node) {
 	}()
 }
 
-func ImportChain(chain *core.BlockChain, fn string) error {
+// ImportChain imports the blocks of a plain export or of an archive, see
+// ExportArchive. A trusted import is only possible from an archive.
+func ImportChain(chain *core.BlockChain, fn string, trusted bool) error {
 	// Watch for Ctrl-C while the import is running.
 	// If a signal is received, the import will stop at the next batch.
 	interrupt := make(chan os.Signal, 1)
@@ -123,7 +127,21 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 			return err
 		}
 	}
-	stream := rlp.NewStream(reader, 0)
+	// Archives are told apart from plain exports by their first item
+	buffered := bufio.NewReader(reader)
+	stream := rlp.NewStream(buffered, 0)
+
+	first, err := stream.Raw()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if header, ok := decodeArchiveHeader(first); ok {
+		return importArchive(chain, header, stream, trusted, checkInterrupt)
+	}
+	if trusted {
+		return errArchiveRequired
+	}
+	stream = rlp.NewStream(io.MultiReader(bytes.NewReader(first), buffered), 0)
 
 	// Run actual the import.
 	blocks := make(types.Blocks, importBatchSize)
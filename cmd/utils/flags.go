@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -39,6 +40,7 @@ import (
 	"github.com/clearmatics/autonity/consensus"
 	"github.com/clearmatics/autonity/consensus/ethash"
 	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/rawdb"
 	"github.com/clearmatics/autonity/core/vm"
 	"github.com/clearmatics/autonity/crypto"
 	"github.com/clearmatics/autonity/eth"
@@ -60,6 +62,7 @@ import (
 	"github.com/clearmatics/autonity/p2p/netutil"
 	"github.com/clearmatics/autonity/params"
 	"github.com/clearmatics/autonity/rpc"
+	"github.com/clearmatics/autonity/tracing"
 	pcsclite "github.com/gballet/go-libpcsclite"
 	cli "gopkg.in/urfave/cli.v1"
 )
@@ -145,6 +148,10 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Backing database implementation to use ('leveldb' or 'pebble', default = the one of the existing database, or leveldb)",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -212,10 +219,22 @@ var (
 		Name:  "nocode",
 		Usage: "Exclude contract code (save db lookups)",
 	}
+	ExportArchiveFlag = cli.BoolFlag{
+		Name:  "archive",
+		Usage: "Export an archive holding the committees and periodic states, for disaster recovery (Tendermint only)",
+	}
+	ExportStateIntervalFlag = cli.Uint64Flag{
+		Name:  "stateinterval",
+		Usage: "Number of blocks between the states held by an archive (0 = last block only)",
+	}
+	ImportTrustedFlag = cli.BoolFlag{
+		Name:  "trusted",
+		Usage: "Import an archive verifying only the committed seals, without executing the blocks up to its last state",
+	}
 	defaultSyncMode = eth.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "bft")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -223,6 +242,15 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	StateRetentionFlag = cli.Uint64Flag{
+		Name:  "pruning.retention",
+		Usage: "Number of final blocks whose state is kept by the online state pruner (0 = disabled, Tendermint only)",
+	}
+	PruneBloomSizeFlag = cli.Uint64Flag{
+		Name:  "pruning.bloomsize",
+		Usage: "Megabytes of memory allocated to the state pruning reachability bloom",
+		Value: eth.DefaultConfig.PruneBloomSize,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -231,6 +259,18 @@ var (
 		Name:  "whitelist",
 		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
 	}
+	BFTCheckpointFlag = cli.StringFlag{
+		Name:  "bftcheckpoint",
+		Usage: "JSON file holding a trusted committed header to sync forward from (Tendermint only, requires --syncmode bft)",
+	}
+	PrivateManagerFlag = DirectoryFlag{
+		Name:  "private.manager",
+		Usage: "Directory shared with the parties of private transactions to exchange their payloads",
+	}
+	PrivateIdentityFlag = cli.StringFlag{
+		Name:  "private.identity",
+		Usage: "Name of the party the node acts for in private transactions",
+	}
 	OverrideIstanbulFlag = cli.Uint64Flag{
 		Name:  "override.istanbul",
 		Usage: "Manually specify Istanbul fork-block, overriding the bundled setting",
@@ -323,6 +363,10 @@ var (
 		Name:  "txpool.nolocals",
 		Usage: "Disables price exemptions for locally submitted transactions",
 	}
+	TxPoolNoPriorityFlag = cli.BoolFlag{
+		Name:  "txpool.nopriority",
+		Usage: "Disables price exemptions for Autonity operator transactions to the contract",
+	}
 	TxPoolJournalFlag = cli.StringFlag{
 		Name:  "txpool.journal",
 		Usage: "Disk journal for local transaction to survive node restarts",
@@ -393,6 +437,10 @@ var (
 		Name:  "cache.noprefetch",
 		Usage: "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
 	}
+	CacheNoSnapshotFlag = cli.BoolFlag{
+		Name:  "cache.nosnapshot",
+		Usage: "Disable the flat state snapshot speeding up state reads and serving snapshot sync",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -464,6 +512,15 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerPipelineFlag = cli.BoolFlag{
+		Name:  "miner.pipeline",
+		Usage: "Build the next proposal while the current height is being decided",
+	}
+	MinerPriorityGasFlag = cli.Uint64Flag{
+		Name:  "miner.prioritygas",
+		Usage: "Block gas reserved to Autonity operator transactions (0 = disabled)",
+		Value: eth.DefaultConfig.Miner.PriorityGas,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -719,6 +776,20 @@ var (
 		Value: "host=localhost",
 	}
 
+	// Tracing flags
+	TracingEnabledFlag = cli.BoolFlag{
+		Name:  "tracing",
+		Usage: "Enable tracing spans of consensus, block import, p2p messages and RPC calls",
+	}
+	TracingFileFlag = cli.StringFlag{
+		Name:  "tracing.file",
+		Usage: "File to append the tracing spans to, in the OTLP JSON encoding",
+	}
+	TracingEndpointFlag = cli.StringFlag{
+		Name:  "tracing.endpoint",
+		Usage: "OTLP/HTTP endpoint to export the tracing spans to (e.g. http://localhost:4318/v1/traces)",
+	}
+
 	EWASMInterpreterFlag = cli.StringFlag{
 		Name:  "vm.ewasm",
 		Usage: "External ewasm configuration (default = built-in interpreter)",
@@ -1170,6 +1241,14 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
 	}
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		switch engine := ctx.GlobalString(DBEngineFlag.Name); engine {
+		case rawdb.LevelDBEngine, rawdb.PebbleEngine:
+			cfg.DBEngine = engine
+		default:
+			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", engine)
+		}
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -1242,6 +1321,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolNoLocalsFlag.Name) {
 		cfg.NoLocals = ctx.GlobalBool(TxPoolNoLocalsFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolNoPriorityFlag.Name) {
+		cfg.NoPriority = ctx.GlobalBool(TxPoolNoPriorityFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolJournalFlag.Name) {
 		cfg.Journal = ctx.GlobalString(TxPoolJournalFlag.Name)
 	}
@@ -1323,6 +1405,12 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerPipelineFlag.Name) {
+		cfg.Pipeline = ctx.GlobalBool(MinerPipelineFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerPriorityGasFlag.Name) {
+		cfg.PriorityGas = ctx.GlobalUint64(MinerPriorityGasFlag.Name)
+	}
 }
 
 func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
@@ -1348,6 +1436,22 @@ func setWhitelist(ctx *cli.Context, cfg *eth.Config) {
 	}
 }
 
+func setBFTCheckpoint(ctx *cli.Context, cfg *eth.Config) {
+	path := ctx.GlobalString(BFTCheckpointFlag.Name)
+	if path == "" {
+		return
+	}
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		Fatalf("Failed to read BFT checkpoint %s: %v", path, err)
+	}
+	checkpoint := new(eth.BFTCheckpoint)
+	if err := json.Unmarshal(blob, checkpoint); err != nil {
+		Fatalf("Invalid BFT checkpoint %s: %v", path, err)
+	}
+	cfg.BFTCheckpoint = checkpoint
+}
+
 // CheckExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1406,6 +1510,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	setEthash(ctx, cfg)
 	setMiner(ctx, &cfg.Miner)
 	setWhitelist(ctx, cfg)
+	setBFTCheckpoint(ctx, cfg)
 	setLes(ctx, cfg)
 
 	if ctx.GlobalIsSet(SyncModeFlag.Name) {
@@ -1431,12 +1536,21 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.GlobalBool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.GlobalIsSet(CacheNoSnapshotFlag.Name) {
+		cfg.NoSnapshot = ctx.GlobalBool(CacheNoSnapshotFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieDirtyCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(StateRetentionFlag.Name) {
+		cfg.StateRetention = ctx.GlobalUint64(StateRetentionFlag.Name)
+	}
+	if ctx.GlobalIsSet(PruneBloomSizeFlag.Name) {
+		cfg.PruneBloomSize = ctx.GlobalUint64(PruneBloomSizeFlag.Name)
+	}
 	if ctx.GlobalIsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.GlobalString(DocRootFlag.Name)
 	}
@@ -1455,6 +1569,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(RPCGlobalGasCap.Name) {
 		cfg.RPCGasCap = new(big.Int).SetUint64(ctx.GlobalUint64(RPCGlobalGasCap.Name))
 	}
+	if ctx.GlobalIsSet(PrivateManagerFlag.Name) {
+		cfg.PrivateManager = ctx.GlobalString(PrivateManagerFlag.Name)
+	}
+	if ctx.GlobalIsSet(PrivateIdentityFlag.Name) {
+		cfg.PrivateIdentity = ctx.GlobalString(PrivateIdentityFlag.Name)
+	}
 
 	// Override any default configs for hard coded networks.
 	switch {
@@ -1557,6 +1677,26 @@ func SetupMetrics(ctx *cli.Context) {
 	}
 }
 
+// SetupTracing starts the export of tracing spans if enabled.
+func SetupTracing(ctx *cli.Context) {
+	if !ctx.GlobalBool(TracingEnabledFlag.Name) {
+		return
+	}
+	config := tracing.Config{
+		ServiceName:    "autonity",
+		ServiceVersion: params.VersionWithMeta,
+		File:           ctx.GlobalString(TracingFileFlag.Name),
+		Endpoint:       ctx.GlobalString(TracingEndpointFlag.Name),
+	}
+	if config.File == "" && config.Endpoint == "" {
+		Fatalf("Tracing requires --%s or --%s", TracingFileFlag.Name, TracingEndpointFlag.Name)
+	}
+	if err := tracing.Setup(config); err != nil {
+		Fatalf("Failed to enable tracing: %v", err)
+	}
+	log.Info("Enabling tracing", "file", config.File, "endpoint", config.Endpoint)
+}
+
 func SplitTagsFlag(tagsFlag string) map[string]string {
 	tags := strings.Split(tagsFlag, ",")
 	tagsMap := map[string]string{}
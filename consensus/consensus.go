@@ -25,6 +25,7 @@ import (
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/event"
 	"github.com/clearmatics/autonity/params"
 	"github.com/clearmatics/autonity/rpc"
 )
@@ -157,3 +158,16 @@ type Syncer interface {
 
 	ResetPeerCache(address common.Address)
 }
+
+// PipelineEvent is posted when the next block is expected to be built on top
+// of Parent, before Parent is committed. State is the state after Parent.
+type PipelineEvent struct {
+	Parent *types.Block
+	State  *state.StateDB
+}
+
+// Pipeliner is implemented by the consensus engines announcing the parent of
+// the next block they have to propose while the current height is decided.
+type Pipeliner interface {
+	SubscribePipelineEvent(ch chan<- PipelineEvent) event.Subscription
+}
@@ -17,16 +17,39 @@
 package backend
 
 import (
+	"errors"
+
 	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/hexutil"
 	"github.com/clearmatics/autonity/consensus"
 	"github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/consensus/tendermint/participation"
+	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/rpc"
 )
 
+// errParticipationDisabled is returned when the chain doesn't track the
+// validators participation.
+var errParticipationDisabled = errors.New("participation tracking disabled")
+
+// FinalityProof holds what is needed to check that a block was committed
+// without trusting the node serving it: the committed seals, which sign
+// core.PrepareCommittedSeal(hash, round, number), and the committee of the
+// block height along with its voting power.
+type FinalityProof struct {
+	Number         uint64           `json:"number"`
+	Hash           common.Hash      `json:"hash"`
+	Round          uint64           `json:"round"`
+	CommittedSeals []hexutil.Bytes  `json:"committedSeals"`
+	Committee      types.Committee  `json:"committee"`
+	Signers        []common.Address `json:"signers"`
+}
+
 // API is a user facing RPC API to dump BFT state
 type API struct {
-	chain      consensus.ChainReader
-	tendermint core.Backend
+	chain         consensus.ChainReader
+	tendermint    core.Backend
+	participation *participation.Tracker
 }
 
 // GetValidators retrieves the list of authorized validators at the specified block.
@@ -68,3 +91,98 @@ func (api *API) GetContractABI() string {
 func (api *API) GetWhitelist() []string {
 	return api.tendermint.WhiteList()
 }
+
+// GetParticipation retrieves the participation statistics of every committee
+// member seen in the tracking window.
+func (api *API) GetParticipation() ([]participation.Stats, error) {
+	if api.participation == nil {
+		return nil, errParticipationDisabled
+	}
+	return api.participation.AllStats(), nil
+}
+
+// GetValidatorParticipation retrieves the participation statistics of a
+// validator over the tracking window.
+func (api *API) GetValidatorParticipation(address common.Address) (*participation.Stats, error) {
+	if api.participation == nil {
+		return nil, errParticipationDisabled
+	}
+	stats := api.participation.Stats(address)
+	return &stats, nil
+}
+
+// GetValidatorActivity retrieves the heights of the tracking window at which
+// a validator proposed, signed or missed a block.
+func (api *API) GetValidatorActivity(address common.Address) (*participation.Activity, error) {
+	if api.participation == nil {
+		return nil, errParticipationDisabled
+	}
+	activity := api.participation.Activity(address)
+	return &activity, nil
+}
+
+// GetParticipationRecord retrieves the proposer and the committed seal signers
+// of the block at the specified height. Within the tracking window the signers
+// include those whose seal was only carried by the past committed seals of the
+// following block.
+func (api *API) GetParticipationRecord(number rpc.BlockNumber) (*participation.Record, error) {
+	header := api.chain.CurrentHeader()
+	if number >= 0 {
+		header = api.chain.GetHeaderByNumber(uint64(number))
+	}
+	if header == nil || header.Number.Uint64() == 0 {
+		return nil, errUnknownBlock
+	}
+	if api.participation != nil {
+		if record := api.participation.Record(header.Number.Uint64()); record != nil {
+			return record, nil
+		}
+	}
+	parent := api.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, errUnknownBlock
+	}
+	return participation.NewRecord(header, parent.Committee)
+}
+
+// GetFinalityProof retrieves the proof that the specified block was committed.
+// Committed blocks are final, so the "latest", "finalized" and "safe" tags all
+// resolve to the current head.
+func (api *API) GetFinalityProof(blockNrOrHash rpc.BlockNumberOrHash) (*FinalityProof, error) {
+	var header *types.Header
+	if number, ok := blockNrOrHash.Number(); ok {
+		switch {
+		case number == rpc.PendingBlockNumber:
+			return nil, errUnknownBlock
+		case number < 0:
+			header = api.chain.CurrentHeader()
+		default:
+			header = api.chain.GetHeaderByNumber(uint64(number))
+		}
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		header = api.chain.GetHeaderByHash(hash)
+	}
+	if header == nil || header.Number.Uint64() == 0 {
+		return nil, errUnknownBlock
+	}
+	parent := api.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, errUnknownBlock
+	}
+	record, err := participation.NewRecord(header, parent.Committee)
+	if err != nil {
+		return nil, err
+	}
+	proof := &FinalityProof{
+		Number:         record.Number,
+		Hash:           header.Hash(),
+		Round:          record.Round,
+		CommittedSeals: make([]hexutil.Bytes, len(header.CommittedSeals)),
+		Committee:      parent.Committee,
+		Signers:        record.Signers,
+	}
+	for i, seal := range header.CommittedSeals {
+		proof.CommittedSeals[i] = seal
+	}
+	return proof, nil
+}
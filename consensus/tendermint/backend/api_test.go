@@ -10,6 +10,7 @@ import (
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/consensus"
 	"github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/consensus/tendermint/participation"
 	"github.com/clearmatics/autonity/consensus/tendermint/validator"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/rpc"
@@ -160,3 +161,174 @@ func TestAPIGetWhitelist(t *testing.T) {
 		t.Fatalf("want %v, got %v", want, got)
 	}
 }
+
+func TestAPIGetValidatorParticipation(t *testing.T) {
+	addr := common.HexToAddress("0x0123456789")
+
+	t.Run("participation tracking disabled, error returned", func(t *testing.T) {
+		API := &API{}
+
+		_, err := API.GetValidatorParticipation(addr)
+		if err != errParticipationDisabled {
+			t.Fatalf("expected %v, got %v", errParticipationDisabled, err)
+		}
+	})
+
+	t.Run("participation tracking enabled, stats returned", func(t *testing.T) {
+		API := &API{
+			participation: participation.NewTracker(10),
+		}
+
+		got, err := API.GetValidatorParticipation(addr)
+		if err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+
+		want := &participation.Stats{Address: addr}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestAPIGetParticipationRecord(t *testing.T) {
+	chain, engine := newBlockChain(1)
+	parent := chain.Genesis()
+	for i := 0; i < 3; i++ {
+		block, err := makeCommittedBlock(chain, engine, parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatal(err)
+		}
+		parent = block
+	}
+	want := []common.Address{engine.Address()}
+
+	t.Run("latest block given, tracked record returned", func(t *testing.T) {
+		API := &API{chain: chain, participation: chain.Participation()}
+
+		got, err := API.GetParticipationRecord(rpc.LatestBlockNumber)
+		if err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+		if got.Number != 3 || got.Proposer != engine.Address() || !reflect.DeepEqual(got.Signers, want) {
+			t.Fatalf("unexpected record %+v", got)
+		}
+	})
+
+	t.Run("block number given, tracked record returned", func(t *testing.T) {
+		API := &API{chain: chain, participation: chain.Participation()}
+
+		got, err := API.GetParticipationRecord(rpc.BlockNumber(1))
+		if err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+		if got.Number != 1 || !reflect.DeepEqual(got.Signers, want) {
+			t.Fatalf("unexpected record %+v", got)
+		}
+	})
+
+	t.Run("block outside of the window given, record rebuilt from the headers", func(t *testing.T) {
+		tracker := participation.NewTracker(1)
+		tracker.Backfill(chain, chain.CurrentHeader())
+		API := &API{chain: chain, participation: tracker}
+
+		if tracker.Record(1) != nil {
+			t.Fatalf("expected height 1 to be out of the window")
+		}
+		got, err := API.GetParticipationRecord(rpc.BlockNumber(1))
+		if err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+		if got.Number != 1 || got.Proposer != engine.Address() || !reflect.DeepEqual(got.Signers, want) {
+			t.Fatalf("unexpected record %+v", got)
+		}
+	})
+
+	t.Run("genesis block given, error returned", func(t *testing.T) {
+		API := &API{chain: chain, participation: chain.Participation()}
+
+		_, err := API.GetParticipationRecord(rpc.BlockNumber(0))
+		if err != errUnknownBlock {
+			t.Fatalf("expected %v, got %v", errUnknownBlock, err)
+		}
+	})
+
+	t.Run("unknown block given, error returned", func(t *testing.T) {
+		API := &API{chain: chain, participation: chain.Participation()}
+
+		_, err := API.GetParticipationRecord(rpc.BlockNumber(10))
+		if err != errUnknownBlock {
+			t.Fatalf("expected %v, got %v", errUnknownBlock, err)
+		}
+	})
+}
+
+func TestAPIGetFinalityProof(t *testing.T) {
+	chain, engine := newBlockChain(1)
+	parent := chain.Genesis()
+	for i := 0; i < 3; i++ {
+		block, err := makeCommittedBlock(chain, engine, parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatal(err)
+		}
+		parent = block
+	}
+	API := &API{chain: chain}
+	want := []common.Address{engine.Address()}
+
+	check := func(t *testing.T, got *FinalityProof, header *types.Header) {
+		if got.Number != header.Number.Uint64() || got.Hash != header.Hash() || !reflect.DeepEqual(got.Signers, want) {
+			t.Fatalf("unexpected proof %+v", got)
+		}
+		committee := chain.GetHeaderByNumber(header.Number.Uint64() - 1).Committee
+		if !reflect.DeepEqual(got.Committee, committee) {
+			t.Fatalf("want committee %v, got %v", committee, got.Committee)
+		}
+		if len(got.CommittedSeals) != len(header.CommittedSeals) {
+			t.Fatalf("want %d committed seals, got %d", len(header.CommittedSeals), len(got.CommittedSeals))
+		}
+		seal := core.PrepareCommittedSeal(got.Hash, new(big.Int).SetUint64(got.Round), header.Number)
+		for _, committedSeal := range got.CommittedSeals {
+			if _, err := types.GetSignatureAddress(seal, committedSeal); err != nil {
+				t.Fatalf("invalid committed seal: %v", err)
+			}
+		}
+	}
+
+	t.Run("finalized block given, proof of the head returned", func(t *testing.T) {
+		got, err := API.GetFinalityProof(rpc.BlockNumberOrHashWithNumber(rpc.FinalizedBlockNumber))
+		if err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+		check(t, got, chain.CurrentHeader())
+	})
+
+	t.Run("block hash given, proof returned", func(t *testing.T) {
+		header := chain.GetHeaderByNumber(2)
+		got, err := API.GetFinalityProof(rpc.BlockNumberOrHashWithHash(header.Hash(), false))
+		if err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+		check(t, got, header)
+	})
+
+	t.Run("pending block given, error returned", func(t *testing.T) {
+		_, err := API.GetFinalityProof(rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
+		if err != errUnknownBlock {
+			t.Fatalf("expected %v, got %v", errUnknownBlock, err)
+		}
+	})
+
+	t.Run("genesis block given, error returned", func(t *testing.T) {
+		_, err := API.GetFinalityProof(rpc.BlockNumberOrHashWithNumber(0))
+		if err != errUnknownBlock {
+			t.Fatalf("expected %v, got %v", errUnknownBlock, err)
+		}
+	})
+}
@@ -30,7 +30,9 @@ import (
 	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
 	"github.com/clearmatics/autonity/consensus/tendermint/events"
 	"github.com/clearmatics/autonity/consensus/tendermint/validator"
+	"github.com/clearmatics/autonity/contracts/autonity"
 	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/core/vm"
 	"github.com/clearmatics/autonity/crypto"
@@ -93,6 +95,8 @@ func New(config *tendermintConfig.Config, privateKey *ecdsa.PrivateKey, db ethdb
 		recentMessages: recentMessages,
 		knownMessages:  knownMessages,
 		vmConfig:       vmConfig,
+
+		verifiedProposals: make(map[common.Hash]*core.VerifiedBlock),
 	}
 
 	backend.pendingMessages.SetCapacity(ringCapacity)
@@ -137,6 +141,14 @@ type Backend struct {
 	autonityContractAddress common.Address // Ethereum address of the white list contract
 	contractsMu             sync.RWMutex
 	vmConfig                *vm.Config
+
+	// execution results of the proposals verified in the current round, handed
+	// to the blockchain on commit so that the block isn't executed twice
+	verifiedProposals   map[common.Hash]*core.VerifiedBlock
+	verifiedProposalsMu sync.Mutex
+
+	// feed of the parents on top of which the next proposal is built in advance
+	pipelineFeed event.Feed
 }
 
 // Address implements tendermint.Backend.Address
@@ -252,12 +264,18 @@ func (sb *Backend) Commit(proposal *types.Block, round *big.Int, seals [][]byte)
 	// -- if success, the ChainHeadEvent event will be broadcasted, try to build
 	//    the next block and the previous Seal() will be stopped.
 	// -- otherwise, a error will be returned and a round change event will be fired.
+	verified := sb.takeVerifiedProposal(proposal.Hash())
 	if sb.proposedBlockHash == proposal.Hash() && !sb.isResultChanNil() {
 		// feed block hash to Seal() and wait the Seal() result
 		sb.sendResultChan(proposal)
 		return nil
 	}
 
+	// the block is inserted with the state computed by VerifyProposal
+	if verified != nil && sb.blockchain != nil {
+		sb.blockchain.AddVerifiedBlock(proposal.Hash(), verified)
+	}
+
 	if sb.broadcaster != nil {
 		sb.broadcaster.Enqueue(fetcherID, proposal)
 	}
@@ -294,6 +312,7 @@ func (sb *Backend) VerifyProposal(proposal types.Block) (time.Duration, error) {
 	if err == nil || err == types.ErrEmptyCommittedSeals {
 		var (
 			receipts  types.Receipts
+			logs      []*types.Log
 			committee types.Committee
 
 			usedGas        = new(uint64)
@@ -314,9 +333,38 @@ func (sb *Backend) VerifyProposal(proposal types.Block) (time.Duration, error) {
 			return 0, err
 		}
 
+		// The transactions have to pass the same minimum gas price and permission checks as in
+		// sb.blockchain.Processor().Process(), since the block is inserted in the chain with the state computed here
+		var (
+			minGasPrice uint64
+			permissions *autonity.Permissions
+			signer      = types.MakeSigner(sb.blockchain.Config(), header.Number)
+		)
+		if ac := sb.blockchain.GetAutonityContract(); ac != nil {
+			if price, priceErr := ac.GetMinimumGasPrice(block, state); priceErr == nil {
+				minGasPrice = price
+			}
+			if permissions, err = ac.GetPermissions(parent.Header(), state); err != nil {
+				return 0, err
+			}
+		}
+
 		// sb.blockchain.Processor().Process() was not called because it calls back Finalize() and would have modified the proposal
 		// Instead only the transactions are applied to the copied state
 		for i, tx := range block.Transactions() {
+			if minGasPrice != 0 && tx.GasPrice().Cmp(new(big.Int).SetUint64(minGasPrice)) == -1 {
+				return 0, core.ErrMinGasPrice
+			}
+			if permissions != nil {
+				from, senderErr := types.Sender(signer, tx)
+				if senderErr != nil {
+					return 0, senderErr
+				}
+				if err = permissions.Check(from, tx.To() == nil); err != nil {
+					return 0, err
+				}
+			}
+
 			state.Prepare(tx.Hash(), block.Hash(), i)
 			// Might be vulnerable to DoS Attack depending on gaslimit
 			// Todo : Double check
@@ -325,6 +373,7 @@ func (sb *Backend) VerifyProposal(proposal types.Block) (time.Duration, error) {
 				return 0, receiptErr
 			}
 			receipts = append(receipts, receipt)
+			logs = append(logs, receipt.Logs...)
 		}
 
 		// Here the order of applying transaction matters
@@ -390,6 +439,12 @@ func (sb *Backend) VerifyProposal(proposal types.Block) (time.Duration, error) {
 		}
 		// At this stage committee field is consistent with the validator list returned by Soma-contract
 
+		sb.addVerifiedProposal(block.Hash(), &core.VerifiedBlock{
+			State:    state,
+			Receipts: receipts,
+			Logs:     logs,
+			UsedGas:  *usedGas,
+		})
 		return 0, nil
 	} else if err == consensus.ErrFutureBlock {
 		return time.Unix(int64(block.Header().Time), 0).Sub(now()), consensus.ErrFutureBlock
@@ -397,6 +452,50 @@ func (sb *Backend) VerifyProposal(proposal types.Block) (time.Duration, error) {
 	return 0, err
 }
 
+func (sb *Backend) addVerifiedProposal(hash common.Hash, verified *core.VerifiedBlock) {
+	sb.verifiedProposalsMu.Lock()
+	defer sb.verifiedProposalsMu.Unlock()
+	sb.verifiedProposals[hash] = verified
+}
+
+func (sb *Backend) takeVerifiedProposal(hash common.Hash) *core.VerifiedBlock {
+	sb.verifiedProposalsMu.Lock()
+	defer sb.verifiedProposalsMu.Unlock()
+	verified := sb.verifiedProposals[hash]
+	delete(sb.verifiedProposals, hash)
+	return verified
+}
+
+// ResetVerifiedProposals implements tendermint.Backend.ResetVerifiedProposals
+func (sb *Backend) ResetVerifiedProposals() {
+	sb.verifiedProposalsMu.Lock()
+	defer sb.verifiedProposalsMu.Unlock()
+	sb.verifiedProposals = make(map[common.Hash]*core.VerifiedBlock)
+}
+
+// PipelineProposal implements tendermint.Backend.PipelineProposal
+func (sb *Backend) PipelineProposal(parent *types.Block) {
+	sb.verifiedProposalsMu.Lock()
+	verified := sb.verifiedProposals[parent.Hash()]
+	var parentState *state.StateDB
+	if verified != nil {
+		// the verified state itself is handed to the blockchain on commit
+		parentState = verified.State.Copy()
+	}
+	sb.verifiedProposalsMu.Unlock()
+
+	if parentState == nil {
+		sb.logger.Debug("Can't pipeline the next proposal, parent not verified", "hash", parent.Hash())
+		return
+	}
+	go sb.pipelineFeed.Send(consensus.PipelineEvent{Parent: parent, State: parentState})
+}
+
+// SubscribePipelineEvent implements tendermint.Backend.SubscribePipelineEvent
+func (sb *Backend) SubscribePipelineEvent(ch chan<- consensus.PipelineEvent) event.Subscription {
+	return sb.pipelineFeed.Subscribe(ch)
+}
+
 // Sign implements tendermint.Backend.Sign
 func (sb *Backend) Sign(data []byte) ([]byte, error) {
 	hashData := crypto.Keccak256(data)
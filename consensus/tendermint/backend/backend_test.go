@@ -210,6 +210,77 @@ func TestVerifyProposal(t *testing.T) {
 	}
 
 }
+func TestVerifiedProposal(t *testing.T) {
+	verify := func(t *testing.T) (*core.BlockChain, *Backend, *types.Block) {
+		chain, engine := newBlockChain(1)
+		block, err := makeBlockWithoutSeal(chain, engine, chain.Genesis())
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, err = engine.AddSeal(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// wait for the timestamp of header so that the block isn't in the future
+		time.Sleep(time.Until(time.Unix(int64(block.Time()), 0)))
+		if _, err := engine.VerifyProposal(*block); err != nil {
+			t.Fatalf("could not verify block, err=%s", err)
+		}
+		if engine.verifiedProposals[block.Hash()] == nil {
+			t.Fatal("execution result of the verified proposal isn't kept")
+		}
+		return chain, engine, block
+	}
+
+	commit := func(t *testing.T, chain *core.BlockChain, engine *Backend, block *types.Block) *types.Block {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var committed *types.Block
+		broadcaster := consensus.NewMockBroadcaster(ctrl)
+		broadcaster.EXPECT().Enqueue(fetcherID, gomock.Any()).Do(func(id string, b *types.Block) {
+			committed = b
+		})
+		engine.SetBroadcaster(broadcaster)
+
+		seal, err := engine.Sign(tendermintCore.PrepareCommittedSeal(block.Hash(), block.Header().Round, block.Number()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.Commit(block, block.Header().Round, [][]byte{seal}); err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+		return committed
+	}
+
+	t.Run("handed to the blockchain on commit", func(t *testing.T) {
+		chain, engine, block := verify(t)
+		committed := commit(t, chain, engine, block)
+		if len(engine.verifiedProposals) != 0 {
+			t.Fatal("execution result kept after commit")
+		}
+		if _, err := chain.InsertChain(types.Blocks{committed}); err != nil {
+			t.Fatalf("could not insert committed block, err=%s", err)
+		}
+		if chain.CurrentBlock().Hash() != block.Hash() {
+			t.Fatalf("head mismatch: have %v, want %v", chain.CurrentBlock().Hash(), block.Hash())
+		}
+	})
+
+	t.Run("dropped on round change", func(t *testing.T) {
+		chain, engine, block := verify(t)
+		engine.ResetVerifiedProposals()
+		if len(engine.verifiedProposals) != 0 {
+			t.Fatal("execution result kept after reset")
+		}
+		// the block is executed again on insertion
+		committed := commit(t, chain, engine, block)
+		if _, err := chain.InsertChain(types.Blocks{committed}); err != nil {
+			t.Fatalf("could not insert committed block, err=%s", err)
+		}
+	})
+}
+
 func TestResetPeerCache(t *testing.T) {
 	addr := common.HexToAddress("0x01234567890")
 	msgCache, err := lru.NewARC(inmemoryMessages)
@@ -453,6 +524,53 @@ func TestGetProposer(t *testing.T) {
 	}
 }
 
+func TestBlockChainParticipation(t *testing.T) {
+	genesis, nodeKeys := getGenesisAndKeys(1)
+	memDB := rawdb.NewMemoryDatabase()
+	cfg := config.DefaultConfig()
+	engine := New(cfg, nodeKeys[0], memDB, genesis.Config, &vm.Config{})
+	genesis.MustCommit(memDB)
+	chain, err := core.NewBlockChain(memDB, nil, genesis.Config, tendermintCore.New(engine, cfg), vm.Config{}, nil, core.NewTxSenderCacher())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent := chain.Genesis()
+	for i := 0; i < 3; i++ {
+		block, err := makeCommittedBlock(chain, engine, parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatal(err)
+		}
+		parent = block
+	}
+	chain.Stop()
+
+	// a restarted chain rebuilds the window from the stored headers
+	restarted, err := core.NewBlockChain(memDB, nil, genesis.Config, tendermintCore.New(engine, cfg), vm.Config{}, nil, core.NewTxSenderCacher())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Stop()
+	stats := restarted.Participation().Stats(engine.Address())
+	if stats.Signed != 3 || stats.Proposed != 3 {
+		t.Fatalf("unexpected backfilled stats %+v", stats)
+	}
+
+	// rewinding the chain drops the records above the new head
+	if err := restarted.SetHead(1); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.Participation().Record(2) != nil {
+		t.Fatalf("expected the record of height 2 to be dropped")
+	}
+	if stats := restarted.Participation().Stats(engine.Address()); stats.Signed != 1 {
+		t.Fatalf("unexpected stats after rewind %+v", stats)
+	}
+}
+
 func TestSyncPeer(t *testing.T) {
 	t.Run("no broadcaster set, nothing done", func(t *testing.T) {
 		b := &Backend{}
@@ -781,6 +899,30 @@ func makeBlock(chain *core.BlockChain, engine *Backend, parent *types.Block) (*t
 	return <-resultCh, nil
 }
 
+// makeCommittedBlock builds a block sealed by the engine and carrying its
+// committed seal, without going through the consensus rounds.
+func makeCommittedBlock(chain *core.BlockChain, engine *Backend, parent *types.Block) (*types.Block, error) {
+	block, err := makeBlockWithoutSeal(chain, engine, parent)
+	if err != nil {
+		return nil, err
+	}
+	block, err = engine.AddSeal(block)
+	if err != nil {
+		return nil, err
+	}
+	header := block.Header()
+	seal, err := engine.Sign(tendermintCore.PrepareCommittedSeal(block.Hash(), header.Round, header.Number))
+	if err != nil {
+		return nil, err
+	}
+	if err := types.WriteCommittedSeals(header, [][]byte{seal}); err != nil {
+		return nil, err
+	}
+	// wait for the timestamp of header so that the block isn't queued as a future block
+	time.Sleep(time.Until(time.Unix(int64(header.Time), 0)))
+	return block.WithSeal(header), nil
+}
+
 func makeBlockWithoutSeal(chain *core.BlockChain, engine *Backend, parent *types.Block) (*types.Block, error) {
 	header := makeHeader(parent, engine.config)
 	_ = engine.Prepare(chain, header)
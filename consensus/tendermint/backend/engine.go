@@ -26,13 +26,16 @@ import (
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/common/hexutil"
 	"github.com/clearmatics/autonity/consensus"
+	"github.com/clearmatics/autonity/consensus/tendermint/config"
 	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
 	"github.com/clearmatics/autonity/consensus/tendermint/events"
+	"github.com/clearmatics/autonity/consensus/tendermint/participation"
 	"github.com/clearmatics/autonity/consensus/tendermint/validator"
 	"github.com/clearmatics/autonity/core"
 	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/log"
 	"github.com/clearmatics/autonity/rpc"
 )
 
@@ -225,7 +228,13 @@ func (sb *Backend) verifyCommittedSeals(chain consensus.ChainReader, header *typ
 	if err != nil {
 		return err
 	}
-	validators := validator.NewSet(committee, sb.config.GetProposerPolicy())
+	return VerifyCommittedSeals(header, committee, sb.config.GetProposerPolicy())
+}
+
+// VerifyCommittedSeals checks that the committed seals of header are signed by
+// a quorum of committee, the committee of the header height.
+func VerifyCommittedSeals(header *types.Header, committee types.Committee, policy config.ProposerPolicy) error {
+	validators := validator.NewSet(committee, policy)
 
 	// The length of Committed seals should be larger than 0
 	if len(header.CommittedSeals) == 0 {
@@ -240,7 +249,7 @@ func (sb *Backend) verifyCommittedSeals(chain consensus.ChainReader, header *typ
 		// 2. Get the original address by seal and parent block hash
 		addr, err := types.GetSignatureAddress(proposalSeal, seal)
 		if err != nil {
-			sb.logger.Error("not a valid address", "err", err)
+			log.Error("not a valid address", "err", err)
 			return types.ErrInvalidSignature
 		}
 		// Every validator can have only one seal. If more than one seals are signed by a
@@ -410,8 +419,10 @@ func (sb *Backend) Seal(chain consensus.ChainReader, block *types.Block, results
 	header := block.Header()
 	number := header.Number.Uint64()
 
-	// Bail out if we're unauthorized to sign a block
-	if _, v := sb.Validators(number).GetByAddress(sb.Address()); v == nil {
+	// Bail out if we're unauthorized to sign a block, the committee is read from
+	// the given chain since the parent of a pipelined block isn't committed yet
+	committee, _ := sb.retrieveSavedCommittee(number, chain)
+	if _, v := validator.NewSet(committee, sb.config.GetProposerPolicy()).GetByAddress(sb.Address()); v == nil {
 		sb.logger.Error("error validator errUnauthorized", "addr", sb.address.String())
 		return errUnauthorized
 	}
@@ -499,10 +510,16 @@ func (sb *Backend) AddSeal(block *types.Block) (*types.Block, error) {
 
 // APIs returns the RPC APIs this consensus engine provides.
 func (sb *Backend) APIs(chain consensus.ChainReader) []rpc.API {
+	api := &API{chain: chain, tendermint: sb}
+	if bc, ok := chain.(interface {
+		Participation() *participation.Tracker
+	}); ok {
+		api.participation = bc.Participation()
+	}
 	return []rpc.API{{
 		Namespace: "tendermint",
 		Version:   "1.0",
-		Service:   &API{chain: chain, tendermint: sb},
+		Service:   api,
 		Public:    true,
 	}}
 }
@@ -409,6 +409,44 @@ func (mr *MockBackendMockRecorder) VerifyProposal(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyProposal", reflect.TypeOf((*MockBackend)(nil).VerifyProposal), arg0)
 }
 
+// ResetVerifiedProposals mocks base method
+func (m *MockBackend) ResetVerifiedProposals() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResetVerifiedProposals")
+}
+
+// ResetVerifiedProposals indicates an expected call of ResetVerifiedProposals
+func (mr *MockBackendMockRecorder) ResetVerifiedProposals() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetVerifiedProposals", reflect.TypeOf((*MockBackend)(nil).ResetVerifiedProposals))
+}
+
+// PipelineProposal mocks base method
+func (m *MockBackend) PipelineProposal(parent *types.Block) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PipelineProposal", parent)
+}
+
+// PipelineProposal indicates an expected call of PipelineProposal
+func (mr *MockBackendMockRecorder) PipelineProposal(parent interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PipelineProposal", reflect.TypeOf((*MockBackend)(nil).PipelineProposal), parent)
+}
+
+// SubscribePipelineEvent mocks base method
+func (m *MockBackend) SubscribePipelineEvent(ch chan<- consensus.PipelineEvent) event.Subscription {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribePipelineEvent", ch)
+	ret0, _ := ret[0].(event.Subscription)
+	return ret0
+}
+
+// SubscribePipelineEvent indicates an expected call of SubscribePipelineEvent
+func (mr *MockBackendMockRecorder) SubscribePipelineEvent(ch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribePipelineEvent", reflect.TypeOf((*MockBackend)(nil).SubscribePipelineEvent), ch)
+}
+
 // Sign mocks base method
 func (m *MockBackend) Sign(arg0 []byte) ([]byte, error) {
 	m.ctrl.T.Helper()
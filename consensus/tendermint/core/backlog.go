@@ -18,6 +18,7 @@ package core
 
 import (
 	"math/big"
+	"sort"
 
 	"github.com/clearmatics/autonity/consensus/tendermint/validator"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
@@ -102,7 +103,17 @@ func (c *core) processBacklog() {
 	c.backlogsMu.Lock()
 	defer c.backlogsMu.Unlock()
 
-	for src, backlog := range c.backlogs {
+	// Go through the backlogs in a deterministic order
+	sources := make([]validator.Validator, 0, len(c.backlogs))
+	for src := range c.backlogs {
+		sources = append(sources, src)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].GetAddress().Hex() < sources[j].GetAddress().Hex()
+	})
+
+	for _, src := range sources {
+		backlog := c.backlogs[src]
 		if backlog == nil {
 			continue
 		}
@@ -150,10 +161,15 @@ func (c *core) processBacklog() {
 			}
 			logger.Debug("Post backlog event", "msg", msg)
 
-			go c.sendEvent(backlogEvent{
+			ev := backlogEvent{
 				src: src,
 				msg: msg,
-			})
+			}
+			if c.syncEvents {
+				c.sendEvent(ev)
+			} else {
+				go c.sendEvent(ev)
+			}
 		}
 	}
 }
@@ -0,0 +1,146 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+)
+
+// ConsensusState is a snapshot of the current consensus view of the core, it is
+// used for monitoring purposes.
+type ConsensusState struct {
+	Height        *big.Int       `json:"height"`
+	Round         *big.Int       `json:"round"`
+	Step          string         `json:"step"`
+	Proposer      common.Address `json:"proposer"`
+	IsProposer    bool           `json:"isProposer"`
+	CommitteeSize int            `json:"committeeSize"`
+	VoteLatencies []VoteLatency  `json:"voteLatencies"`
+}
+
+// VoteLatency is the delay between the start of a round and the reception of
+// the votes of a validator for that round. A zero delay means that no vote of
+// that type was received.
+type VoteLatency struct {
+	Address   common.Address `json:"address"`
+	Height    *big.Int       `json:"height"`
+	Round     *big.Int       `json:"round"`
+	Prevote   time.Duration  `json:"prevote"`
+	Precommit time.Duration  `json:"precommit"`
+}
+
+// voteLatencies keeps the latest vote latency of every validator for the
+// current height. Votes for an old round of the height are measured from the
+// start of their own round.
+type voteLatencies struct {
+	height     *big.Int
+	roundStart map[int64]time.Time
+	latencies  map[common.Address]*VoteLatency
+	mu         sync.RWMutex
+}
+
+// startRound records the start time of a round, moving to a new height resets
+// the latencies of the previous one.
+func (v *voteLatencies) startRound(height, round *big.Int, now time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.height == nil || v.height.Cmp(height) != 0 {
+		v.height = new(big.Int).Set(height)
+		v.roundStart = make(map[int64]time.Time)
+		v.latencies = make(map[common.Address]*VoteLatency)
+	}
+	v.roundStart[round.Int64()] = now
+}
+
+// record stores the latency of a vote received at now. Votes for other heights,
+// for rounds which weren't started or for rounds older than the latest one seen
+// from the validator are ignored.
+func (v *voteLatencies) record(addr common.Address, height, round *big.Int, step Step, now time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.height == nil || v.height.Cmp(height) != 0 {
+		return
+	}
+	start, ok := v.roundStart[round.Int64()]
+	if !ok {
+		return
+	}
+	l, ok := v.latencies[addr]
+	if ok && l.Round.Cmp(round) > 0 {
+		return
+	}
+	if !ok || l.Round.Cmp(round) != 0 {
+		l = &VoteLatency{
+			Address: addr,
+			Height:  new(big.Int).Set(height),
+			Round:   new(big.Int).Set(round),
+		}
+		v.latencies[addr] = l
+	}
+	latency := now.Sub(start)
+	switch step {
+	case prevote:
+		l.Prevote = latency
+	case precommit:
+		l.Precommit = latency
+	}
+	measureVoteLatency(addr, round.Int64(), step, latency)
+}
+
+func (v *voteLatencies) list() []VoteLatency {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	result := make([]VoteLatency, 0, len(v.latencies))
+	for _, l := range v.latencies {
+		result = append(result, *l)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address.Hex() < result[j].Address.Hex()
+	})
+	return result
+}
+
+// CoreState returns a snapshot of the current height, round and step of the
+// core along with the vote latency of the committee members.
+func (c *core) CoreState() ConsensusState {
+	height, round, step := c.currentRoundState.State()
+	state := ConsensusState{
+		Height:        new(big.Int),
+		Round:         new(big.Int),
+		Step:          Step(step).String(),
+		IsProposer:    c.isProposer(),
+		CommitteeSize: c.valSet.Size(),
+		VoteLatencies: c.voteLatencies.list(),
+	}
+	// the round state is only set once the core is started
+	if height != nil && round != nil {
+		state.Height.Set(height)
+		state.Round.Set(round)
+	}
+	if proposer := c.valSet.GetProposer(); proposer != nil {
+		state.Proposer = proposer.GetAddress()
+	}
+	return state
+}
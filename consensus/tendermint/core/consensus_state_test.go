@@ -0,0 +1,181 @@
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/tendermint/config"
+	"github.com/clearmatics/autonity/consensus/tendermint/validator"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/metrics"
+)
+
+func TestVoteLatencies(t *testing.T) {
+	var (
+		addrA = common.HexToAddress("0x01")
+		addrB = common.HexToAddress("0x02")
+		start = time.Unix(100, 0)
+	)
+
+	type vote struct {
+		addr          common.Address
+		height, round int64
+		step          Step
+		at            time.Duration // since start
+	}
+	cases := []struct {
+		name  string
+		votes []vote
+		want  []VoteLatency
+	}{
+		{
+			name: "prevote and precommit of the current round",
+			votes: []vote{
+				{addrB, 1, 1, prevote, 3 * time.Second},
+				{addrA, 1, 1, prevote, 2 * time.Second},
+				{addrA, 1, 1, precommit, 4 * time.Second},
+			},
+			want: []VoteLatency{
+				{Address: addrA, Height: big.NewInt(1), Round: big.NewInt(1), Prevote: time.Second, Precommit: 3 * time.Second},
+				{Address: addrB, Height: big.NewInt(1), Round: big.NewInt(1), Prevote: 2 * time.Second},
+			},
+		},
+		{
+			name: "old round vote measured from the start of its own round",
+			votes: []vote{
+				{addrA, 1, 0, prevote, 2 * time.Second},
+			},
+			want: []VoteLatency{
+				{Address: addrA, Height: big.NewInt(1), Round: big.NewInt(0), Prevote: 2 * time.Second},
+			},
+		},
+		{
+			name: "old round vote doesn't replace a newer round",
+			votes: []vote{
+				{addrA, 1, 1, prevote, 2 * time.Second},
+				{addrA, 1, 0, precommit, 3 * time.Second},
+			},
+			want: []VoteLatency{
+				{Address: addrA, Height: big.NewInt(1), Round: big.NewInt(1), Prevote: time.Second},
+			},
+		},
+		{
+			name: "votes for other heights and rounds not started ignored",
+			votes: []vote{
+				{addrA, 2, 0, prevote, 2 * time.Second},
+				{addrB, 1, 2, prevote, 2 * time.Second},
+			},
+			want: []VoteLatency{},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var v voteLatencies
+			v.startRound(big.NewInt(1), big.NewInt(0), start)
+			v.startRound(big.NewInt(1), big.NewInt(1), start.Add(time.Second))
+			for _, vote := range testCase.votes {
+				v.record(vote.addr, big.NewInt(vote.height), big.NewInt(vote.round), vote.step, start.Add(vote.at))
+			}
+			if got := v.list(); !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("want %+v, got %+v", testCase.want, got)
+			}
+		})
+	}
+
+	t.Run("new height resets the latencies", func(t *testing.T) {
+		var v voteLatencies
+		v.startRound(big.NewInt(1), big.NewInt(0), start)
+		v.record(addrA, big.NewInt(1), big.NewInt(0), prevote, start.Add(time.Second))
+		v.startRound(big.NewInt(2), big.NewInt(0), start.Add(2*time.Second))
+		if got := v.list(); len(got) != 0 {
+			t.Fatalf("expected no latency, got %+v", got)
+		}
+	})
+}
+
+func TestVoteLatencyMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	var (
+		addr  = common.HexToAddress("0x03")
+		start = time.Unix(100, 0)
+		v     voteLatencies
+	)
+	before := tendermintVoteLatency.Count("precommit", "10+")
+	v.startRound(big.NewInt(1), big.NewInt(12), start)
+	v.record(addr, big.NewInt(1), big.NewInt(12), precommit, start.Add(1500*time.Millisecond))
+
+	// late rounds share the last round label
+	if have := tendermintVoteLatency.Count("precommit", "10+"); have != before+1 {
+		t.Fatalf("observations mismatch: have %d, want %d", have, before+1)
+	}
+	if have := tendermintVoteLatency.Count("precommit", "12"); have != 0 {
+		t.Fatalf("round label not bounded: %d observations", have)
+	}
+	if latency, ok := tendermintCommitteeVoteLatency.Value(addr.String(), "precommit"); !ok || latency != 1.5 {
+		t.Fatalf("validator latency mismatch: have %v, set %v", latency, ok)
+	}
+}
+
+func TestCoreState(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	logger := log.New("core", "test", "id", 0)
+
+	t.Run("core not started", func(t *testing.T) {
+		c := &core{
+			address:           addr,
+			logger:            logger,
+			valSet:            new(validatorSet),
+			currentRoundState: new(roundState),
+		}
+		want := ConsensusState{
+			Height:        big.NewInt(0),
+			Round:         big.NewInt(0),
+			Step:          propose.String(),
+			VoteLatencies: []VoteLatency{},
+		}
+		if got := c.CoreState(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("want %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("core in a round", func(t *testing.T) {
+		valSet := validator.NewSet([]types.CommitteeMember{
+			{Address: addr, VotingPower: big.NewInt(1)},
+			{Address: common.HexToAddress("0x02"), VotingPower: big.NewInt(1)},
+		}, config.RoundRobin)
+		c := &core{
+			address:           addr,
+			logger:            logger,
+			valSet:            &validatorSet{Set: valSet},
+			currentRoundState: NewRoundState(big.NewInt(2), big.NewInt(5)),
+		}
+		c.currentRoundState.SetStep(prevote)
+		now := time.Now()
+		c.voteLatencies.startRound(big.NewInt(5), big.NewInt(2), now)
+		c.voteLatencies.record(addr, big.NewInt(5), big.NewInt(2), prevote, now.Add(time.Second))
+
+		got := c.CoreState()
+		want := ConsensusState{
+			Height:        big.NewInt(5),
+			Round:         big.NewInt(2),
+			Step:          prevote.String(),
+			Proposer:      valSet.GetProposer().GetAddress(),
+			IsProposer:    valSet.GetProposer().GetAddress() == addr,
+			CommitteeSize: 2,
+			VoteLatencies: []VoteLatency{
+				{Address: addr, Height: big.NewInt(5), Round: big.NewInt(2), Prevote: time.Second},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("want %+v, got %+v", want, got)
+		}
+	})
+}
@@ -96,6 +96,7 @@ func New(backend Backend, config *config.Config) *core {
 		proposeTimeout:               newTimeout(propose, logger),
 		prevoteTimeout:               newTimeout(prevote, logger),
 		precommitTimeout:             newTimeout(precommit, logger),
+		clock:                        systemClock{},
 	}
 }
 
@@ -112,7 +113,7 @@ type core struct {
 	committedSub            *event.TypeMuxSubscription
 	timeoutEventSub         *event.TypeMuxSubscription
 	syncEventSub            *event.TypeMuxSubscription
-	futureProposalTimer     *time.Timer
+	futureProposalTimer     timer
 	stopped                 chan struct{}
 	isStarted               *uint32
 	isStarting              *uint32
@@ -131,6 +132,7 @@ type core struct {
 	pendingUnminedBlocksMu   sync.Mutex
 	pendingUnminedBlockCh    chan *types.Block
 	isWaitingForUnminedBlock bool
+	unminedBlockParent       common.Hash
 
 	sentProposal          bool
 	sentPrevote           bool
@@ -151,6 +153,19 @@ type core struct {
 
 	//map[futureRoundNumber]NumberOfMessagesReceivedForTheRound
 	futureRoundsChange map[int64]int64
+
+	// latest vote latency per validator at the current height
+	voteLatencies voteLatencies
+	// start of the current round, to measure its duration
+	roundStart time.Time
+
+	// tracing spans of the current height, round and step
+	spans consensusSpans
+
+	clock clock
+	// syncEvents makes the core post the backlog events from the calling go
+	// routine, it is set when the backend queues events without blocking.
+	syncEvents bool
 }
 
 func (c *core) GetCurrentHeightMessages() []*Message {
@@ -250,6 +265,7 @@ func (c *core) commit() {
 		copy(committedSeals[i][:], v.CommittedSeal[:])
 	}
 
+	c.spans.committed(proposal.ProposalBlock, c.currentRoundState.Round())
 	if err := c.backend.Commit(proposal.ProposalBlock, c.currentRoundState.Round(), committedSeals); err != nil {
 		c.logger.Error("failed to commit a block", "err", err)
 		return
@@ -275,6 +291,7 @@ func (c *core) startRound(ctx context.Context, round *big.Int) {
 	height := new(big.Int).Add(lastCommittedProposalBlock.Number(), common.Big1)
 
 	c.setCore(round, height, lastCommittedProposalBlockProposer)
+	c.backend.ResetVerifiedProposals()
 
 	// c.setStep(propose) will process the pending unmined blocks sent by the backed.Seal() and set c.lastestPendingRequest
 	c.setStep(propose)
@@ -292,7 +309,7 @@ func (c *core) startRound(ctx context.Context, round *big.Int) {
 		if c.validValue != nil {
 			p = c.validValue
 		} else {
-			p = c.getUnminedBlock()
+			p = c.getUnminedBlock(lastCommittedProposalBlock.Hash())
 			if p == nil {
 				select {
 				case <-ctx.Done():
@@ -321,6 +338,7 @@ func (c *core) setCore(r *big.Int, h *big.Int, lastProposer common.Address) {
 		// Set validator set for height
 		valSet := c.backend.Validators(h.Uint64())
 		c.valSet.set(valSet)
+		c.measureCommitteeMetrics()
 
 		// Assuming that round == 0 only when the node moves to a new height
 		// Therefore, resetting round related maps
@@ -350,7 +368,13 @@ func (c *core) setCore(r *big.Int, h *big.Int, lastProposer common.Address) {
 		c.currentHeightOldRoundsStates[r.Int64()-1] = c.currentRoundState
 		c.currentHeightOldRoundsStatesMu.Unlock()
 	}
+	now := c.getClock().Now()
+	c.measureRoundDuration(now)
+	c.roundStart = now
+	c.spans.startRound(h, r)
+
 	c.currentRoundState.Update(r, h)
+	c.voteLatencies.startRound(h, r, now)
 
 	// Calculate new proposer
 	c.valSet.CalcProposer(lastProposer, r.Uint64())
@@ -380,6 +404,7 @@ func (c *core) acceptVote(roundState *roundState, step Step, hash common.Hash, m
 
 func (c *core) setStep(step Step) {
 	c.currentRoundState.SetStep(step)
+	c.spans.startStep(step)
 	c.processBacklog()
 }
 
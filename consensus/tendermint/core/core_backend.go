@@ -96,6 +96,10 @@ func (c *core) ResetPeerCache(address common.Address) {
 	c.backend.ResetPeerCache(address)
 }
 
+func (c *core) SubscribePipelineEvent(ch chan<- consensus.PipelineEvent) event.Subscription {
+	return c.backend.SubscribePipelineEvent(ch)
+}
+
 // Backend provides application specific functions for Istanbul core
 type Backend interface {
 	consensus.Engine
@@ -126,6 +130,17 @@ type Backend interface {
 	// the time difference of the proposal and current time is also returned.
 	VerifyProposal(types.Block) (time.Duration, error)
 
+	// ResetVerifiedProposals drops the execution results kept from the proposals
+	// verified in the previous round.
+	ResetVerifiedProposals()
+
+	// PipelineProposal starts building the block of the next height on top of
+	// the given block, before it is decided.
+	PipelineProposal(parent *types.Block)
+
+	// SubscribePipelineEvent subscribes the block builder to the pipelined parents.
+	SubscribePipelineEvent(ch chan<- consensus.PipelineEvent) event.Subscription
+
 	// Sign signs input data with the backend's private key
 	Sign([]byte) ([]byte, error)
 
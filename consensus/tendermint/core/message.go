@@ -34,6 +34,13 @@ const (
 	msgPrecommit
 )
 
+// Codes of the consensus messages, for the external signers to decode them.
+const (
+	MsgProposal  = msgProposal
+	MsgPrevote   = msgPrevote
+	MsgPrecommit = msgPrecommit
+)
+
 type Message struct {
 	Code          uint64
 	Msg           []byte
@@ -17,7 +17,13 @@
 package core
 
 import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/metrics"
+	"github.com/clearmatics/autonity/metrics/prometheus"
 )
 
 var (
@@ -27,3 +33,56 @@ var (
 	tendermintPrevoteTimer      = metrics.NewRegisteredTimer("tendermint/timer/prevote", nil)
 	tendermintPrecommitTimer    = metrics.NewRegisteredTimer("tendermint/timer/precommit", nil)
 )
+
+// Labeled metrics exported to Prometheus, durations are in seconds.
+var (
+	tendermintRoundDuration = prometheus.NewRegisteredHistogramVec("tendermint/round/duration",
+		[]float64{0.25, 0.5, 1, 2, 3, 5, 10, 20, 30, 60}, "round")
+	tendermintVoteLatency = prometheus.NewRegisteredHistogramVec("tendermint/vote/latency",
+		[]float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}, "step", "round")
+
+	// per validator gauges of the committee of the current height
+	tendermintCommitteeVotingPower = prometheus.NewRegisteredGaugeVec("tendermint/committee/votingpower", "address")
+	tendermintCommitteeVoteLatency = prometheus.NewRegisteredGaugeVec("tendermint/committee/vote/latency", "address", "step")
+)
+
+// maxRoundLabel bounds the values of the round label, the later rounds sharing
+// the last value.
+const maxRoundLabel = 10
+
+func roundLabel(round int64) string {
+	if round >= maxRoundLabel {
+		return strconv.Itoa(maxRoundLabel) + "+"
+	}
+	return strconv.FormatInt(round, 10)
+}
+
+// measureRoundDuration observes the duration of the current round, which ends
+// at now with the start of the next round or height.
+func (c *core) measureRoundDuration(now time.Time) {
+	round := c.currentRoundState.Round()
+	if c.roundStart.IsZero() || round == nil {
+		return
+	}
+	tendermintRoundDuration.Observe(now.Sub(c.roundStart).Seconds(), roundLabel(round.Int64()))
+}
+
+// measureCommitteeMetrics resets the per validator gauges for the committee of
+// a new height.
+func (c *core) measureCommitteeMetrics() {
+	if !metrics.Enabled {
+		return
+	}
+	tendermintCommitteeVotingPower.Reset()
+	tendermintCommitteeVoteLatency.Reset()
+	for _, val := range c.valSet.List() {
+		power, _ := new(big.Float).SetInt(val.GetVotingPower()).Float64()
+		tendermintCommitteeVotingPower.Update(power, val.GetAddress().String())
+	}
+}
+
+// measureVoteLatency observes the latency of a vote of a validator.
+func measureVoteLatency(addr common.Address, round int64, step Step, latency time.Duration) {
+	tendermintVoteLatency.Observe(latency.Seconds(), step.String(), roundLabel(round))
+	tendermintCommitteeVoteLatency.Update(latency.Seconds(), addr.String(), step.String())
+}
@@ -0,0 +1,448 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/tendermint/validator"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/rlp"
+)
+
+// futureHeightFlood is the number of future heights the flooding validator
+// sends votes for along with each of its votes.
+const futureHeightFlood = 10
+
+var errUnknownByzantineBehaviour = errors.New("unknown byzantine behaviour")
+
+// ByzantineBehaviour wraps the backend of a validator to make it misbehave.
+type ByzantineBehaviour func(Backend) Backend
+
+var byzantineBehaviours = map[string]ByzantineBehaviour{
+	"equivocation":     NewEquivocatingBackend,
+	"withhold-votes":   NewVoteWithholdingBackend,
+	"amnesia":          NewAmnesiaBackend,
+	"invalid-proposal": NewInvalidProposalBackend,
+	"future-flood":     NewFutureHeightFloodingBackend,
+	"forgery":          NewSignatureForgeryBackend,
+	"selective-gossip": NewSelectiveGossipBackend,
+}
+
+// ByzantineBehaviours returns the names of the available byzantine behaviours.
+func ByzantineBehaviours() []string {
+	names := make([]string, 0, len(byzantineBehaviours))
+	for name := range byzantineBehaviours {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewByzantineBackend wraps the backend with the named behaviours, the first
+// one being the closest to the original backend.
+func NewByzantineBackend(b Backend, names ...string) (Backend, error) {
+	for _, name := range names {
+		behaviour, ok := byzantineBehaviours[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errUnknownByzantineBehaviour, name)
+		}
+		b = behaviour(b)
+	}
+	return b, nil
+}
+
+// byzantineBackend holds the helpers shared by the byzantine behaviours.
+type byzantineBackend struct {
+	Backend
+}
+
+func (b byzantineBackend) GetOriginal() Backend {
+	return b.Backend
+}
+
+// send signs the message and broadcasts it to the given validators, self
+// included if it belongs to them.
+func (b byzantineBackend) send(ctx context.Context, valSet validator.Set, msg *Message) error {
+	payload, err := b.sign(msg)
+	if err != nil {
+		return err
+	}
+	if _, v := valSet.GetByAddress(b.Address()); v != nil {
+		return b.Backend.Broadcast(ctx, valSet, payload)
+	}
+	b.Backend.Gossip(ctx, valSet, payload)
+	return nil
+}
+
+func (b byzantineBackend) sign(msg *Message) ([]byte, error) {
+	data, err := msg.PayloadNoSig()
+	if err != nil {
+		return nil, err
+	}
+	msg.Signature, err = b.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Payload()
+}
+
+// vote builds a signed prevote or precommit, the committed seal of a
+// precommit being signed as well.
+func (b byzantineBackend) vote(code uint64, round, height *big.Int, hash common.Hash) (*Message, error) {
+	encoded, err := Encode(&Vote{Round: round, Height: height, ProposedBlockHash: hash})
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{
+		Code:          code,
+		Msg:           encoded,
+		Address:       b.Address(),
+		CommittedSeal: []byte{},
+	}
+	if code == msgPrecommit {
+		msg.CommittedSeal, err = b.Sign(PrepareCommittedSeal(hash, round, height))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// proposal builds a proposal for another block, sealed by the validator.
+func (b byzantineBackend) proposal(p *Proposal, block *types.Block) (*Message, error) {
+	sealed, err := b.AddSeal(block)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := Encode(NewProposal(p.Round, p.Height, p.ValidRound, sealed, p.logger))
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		Code:          msgProposal,
+		Msg:           encoded,
+		Address:       b.Address(),
+		CommittedSeal: []byte{},
+	}, nil
+}
+
+// proposalTracker remembers the last proposal the validator found valid.
+type proposalTracker struct {
+	mu     sync.Mutex
+	height uint64
+	hash   common.Hash
+}
+
+func (t *proposalTracker) track(b Backend, proposal types.Block) (time.Duration, error) {
+	duration, err := b.VerifyProposal(proposal)
+	if err == nil {
+		t.mu.Lock()
+		t.height = proposal.NumberU64()
+		t.hash = proposal.Hash()
+		t.mu.Unlock()
+	}
+	return duration, err
+}
+
+// last returns the last valid proposal at the given height.
+func (t *proposalTracker) last(height *big.Int) (common.Hash, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.hash, t.hash != (common.Hash{}) && t.height == height.Uint64()
+}
+
+func decodeMessage(payload []byte) (*Message, error) {
+	msg := new(Message)
+	if err := rlp.DecodeBytes(payload, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// splitValidators splits the validators in two halves, the validator itself
+// being in the first one.
+func splitValidators(self common.Address, valSet validator.Set) (validator.Set, validator.Set) {
+	first, second := valSet.Copy(), valSet.Copy()
+	var others []common.Address
+	for _, v := range valSet.List() {
+		if v.GetAddress() != self {
+			others = append(others, v.GetAddress())
+		}
+	}
+	for i, addr := range others {
+		if i < len(others)/2 {
+			second.RemoveValidator(addr)
+		} else {
+			first.RemoveValidator(addr)
+		}
+	}
+	second.RemoveValidator(self)
+	return first, second
+}
+
+// equivocatingBackend sends conflicting messages to the two halves of the
+// validators: another block is proposed to the second half, and its votes are
+// flipped between the proposal and nil.
+type equivocatingBackend struct {
+	byzantineBackend
+	proposalTracker
+}
+
+func NewEquivocatingBackend(b Backend) Backend {
+	return &equivocatingBackend{byzantineBackend: byzantineBackend{b}}
+}
+
+func (b *equivocatingBackend) VerifyProposal(proposal types.Block) (time.Duration, error) {
+	return b.track(b.Backend, proposal)
+}
+
+func (b *equivocatingBackend) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	msg, err := decodeMessage(payload)
+	if err != nil {
+		return err
+	}
+	first, second := splitValidators(b.Address(), valSet)
+	if err := b.Backend.Broadcast(ctx, first, payload); err != nil {
+		return err
+	}
+
+	var conflicting *Message
+	switch msg.Code {
+	case msgProposal:
+		var p Proposal
+		if err := msg.Decode(&p); err != nil {
+			return err
+		}
+		header := p.ProposalBlock.Header()
+		header.Time++
+		conflicting, err = b.proposal(&p, p.ProposalBlock.WithSeal(header))
+	case msgPrevote, msgPrecommit:
+		var v Vote
+		if err := msg.Decode(&v); err != nil {
+			return err
+		}
+		hash := common.Hash{}
+		if v.ProposedBlockHash == (common.Hash{}) {
+			proposal, ok := b.last(v.Height)
+			if !ok {
+				break
+			}
+			hash = proposal
+		}
+		conflicting, err = b.vote(msg.Code, v.Round, v.Height, hash)
+	}
+	if err != nil || conflicting == nil {
+		return err
+	}
+	return b.send(ctx, second, conflicting)
+}
+
+// voteWithholdingBackend never sends its votes to the other validators.
+type voteWithholdingBackend struct {
+	byzantineBackend
+}
+
+func NewVoteWithholdingBackend(b Backend) Backend {
+	return &voteWithholdingBackend{byzantineBackend{b}}
+}
+
+func (b *voteWithholdingBackend) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	msg, err := decodeMessage(payload)
+	if err != nil {
+		return err
+	}
+	if msg.Code == msgPrevote || msg.Code == msgPrecommit {
+		self := valSet.Copy()
+		for _, v := range valSet.List() {
+			if v.GetAddress() != b.Address() {
+				self.RemoveValidator(v.GetAddress())
+			}
+		}
+		valSet = self
+	}
+	return b.Backend.Broadcast(ctx, valSet, payload)
+}
+
+// amnesiaBackend forgets its lock: it prevotes for the last valid proposal of
+// the height whatever it locked on.
+type amnesiaBackend struct {
+	byzantineBackend
+	proposalTracker
+}
+
+func NewAmnesiaBackend(b Backend) Backend {
+	return &amnesiaBackend{byzantineBackend: byzantineBackend{b}}
+}
+
+func (b *amnesiaBackend) VerifyProposal(proposal types.Block) (time.Duration, error) {
+	return b.track(b.Backend, proposal)
+}
+
+func (b *amnesiaBackend) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	msg, err := decodeMessage(payload)
+	if err != nil {
+		return err
+	}
+	if msg.Code != msgPrevote {
+		return b.Backend.Broadcast(ctx, valSet, payload)
+	}
+	var v Vote
+	if err := msg.Decode(&v); err != nil {
+		return err
+	}
+
+	proposal, ok := b.last(v.Height)
+	if !ok || proposal == v.ProposedBlockHash {
+		return b.Backend.Broadcast(ctx, valSet, payload)
+	}
+
+	prevote, err := b.vote(msgPrevote, v.Round, v.Height, proposal)
+	if err != nil {
+		return err
+	}
+	return b.send(ctx, valSet, prevote)
+}
+
+// invalidProposalBackend proposes blocks with a wrong state root.
+type invalidProposalBackend struct {
+	byzantineBackend
+}
+
+func NewInvalidProposalBackend(b Backend) Backend {
+	return &invalidProposalBackend{byzantineBackend{b}}
+}
+
+func (b *invalidProposalBackend) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	msg, err := decodeMessage(payload)
+	if err != nil {
+		return err
+	}
+	if msg.Code != msgProposal {
+		return b.Backend.Broadcast(ctx, valSet, payload)
+	}
+	var p Proposal
+	if err := msg.Decode(&p); err != nil {
+		return err
+	}
+	header := p.ProposalBlock.Header()
+	header.Root = crypto.Keccak256Hash(header.Root.Bytes())
+	invalid, err := b.proposal(&p, p.ProposalBlock.WithSeal(header))
+	if err != nil {
+		return err
+	}
+	return b.send(ctx, valSet, invalid)
+}
+
+// futureHeightFloodingBackend sends along with each of its votes prevotes for
+// random blocks at the next heights.
+type futureHeightFloodingBackend struct {
+	byzantineBackend
+}
+
+func NewFutureHeightFloodingBackend(b Backend) Backend {
+	return &futureHeightFloodingBackend{byzantineBackend{b}}
+}
+
+func (b *futureHeightFloodingBackend) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	if err := b.Backend.Broadcast(ctx, valSet, payload); err != nil {
+		return err
+	}
+	msg, err := decodeMessage(payload)
+	if err != nil {
+		return err
+	}
+	if msg.Code != msgPrevote && msg.Code != msgPrecommit {
+		return nil
+	}
+	var v Vote
+	if err := msg.Decode(&v); err != nil {
+		return err
+	}
+	for i := int64(1); i <= futureHeightFlood; i++ {
+		height := new(big.Int).Add(v.Height, big.NewInt(i))
+		prevote, err := b.vote(msgPrevote, common.Big0, height, crypto.Keccak256Hash(height.Bytes(), payload))
+		if err != nil {
+			return err
+		}
+		payload, err := b.sign(prevote)
+		if err != nil {
+			return err
+		}
+		b.Backend.Gossip(ctx, valSet, payload)
+	}
+	return nil
+}
+
+// signatureForgeryBackend sends along with each of its messages a copy
+// claiming to come from another validator and a copy with a corrupted
+// signature.
+type signatureForgeryBackend struct {
+	byzantineBackend
+}
+
+func NewSignatureForgeryBackend(b Backend) Backend {
+	return &signatureForgeryBackend{byzantineBackend{b}}
+}
+
+func (b *signatureForgeryBackend) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	if err := b.Backend.Broadcast(ctx, valSet, payload); err != nil {
+		return err
+	}
+	msg, err := decodeMessage(payload)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range valSet.List() {
+		if v.GetAddress() == b.Address() {
+			continue
+		}
+		impersonated := *msg
+		impersonated.Address = v.GetAddress()
+		forged, err := b.sign(&impersonated)
+		if err != nil {
+			return err
+		}
+		b.Backend.Gossip(ctx, valSet, forged)
+		break
+	}
+
+	corrupted := *msg
+	corrupted.Signature = append([]byte(nil), msg.Signature...)
+	if len(corrupted.Signature) > 0 {
+		corrupted.Signature[0] ^= 0xff
+	}
+	forged, err := corrupted.Payload()
+	if err != nil {
+		return err
+	}
+	b.Backend.Gossip(ctx, valSet, forged)
+	return nil
+}
+
+// selectiveGossipBackend sends its messages only to the first half of the
+// validators.
+type selectiveGossipBackend struct {
+	byzantineBackend
+}
+
+func NewSelectiveGossipBackend(b Backend) Backend {
+	return &selectiveGossipBackend{byzantineBackend{b}}
+}
+
+func (b *selectiveGossipBackend) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	first, _ := splitValidators(b.Address(), valSet)
+	return b.Backend.Broadcast(ctx, first, payload)
+}
+
+func (b *selectiveGossipBackend) Gossip(ctx context.Context, valSet validator.Set, payload []byte) {
+	first, _ := splitValidators(b.Address(), valSet)
+	b.Backend.Gossip(ctx, first, payload)
+}
@@ -0,0 +1,327 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/tendermint/crypto"
+	"github.com/clearmatics/autonity/consensus/tendermint/validator"
+	"github.com/clearmatics/autonity/core/types"
+	ethcrypto "github.com/clearmatics/autonity/crypto"
+	"github.com/golang/mock/gomock"
+)
+
+// byzantineTest holds a mocked backend signing with the key of the first of
+// four validators.
+type byzantineTest struct {
+	backend *MockBackend
+	valSet  validator.Set
+	self    common.Address
+}
+
+func newByzantineTest(t *testing.T) *byzantineTest {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	valSet, keys := newTestValidatorSetWithKeys(4)
+	self := valSet.GetByIndex(0).GetAddress()
+
+	backend := NewMockBackend(ctrl)
+	backend.EXPECT().Address().AnyTimes().Return(self)
+	backend.EXPECT().Sign(gomock.Any()).AnyTimes().DoAndReturn(func(data []byte) ([]byte, error) {
+		return ethcrypto.Sign(ethcrypto.Keccak256(data), keys[self])
+	})
+	backend.EXPECT().AddSeal(gomock.Any()).AnyTimes().DoAndReturn(func(block *types.Block) (*types.Block, error) {
+		return block, nil
+	})
+	return &byzantineTest{backend: backend, valSet: valSet, self: self}
+}
+
+func (bt *byzantineTest) sign(t *testing.T, msg *Message) []byte {
+	payload, err := byzantineBackend{bt.backend}.sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func (bt *byzantineTest) vote(t *testing.T, code uint64, height int64, hash common.Hash) []byte {
+	msg, err := byzantineBackend{bt.backend}.vote(code, big.NewInt(0), big.NewInt(height), hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bt.sign(t, msg)
+}
+
+func (bt *byzantineTest) proposal(t *testing.T, block *types.Block) []byte {
+	encoded, err := Encode(NewProposal(big.NewInt(0), block.Number(), big.NewInt(-1), block, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bt.sign(t, &Message{Code: msgProposal, Msg: encoded, Address: bt.self, CommittedSeal: []byte{}})
+}
+
+// verify decodes a payload as an honest validator does.
+func (bt *byzantineTest) verify(t *testing.T, payload []byte) *Message {
+	msg := new(Message)
+	if _, err := msg.FromPayload(payload, bt.valSet, crypto.CheckValidatorSignature); err != nil {
+		t.Fatalf("expected a valid message, got %v", err)
+	}
+	return msg
+}
+
+func decodeVote(t *testing.T, msg *Message) Vote {
+	var v Vote
+	if err := msg.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func decodeProposal(t *testing.T, msg *Message) Proposal {
+	var p Proposal
+	if err := msg.Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func testBlock(height int64) *types.Block {
+	return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(height), Time: 10})
+}
+
+func TestNewByzantineBackend(t *testing.T) {
+	bt := newByzantineTest(t)
+
+	b, err := NewByzantineBackend(bt.backend, "amnesia", "selective-gossip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer, ok := b.(*selectiveGossipBackend)
+	if !ok {
+		t.Fatalf("expected the last behaviour outermost, got %T", b)
+	}
+	if _, ok := outer.GetOriginal().(*amnesiaBackend); !ok {
+		t.Fatalf("expected the first behaviour innermost, got %T", outer.GetOriginal())
+	}
+
+	if _, err := NewByzantineBackend(bt.backend, "amnesia", "unknown"); !errors.Is(err, errUnknownByzantineBehaviour) {
+		t.Fatalf("expected %v, got %v", errUnknownByzantineBehaviour, err)
+	}
+
+	for _, name := range ByzantineBehaviours() {
+		if _, err := NewByzantineBackend(bt.backend, name); err != nil {
+			t.Fatalf("behaviour %s: %v", name, err)
+		}
+	}
+}
+
+func TestSplitValidators(t *testing.T) {
+	bt := newByzantineTest(t)
+
+	first, second := splitValidators(bt.self, bt.valSet)
+	if first.Size() != 2 || second.Size() != 2 {
+		t.Fatalf("expected two halves of 2 validators, got %d and %d", first.Size(), second.Size())
+	}
+	if _, v := first.GetByAddress(bt.self); v == nil {
+		t.Fatal("expected the validator in the first half")
+	}
+	for _, v := range second.List() {
+		if _, other := first.GetByAddress(v.GetAddress()); other != nil {
+			t.Fatalf("validator %v in both halves", v.GetAddress())
+		}
+	}
+}
+
+func TestEquivocatingBackend(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("another block is proposed to the second half", func(t *testing.T) {
+		bt := newByzantineTest(t)
+		block := testBlock(1)
+		payload := bt.proposal(t, block)
+
+		bt.backend.EXPECT().Broadcast(ctx, gomock.Any(), payload).DoAndReturn(func(_ context.Context, set validator.Set, _ []byte) error {
+			if set.Size() != 2 {
+				t.Fatalf("expected the proposal sent to 2 validators, got %d", set.Size())
+			}
+			return nil
+		})
+		bt.backend.EXPECT().Gossip(ctx, gomock.Any(), gomock.Any()).Do(func(_ context.Context, set validator.Set, conflicting []byte) {
+			if _, v := set.GetByAddress(bt.self); v != nil {
+				t.Fatal("expected the conflicting proposal not sent to self")
+			}
+			p := decodeProposal(t, bt.verify(t, conflicting))
+			if p.ProposalBlock.Hash() == block.Hash() {
+				t.Fatal("expected a conflicting block")
+			}
+		})
+
+		if err := NewEquivocatingBackend(bt.backend).Broadcast(ctx, bt.valSet, payload); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("nil prevote is flipped to the verified proposal", func(t *testing.T) {
+		bt := newByzantineTest(t)
+		block := testBlock(1)
+		b := NewEquivocatingBackend(bt.backend)
+
+		bt.backend.EXPECT().VerifyProposal(*block).Return(time.Duration(0), nil)
+		if _, err := b.VerifyProposal(*block); err != nil {
+			t.Fatal(err)
+		}
+
+		payload := bt.vote(t, msgPrecommit, 1, common.Hash{})
+		bt.backend.EXPECT().Broadcast(ctx, gomock.Any(), payload)
+		bt.backend.EXPECT().Gossip(ctx, gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ validator.Set, conflicting []byte) {
+			msg := bt.verify(t, conflicting)
+			v := decodeVote(t, msg)
+			if v.ProposedBlockHash != block.Hash() {
+				t.Fatalf("expected a vote for %v, got %v", block.Hash(), v.ProposedBlockHash)
+			}
+			seal := PrepareCommittedSeal(block.Hash(), v.Round, v.Height)
+			if signer, err := types.GetSignatureAddress(seal, msg.CommittedSeal); err != nil || signer != bt.self {
+				t.Fatalf("expected a committed seal of %v, got %v %v", bt.self, signer, err)
+			}
+		})
+
+		if err := b.Broadcast(ctx, bt.valSet, payload); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestVoteWithholdingBackend(t *testing.T) {
+	ctx := context.Background()
+	bt := newByzantineTest(t)
+	b := NewVoteWithholdingBackend(bt.backend)
+
+	proposal := bt.proposal(t, testBlock(1))
+	bt.backend.EXPECT().Broadcast(ctx, bt.valSet, proposal)
+	if err := b.Broadcast(ctx, bt.valSet, proposal); err != nil {
+		t.Fatal(err)
+	}
+
+	prevote := bt.vote(t, msgPrevote, 1, common.Hash{1})
+	bt.backend.EXPECT().Broadcast(ctx, gomock.Any(), prevote).DoAndReturn(func(_ context.Context, set validator.Set, _ []byte) error {
+		if _, v := set.GetByAddress(bt.self); set.Size() != 1 || v == nil {
+			t.Fatalf("expected the vote only sent to self, got %v", set.List())
+		}
+		return nil
+	})
+	if err := b.Broadcast(ctx, bt.valSet, prevote); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAmnesiaBackend(t *testing.T) {
+	ctx := context.Background()
+	bt := newByzantineTest(t)
+	b := NewAmnesiaBackend(bt.backend)
+	block := testBlock(2)
+
+	// no valid proposal known at the height, the vote is unchanged
+	payload := bt.vote(t, msgPrevote, 2, common.Hash{})
+	bt.backend.EXPECT().Broadcast(ctx, bt.valSet, payload)
+	if err := b.Broadcast(ctx, bt.valSet, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	bt.backend.EXPECT().VerifyProposal(*block).Return(time.Duration(0), nil)
+	if _, err := b.VerifyProposal(*block); err != nil {
+		t.Fatal(err)
+	}
+
+	bt.backend.EXPECT().Broadcast(ctx, bt.valSet, gomock.Any()).DoAndReturn(func(_ context.Context, _ validator.Set, prevote []byte) error {
+		if v := decodeVote(t, bt.verify(t, prevote)); v.ProposedBlockHash != block.Hash() {
+			t.Fatalf("expected a prevote for %v, got %v", block.Hash(), v.ProposedBlockHash)
+		}
+		return nil
+	})
+	if err := b.Broadcast(ctx, bt.valSet, payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvalidProposalBackend(t *testing.T) {
+	ctx := context.Background()
+	bt := newByzantineTest(t)
+	block := testBlock(1)
+
+	bt.backend.EXPECT().Broadcast(ctx, bt.valSet, gomock.Any()).DoAndReturn(func(_ context.Context, _ validator.Set, payload []byte) error {
+		p := decodeProposal(t, bt.verify(t, payload))
+		if p.ProposalBlock.Root() == block.Root() {
+			t.Fatal("expected a block with another state root")
+		}
+		return nil
+	})
+	if err := NewInvalidProposalBackend(bt.backend).Broadcast(ctx, bt.valSet, bt.proposal(t, block)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFutureHeightFloodingBackend(t *testing.T) {
+	ctx := context.Background()
+	bt := newByzantineTest(t)
+	payload := bt.vote(t, msgPrevote, 5, common.Hash{1})
+
+	bt.backend.EXPECT().Broadcast(ctx, bt.valSet, payload)
+	var heights []uint64
+	bt.backend.EXPECT().Gossip(ctx, bt.valSet, gomock.Any()).Times(futureHeightFlood).Do(func(_ context.Context, _ validator.Set, flood []byte) {
+		heights = append(heights, decodeVote(t, bt.verify(t, flood)).Height.Uint64())
+	})
+	if err := NewFutureHeightFloodingBackend(bt.backend).Broadcast(ctx, bt.valSet, payload); err != nil {
+		t.Fatal(err)
+	}
+	for i, h := range heights {
+		if h != uint64(6+i) {
+			t.Fatalf("expected prevotes for heights 6 to 15, got %v", heights)
+		}
+	}
+}
+
+func TestSignatureForgeryBackend(t *testing.T) {
+	ctx := context.Background()
+	bt := newByzantineTest(t)
+	payload := bt.vote(t, msgPrevote, 1, common.Hash{1})
+
+	bt.backend.EXPECT().Broadcast(ctx, bt.valSet, payload)
+	bt.backend.EXPECT().Gossip(ctx, bt.valSet, gomock.Any()).Times(2).Do(func(_ context.Context, _ validator.Set, forged []byte) {
+		if _, err := new(Message).FromPayload(forged, bt.valSet, crypto.CheckValidatorSignature); err == nil {
+			t.Fatal("expected a forged message to be rejected")
+		}
+	})
+	if err := NewSignatureForgeryBackend(bt.backend).Broadcast(ctx, bt.valSet, payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectiveGossipBackend(t *testing.T) {
+	ctx := context.Background()
+	bt := newByzantineTest(t)
+	b := NewSelectiveGossipBackend(bt.backend)
+	payload := bt.vote(t, msgPrevote, 1, common.Hash{1})
+
+	firstHalf := func(set validator.Set) {
+		if _, v := set.GetByAddress(bt.self); set.Size() != 2 || v == nil {
+			t.Fatalf("expected the first half of the validators, got %v", set.List())
+		}
+	}
+	bt.backend.EXPECT().Broadcast(ctx, gomock.Any(), payload).DoAndReturn(func(_ context.Context, set validator.Set, _ []byte) error {
+		firstHalf(set)
+		return nil
+	})
+	bt.backend.EXPECT().Gossip(ctx, gomock.Any(), payload).Do(func(_ context.Context, set validator.Set, _ []byte) {
+		firstHalf(set)
+	})
+
+	if err := b.Broadcast(ctx, bt.valSet, payload); err != nil {
+		t.Fatal(err)
+	}
+	b.Gossip(ctx, bt.valSet, payload)
+}
@@ -0,0 +1,47 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/clearmatics/autonity/consensus/tendermint/validator"
+	"github.com/clearmatics/autonity/core/types"
+)
+
+// pipelineProposal asks the backend to build the block of the next height on
+// top of the precommitted block, if this validator proposes the first round of
+// the next height. The block is discarded if another one is decided.
+func (c *core) pipelineProposal(block *types.Block) {
+	if block == nil || !c.isNextProposer(block) {
+		return
+	}
+	c.logger.Debug("Pipelining the next proposal", "parent", block.Hash(), "number", block.NumberU64())
+	c.backend.PipelineProposal(block)
+}
+
+// isNextProposer returns whether this validator is the proposer of the first
+// round of the height following the block if it is decided, the committee of
+// which is the one of the block header.
+func (c *core) isNextProposer(block *types.Block) bool {
+	header := block.Header()
+	proposer, err := types.Ecrecover(header)
+	if err != nil {
+		return false
+	}
+	committee := validator.NewSet(header.Committee, c.config.GetProposerPolicy())
+	committee.CalcProposer(proposer, 0)
+	return committee.IsProposer(c.address)
+}
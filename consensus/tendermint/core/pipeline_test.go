@@ -0,0 +1,76 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/tendermint/config"
+	"github.com/clearmatics/autonity/consensus/tendermint/validator"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/log"
+	"github.com/golang/mock/gomock"
+)
+
+func TestPipelineProposal(t *testing.T) {
+	committee, keys := generateValidators(4)
+	valSet := validator.NewSet(committee, config.RoundRobin)
+	proposer := valSet.GetByIndex(0).GetAddress()
+	next := valSet.GetByIndex(1).GetAddress()
+
+	header := &types.Header{
+		Number:    big.NewInt(5),
+		MixDigest: types.BFTDigest,
+		Committee: committee,
+	}
+	seal, err := crypto.Sign(types.SigHash(header).Bytes(), keys[proposer])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := types.WriteSeal(header, seal); err != nil {
+		t.Fatal(err)
+	}
+	block := types.NewBlockWithHeader(header)
+
+	newCore := func(backend Backend, address common.Address) *core {
+		return &core{
+			address: address,
+			backend: backend,
+			config:  config.DefaultConfig(),
+			logger:  log.New("backend", "test", "id", 0),
+		}
+	}
+
+	t.Run("next proposer pipelines the proposal", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		backendMock := NewMockBackend(ctrl)
+		backendMock.EXPECT().PipelineProposal(block)
+
+		newCore(backendMock, next).pipelineProposal(block)
+	})
+
+	t.Run("other validators don't", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		backendMock := NewMockBackend(ctrl)
+		for _, member := range committee {
+			if member.Address != next {
+				newCore(backendMock, member.Address).pipelineProposal(block)
+			}
+		}
+	})
+
+	t.Run("unsealed block isn't pipelined", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		backendMock := NewMockBackend(ctrl)
+		unsealed := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(5), Committee: committee})
+		newCore(backendMock, next).pipelineProposal(unsealed)
+		newCore(backendMock, next).pipelineProposal(nil)
+	})
+}
@@ -97,6 +97,9 @@ func (c *core) handlePrecommit(ctx context.Context, msg *Message) error {
 		//	}
 		//}
 
+		if err == errOldRoundMessage {
+			c.voteLatencies.record(msg.Address, preCommit.Height, preCommit.Round, precommit, c.getClock().Now())
+		}
 		return err
 	}
 
@@ -105,6 +108,8 @@ func (c *core) handlePrecommit(ctx context.Context, msg *Message) error {
 		return err
 	}
 
+	c.voteLatencies.record(msg.Address, preCommit.Height, preCommit.Round, precommit, c.getClock().Now())
+
 	// We don't care about which step we are in to accept a preCommit, since it has the highest importance
 	precommitHash := preCommit.ProposedBlockHash
 	curR := c.currentRoundState.Round().Int64()
@@ -425,6 +425,13 @@ func TestHandlePrecommit(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Expected nil, got %v", err)
 		}
+		if !c.precommitTimeout.timerStarted() {
+			t.Fatal("Expected pre-commit timeout to be started")
+		}
+		// the core has no backend to send the timeout event to
+		if err := c.precommitTimeout.stopTimer(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
 	})
 }
 
@@ -502,6 +509,7 @@ func TestHandleCommit(t *testing.T) {
 
 	backendMock := NewMockBackend(ctrl)
 	backendMock.EXPECT().LastCommittedProposal().MinTimes(1).Return(block, addr)
+	backendMock.EXPECT().ResetVerifiedProposals()
 
 	valSet := validator.NewMockSet(ctrl)
 	valSet.EXPECT().CalcProposer(addr, uint64(0))
@@ -520,4 +528,11 @@ func TestHandleCommit(t *testing.T) {
 		valSet:            new(validatorSet),
 	}
 	c.handleCommit(context.Background())
+	if !c.proposeTimeout.timerStarted() {
+		t.Fatal("Expected propose timeout to be started")
+	}
+	// the mocked backend doesn't expect the timeout event
+	if err := c.proposeTimeout.stopTimer(); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
 }
@@ -65,6 +65,9 @@ func (c *core) handlePrevote(ctx context.Context, msg *Message) error {
 		return errFailedDecodePrevote
 	}
 
+	// Old round votes are measured from the start of their own round
+	c.voteLatencies.record(msg.Address, preVote.Height, preVote.Round, prevote, c.getClock().Now())
+
 	if err = c.checkMessage(preVote.Round, preVote.Height, prevote); err != nil {
 		// Store old round prevote messages for future rounds since it is required for validRound
 		if err == errOldRoundMessage {
@@ -112,6 +115,7 @@ func (c *core) handlePrevote(ctx context.Context, msg *Message) error {
 				c.lockedRound = big.NewInt(curR)
 				c.sendPrecommit(ctx, false)
 				c.setStep(precommit)
+				c.pipelineProposal(c.lockedValue)
 			}
 			c.validValue = c.currentRoundState.Proposal().ProposalBlock
 			c.validRound = big.NewInt(curR)
@@ -442,5 +442,12 @@ func TestHandlePrevote(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Expected nil, got %v", err)
 		}
+		if !c.prevoteTimeout.timerStarted() {
+			t.Fatal("Expected prevote timeout to be started")
+		}
+		// the mocked backend doesn't expect the timeout event
+		if err := c.prevoteTimeout.stopTimer(); err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
 	})
 }
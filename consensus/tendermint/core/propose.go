@@ -18,7 +18,6 @@ package core
 
 import (
 	"context"
-	"time"
 
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/consensus"
@@ -71,7 +70,7 @@ func (c *core) handleProposal(ctx context.Context, msg *Message) error {
 	}
 
 	// Verify the proposal we received
-	if duration, err := c.backend.VerifyProposal(*proposal.ProposalBlock); err != nil {
+	if duration, err := c.verifyProposal(proposal.ProposalBlock); err != nil {
 		if timeoutErr := c.proposeTimeout.stopTimer(); timeoutErr != nil {
 			return timeoutErr
 		}
@@ -86,7 +85,7 @@ func (c *core) handleProposal(ctx context.Context, msg *Message) error {
 		// TODO: implement wiggle time / median time
 		if err == consensus.ErrFutureBlock {
 			c.stopFutureProposalTimer()
-			c.futureProposalTimer = time.AfterFunc(duration, func() {
+			c.futureProposalTimer = c.getClock().AfterFunc(duration, func() {
 				_, sender := c.valSet.GetByAddress(msg.Address)
 				c.sendEvent(backlogEvent{
 					src: sender,
@@ -99,11 +98,6 @@ func (c *core) handleProposal(ctx context.Context, msg *Message) error {
 
 	// Here is about to accept the Proposal
 	if c.currentRoundState.Step() == propose {
-		if err := c.proposeTimeout.stopTimer(); err != nil {
-			return err
-		}
-		c.logger.Debug("Stopped Scheduled Proposal Timeout")
-
 		// Set the proposal for the current round
 		c.currentRoundState.SetProposal(&proposal, msg)
 
@@ -118,6 +112,11 @@ func (c *core) handleProposal(ctx context.Context, msg *Message) error {
 
 		// Line 22 in Algorithm 1 of The latest gossip on BFT consensus
 		if vr == -1 {
+			if err := c.proposeTimeout.stopTimer(); err != nil {
+				return err
+			}
+			c.logger.Debug("Stopped Scheduled Proposal Timeout")
+
 			var voteForProposal = false
 			if c.lockedValue != nil {
 				voteForProposal = c.lockedRound.Int64() == -1 || h == c.lockedValue.Hash()
@@ -139,7 +138,14 @@ func (c *core) handleProposal(ctx context.Context, msg *Message) error {
 		}
 
 		// Line 28 in Algorithm 1 of The latest gossip on BFT consensus
+		// Without a quorum of prevotes for the valid round the propose timeout
+		// keeps running, otherwise the validator could stay in propose forever.
 		if ok && vr < curR && c.Quorum(rs.Prevotes.VotesSize(h)) {
+			if err := c.proposeTimeout.stopTimer(); err != nil {
+				return err
+			}
+			c.logger.Debug("Stopped Scheduled Proposal Timeout")
+
 			var voteForProposal = false
 			if c.lockedValue != nil {
 				voteForProposal = c.lockedRound.Int64() <= vr || h == c.lockedValue.Hash()
@@ -0,0 +1,592 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"container/heap"
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/tendermint/config"
+	"github.com/clearmatics/autonity/consensus/tendermint/events"
+	"github.com/clearmatics/autonity/consensus/tendermint/validator"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+)
+
+var (
+	// errUnknownProposal is returned by the simulated backend when a proposal
+	// wasn't built by a validator of the simulation.
+	errUnknownProposal = errors.New("unknown proposal")
+	// errProposalParent is returned by the simulated backend when a proposal
+	// doesn't extend the last decided block of the validator.
+	errProposalParent = errors.New("proposal doesn't extend the last decided block")
+)
+
+// simulationEpoch is the virtual time at which every simulation starts.
+var simulationEpoch = time.Unix(1000000000, 0)
+
+// SimulationConfig describes a deterministic run of several tendermint cores
+// exchanging messages through a simulated network. Two runs with the same
+// configuration, seed included, produce the same sequence of events.
+type SimulationConfig struct {
+	Seed         int64  // seed of the message scheduler and of the validator keys
+	Validators   int    // number of validators, all with the same voting power
+	TargetHeight uint64 // height every honest validator must decide for the run to end
+
+	MinDelay time.Duration // minimum delay of a message between two validators
+	MaxDelay time.Duration // maximum delay of a message between two validators
+	DropRate float64       // probability of a message being dropped before GST
+
+	// GST is the global stabilisation time, after it no message is dropped or
+	// partitioned anymore and every honest validator must eventually reach the
+	// target height.
+	GST        time.Duration
+	Partitions []Partition
+
+	// SyncInterval is the interval at which validators whose view didn't change
+	// ask the others for their current height messages, and at which lagging
+	// validators import the decided blocks they miss. 0 disables it.
+	SyncInterval time.Duration
+	MaxTime      time.Duration // virtual time after which the run is stopped
+}
+
+// Partition splits the validators in groups which can't reach each other
+// between Start and End. Validators absent of every group form a group on their
+// own.
+type Partition struct {
+	Start  time.Duration
+	End    time.Duration
+	Groups [][]int
+}
+
+// Decision is a block decided by a validator of a simulation.
+type Decision struct {
+	Validator int
+	Height    uint64
+	Round     int64
+	Hash      common.Hash
+	At        time.Duration
+	Imported  bool // decided by another validator and imported by block sync
+}
+
+// SimulationResult summarises a run.
+type SimulationResult struct {
+	Seed       int64
+	Duration   time.Duration // virtual duration of the run
+	Heights    []uint64      // last decided height of every validator
+	Decisions  []Decision
+	Messages   int // messages sent through the network
+	Dropped    int // messages dropped or partitioned
+	Violations []string
+}
+
+// Err returns an error listing the violated properties, nil if there is none.
+// The seed is part of the message so that the run can be reproduced.
+func (r *SimulationResult) Err() error {
+	if len(r.Violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("simulation with seed %d failed: %v", r.Seed, r.Violations)
+}
+
+// Simulation runs several cores in the calling go routine on a virtual clock.
+// Every message, timeout and commit goes through a single queue ordered by
+// virtual time, the ties being broken by insertion order.
+type Simulation struct {
+	config    SimulationConfig
+	rand      *rand.Rand
+	now       time.Time
+	queue     simQueue
+	seq       uint64
+	committee types.Committee
+	nodes     []*simNode
+	blocks    map[common.Hash]*types.Block // every block built by a validator
+	decided   map[uint64]common.Hash       // first decision of every height
+	result    *SimulationResult
+	ctx       context.Context
+}
+
+// NewSimulation creates the validators of a simulation, their keys are derived
+// from the seed.
+func NewSimulation(cfg SimulationConfig) *Simulation {
+	if cfg.MaxDelay < cfg.MinDelay {
+		cfg.MaxDelay = cfg.MinDelay
+	}
+	s := &Simulation{
+		config:  cfg,
+		rand:    rand.New(rand.NewSource(cfg.Seed)),
+		now:     simulationEpoch,
+		blocks:  make(map[common.Hash]*types.Block),
+		decided: make(map[uint64]common.Hash),
+		result:  &SimulationResult{Seed: cfg.Seed},
+		ctx:     context.Background(),
+	}
+
+	keys := make([]*ecdsa.PrivateKey, cfg.Validators)
+	for i := range keys {
+		seed := make([]byte, 16)
+		binary.BigEndian.PutUint64(seed, uint64(cfg.Seed))
+		binary.BigEndian.PutUint64(seed[8:], uint64(i))
+		key, err := crypto.ToECDSA(crypto.Keccak256(seed))
+		if err != nil {
+			panic(err)
+		}
+		keys[i] = key
+	}
+	// validators are indexed in the committee order
+	sort.Slice(keys, func(i, j int) bool {
+		return crypto.PubkeyToAddress(keys[i].PublicKey).Hex() < crypto.PubkeyToAddress(keys[j].PublicKey).Hex()
+	})
+	for _, key := range keys {
+		s.committee = append(s.committee, types.CommitteeMember{
+			Address:     crypto.PubkeyToAddress(key.PublicKey),
+			VotingPower: big.NewInt(1),
+		})
+	}
+
+	genesis := types.NewBlockWithHeader(&types.Header{
+		Number:     common.Big0,
+		Difficulty: common.Big1,
+		MixDigest:  types.BFTDigest,
+		Committee:  s.committee,
+	})
+	for i, key := range keys {
+		n := &simNode{
+			sim:     s,
+			index:   i,
+			key:     key,
+			address: crypto.PubkeyToAddress(key.PublicKey),
+			chain:   []*types.Block{genesis},
+		}
+		n.core = New(n, config.DefaultConfig())
+		n.core.setClock(simClock{s})
+		n.core.syncEvents = true
+		s.nodes = append(s.nodes, n)
+	}
+	return s
+}
+
+// Run runs the simulation until every honest validator reaches the target
+// height or until the maximum virtual time, and checks the agreement,
+// validity, no double commit and liveness properties along the way.
+func (s *Simulation) Run() *SimulationResult {
+	for _, n := range s.nodes {
+		n := n
+		n.core.currentRoundState.Update(big.NewInt(0), big.NewInt(1))
+		n.core.storeUnminedBlockMsg(n.buildBlock())
+		s.schedule(0, func() { n.core.startRound(s.ctx, common.Big0) })
+	}
+	if s.config.SyncInterval > 0 {
+		s.schedule(s.config.SyncInterval, s.sync)
+	}
+
+	deadline := simulationEpoch.Add(s.config.MaxTime)
+	for s.queue.Len() > 0 && !s.done() {
+		ev := heap.Pop(&s.queue).(*simEvent)
+		if ev.cancelled {
+			continue
+		}
+		if ev.at.After(deadline) {
+			s.now = deadline
+			break
+		}
+		s.now = ev.at
+		ev.fired = true
+		if ev.fn != nil {
+			ev.fn()
+			continue
+		}
+		ev.node.core.handleEvent(s.ctx, ev.data)
+	}
+
+	s.result.Duration = s.now.Sub(simulationEpoch)
+	for _, n := range s.nodes {
+		s.result.Heights = append(s.result.Heights, n.height())
+		if n.height() < s.config.TargetHeight {
+			s.violation("liveness: validator %d stuck at height %d after %v", n.index, n.height(), s.result.Duration)
+		}
+	}
+	return s.result
+}
+
+// Committee returns the committee of the simulation, in validator index order.
+func (s *Simulation) Committee() types.Committee {
+	return s.committee
+}
+
+func (s *Simulation) done() bool {
+	for _, n := range s.nodes {
+		if n.height() < s.config.TargetHeight {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Simulation) violation(format string, args ...interface{}) {
+	s.result.Violations = append(s.result.Violations, fmt.Sprintf(format, args...))
+}
+
+// elapsed returns the virtual time since the start of the simulation.
+func (s *Simulation) elapsed() time.Duration {
+	return s.now.Sub(simulationEpoch)
+}
+
+// schedule calls fn after the given virtual delay.
+func (s *Simulation) schedule(delay time.Duration, fn func()) *simEvent {
+	ev := &simEvent{at: s.now.Add(delay), fn: fn}
+	s.push(ev)
+	return ev
+}
+
+// deliver hands ev to the core of n after the given virtual delay.
+func (s *Simulation) deliver(n *simNode, delay time.Duration, ev interface{}) {
+	s.push(&simEvent{at: s.now.Add(delay), node: n, data: ev})
+}
+
+func (s *Simulation) push(ev *simEvent) {
+	ev.seq = s.seq
+	s.seq++
+	heap.Push(&s.queue, ev)
+}
+
+// send transmits a payload from one validator to another through the
+// simulated network.
+func (s *Simulation) send(from, to *simNode, payload []byte) {
+	if from == to {
+		s.deliver(to, 0, events.MessageEvent{Payload: payload})
+		return
+	}
+	if delay, ok := s.transmit(from, to); ok {
+		s.deliver(to, delay, events.MessageEvent{Payload: payload})
+	}
+}
+
+// transmit returns the delay of a message between two distinct validators, or
+// false if the message is lost.
+func (s *Simulation) transmit(from, to *simNode) (time.Duration, bool) {
+	s.result.Messages++
+	if s.partitioned(from.index, to.index) || (s.elapsed() < s.config.GST && s.rand.Float64() < s.config.DropRate) {
+		s.result.Dropped++
+		return 0, false
+	}
+	delay := s.config.MinDelay
+	if spread := s.config.MaxDelay - s.config.MinDelay; spread > 0 {
+		delay += time.Duration(s.rand.Int63n(int64(spread)))
+	}
+	return delay, true
+}
+
+// partitioned returns whether two validators are in different groups of a
+// partition active at the current virtual time.
+func (s *Simulation) partitioned(a, b int) bool {
+	now := s.elapsed()
+	for _, p := range s.config.Partitions {
+		if now < p.Start || now >= p.End || now >= s.config.GST {
+			continue
+		}
+		if p.group(a) != p.group(b) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Partition) group(index int) int {
+	for g, members := range p.Groups {
+		for _, m := range members {
+			if m == index {
+				return g
+			}
+		}
+	}
+	return -1
+}
+
+// sync replays the consensus sync loop of the validators: the ones whose view
+// stayed the same since the last interval ask the others for their messages.
+// The validators behind the highest height they can reach also import the
+// blocks they miss, as block synchronisation does outside of the consensus.
+func (s *Simulation) sync() {
+	for _, n := range s.nodes {
+		var longest *simNode
+		for _, from := range s.nodes {
+			if s.partitioned(n.index, from.index) {
+				continue
+			}
+			if longest == nil || from.height() > longest.height() {
+				longest = from
+			}
+		}
+		for n.height() < longest.height() {
+			n.importBlock(longest.chain[n.height()+1])
+		}
+	}
+	for _, n := range s.nodes {
+		view := [2]int64{n.core.currentRoundState.Height().Int64(), n.core.currentRoundState.Round().Int64()}
+		if view == n.lastView {
+			n.AskSync(n.core.valSet.Copy())
+		}
+		n.lastView = view
+	}
+	s.schedule(s.config.SyncInterval, s.sync)
+}
+
+// decide checks a block decided by a validator against the properties of the
+// consensus and records it.
+func (s *Simulation) decide(n *simNode, block *types.Block, round int64, imported bool) {
+	height := block.NumberU64()
+	hash := block.Hash()
+
+	if _, ok := s.blocks[hash]; !ok {
+		s.violation("validity: validator %d decided block %v at height %d which no validator proposed", n.index, hash, height)
+	}
+	if parent := n.chain[len(n.chain)-1]; block.ParentHash() != parent.Hash() {
+		s.violation("validity: validator %d decided block %v at height %d which doesn't extend %v", n.index, hash, height, parent.Hash())
+	}
+	if first, ok := s.decided[height]; !ok {
+		s.decided[height] = hash
+	} else if first != hash {
+		s.violation("agreement: validator %d decided %v at height %d while %v was decided", n.index, hash, height, first)
+	}
+	s.result.Decisions = append(s.result.Decisions, Decision{
+		Validator: n.index,
+		Height:    height,
+		Round:     round,
+		Hash:      hash,
+		At:        s.elapsed(),
+		Imported:  imported,
+	})
+}
+
+// simNode is a validator of the simulation, it implements the backend of its
+// core on top of the simulated network and clock.
+type simNode struct {
+	Backend // methods not needed by the core handlers aren't implemented
+
+	sim       *Simulation
+	index     int
+	key       *ecdsa.PrivateKey
+	address   common.Address
+	core      *core
+	chain     []*types.Block // decided blocks, starting from the genesis
+	committed map[uint64]bool
+	lastView  [2]int64 // height and round at the last sync interval
+}
+
+func (n *simNode) height() uint64 {
+	return uint64(len(n.chain) - 1)
+}
+
+// buildBlock builds the block the validator proposes at the next height.
+func (n *simNode) buildBlock() *types.Block {
+	parent := n.chain[len(n.chain)-1]
+	block := types.NewBlockWithHeader(&types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Coinbase:   n.address,
+		Difficulty: common.Big1,
+		Time:       uint64(n.sim.now.Unix()),
+		MixDigest:  types.BFTDigest,
+		Committee:  n.sim.committee,
+	})
+	n.sim.blocks[block.Hash()] = block
+	return block
+}
+
+// appendBlock moves the validator to the next height.
+func (n *simNode) appendBlock(block *types.Block) {
+	n.chain = append(n.chain, block)
+	n.core.storeUnminedBlockMsg(n.buildBlock())
+	n.sim.deliver(n, 0, events.CommitEvent{})
+}
+
+// importBlock appends a block decided by another validator.
+func (n *simNode) importBlock(block *types.Block) {
+	n.sim.decide(n, block, block.Header().Round.Int64(), true)
+	n.appendBlock(block)
+}
+
+func (n *simNode) Address() common.Address {
+	return n.address
+}
+
+func (n *simNode) Validators(number uint64) validator.Set {
+	return validator.NewSet(n.sim.committee, config.RoundRobin)
+}
+
+func (n *simNode) Post(ev interface{}) {
+	n.sim.deliver(n, 0, ev)
+}
+
+func (n *simNode) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	for _, to := range n.sim.nodes {
+		if _, v := valSet.GetByAddress(to.address); v != nil {
+			n.sim.send(n, to, payload)
+		}
+	}
+	return nil
+}
+
+// Gossip is a no-op since every validator is directly connected to the others.
+func (n *simNode) Gossip(ctx context.Context, valSet validator.Set, payload []byte) {}
+
+func (n *simNode) Commit(proposal *types.Block, round *big.Int, seals [][]byte) error {
+	height := proposal.NumberU64()
+	if n.committed == nil {
+		n.committed = make(map[uint64]bool)
+	}
+	if n.committed[height] {
+		n.sim.violation("no double commit: validator %d committed height %d twice", n.index, height)
+	}
+	n.committed[height] = true
+
+	// the block may have been imported while the core was still deciding it
+	if height <= n.height() {
+		if hash := n.chain[height].Hash(); hash != proposal.Hash() {
+			n.sim.violation("agreement: validator %d committed %v at height %d while it imported %v", n.index, proposal.Hash(), height, hash)
+		}
+		return nil
+	}
+
+	header := proposal.Header()
+	header.Round = new(big.Int).Set(round)
+	header.CommittedSeals = seals
+	block := proposal.WithSeal(header)
+	n.sim.decide(n, block, round.Int64(), false)
+	n.appendBlock(block)
+	return nil
+}
+
+func (n *simNode) VerifyProposal(proposal types.Block) (time.Duration, error) {
+	if _, ok := n.sim.blocks[proposal.Hash()]; !ok {
+		return 0, errUnknownProposal
+	}
+	if parent := n.chain[len(n.chain)-1]; proposal.ParentHash() != parent.Hash() {
+		return 0, errProposalParent
+	}
+	return 0, nil
+}
+
+func (n *simNode) Sign(data []byte) ([]byte, error) {
+	return crypto.Sign(crypto.Keccak256(data), n.key)
+}
+
+func (n *simNode) LastCommittedProposal() (*types.Block, common.Address) {
+	block := n.chain[len(n.chain)-1]
+	return block, block.Coinbase()
+}
+
+func (n *simNode) SetProposedBlockHash(hash common.Hash) {}
+
+func (n *simNode) ResetVerifiedProposals() {}
+
+func (n *simNode) PipelineProposal(parent *types.Block) {}
+
+// AskSync sends a sync request to the other validators, each one answers with
+// its current height messages.
+func (n *simNode) AskSync(valSet validator.Set) {
+	for _, to := range n.sim.nodes {
+		to := to
+		if _, v := valSet.GetByAddress(to.address); v == nil || to == n {
+			continue
+		}
+		if delay, ok := n.sim.transmit(n, to); ok {
+			n.sim.schedule(delay, func() { to.core.SyncPeer(n.address) })
+		}
+	}
+}
+
+func (n *simNode) SyncPeer(address common.Address, messages []*Message) {
+	for _, to := range n.sim.nodes {
+		if to.address != address {
+			continue
+		}
+		for _, msg := range messages {
+			payload, err := msg.Payload()
+			if err != nil {
+				continue
+			}
+			n.sim.send(n, to, payload)
+		}
+	}
+}
+
+// simClock is the virtual clock of a simulation.
+type simClock struct {
+	sim *Simulation
+}
+
+func (c simClock) Now() time.Time {
+	return c.sim.now
+}
+
+func (c simClock) AfterFunc(d time.Duration, f func()) timer {
+	return c.sim.schedule(d, f)
+}
+
+// simEvent is either a timer callback or an event delivered to a validator.
+type simEvent struct {
+	at        time.Time
+	seq       uint64
+	fn        func()
+	node      *simNode
+	data      interface{}
+	fired     bool
+	cancelled bool
+}
+
+// Stop cancels the event, it returns false if it already happened.
+func (e *simEvent) Stop() bool {
+	if e.fired || e.cancelled {
+		return false
+	}
+	e.cancelled = true
+	return true
+}
+
+// simQueue orders the events by virtual time then insertion order.
+type simQueue []*simEvent
+
+func (q simQueue) Len() int { return len(q) }
+
+func (q simQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q simQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *simQueue) Push(x interface{}) { *q = append(*q, x.(*simEvent)) }
+
+func (q *simQueue) Pop() interface{} {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
+)
+
+func simulationConfig(seed int64) SimulationConfig {
+	return SimulationConfig{
+		Seed:         seed,
+		Validators:   4,
+		TargetHeight: 5,
+		MinDelay:     10 * time.Millisecond,
+		MaxDelay:     300 * time.Millisecond,
+		DropRate:     0.2,
+		GST:          20 * time.Second,
+		SyncInterval: 5 * time.Second,
+		MaxTime:      10 * time.Minute,
+	}
+}
+
+func TestSimulationProperties(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		result := NewSimulation(simulationConfig(seed)).Run()
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		for i, h := range result.Heights {
+			if h < 5 {
+				t.Fatalf("seed %d: validator %d at height %d", seed, i, h)
+			}
+		}
+	}
+}
+
+func TestSimulationDeterministic(t *testing.T) {
+	first := NewSimulation(simulationConfig(42)).Run()
+	second := NewSimulation(simulationConfig(42)).Run()
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("runs with the same seed differ:\n%+v\n%+v", first, second)
+	}
+
+	other := NewSimulation(simulationConfig(43)).Run()
+	if reflect.DeepEqual(first.Decisions, other.Decisions) {
+		t.Fatal("runs with different seeds decided the same blocks")
+	}
+}
+
+func TestSimulationPartition(t *testing.T) {
+	cfg := simulationConfig(7)
+	cfg.DropRate = 0
+	cfg.GST = 30 * time.Second
+	cfg.Partitions = []Partition{{
+		Start:  0,
+		End:    30 * time.Second,
+		Groups: [][]int{{0, 1}, {2, 3}},
+	}}
+
+	result := NewSimulation(cfg).Run()
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	// no side of the partition holds a quorum
+	for _, d := range result.Decisions {
+		if d.At < 30*time.Second {
+			t.Fatalf("block decided during the partition: %+v", d)
+		}
+	}
+}
+
+func TestSimulationLiveness(t *testing.T) {
+	cfg := simulationConfig(3)
+	cfg.DropRate = 0
+	cfg.MaxTime = time.Minute
+	// a partition never healed before the end of the run breaks liveness
+	cfg.GST = time.Hour
+	cfg.Partitions = []Partition{{
+		Start:  0,
+		End:    time.Hour,
+		Groups: [][]int{{0}, {1, 2, 3}},
+	}}
+
+	result := NewSimulation(cfg).Run()
+	if result.Err() == nil {
+		t.Fatal("expected a liveness violation")
+	}
+	if result.Heights[0] != 0 {
+		t.Fatalf("isolated validator decided: %v", result.Heights)
+	}
+	for _, h := range result.Heights[1:] {
+		if h < cfg.TargetHeight {
+			t.Fatalf("quorum didn't reach the target height: %v", result.Heights)
+		}
+	}
+}
+
+func TestSimulationAgreementViolation(t *testing.T) {
+	s := NewSimulation(simulationConfig(1))
+	a := s.nodes[0].buildBlock()
+	b := s.nodes[1].buildBlock()
+
+	s.decide(s.nodes[0], a, 0, false)
+	s.decide(s.nodes[1], b, 0, false)
+	if len(s.result.Violations) != 1 {
+		t.Fatalf("expected an agreement violation, got %v", s.result.Violations)
+	}
+
+	unknown := types.NewBlockWithHeader(&types.Header{Number: common.Big1, ParentHash: a.ParentHash()})
+	s.decide(s.nodes[2], unknown, 0, false)
+	if len(s.result.Violations) != 3 {
+		t.Fatalf("expected validity and agreement violations, got %v", s.result.Violations)
+	}
+}
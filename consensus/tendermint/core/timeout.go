@@ -26,7 +26,8 @@ type TimeoutEvent struct {
 }
 
 type timeout struct {
-	timer   *time.Timer
+	timer   timer
+	clock   clock
 	started bool
 	step    Step
 	// start will be refreshed on each new schedule, it is used for metric collection of tendermint timeout.
@@ -38,6 +39,7 @@ type timeout struct {
 func newTimeout(s Step, logger log.Logger) *timeout {
 	return &timeout{
 		started: false,
+		clock:   systemClock{},
 		step:    s,
 		start:   time.Now(),
 		logger:  logger,
@@ -49,12 +51,20 @@ func (t *timeout) scheduleTimeout(stepTimeout time.Duration, round int64, height
 	t.Lock()
 	defer t.Unlock()
 	t.started = true
-	t.start = time.Now()
-	t.timer = time.AfterFunc(stepTimeout, func() {
+	t.start = t.getClock().Now()
+	t.timer = t.getClock().AfterFunc(stepTimeout, func() {
 		runAfterTimeout(round, height)
 	})
 }
 
+// getClock returns the clock of the timeout, the system clock if none was set.
+func (t *timeout) getClock() clock {
+	if t.clock == nil {
+		return systemClock{}
+	}
+	return t.clock
+}
+
 func (t *timeout) timerStarted() bool {
 	t.Lock()
 	defer t.Unlock()
@@ -83,11 +93,11 @@ func (t *timeout) stopTimer() error {
 func (t *timeout) measureMetricsOnStopTimer() {
 	switch t.step {
 	case propose:
-		tendermintProposeTimer.UpdateSince(t.start)
+		tendermintProposeTimer.Update(t.getClock().Now().Sub(t.start))
 	case prevote:
-		tendermintPrevoteTimer.UpdateSince(t.start)
+		tendermintPrevoteTimer.Update(t.getClock().Now().Sub(t.start))
 	case precommit:
-		tendermintPrecommitTimer.UpdateSince(t.start)
+		tendermintPrecommitTimer.Update(t.getClock().Now().Sub(t.start))
 	}
 }
 
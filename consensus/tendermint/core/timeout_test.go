@@ -150,6 +150,7 @@ func TestHandleTimeoutPrecommit(t *testing.T) {
 
 		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
 		mockBackend.EXPECT().LastCommittedProposal().Return(block, currentValidator.GetAddress())
+		mockBackend.EXPECT().ResetVerifiedProposals()
 		engine.handleTimeoutPrecommit(context.Background(), timeoutEvent)
 
 		if engine.currentRoundState.height.Uint64() != 2 || engine.currentRoundState.round.Uint64() != 2 {
@@ -0,0 +1,112 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/tracing"
+)
+
+// consensusSpans are the tracing spans of the current height, round and step.
+// They are only accessed from the consensus event loop.
+type consensusSpans struct {
+	height *tracing.Span
+	round  *tracing.Span
+	step   *tracing.Span
+
+	stepValue Step // step of the step span
+}
+
+// startRound ends the spans of the previous round, and of the previous height
+// if the round is the first one, then starts the span of the round.
+func (s *consensusSpans) startRound(height, round *big.Int) {
+	s.step.Finish()
+	s.round.Finish()
+	s.step, s.round = nil, nil
+
+	if round.Sign() == 0 {
+		s.height.Finish()
+		s.height = tracing.StartHeight(height.Uint64(), "tendermint.height")
+	}
+	s.round = tracing.StartChild(s.height, "tendermint.round", tracing.Int64("round", round.Int64()))
+}
+
+// startStep ends the span of the previous step and starts the span of the step,
+// unless the step doesn't change.
+func (s *consensusSpans) startStep(step Step) {
+	if s.step != nil && s.stepValue == step {
+		return
+	}
+	s.step.Finish()
+	s.step, s.stepValue = tracing.StartChild(s.round, "tendermint."+step.String()), step
+}
+
+// committed records the block decided at the height.
+func (s *consensusSpans) committed(block *types.Block, round *big.Int) {
+	if s.height == nil {
+		return
+	}
+	s.height.SetAttributes(
+		tracing.String("block.hash", block.Hash().Hex()),
+		tracing.Int64("round", round.Int64()),
+	)
+}
+
+// finish ends the spans when the consensus stops.
+func (s *consensusSpans) finish() {
+	s.step.Finish()
+	s.round.Finish()
+	s.height.Finish()
+	s.step, s.round, s.height = nil, nil, nil
+}
+
+// startMessage starts the span of the handling of a consensus message, within
+// the current step.
+func (s *consensusSpans) startMessage(msg *Message) *tracing.Span {
+	var name string
+	switch msg.Code {
+	case msgProposal:
+		name = "tendermint.handleProposal"
+	case msgPrevote:
+		name = "tendermint.handlePrevote"
+	case msgPrecommit:
+		name = "tendermint.handlePrecommit"
+	default:
+		name = "tendermint.handleMessage"
+	}
+	return tracing.StartChild(s.step, name, tracing.String("sender", msg.Address.Hex()))
+}
+
+// verifyProposal verifies the proposed block with the backend, within a span.
+func (c *core) verifyProposal(block *types.Block) (time.Duration, error) {
+	// the block hash is only computed, and cached, if tracing is enabled
+	var span *tracing.Span
+	if tracing.Enabled() {
+		span = tracing.StartChild(c.spans.step, "tendermint.verifyProposal",
+			tracing.String("block.hash", block.Hash().Hex()),
+			tracing.Int64("block.txs", int64(len(block.Transactions()))),
+		)
+	}
+	defer span.Finish()
+
+	duration, err := c.backend.VerifyProposal(*block)
+	span.SetError(err)
+	return duration, err
+}
@@ -54,24 +54,33 @@ func (c *core) updatePendingUnminedBlocks(unminedBlock *types.Block) {
 		}
 	}
 
-	if c.isWaitingForUnminedBlock {
+	// A pipelined block built on top of a block which wasn't decided is never proposed
+	if c.isWaitingForUnminedBlock && unminedBlock.ParentHash() == c.unminedBlockParent {
 		c.pendingUnminedBlockCh <- unminedBlock
 		c.isWaitingForUnminedBlock = false
 	}
 	c.pendingUnminedBlocks[unminedBlock.NumberU64()] = unminedBlock
 }
 
-func (c *core) getUnminedBlock() *types.Block {
+// getUnminedBlock returns the block to propose at the current height, it has to
+// extend the last committed block.
+func (c *core) getUnminedBlock(parent common.Hash) *types.Block {
 	c.pendingUnminedBlocksMu.Lock()
 	defer c.pendingUnminedBlocksMu.Unlock()
 
-	ub, ok := c.pendingUnminedBlocks[c.currentRoundState.Height().Uint64()]
+	height := c.currentRoundState.Height().Uint64()
+	ub, ok := c.pendingUnminedBlocks[height]
 
-	if ok {
+	if ok && ub.ParentHash() == parent {
 		return ub
 	}
+	if ok {
+		c.logger.Debug("Discarding pipelined block", "hash", ub.Hash(), "parent", ub.ParentHash())
+		delete(c.pendingUnminedBlocks, height)
+	}
 
 	c.isWaitingForUnminedBlock = true
+	c.unminedBlockParent = parent
 	return nil
 
 }
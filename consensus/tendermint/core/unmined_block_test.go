@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/consensus"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/log"
@@ -90,6 +91,30 @@ func TestUpdatePendingUnminedBlocks(t *testing.T) {
 			t.Fatalf("Unmined blocks size must be 1, got %d", s)
 		}
 	})
+
+	t.Run("wait for unmined block, block on top of another parent not proposed", func(t *testing.T) {
+		pendingUnminedBlockCh := make(chan *types.Block, 1)
+
+		c := &core{
+			currentRoundState:        NewRoundState(big.NewInt(2), big.NewInt(3)),
+			pendingUnminedBlocks:     make(map[uint64]*types.Block),
+			pendingUnminedBlockCh:    pendingUnminedBlockCh,
+			isWaitingForUnminedBlock: true,
+			unminedBlockParent:       common.HexToHash("0x02"),
+		}
+		pipelined := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(3), ParentHash: common.HexToHash("0x01")})
+
+		c.updatePendingUnminedBlocks(pipelined)
+
+		select {
+		case block := <-pendingUnminedBlockCh:
+			t.Fatalf("unexpected block %v", block)
+		default:
+		}
+		if !c.isWaitingForUnminedBlock {
+			t.Fatal("core must still wait for a block")
+		}
+	})
 }
 
 func TestGetUnminedBlock(t *testing.T) {
@@ -103,7 +128,7 @@ func TestGetUnminedBlock(t *testing.T) {
 			pendingUnminedBlocks: unminedBlocks,
 		}
 
-		block := c.getUnminedBlock()
+		block := c.getUnminedBlock(common.Hash{})
 		if !reflect.DeepEqual(block, expectedBlock) {
 			t.Fatalf("Want %v, got %v", expectedBlock, block)
 		}
@@ -115,11 +140,35 @@ func TestGetUnminedBlock(t *testing.T) {
 			pendingUnminedBlocks: make(map[uint64]*types.Block),
 		}
 
-		block := c.getUnminedBlock()
+		block := c.getUnminedBlock(common.Hash{})
 		if block != nil {
 			t.Fatalf("Want <nil>. got %v", block)
 		}
 	})
+
+	t.Run("block on top of another parent is discarded", func(t *testing.T) {
+		pipelined := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), ParentHash: common.HexToHash("0x01")})
+
+		unminedBlocks := make(map[uint64]*types.Block)
+		unminedBlocks[pipelined.NumberU64()] = pipelined
+		c := &core{
+			logger:               log.New("backend", "test", "id", 0),
+			currentRoundState:    NewRoundState(big.NewInt(1), big.NewInt(1)),
+			pendingUnminedBlocks: unminedBlocks,
+		}
+
+		parent := common.HexToHash("0x02")
+		block := c.getUnminedBlock(parent)
+		if block != nil {
+			t.Fatalf("Want <nil>. got %v", block)
+		}
+		if s := len(c.pendingUnminedBlocks); s != 0 {
+			t.Fatalf("Unmined blocks size must be 0, got %d", s)
+		}
+		if !c.isWaitingForUnminedBlock || c.unminedBlockParent != parent {
+			t.Fatalf("core must wait for a block on top of %v", parent)
+		}
+	})
 }
 
 func TestCheckUnminedBlockMsg(t *testing.T) {
@@ -0,0 +1,89 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package participation
+
+import (
+	"github.com/clearmatics/autonity/metrics"
+	"github.com/clearmatics/autonity/metrics/prometheus"
+)
+
+// Labeled gauges of every validator of the window, exported to Prometheus.
+var (
+	validatorUptimeGauge       = prometheus.NewRegisteredGaugeVec("tendermint/participation/validator/uptime", "address")
+	validatorMissedGauge       = prometheus.NewRegisteredGaugeVec("tendermint/participation/validator/missed", "address")
+	validatorMissedStreakGauge = prometheus.NewRegisteredGaugeVec("tendermint/participation/validator/missedstreak", "address")
+)
+
+// trackerMetrics are the gauges summarising the participation of the committee
+// members over the window of a tracker.
+type trackerMetrics struct {
+	validators metrics.Gauge        // number of validators seen in the window
+	offline    metrics.Gauge        // number of validators which missed the last height they were part of
+	missed     metrics.Gauge        // number of missed committed seals in the window
+	minUptime  metrics.GaugeFloat64 // lowest uptime percentage in the window
+	avgUptime  metrics.GaugeFloat64 // average uptime percentage in the window
+}
+
+func newTrackerMetrics(r metrics.Registry) *trackerMetrics {
+	return &trackerMetrics{
+		validators: metrics.GetOrRegisterGauge("tendermint/participation/validators", r),
+		offline:    metrics.GetOrRegisterGauge("tendermint/participation/offline", r),
+		missed:     metrics.GetOrRegisterGauge("tendermint/participation/missed", r),
+		minUptime:  metrics.GetOrRegisterGaugeFloat64("tendermint/participation/uptime/min", r),
+		avgUptime:  metrics.GetOrRegisterGaugeFloat64("tendermint/participation/uptime/avg", r),
+	}
+}
+
+// update refreshes the gauges from the statistics of the window.
+func (m *trackerMetrics) update(all []Stats) {
+	if !metrics.Enabled {
+		return
+	}
+	var (
+		offline, missed int64
+		minUptime       float64
+		sumUptime       float64
+	)
+	for i, stats := range all {
+		if stats.MissedStreak > 0 {
+			offline++
+		}
+		missed += int64(stats.Missed)
+		sumUptime += stats.Uptime
+		if i == 0 || stats.Uptime < minUptime {
+			minUptime = stats.Uptime
+		}
+	}
+	validatorUptimeGauge.Reset()
+	validatorMissedGauge.Reset()
+	validatorMissedStreakGauge.Reset()
+	for _, stats := range all {
+		address := stats.Address.String()
+		validatorUptimeGauge.Update(stats.Uptime, address)
+		validatorMissedGauge.Update(float64(stats.Missed), address)
+		validatorMissedStreakGauge.Update(float64(stats.MissedStreak), address)
+	}
+	m.validators.Update(int64(len(all)))
+	m.offline.Update(offline)
+	m.missed.Update(missed)
+	m.minUptime.Update(minUptime)
+	if len(all) > 0 {
+		m.avgUptime.Update(sumUptime / float64(len(all)))
+	} else {
+		m.avgUptime.Update(0)
+	}
+}
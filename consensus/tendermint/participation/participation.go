@@ -0,0 +1,347 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package participation aggregates the committed seals carried by tendermint
+// headers into per validator liveness statistics.
+package participation
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/clearmatics/autonity/common"
+	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/metrics"
+)
+
+// DefaultWindow is the number of heights kept by a Tracker when the chain
+// configuration doesn't specify one.
+const DefaultWindow = 1000
+
+var (
+	// errMissingCommittee is returned when a record is built for a header without
+	// the committee of its parent.
+	errMissingCommittee = errors.New("missing parent committee")
+)
+
+// HeaderReader is the subset of the chain needed to rebuild participation
+// records from the stored headers.
+type HeaderReader interface {
+	GetHeader(hash common.Hash, number uint64) *types.Header
+}
+
+// Record describes who proposed and who signed a precommit for a single height.
+// The committee is the one of the parent header, which is the committee that
+// decided this height.
+type Record struct {
+	Number    uint64           `json:"number"`
+	Round     uint64           `json:"round"`
+	Proposer  common.Address   `json:"proposer"`
+	Committee []common.Address `json:"committee"`
+	Signers   []common.Address `json:"signers"`
+}
+
+// NewRecord recovers the proposer and the committed seal signers of header.
+// Seals which are not signed by a member of committee are ignored.
+func NewRecord(header *types.Header, committee types.Committee) (*Record, error) {
+	if len(committee) == 0 {
+		return nil, errMissingCommittee
+	}
+	proposer, err := types.Ecrecover(header)
+	if err != nil {
+		return nil, err
+	}
+	record := &Record{
+		Number:    header.Number.Uint64(),
+		Proposer:  proposer,
+		Committee: make([]common.Address, len(committee)),
+	}
+	if header.Round != nil {
+		record.Round = header.Round.Uint64()
+	}
+	for i, member := range committee {
+		record.Committee[i] = member.Address
+	}
+	record.addSeals(tendermintCore.PrepareCommittedSeal(header.Hash(), header.Round, header.Number), header.CommittedSeals)
+	return record, nil
+}
+
+// addSeals adds the signers of seals over data to the record, skipping invalid
+// seals, duplicated signers and signers outside of the committee.
+func (r *Record) addSeals(data []byte, seals [][]byte) {
+	for _, seal := range seals {
+		addr, err := types.GetSignatureAddress(data, seal)
+		if err != nil {
+			continue
+		}
+		if !r.InCommittee(addr) || r.Signed(addr) {
+			continue
+		}
+		r.Signers = append(r.Signers, addr)
+	}
+}
+
+// InCommittee returns whether addr was part of the committee for this height.
+func (r *Record) InCommittee(addr common.Address) bool {
+	for _, member := range r.Committee {
+		if member == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Signed returns whether addr has a committed seal for this height.
+func (r *Record) Signed(addr common.Address) bool {
+	for _, signer := range r.Signers {
+		if signer == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Missed returns whether addr was part of the committee but has no committed
+// seal for this height.
+func (r *Record) Missed(addr common.Address) bool {
+	return r.InCommittee(addr) && !r.Signed(addr)
+}
+
+// Stats summarises the participation of a single validator over the window of
+// a Tracker.
+type Stats struct {
+	Address             common.Address `json:"address"`
+	Proposed            uint64         `json:"proposed"`
+	Signed              uint64         `json:"signed"`
+	Missed              uint64         `json:"missed"`
+	Uptime              float64        `json:"uptime"`
+	MissedStreak        uint64         `json:"missedStreak"`
+	LongestMissedStreak uint64         `json:"longestMissedStreak"`
+	LastProposed        uint64         `json:"lastProposed"`
+	LastSigned          uint64         `json:"lastSigned"`
+}
+
+// Activity lists the heights of the window at which a validator proposed,
+// signed a precommit for or missed the decided block.
+type Activity struct {
+	Address  common.Address `json:"address"`
+	Proposed []uint64       `json:"proposed"`
+	Signed   []uint64       `json:"signed"`
+	Missed   []uint64       `json:"missed"`
+}
+
+// Tracker keeps the participation records of the last window heights.
+type Tracker struct {
+	window  uint64
+	records []*Record // ascending height order
+	mu      sync.RWMutex
+
+	metrics *trackerMetrics
+}
+
+// NewTracker creates a tracker keeping the records of the last window heights
+// and reporting its summary to the default metrics registry.
+func NewTracker(window uint64) *Tracker {
+	return NewTrackerWithRegistry(window, metrics.DefaultRegistry)
+}
+
+// NewTrackerWithRegistry creates a tracker keeping the records of the last
+// window heights and reporting its summary to the given metrics registry.
+func NewTrackerWithRegistry(window uint64, r metrics.Registry) *Tracker {
+	if window == 0 {
+		window = DefaultWindow
+	}
+	return &Tracker{
+		window:  window,
+		records: make([]*Record, 0, window),
+		metrics: newTrackerMetrics(r),
+	}
+}
+
+// Window returns the number of heights kept by the tracker.
+func (t *Tracker) Window() uint64 {
+	return t.window
+}
+
+// Backfill rebuilds the records of the window ending at head from the stored
+// headers, replacing any previously tracked record.
+func (t *Tracker) Backfill(chain HeaderReader, head *types.Header) {
+	var records []*Record
+	for header := head; header != nil && header.Number.Uint64() > 0 && uint64(len(records)) < t.window; {
+		parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			break
+		}
+		record, err := NewRecord(header, parent.Committee)
+		if err != nil {
+			break
+		}
+		records = append(records, record)
+		header = parent
+	}
+	// records were collected from the head backwards
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	t.mu.Lock()
+	t.records = records
+	t.mu.Unlock()
+	t.metrics.update(t.AllStats())
+}
+
+// Process adds the record of header, whose parent is the given header, to the
+// window. The past committed seals of header are credited to the parent height.
+func (t *Tracker) Process(header *types.Header, parent *types.Header) error {
+	record, err := NewRecord(header, parent.Committee)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	// drop the records at or above the new height in case the chain was rewound
+	n := len(t.records)
+	for n > 0 && t.records[n-1].Number >= record.Number {
+		n--
+	}
+	t.records = t.records[:n]
+	if n > 0 && len(header.PastCommittedSeals) > 0 {
+		if last := t.records[n-1]; last.Number == parent.Number.Uint64() {
+			last.addSeals(tendermintCore.PrepareCommittedSeal(parent.Hash(), parent.Round, parent.Number), header.PastCommittedSeals)
+		}
+	}
+	t.records = append(t.records, record)
+	if uint64(len(t.records)) > t.window {
+		t.records = t.records[uint64(len(t.records))-t.window:]
+	}
+	t.mu.Unlock()
+
+	t.metrics.update(t.AllStats())
+	return nil
+}
+
+// Record returns the tracked record for the given height, or nil if it is
+// outside of the window. The signers include those credited by the past
+// committed seals of the following height.
+func (t *Tracker) Record(number uint64) *Record {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, record := range t.records {
+		if record.Number == number {
+			cpy := *record
+			cpy.Signers = append([]common.Address(nil), record.Signers...)
+			return &cpy
+		}
+	}
+	return nil
+}
+
+// Stats returns the participation statistics of addr over the window.
+func (t *Tracker) Stats(addr common.Address) Stats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.stats(addr)
+}
+
+func (t *Tracker) stats(addr common.Address) Stats {
+	stats := Stats{Address: addr}
+	var streak uint64
+	for _, record := range t.records {
+		if record.Proposer == addr {
+			stats.Proposed++
+			stats.LastProposed = record.Number
+		}
+		if !record.InCommittee(addr) {
+			continue
+		}
+		if record.Signed(addr) {
+			stats.Signed++
+			stats.LastSigned = record.Number
+			streak = 0
+			continue
+		}
+		stats.Missed++
+		streak++
+		if streak > stats.LongestMissedStreak {
+			stats.LongestMissedStreak = streak
+		}
+	}
+	stats.MissedStreak = streak
+	if total := stats.Signed + stats.Missed; total > 0 {
+		stats.Uptime = float64(stats.Signed) * 100 / float64(total)
+	}
+	return stats
+}
+
+// AllStats returns the statistics of every validator which was part of a
+// committee during the window.
+func (t *Tracker) AllStats() []Stats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var result []Stats
+	for _, addr := range t.validators() {
+		result = append(result, t.stats(addr))
+	}
+	return result
+}
+
+// validators returns the committee members seen in the window, in order of
+// first appearance.
+func (t *Tracker) validators() []common.Address {
+	var (
+		addresses []common.Address
+		seen      = make(map[common.Address]struct{})
+	)
+	for _, record := range t.records {
+		for _, member := range record.Committee {
+			if _, ok := seen[member]; ok {
+				continue
+			}
+			seen[member] = struct{}{}
+			addresses = append(addresses, member)
+		}
+	}
+	return addresses
+}
+
+// Activity returns the heights of the window at which addr proposed, signed or
+// missed a block.
+func (t *Tracker) Activity(addr common.Address) Activity {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	activity := Activity{
+		Address:  addr,
+		Proposed: []uint64{},
+		Signed:   []uint64{},
+		Missed:   []uint64{},
+	}
+	for _, record := range t.records {
+		if record.Proposer == addr {
+			activity.Proposed = append(activity.Proposed, record.Number)
+		}
+		if record.Signed(addr) {
+			activity.Signed = append(activity.Signed, record.Number)
+		} else if record.InCommittee(addr) {
+			activity.Missed = append(activity.Missed, record.Number)
+		}
+	}
+	return activity
+}
@@ -0,0 +1,235 @@
+package participation
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/metrics"
+)
+
+type testChain struct {
+	keys      []*ecdsa.PrivateKey
+	committee types.Committee
+	headers   []*types.Header
+	pastSeals [][]byte // past committed seals of the next extended header
+}
+
+func (c *testChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) || c.headers[number].Hash() != hash {
+		return nil
+	}
+	return c.headers[number]
+}
+
+func newTestChain(t *testing.T, n int) *testChain {
+	t.Helper()
+	chain := &testChain{}
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain.keys = append(chain.keys, key)
+	}
+	sort.Slice(chain.keys, func(i, j int) bool {
+		return crypto.PubkeyToAddress(chain.keys[i].PublicKey).Hex() < crypto.PubkeyToAddress(chain.keys[j].PublicKey).Hex()
+	})
+	for _, key := range chain.keys {
+		chain.committee = append(chain.committee, types.CommitteeMember{
+			Address:     crypto.PubkeyToAddress(key.PublicKey),
+			VotingPower: big.NewInt(1),
+		})
+	}
+	chain.headers = []*types.Header{{
+		Number:    big.NewInt(0),
+		MixDigest: types.BFTDigest,
+		Round:     big.NewInt(0),
+		Committee: chain.committee,
+	}}
+	return chain
+}
+
+func (c *testChain) address(i int) common.Address {
+	return c.committee[i].Address
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	t.Helper()
+	sig, err := crypto.Sign(crypto.Keccak256(data), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// extend appends a block proposed by the proposer index and sealed by the
+// signers indexes.
+func (c *testChain) extend(t *testing.T, proposer int, signers ...int) *types.Header {
+	t.Helper()
+	parent := c.headers[len(c.headers)-1]
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		MixDigest:  types.BFTDigest,
+		Round:      big.NewInt(0),
+		Committee:  c.committee,
+	}
+	header.PastCommittedSeals, c.pastSeals = c.pastSeals, nil
+	header.ProposerSeal = sign(t, c.keys[proposer], types.SigHash(header).Bytes())
+	data := tendermintCore.PrepareCommittedSeal(header.Hash(), header.Round, header.Number)
+	for _, i := range signers {
+		header.CommittedSeals = append(header.CommittedSeals, sign(t, c.keys[i], data))
+	}
+	c.headers = append(c.headers, header)
+	return header
+}
+
+func TestNewRecord(t *testing.T) {
+	chain := newTestChain(t, 4)
+	outsider, _ := crypto.GenerateKey()
+	header := chain.extend(t, 1, 0, 1, 2)
+	header.CommittedSeals = append(header.CommittedSeals,
+		sign(t, outsider, tendermintCore.PrepareCommittedSeal(header.Hash(), header.Round, header.Number)),
+		header.CommittedSeals[0])
+
+	record, err := NewRecord(header, chain.committee)
+	if err != nil {
+		t.Fatalf("expected <nil>, got %v", err)
+	}
+	if record.Proposer != chain.address(1) {
+		t.Fatalf("proposer mismatch, want %v, got %v", chain.address(1), record.Proposer)
+	}
+	want := []common.Address{chain.address(0), chain.address(1), chain.address(2)}
+	if !reflect.DeepEqual(record.Signers, want) {
+		t.Fatalf("signers mismatch, want %v, got %v", want, record.Signers)
+	}
+	if !record.Missed(chain.address(3)) {
+		t.Fatalf("expected %v to have missed the block", chain.address(3))
+	}
+
+	if _, err := NewRecord(header, nil); err != errMissingCommittee {
+		t.Fatalf("expected %v, got %v", errMissingCommittee, err)
+	}
+}
+
+func TestTrackerStats(t *testing.T) {
+	chain := newTestChain(t, 4)
+	tracker := NewTracker(8)
+	for i := 1; i <= 10; i++ {
+		signers := []int{0, 1, 2, 3}
+		if i > 7 || i == 4 {
+			signers = []int{0, 1, 2}
+		}
+		header := chain.extend(t, i%4, signers...)
+		if err := tracker.Process(header, chain.headers[i-1]); err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+	}
+
+	// The window holds heights 3 to 10.
+	got := tracker.Stats(chain.address(3))
+	want := Stats{
+		Address:             chain.address(3),
+		Proposed:            2,
+		Signed:              4,
+		Missed:              4,
+		Uptime:              50,
+		MissedStreak:        3,
+		LongestMissedStreak: 3,
+		LastProposed:        7,
+		LastSigned:          7,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+
+	activity := tracker.Activity(chain.address(3))
+	if !reflect.DeepEqual(activity.Missed, []uint64{4, 8, 9, 10}) {
+		t.Fatalf("unexpected missed heights %v", activity.Missed)
+	}
+	if !reflect.DeepEqual(activity.Proposed, []uint64{3, 7}) {
+		t.Fatalf("unexpected proposed heights %v", activity.Proposed)
+	}
+
+	if uptime := tracker.Stats(chain.address(0)).Uptime; uptime != 100 {
+		t.Fatalf("expected 100%% uptime, got %v", uptime)
+	}
+	if n := len(tracker.AllStats()); n != 4 {
+		t.Fatalf("expected 4 validators, got %d", n)
+	}
+	if tracker.Record(2) != nil {
+		t.Fatalf("expected height 2 to be out of the window")
+	}
+
+	backfilled := NewTracker(8)
+	backfilled.Backfill(chain, chain.headers[len(chain.headers)-1])
+	if !reflect.DeepEqual(backfilled.AllStats(), tracker.AllStats()) {
+		t.Fatalf("backfilled stats mismatch, want %+v, got %+v", tracker.AllStats(), backfilled.AllStats())
+	}
+}
+
+func TestTrackerPastCommittedSeals(t *testing.T) {
+	chain := newTestChain(t, 4)
+	tracker := NewTracker(0)
+
+	parent := chain.extend(t, 0, 0, 1, 2)
+	if err := tracker.Process(parent, chain.headers[0]); err != nil {
+		t.Fatalf("expected <nil>, got %v", err)
+	}
+	chain.pastSeals = [][]byte{
+		sign(t, chain.keys[3], tendermintCore.PrepareCommittedSeal(parent.Hash(), parent.Round, parent.Number)),
+	}
+	header := chain.extend(t, 1, 0, 1, 2, 3)
+	if err := tracker.Process(header, parent); err != nil {
+		t.Fatalf("expected <nil>, got %v", err)
+	}
+
+	if missed := tracker.Stats(chain.address(3)).Missed; missed != 0 {
+		t.Fatalf("expected past committed seal to be credited, got %d missed", missed)
+	}
+}
+
+func TestTrackerMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	chain := newTestChain(t, 4)
+	first, second := metrics.NewRegistry(), metrics.NewRegistry()
+	tracker := NewTrackerWithRegistry(4, first)
+	other := NewTrackerWithRegistry(4, second)
+	for i := 1; i <= 4; i++ {
+		header := chain.extend(t, 0, 0, 1, 2)
+		if err := tracker.Process(header, chain.headers[i-1]); err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+	}
+	other.Backfill(chain, chain.headers[1])
+
+	if n := first.Get("tendermint/participation/validators").(metrics.Gauge).Value(); n != 4 {
+		t.Fatalf("expected 4 validators, got %d", n)
+	}
+	if n := first.Get("tendermint/participation/offline").(metrics.Gauge).Value(); n != 1 {
+		t.Fatalf("expected 1 offline validator, got %d", n)
+	}
+	if n := first.Get("tendermint/participation/missed").(metrics.Gauge).Value(); n != 4 {
+		t.Fatalf("expected 4 missed seals, got %d", n)
+	}
+	if uptime := first.Get("tendermint/participation/uptime/min").(metrics.GaugeFloat64).Value(); uptime != 0 {
+		t.Fatalf("expected 0%% minimum uptime, got %v", uptime)
+	}
+	if uptime := first.Get("tendermint/participation/uptime/avg").(metrics.GaugeFloat64).Value(); uptime != 75 {
+		t.Fatalf("expected 75%% average uptime, got %v", uptime)
+	}
+	// trackers don't interfere with each other's registry
+	if n := second.Get("tendermint/participation/missed").(metrics.Gauge).Value(); n != 1 {
+		t.Fatalf("expected 1 missed seal, got %d", n)
+	}
+}
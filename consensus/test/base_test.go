@@ -31,6 +31,43 @@ func TestTendermintSuccess(t *testing.T) {
 	}
 }
 
+func TestTendermintPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	cases := []*testCase{
+		{
+			name:          "no malicious",
+			numValidators: 5,
+			numBlocks:     20,
+			txPerPeer:     1,
+			pipeline:      true,
+		},
+		{
+			name:          "one node stops for a few blocks",
+			numValidators: 5,
+			numBlocks:     20,
+			txPerPeer:     1,
+			pipeline:      true,
+			beforeHooks: map[string]hook{
+				"VD": hookStopNode("VD", 5),
+			},
+			afterHooks: map[string]hook{
+				"VD": hookStartNode("VD", 10),
+			},
+			stopTime: make(map[string]time.Time),
+		},
+	}
+
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(fmt.Sprintf("test case %s", testCase.name), func(t *testing.T) {
+			runTest(t, testCase)
+		})
+	}
+}
+
 func TestTendermintSlowConnections(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping test in short mode")
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
+)
+
+// byzantine returns the injectors making a validator misbehave with the named
+// behaviours of the tendermint core.
+func byzantine(t *testing.T, behaviours ...string) injectors {
+	return injectors{
+		backs: func(basic tendermintCore.Backend) tendermintCore.Backend {
+			b, err := tendermintCore.NewByzantineBackend(basic, behaviours...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return b
+		},
+	}
+}
+
+// TestTendermintByzantine runs each byzantine behaviour on one validator, the
+// honest validators must keep deciding the same blocks.
+func TestTendermintByzantine(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	var cases []*testCase
+	for _, behaviour := range tendermintCore.ByzantineBehaviours() {
+		cases = append(cases, &testCase{
+			name:          behaviour,
+			numValidators: 5,
+			numBlocks:     10,
+			txPerPeer:     1,
+			maliciousPeers: map[string]injectors{
+				"VE": byzantine(t, behaviour),
+			},
+		})
+	}
+	cases = append(cases, &testCase{
+		name:          "equivocation and amnesia with future-flood",
+		numValidators: 5,
+		numBlocks:     10,
+		txPerPeer:     1,
+		maliciousPeers: map[string]injectors{
+			"VE": byzantine(t, "amnesia", "equivocation", "future-flood"),
+		},
+	})
+
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(fmt.Sprintf("test case %s", testCase.name), func(t *testing.T) {
+			runTest(t, testCase)
+		})
+	}
+}
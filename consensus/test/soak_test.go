@@ -0,0 +1,622 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clearmatics/autonity/accounts/abi/bind"
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/keygenerator"
+	"github.com/clearmatics/autonity/consensus"
+	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/consensus/tendermint/validator"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/ethclient"
+	"github.com/clearmatics/autonity/p2p/enode"
+)
+
+var (
+	soakBlocks     = flag.Int("soak.blocks", 0, "number of blocks the soak test runs for, the test is skipped if 0")
+	soakValidators = flag.Int("soak.validators", 7, "number of validators of the soak test")
+	soakSeed       = flag.Int64("soak.seed", 0, "seed of the soak test faults, the current time if 0")
+	soakReportFile = flag.String("soak.report", "", "file the soak test report is written to as JSON")
+)
+
+// TestTendermintSoak runs the validators for a long time while restarting
+// them, changing the topology, delaying and dropping consensus messages,
+// skewing clocks and changing the committee. For instance:
+//
+//	go test ./consensus/test -run TestTendermintSoak -timeout 0 -soak.blocks 2000 -soak.report soak.json
+func TestTendermintSoak(t *testing.T) {
+	if *soakBlocks == 0 {
+		t.Skip("soak test disabled, set -soak.blocks to run it")
+	}
+	seed := *soakSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	cfg := defaultSoakConfig(seed, *soakValidators, *soakBlocks)
+	test, c := newSoakCase(t, cfg)
+	t.Logf("soak test with seed %d", seed)
+	runTest(t, test)
+
+	if violations := len(c.report.Safety) + len(c.report.Liveness); violations > 0 {
+		t.Errorf("soak test with seed %d: %d violations, safety %v, liveness %v", seed, violations, c.report.Safety, c.report.Liveness)
+	}
+}
+
+// soakConfig describes the faults injected during a soak run. The seed drives
+// every fault decision, the network and the go routines scheduling still make
+// two runs with the same seed differ.
+type soakConfig struct {
+	seed       int64
+	validators int
+	blocks     int
+
+	restartRate    float64       // probability for a validator to stop at each block
+	maxDowntime    time.Duration // a stopped validator restarts after at most this duration
+	latency        latency       // delay of each consensus message
+	lossRate       float64       // probability of a consensus message being lost
+	maxSkew        time.Duration // clocks of the validators are behind by up to this duration
+	topologyRate   float64       // probability for a validator to drop one of its peers at each block
+	reconnectAfter uint64        // blocks after which a dropped peer is connected again
+	committeeEvery uint64        // blocks between two committee changing transactions
+	maxBlockGap    uint64        // longer intervals in seconds between two blocks are liveness violations
+}
+
+func defaultSoakConfig(seed int64, validators, blocks int) soakConfig {
+	return soakConfig{
+		seed:           seed,
+		validators:     validators,
+		blocks:         blocks,
+		restartRate:    0.01,
+		maxDowntime:    20 * time.Second,
+		latency:        latency{base: 20 * time.Millisecond, jitter: 80 * time.Millisecond},
+		lossRate:       0.05,
+		maxSkew:        500 * time.Millisecond,
+		topologyRate:   0.02,
+		reconnectAfter: 10,
+		committeeEvery: 25,
+		maxBlockGap:    60,
+	}
+}
+
+// latency is a base delay plus an exponentially distributed jitter.
+type latency struct {
+	base   time.Duration
+	jitter time.Duration // mean of the jitter
+}
+
+func (l latency) sample(r *rand.Rand) time.Duration {
+	return l.base + time.Duration(r.ExpFloat64()*float64(l.jitter))
+}
+
+// soakReport summarises a soak run.
+type soakReport struct {
+	Seed       int64          `json:"seed"`
+	Validators int            `json:"validators"`
+	Height     uint64         `json:"height"`
+	Duration   string         `json:"duration"`
+	BlockTime  blockTimeStats `json:"blockTime"`
+	Rounds     map[int64]int  `json:"rounds"` // decided round to number of blocks
+	Faults     faultCounters  `json:"faults"`
+	Safety     []string       `json:"safetyViolations"`
+	Liveness   []string       `json:"livenessViolations"`
+}
+
+// blockTimeStats are computed in seconds from the block timestamps.
+type blockTimeStats struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	Max float64 `json:"max"`
+}
+
+type faultCounters struct {
+	Restarts        int      `json:"restarts"`
+	Disconnections  int      `json:"disconnections"`
+	DelayedMessages int      `json:"delayedMessages"`
+	DroppedMessages int      `json:"droppedMessages"`
+	CommitteeTxs    []string `json:"committeeTxs"`
+}
+
+// chaos holds the state of the faults injected in a soak run, it is shared by
+// the hooks and the backends of every validator.
+type chaos struct {
+	config soakConfig
+	start  time.Time
+
+	mu          sync.Mutex
+	rand        *rand.Rand
+	skews       map[string]time.Duration
+	stopped     int
+	downtimes   map[string]time.Duration
+	reconnects  map[string]map[uint64][]*enode.Node
+	nextChange  uint64
+	changes     int
+	operatorKey *ecdsa.PrivateKey
+	added       common.Address
+	report      soakReport
+}
+
+func newSoakCase(t *testing.T, cfg soakConfig) (*testCase, *chaos) {
+	operatorKey, err := keygenerator.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	operator := crypto.PubkeyToAddress(operatorKey.PublicKey)
+
+	c := &chaos{
+		config:      cfg,
+		start:       time.Now(),
+		rand:        rand.New(rand.NewSource(cfg.seed)),
+		skews:       make(map[string]time.Duration),
+		downtimes:   make(map[string]time.Duration),
+		reconnects:  make(map[string]map[uint64][]*enode.Node),
+		nextChange:  cfg.committeeEvery,
+		operatorKey: operatorKey,
+		report: soakReport{
+			Seed:       cfg.seed,
+			Validators: cfg.validators,
+			Rounds:     make(map[int64]int),
+		},
+	}
+
+	test := &testCase{
+		name:                 "soak",
+		numValidators:        cfg.validators,
+		numBlocks:            cfg.blocks,
+		txPerPeer:            1,
+		faultyPeers:          make(map[string]injectors),
+		beforeHooks:          make(map[string]hook),
+		afterHooks:           make(map[string]hook),
+		sendTransactionHooks: make(map[string]func(validator *testNode, fromAddr common.Address, toAddr common.Address) (bool, *types.Transaction, error)),
+		stopTime:             make(map[string]time.Time),
+		genesisHook: func(g *core.Genesis) *core.Genesis {
+			g.Config.AutonityContractConfig.Operator = operator
+			g.Alloc[operator] = core.GenesisAccount{
+				Balance: big.NewInt(100000000000000000),
+			}
+			return g
+		},
+		afterRun: c.buildReport,
+	}
+	for _, index := range getNodeNames()[:cfg.validators] {
+		c.skews[index] = time.Duration(c.rand.Int63n(int64(cfg.maxSkew) + 1))
+		test.faultyPeers[index] = injectors{backs: c.backend(index)}
+		test.beforeHooks[index] = c.stopHook(index)
+		test.afterHooks[index] = c.afterHook(index)
+		test.sendTransactionHooks[index] = c.committeeHook(t)
+	}
+	return test, c
+}
+
+// maxStopped returns the number of validators which can be stopped at the same
+// time without losing the quorum.
+func (c *chaos) maxStopped() int {
+	return (c.config.validators - 1) / 3
+}
+
+// stopHook stops the validator at random blocks, a few blocks after the start
+// and before the end of the run.
+func (c *chaos) stopHook(index string) hook {
+	return func(block *types.Block, validator *testNode, tCase *testCase, currentTime time.Time) error {
+		number := block.NumberU64()
+		if number < 5 || number+15 > uint64(c.config.blocks) {
+			return nil
+		}
+
+		c.mu.Lock()
+		stop := c.stopped < c.maxStopped() && c.rand.Float64() < c.config.restartRate
+		if stop {
+			c.stopped++
+			c.downtimes[index] = c.config.maxDowntime/4 + time.Duration(c.rand.Int63n(int64(c.config.maxDowntime)*3/4+1))
+		}
+		c.mu.Unlock()
+		if !stop {
+			return nil
+		}
+
+		if err := validator.stopNode(); err != nil {
+			return err
+		}
+		tCase.setStopTime(index, currentTime)
+		return nil
+	}
+}
+
+// afterHook restarts the stopped validator after its downtime, and drops and
+// reconnects its peers.
+func (c *chaos) afterHook(index string) hook {
+	return func(block *types.Block, validator *testNode, tCase *testCase, currentTime time.Time) error {
+		if block == nil {
+			return c.restart(index, validator, tCase, currentTime)
+		}
+		if !validator.isRunning {
+			return nil
+		}
+		return c.changeTopology(index, block.NumberU64(), validator)
+	}
+}
+
+func (c *chaos) restart(index string, validator *testNode, tCase *testCase, currentTime time.Time) error {
+	c.mu.Lock()
+	downtime, ok := c.downtimes[index]
+	c.mu.Unlock()
+	if !ok || validator.isRunning || currentTime.Sub(tCase.getStopTime(index)) < downtime {
+		return nil
+	}
+
+	if err := validator.startNode(); err != nil {
+		return err
+	}
+	if err := validator.startService(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	delete(c.downtimes, index)
+	c.stopped--
+	c.report.Faults.Restarts++
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *chaos) changeTopology(index string, number uint64, validator *testNode) error {
+	server := validator.node.Server()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, peer := range c.reconnects[index][number] {
+		server.AddPeer(peer)
+	}
+	delete(c.reconnects[index], number)
+
+	peers := server.Peers()
+	if len(peers) <= 1 || c.rand.Float64() >= c.config.topologyRate {
+		return nil
+	}
+	peer := peers[c.rand.Intn(len(peers))].Node()
+	server.RemovePeer(peer)
+
+	if c.reconnects[index] == nil {
+		c.reconnects[index] = make(map[uint64][]*enode.Node)
+	}
+	at := number + c.config.reconnectAfter
+	c.reconnects[index][at] = append(c.reconnects[index][at], peer)
+	c.report.Faults.Disconnections++
+	return nil
+}
+
+// committeeHook sends every few blocks a transaction of the operator changing
+// the committee: a validator which never runs is added, stake is minted and
+// redeemed, and the added validator is removed.
+func (c *chaos) committeeHook(t *testing.T) func(validator *testNode, fromAddr common.Address, toAddr common.Address) (bool, *types.Transaction, error) {
+	return func(validator *testNode, _ common.Address, _ common.Address) (bool, *types.Transaction, error) {
+		validators := validator.service.BlockChain().Config().AutonityContractConfig.GetValidatorUsers()
+
+		c.mu.Lock()
+		if validator.lastBlock < c.nextChange || validator.lastBlock+15 > uint64(c.config.blocks) {
+			c.mu.Unlock()
+			return true, nil, nil
+		}
+		c.nextChange = validator.lastBlock + c.config.committeeEvery
+		change := c.changes
+		c.changes++
+		target := validators[c.rand.Intn(len(validators))].Address
+		added := c.added
+		c.mu.Unlock()
+
+		conn, err := ethclient.Dial("http://127.0.0.1:" + strconv.Itoa(validator.rpcPort))
+		if err != nil {
+			return false, nil, err
+		}
+		defer conn.Close()
+
+		operator := crypto.PubkeyToAddress(c.operatorKey.PublicKey)
+		nonce, err := conn.PendingNonceAt(context.Background(), operator)
+		if err != nil {
+			return false, nil, err
+		}
+		gasPrice, err := conn.SuggestGasPrice(context.Background())
+		if err != nil {
+			return false, nil, err
+		}
+		auth := bind.NewKeyedTransactor(c.operatorKey)
+		auth.Nonce = new(big.Int).SetUint64(nonce)
+		auth.GasLimit = uint64(300000)
+		auth.GasPrice = gasPrice
+
+		instance, err := NewAutonity(validator.service.BlockChain().GetAutonityContract().Address(), conn)
+		if err != nil {
+			return false, nil, err
+		}
+
+		var description string
+		switch change % 4 {
+		case 0:
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				return false, nil, err
+			}
+			added = crypto.PubkeyToAddress(key.PublicKey)
+			url := enode.V4URL(key.PublicKey, net.IPv4(127, 0, 0, 1), 30303, 30303)
+			if _, err = instance.AddValidator(auth, added, big.NewInt(1), url); err != nil {
+				return false, nil, err
+			}
+			description = fmt.Sprintf("add validator %s", added.Hex())
+		case 1:
+			if _, err := instance.MintStake(auth, target, big.NewInt(10)); err != nil {
+				return false, nil, err
+			}
+			description = fmt.Sprintf("mint stake to %s", target.Hex())
+		case 2:
+			if _, err := instance.RedeemStake(auth, target, big.NewInt(10)); err != nil {
+				return false, nil, err
+			}
+			description = fmt.Sprintf("redeem stake of %s", target.Hex())
+		case 3:
+			if _, err := instance.RemoveUser(auth, added); err != nil {
+				return false, nil, err
+			}
+			description = fmt.Sprintf("remove validator %s", added.Hex())
+		}
+
+		c.mu.Lock()
+		c.added = added
+		c.report.Faults.CommitteeTxs = append(c.report.Faults.CommitteeTxs, fmt.Sprintf("block %d: %s", validator.lastBlock, description))
+		c.mu.Unlock()
+		t.Logf("soak: %s at block %d", description, validator.lastBlock)
+		return false, nil, nil
+	}
+}
+
+// backend returns the injector wrapping the tendermint backend of a validator
+// on the faulty network.
+func (c *chaos) backend(index string) func(basic tendermintCore.Backend) tendermintCore.Backend {
+	return func(basic tendermintCore.Backend) tendermintCore.Backend {
+		c.mu.Lock()
+		skew := c.skews[index]
+		c.mu.Unlock()
+		return &faultyBackend{Backend: basic, chaos: c, skew: skew}
+	}
+}
+
+// transmit returns the delay of a consensus message, or false if it's lost.
+func (c *chaos) transmit() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rand.Float64() < c.config.lossRate {
+		c.report.Faults.DroppedMessages++
+		return 0, false
+	}
+	c.report.Faults.DelayedMessages++
+	return c.config.latency.sample(c.rand), true
+}
+
+// buildReport computes the report from the chains of the validators once the
+// run is over, and writes it to the report file if any.
+func (c *chaos) buildReport(t *testing.T, validators map[string]*testNode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.report.Duration = time.Since(c.start).String()
+
+	var reference *testNode
+	chains := make(map[string]map[uint64]common.Hash, len(validators))
+	for index, v := range validators {
+		chain := make(map[uint64]common.Hash, len(v.blocks))
+		for number, b := range v.blocks {
+			chain[number] = b.hash
+		}
+		chains[index] = chain
+		if v.lastBlock < uint64(c.config.blocks) {
+			c.report.Liveness = append(c.report.Liveness, fmt.Sprintf("validator %s stopped at block %d", index, v.lastBlock))
+		}
+		if v.isRunning && (reference == nil || v.lastBlock > reference.lastBlock) {
+			reference = v
+		}
+	}
+	c.report.Safety = safetyViolations(chains)
+
+	if reference != nil {
+		chain := reference.service.BlockChain()
+		c.report.Height = chain.CurrentHeader().Number.Uint64()
+		var times []uint64
+		for number := uint64(1); number <= c.report.Height; number++ {
+			header := chain.GetHeaderByNumber(number)
+			if header == nil {
+				break
+			}
+			times = append(times, header.Time)
+			c.report.Rounds[header.Round.Int64()]++
+		}
+		var gaps []uint64
+		c.report.BlockTime, gaps = computeBlockTimes(times, c.config.maxBlockGap)
+		for _, number := range gaps {
+			c.report.Liveness = append(c.report.Liveness, fmt.Sprintf("block %d came more than %ds after its parent", number, c.config.maxBlockGap))
+		}
+	}
+
+	report, err := json.MarshalIndent(c.report, "", "  ")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Logf("soak report:\n%s", report)
+	if *soakReportFile != "" {
+		if err := ioutil.WriteFile(*soakReportFile, report, 0644); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// safetyViolations lists the heights at which two validators have different
+// blocks.
+func safetyViolations(chains map[string]map[uint64]common.Hash) []string {
+	var indexes []string
+	for index := range chains {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+
+	decided := make(map[uint64]string)
+	var numbers []uint64
+	for _, index := range indexes {
+		for number := range chains[index] {
+			if _, ok := decided[number]; !ok {
+				decided[number] = index
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	var violations []string
+	for _, number := range numbers {
+		first := decided[number]
+		for _, index := range indexes {
+			hash, ok := chains[index][number]
+			if ok && hash != chains[first][number] {
+				violations = append(violations, fmt.Sprintf("block %d: %s has %s, %s has %s", number, first, chains[first][number].Hex(), index, hash.Hex()))
+			}
+		}
+	}
+	return violations
+}
+
+// computeBlockTimes returns the statistics of the intervals between the given
+// block timestamps, and the blocks whose interval is longer than maxGap.
+func computeBlockTimes(times []uint64, maxGap uint64) (blockTimeStats, []uint64) {
+	var stats blockTimeStats
+	if len(times) < 2 {
+		return stats, nil
+	}
+
+	var (
+		intervals []float64
+		total     float64
+		gaps      []uint64
+	)
+	for i := 1; i < len(times); i++ {
+		interval := times[i] - times[i-1]
+		if interval > maxGap {
+			// the first timestamp is of block 1
+			gaps = append(gaps, uint64(i+1))
+		}
+		intervals = append(intervals, float64(interval))
+		total += float64(interval)
+	}
+	sort.Float64s(intervals)
+
+	percentile := func(p float64) float64 {
+		return intervals[int(p*float64(len(intervals)-1))]
+	}
+	stats.Min = intervals[0]
+	stats.Max = intervals[len(intervals)-1]
+	stats.Avg = total / float64(len(intervals))
+	stats.P50 = percentile(0.5)
+	stats.P95 = percentile(0.95)
+	return stats, gaps
+}
+
+// faultyBackend delays and drops the consensus messages sent by a validator,
+// and emulates a clock behind by skew when verifying proposals.
+type faultyBackend struct {
+	tendermintCore.Backend
+	chaos *chaos
+	skew  time.Duration
+}
+
+func (b *faultyBackend) Broadcast(ctx context.Context, valSet validator.Set, payload []byte) error {
+	if err := b.Backend.Broadcast(ctx, only(valSet, b.Address()), payload); err != nil {
+		return err
+	}
+	b.Gossip(ctx, valSet, payload)
+	return nil
+}
+
+func (b *faultyBackend) Gossip(ctx context.Context, valSet validator.Set, payload []byte) {
+	for _, v := range valSet.List() {
+		if v.GetAddress() == b.Address() {
+			continue
+		}
+		delay, ok := b.chaos.transmit()
+		if !ok {
+			continue
+		}
+		target := only(valSet, v.GetAddress())
+		time.AfterFunc(delay, func() {
+			if ctx.Err() == nil {
+				b.Backend.Gossip(ctx, target, payload)
+			}
+		})
+	}
+}
+
+func (b *faultyBackend) VerifyProposal(proposal types.Block) (time.Duration, error) {
+	now := time.Now().Add(-b.skew)
+	if ahead := time.Unix(int64(proposal.Time()), 0).Sub(now); ahead > 0 {
+		return ahead, consensus.ErrFutureBlock
+	}
+	return b.Backend.VerifyProposal(proposal)
+}
+
+// only returns a copy of the validator set holding only the given validator.
+func only(valSet validator.Set, addr common.Address) validator.Set {
+	set := valSet.Copy()
+	for _, v := range valSet.List() {
+		if v.GetAddress() != addr {
+			set.RemoveValidator(v.GetAddress())
+		}
+	}
+	return set
+}
+
+func TestSafetyViolations(t *testing.T) {
+	chains := map[string]map[uint64]common.Hash{
+		"VA": {1: {1}, 2: {2}, 3: {3}},
+		"VB": {1: {1}, 2: {2}},
+		"VC": {1: {1}, 2: {2}, 3: {4}},
+	}
+	violations := safetyViolations(chains)
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation at block 3, got %v", violations)
+	}
+
+	delete(chains, "VC")
+	if violations := safetyViolations(chains); len(violations) != 0 {
+		t.Fatalf("expected no violation, got %v", violations)
+	}
+}
+
+func TestComputeBlockTimes(t *testing.T) {
+	stats, gaps := computeBlockTimes([]uint64{10, 11, 12, 14, 15, 80}, 60)
+	expected := blockTimeStats{Min: 1, Avg: 14, P50: 1, P95: 2, Max: 65}
+	if stats != expected {
+		t.Fatalf("expected %+v, got %+v", expected, stats)
+	}
+	if len(gaps) != 1 || gaps[0] != 6 {
+		t.Fatalf("expected a gap before block 6, got %v", gaps)
+	}
+
+	if stats, gaps := computeBlockTimes([]uint64{10}, 60); stats != (blockTimeStats{}) || gaps != nil {
+		t.Fatalf("expected no statistics for a single block, got %+v %v", stats, gaps)
+	}
+}
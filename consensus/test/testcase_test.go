@@ -44,6 +44,7 @@ type testCase struct {
 	validatorsCanBeStopped *int64
 
 	maliciousPeers          map[string]injectors
+	faultyPeers             map[string]injectors // honest validators running on a faulty network or clock
 	removedPeers            map[common.Address]uint64
 	addedValidatorsBlocks   map[common.Hash]uint64
 	removedValidatorsBlocks map[common.Hash]uint64 //nolint: unused, structcheck
@@ -61,6 +62,10 @@ type testCase struct {
 	noQuorumTimeout      time.Duration
 	topology             *Topology
 	skipNoLeakCheck      bool
+	pipeline             bool // validators build their next proposal before the current height is decided
+	// afterRun is called once the nodes ran, even if the test failed, before
+	// they are stopped
+	afterRun func(t *testing.T, validators map[string]*testNode)
 }
 
 type injectors struct {
@@ -175,13 +180,17 @@ func runTest(t *testing.T, test *testCase) {
 			engineConstructor = test.maliciousPeers[i].cons
 			backendConstructor = test.maliciousPeers[i].backs
 		}
+		if faulty, ok := test.faultyPeers[i]; ok {
+			engineConstructor = faulty.cons
+			backendConstructor = faulty.backs
+		}
 
 		validator.listener[0].Close()
 		validator.listener[1].Close()
 
 		rates := test.networkRates[i]
 
-		validator.node, err = makeValidator(genesis, validator.privateKey, validator.address, validator.rpcPort, rates.in, rates.out, engineConstructor, backendConstructor)
+		validator.node, err = makeValidator(genesis, validator.privateKey, validator.address, validator.rpcPort, rates.in, rates.out, test.pipeline, engineConstructor, backendConstructor)
 		if err != nil {
 			t.Fatal("cant make a node", i, err)
 		}
@@ -242,6 +251,10 @@ func runTest(t *testing.T, test *testCase) {
 		time.Sleep(time.Second) //level DB needs a second to close
 	}()
 
+	if test.afterRun != nil {
+		defer test.afterRun(t, nodes)
+	}
+
 	wg = &errgroup.Group{}
 	for _, validator := range nodes {
 		validator := validator
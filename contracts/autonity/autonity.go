@@ -2,6 +2,7 @@ package autonity
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"reflect"
 	"sort"
@@ -16,6 +17,7 @@ import (
 	"github.com/clearmatics/autonity/core/vm"
 	"github.com/clearmatics/autonity/log"
 	"github.com/clearmatics/autonity/params"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 var ErrAutonityContract = errors.New("could not call Autonity contract")
@@ -29,11 +31,13 @@ func NewAutonityContract(
 	transfer func(db vm.StateDB, sender, recipient common.Address, amount *big.Int),
 	GetHashFn func(ref *types.Header, chain ChainContext) func(n uint64) common.Hash,
 ) *Contract {
+	permissions, _ := lru.New(permissionsCacheSize)
 	return &Contract{
 		bc:          bc,
 		canTransfer: canTransfer,
 		transfer:    transfer,
 		GetHashFn:   GetHashFn,
+		permissions: permissions,
 	}
 }
 
@@ -63,6 +67,7 @@ type Contract struct {
 	bc                      Blockchainer
 	SavedCommitteeRetriever func(i uint64) (types.Committee, error)
 	metrics                 EconomicMetrics
+	permissions             *lru.Cache // Submission permissions of the recent blocks
 
 	canTransfer func(db vm.StateDB, addr common.Address, amount *big.Int) bool
 	transfer    func(db vm.StateDB, sender, recipient common.Address, amount *big.Int)
@@ -173,6 +178,16 @@ func (ac *Contract) GetMinimumGasPrice(block *types.Block, db *state.StateDB) (u
 	return ac.callGetMinimumGasPrice(db, block.Header())
 }
 
+// GetAccountStake returns the stake of a user of the contract after the block.
+func (ac *Contract) GetAccountStake(block *types.Block, db *state.StateDB, account common.Address) (*big.Int, error) {
+	return ac.callGetAccountStake(db, block.Header(), account)
+}
+
+// GetVersion returns the version of the contract after the block.
+func (ac *Contract) GetVersion(block *types.Block, db *state.StateDB) (string, error) {
+	return ac.callGetVersion(db, block.Header())
+}
+
 func (ac *Contract) SetMinimumGasPrice(block *types.Block, db *state.StateDB, price *big.Int) error {
 	if block.Number().Uint64() <= 1 {
 		return nil
@@ -185,10 +200,7 @@ func (ac *Contract) ApplyFinalize(transactions types.Transactions, receipts type
 	if header.Number.Cmp(big.NewInt(1)) < 1 {
 		return nil
 	}
-	blockGas := new(big.Int)
-	for i, tx := range transactions {
-		blockGas.Add(blockGas, new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(receipts[i].GasUsed)))
-	}
+	blockGas := blockGas(transactions, receipts)
 	log.Info("ApplyFinalize", "balance", statedb.GetBalance(ac.Address()), "block", header.Number.Uint64(), "gas", blockGas.Uint64())
 
 	if header.Number.Uint64() <= 1 {
@@ -214,6 +226,36 @@ func (ac *Contract) ApplyFinalize(transactions types.Transactions, receipts type
 	return nil
 }
 
+// TraceFinalize runs the finalize call ApplyFinalize makes to the contract at the
+// end of a block again, reporting its execution to the given tracer. The state
+// must be the one right after the block transactions were applied. It returns the
+// output of the call, the gas it used and whether it failed.
+func (ac *Contract) TraceFinalize(transactions types.Transactions, receipts types.Receipts, header *types.Header, statedb *state.StateDB, tracer vm.Tracer) ([]byte, uint64, bool, error) {
+	if header.Number.Uint64() <= 1 {
+		return nil, 0, false, fmt.Errorf("block %d does not finalize the Autonity contract", header.Number.Uint64())
+	}
+	contractABI, err := ac.abi()
+	if err != nil {
+		return nil, 0, false, err
+	}
+	input, err := contractABI.Pack("finalize", blockGas(transactions, receipts))
+	if err != nil {
+		return nil, 0, false, err
+	}
+	evm := ac.getEVMWithConfig(header, ac.bc.Config().AutonityContractConfig.Deployer, statedb, vm.Config{Debug: true, Tracer: tracer})
+	ret, gas, vmerr := ac.callContract(evm, input)
+	return ret, gas, vmerr != nil, nil
+}
+
+// blockGas returns the fees paid by the transactions of a block.
+func blockGas(transactions types.Transactions, receipts types.Receipts) *big.Int {
+	gas := new(big.Int)
+	for i, tx := range transactions {
+		gas.Add(gas, new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(receipts[i].GasUsed)))
+	}
+	return gas
+}
+
 func (ac *Contract) performContractUpgrade(statedb *state.StateDB, header *types.Header) error {
 	log.Error("Initiating Autonity Contract upgrade", "header", header.Number.Uint64())
 
@@ -232,10 +274,13 @@ func (ac *Contract) performContractUpgrade(statedb *state.StateDB, header *types
 	// take snapshot in case of roll back to former view.
 	snapshot := statedb.Snapshot()
 
+	// keep the former view to record the changes of the upgrade against.
+	pre := statedb.Copy()
+
 	//Create account will delete previous the AC stateobject and carry over the balance
 	statedb.CreateAccount(ac.Address())
 
-	if err := ac.UpdateAutonityContract(header, statedb, bytecode, newAbi, stateBefore); err != nil {
+	if err := ac.UpdateAutonityContract(header, statedb, pre, bytecode, newAbi, stateBefore); err != nil {
 		statedb.RevertToSnapshot(snapshot)
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/core/vm"
+	"github.com/clearmatics/autonity/crypto"
 	"github.com/clearmatics/autonity/log"
 	"math"
 	"math/big"
@@ -33,6 +34,12 @@ type raw []byte
 
 //// Instantiates a new EVM object which is required when creating or calling a deployed contract
 func (ac *Contract) getEVM(header *types.Header, origin common.Address, statedb *state.StateDB) *vm.EVM {
+	return ac.getEVMWithConfig(header, origin, statedb, *ac.bc.GetVMConfig())
+}
+
+// getEVMWithConfig instantiates a new EVM object running with the given configuration,
+// e.g. to trace the calls made to the contract.
+func (ac *Contract) getEVMWithConfig(header *types.Header, origin common.Address, statedb *state.StateDB, vmConfig vm.Config) *vm.EVM {
 	coinbase, _ := types.Ecrecover(header)
 	evmContext := vm.Context{
 		CanTransfer: ac.canTransfer,
@@ -46,7 +53,6 @@ func (ac *Contract) getEVM(header *types.Header, origin common.Address, statedb
 		Difficulty:  header.Difficulty,
 		GasPrice:    new(big.Int).SetUint64(0x0),
 	}
-	vmConfig := *ac.bc.GetVMConfig()
 	evm := vm.NewEVM(evmContext, statedb, ac.bc.Config(), vmConfig)
 	return evm
 }
@@ -55,7 +61,6 @@ func (ac *Contract) getEVM(header *types.Header, origin common.Address, statedb
 func (ac *Contract) DeployAutonityContract(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) (common.Address, error) {
 	// Convert the contract bytecode from hex into bytes
 	contractBytecode := common.Hex2Bytes(chain.Config().AutonityContractConfig.Bytecode)
-	evm := ac.getEVM(header, chain.Config().AutonityContractConfig.Deployer, statedb)
 	sender := vm.AccountRef(chain.Config().AutonityContractConfig.Deployer)
 
 	//todo do we need it?
@@ -116,7 +121,18 @@ func (ac *Contract) DeployAutonityContract(chain consensus.ChainReader, header *
 	value := new(big.Int).SetUint64(0x00)
 
 	// Deploy the Autonity contract
-	_, contractAddress, _, vmerr := evm.Create(sender, data, gas, value)
+	call := &types.SystemCall{
+		Type:  types.SystemCallCreate,
+		From:  sender.Address(),
+		To:    crypto.CreateAddress(sender.Address(), statedb.GetNonce(sender.Address())),
+		Input: data,
+	}
+	var contractAddress common.Address
+	_, _, vmerr := ac.recordSystemCall(statedb, statedb, header, call, func(evm *vm.EVM) ([]byte, uint64, error) {
+		ret, address, leftOverGas, err := evm.Create(sender, data, gas, value)
+		contractAddress = address
+		return ret, gas - leftOverGas, err
+	})
 	if vmerr != nil {
 		log.Error("evm.Create returns err", "err", vmerr)
 		return contractAddress, vmerr
@@ -129,14 +145,27 @@ func (ac *Contract) DeployAutonityContract(chain consensus.ChainReader, header *
 	return contractAddress, nil
 }
 
-func (ac *Contract) UpdateAutonityContract(header *types.Header, statedb *state.StateDB, bytecode string, abi string, state []byte) error {
+// UpdateAutonityContract redeploys the Autonity contract at its address with the
+// given bytecode, the state of the previous contract being passed to the new
+// constructor. The account must have been recreated beforehand, the pre state
+// being the state before, to record the changes the upgrade made.
+func (ac *Contract) UpdateAutonityContract(header *types.Header, statedb *state.StateDB, pre vm.StateDB, bytecode string, abi string, state []byte) error {
 	caller := ac.bc.Config().AutonityContractConfig.Deployer
-	evm := ac.getEVM(header, caller, statedb)
 	contractBytecode := common.Hex2Bytes(bytecode)
 	data := append(contractBytecode, state...)
 	gas := uint64(0xFFFFFFFF)
 	value := new(big.Int).SetUint64(0x00)
-	_, _, _, vmerr := evm.CreateWithAddress(vm.AccountRef(caller), data, gas, value, ac.Address())
+
+	call := &types.SystemCall{
+		Type:  types.SystemCallCreate,
+		From:  caller,
+		To:    ac.Address(),
+		Input: data,
+	}
+	_, _, vmerr := ac.recordSystemCall(statedb, pre, header, call, func(evm *vm.EVM) ([]byte, uint64, error) {
+		ret, _, leftOverGas, err := evm.CreateWithAddress(vm.AccountRef(caller), data, gas, value, call.To)
+		return ret, gas - leftOverGas, err
+	})
 	if vmerr != nil {
 		log.Error("evm.Create returns err", "err", vmerr)
 		return vmerr
@@ -145,21 +174,17 @@ func (ac *Contract) UpdateAutonityContract(header *types.Header, statedb *state.
 }
 
 func (ac *Contract) AutonityContractCall(statedb *state.StateDB, header *types.Header, function string, result interface{}, args ...interface{}) error {
-	caller := ac.bc.Config().AutonityContractConfig.Deployer
 	contractABI, err := ac.abi()
 	if err != nil {
 		return err
 	}
 
-	gas := uint64(math.MaxUint64)
-	evm := ac.getEVM(header, caller, statedb)
-
 	input, err := contractABI.Pack(function, args...)
 	if err != nil {
 		return err
 	}
 
-	ret, _, vmerr := evm.Call(vm.AccountRef(caller), ac.Address(), input, gas, new(big.Int))
+	ret, _, vmerr := ac.systemCall(statedb, header, function, input)
 	if vmerr != nil {
 		log.Error("Error Autonity Contract", "function", function)
 		return vmerr
@@ -179,6 +204,32 @@ func (ac *Contract) AutonityContractCall(statedb *state.StateDB, header *types.H
 	return nil
 }
 
+// systemCall runs a call of the deployer to the given function of the Autonity
+// contract, recording it in the state. It returns the output of the call and the
+// gas it used.
+func (ac *Contract) systemCall(statedb *state.StateDB, header *types.Header, function string, input []byte) ([]byte, uint64, error) {
+	call := &types.SystemCall{
+		Type:   types.SystemCallCall,
+		Method: function,
+		From:   ac.bc.Config().AutonityContractConfig.Deployer,
+		To:     ac.Address(),
+		Input:  input,
+	}
+	return ac.recordSystemCall(statedb, statedb, header, call, func(evm *vm.EVM) ([]byte, uint64, error) {
+		return ac.callContract(evm, input)
+	})
+}
+
+// callContract runs a call of the deployer to the Autonity contract in the given
+// EVM, returning its output and the gas it used.
+func (ac *Contract) callContract(evm *vm.EVM, input []byte) ([]byte, uint64, error) {
+	caller := ac.bc.Config().AutonityContractConfig.Deployer
+	gas := uint64(math.MaxUint64)
+
+	ret, leftOverGas, vmerr := evm.Call(vm.AccountRef(caller), ac.Address(), input, gas, new(big.Int))
+	return ret, gas - leftOverGas, vmerr
+}
+
 func (ac *Contract) callGetWhitelist(state *state.StateDB, header *types.Header) (*types.Nodes, error) {
 	var returnedEnodes []string
 	err := ac.AutonityContractCall(state, header, "getWhitelist", &returnedEnodes)
@@ -197,6 +248,24 @@ func (ac *Contract) callGetMinimumGasPrice(state *state.StateDB, header *types.H
 	return minGasPrice.Uint64(), nil
 }
 
+func (ac *Contract) callGetAccountStake(state *state.StateDB, header *types.Header, account common.Address) (*big.Int, error) {
+	stake := new(big.Int)
+	err := ac.AutonityContractCall(state, header, "getAccountStake", &stake, account)
+	if err != nil {
+		return nil, err
+	}
+	return stake, nil
+}
+
+func (ac *Contract) callGetVersion(state *state.StateDB, header *types.Header) (string, error) {
+	var version string
+	err := ac.AutonityContractCall(state, header, "getVersion", &version)
+	if err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
 func (ac *Contract) callFinalize(state *state.StateDB, header *types.Header, blockGas *big.Int) (bool, error) {
 	v := RewardDistributionMetaData{}
 	err := ac.AutonityContractCall(state, header, "finalize", &v, blockGas)
@@ -235,7 +304,6 @@ func (ac *Contract) callSetMinimumGasPrice(state *state.StateDB, header *types.H
 	deployer := ac.bc.Config().AutonityContractConfig.Deployer
 	sender := vm.AccountRef(deployer)
 	gas := uint64(0xFFFFFFFF)
-	evm := ac.getEVM(header, deployer, state)
 
 	ABI, err := ac.abi()
 	if err != nil {
@@ -247,7 +315,17 @@ func (ac *Contract) callSetMinimumGasPrice(state *state.StateDB, header *types.H
 		return err
 	}
 
-	_, _, vmerr := evm.Call(sender, ac.Address(), input, gas, price)
+	call := &types.SystemCall{
+		Type:   types.SystemCallCall,
+		Method: "setMinimumGasPrice",
+		From:   deployer,
+		To:     ac.Address(),
+		Input:  input,
+	}
+	_, _, vmerr := ac.recordSystemCall(state, state, header, call, func(evm *vm.EVM) ([]byte, uint64, error) {
+		ret, leftOverGas, err := evm.Call(sender, call.To, input, gas, price)
+		return ret, gas - leftOverGas, err
+	})
 	if vmerr != nil {
 		log.Error("Error Autonity Contract getMinimumGasPrice()")
 		return vmerr
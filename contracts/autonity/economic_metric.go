@@ -7,6 +7,7 @@ import (
 	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/log"
 	"github.com/clearmatics/autonity/metrics"
+	"github.com/clearmatics/autonity/metrics/prometheus"
 	"github.com/clearmatics/autonity/params"
 	"math/big"
 	"sync"
@@ -66,6 +67,22 @@ const (
 	BlockRewardHeightWindowStepRange = 600  // each 10 minutes to shrink the window.
 )
 
+// Labeled gauges exporting the user and block metrics to Prometheus, in place of
+// the dynamically named gauges of the registry which the other reporters keep
+// reading.
+var (
+	userStakeGauge          = prometheus.NewRegisteredGaugeVec("contract/user/stake", "address", "role")
+	userBalanceGauge        = prometheus.NewRegisteredGaugeVec("contract/user/balance", "address", "role")
+	userCommissionRateGauge = prometheus.NewRegisteredGaugeVec("contract/user/commissionrate", "address", "role")
+	userRewardGauge         = prometheus.NewRegisteredGaugeVec("contract/user/reward", "address", "role")
+	blockRewardGauge        = prometheus.NewRegisteredGaugeVec("contract/block/reward")
+)
+
+func init() {
+	prometheus.Exclude("contract/user/")
+	prometheus.Exclude("contract/block/")
+}
+
 // refer to autonity contract abt spec, keep in same meta.
 type EconomicMetaData struct {
 	Accounts        []common.Address `abi:"accounts"`
@@ -106,6 +123,19 @@ func (em *EconomicMetrics) recordMetric(name string, value *big.Int, isWei bool)
 	}
 }
 
+// recordLabeledMetric records the value in the labeled gauge, in ETH if isWei.
+func (em *EconomicMetrics) recordLabeledMetric(gauge *prometheus.GaugeVec, value *big.Int, isWei bool, labels ...string) {
+	if value == nil {
+		return
+	}
+	if isWei {
+		val2Float64, _ := new(big.Rat).SetFrac(value, big.NewInt(params.Ether)).Float64()
+		gauge.Update(val2Float64, labels...)
+	} else {
+		gauge.Update(float64(value.Int64()), labels...)
+	}
+}
+
 // measure metrics of user's meta data by regarding of network economic.
 func (em *EconomicMetrics) SubmitEconomicMetrics(v *EconomicMetaData, stateDB *state.StateDB, height uint64, operator common.Address) {
 
@@ -143,6 +173,20 @@ func (em *EconomicMetrics) SubmitEconomicMetrics(v *EconomicMetaData, stateDB *s
 		em.recordMetric(stakeID, stake, false)
 		em.recordMetric(balanceID, balance, true)
 		em.recordMetric(commmissionRateID, rate, false)
+
+		// the labeled gauges of the former role of the user are dropped
+		address := user.String()
+		for role := Participant; role <= Validator; role++ {
+			if role != userType {
+				userStakeGauge.Delete(address, em.resolveUserTypeName(role))
+				userBalanceGauge.Delete(address, em.resolveUserTypeName(role))
+				userCommissionRateGauge.Delete(address, em.resolveUserTypeName(role))
+			}
+		}
+		role := em.resolveUserTypeName(userType)
+		em.recordLabeledMetric(userStakeGauge, stake, false, address, role)
+		em.recordLabeledMetric(userBalanceGauge, balance, true, address, role)
+		em.recordLabeledMetric(userCommissionRateGauge, rate, false, address, role)
 	}
 
 	// clean up useless metrics if there exists.
@@ -159,11 +203,13 @@ func (em *EconomicMetrics) SubmitRewardDistributionMetrics(v *RewardDistribution
 	for i := 0; i < len(v.Holders); i++ {
 		rewardDistributionMetricID := em.generateRewardDistributionMetricsID(v.Holders[i], Stakeholder, height)
 		em.recordMetric(rewardDistributionMetricID, v.Rewardfractions[i], true)
+		em.recordLabeledMetric(userRewardGauge, v.Rewardfractions[i], true, v.Holders[i].String(), RoleStakeHolder)
 	}
 
 	// submit block reward metric to registry.
 	blockRewardMetricID := em.generateBlockRewardMetricsID(height)
 	em.recordMetric(blockRewardMetricID, v.Amount, true)
+	em.recordLabeledMetric(blockRewardGauge, v.Amount, true)
 
 	// check to remove reward distribution metrics which is out of time/height window.
 	em.removeMetricsOutOfWindow(height)
@@ -213,6 +259,11 @@ func (em *EconomicMetrics) removeMetricsFromRegistry(user common.Address, blockN
 			metrics.DefaultRegistry.Unregister(balanceID)
 			metrics.DefaultRegistry.Unregister(commissionRateID)
 		}
+		address, userType := user.String(), em.resolveUserTypeName(role)
+		userStakeGauge.Delete(address, userType)
+		userBalanceGauge.Delete(address, userType)
+		userCommissionRateGauge.Delete(address, userType)
+		userRewardGauge.Delete(address, userType)
 	}
 	// clean up metrics which counts the removed user's reward.
 	for height := em.heightLowBounder; height <= blockNumber; height++ {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/metrics"
+	"github.com/clearmatics/autonity/params"
 	"math/big"
 	"sync"
 	"testing"
@@ -316,3 +317,31 @@ func TestEconomicMetrics_recordMetric(t *testing.T) {
 	})
 
 }
+
+func TestEconomicMetrics_labeledMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	em := &EconomicMetrics{}
+	address := common.BytesToAddress(common.Hex2Bytes(testAddress1))
+	distributions := RewardDistributionMetaData{
+		Result:          true,
+		Holders:         []common.Address{address},
+		Rewardfractions: []*big.Int{new(big.Int).Mul(big.NewInt(2), big.NewInt(params.Ether))},
+		Amount:          big.NewInt(params.Ether),
+	}
+	em.SubmitRewardDistributionMetrics(&distributions, 1)
+
+	if reward, ok := userRewardGauge.Value(address.String(), RoleStakeHolder); !ok || reward != 2 {
+		t.Fatalf("user reward mismatch: have %v, set %v", reward, ok)
+	}
+	if reward, ok := blockRewardGauge.Value(); !ok || reward != 1 {
+		t.Fatalf("block reward mismatch: have %v, set %v", reward, ok)
+	}
+
+	em.removeMetricsFromRegistry(address, 1)
+	if _, ok := userRewardGauge.Value(address.String(), RoleStakeHolder); ok {
+		t.Fatal("user reward not removed")
+	}
+}
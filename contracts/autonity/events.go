@@ -0,0 +1,71 @@
+package autonity
+
+import (
+	"github.com/clearmatics/autonity/accounts/abi"
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/log"
+)
+
+// Events emitted by the Autonity contract the node reacts to.
+const (
+	EventAddValidator       = "AddValidator"
+	EventAddStakeholder     = "AddStakeholder"
+	EventAddParticipant     = "AddParticipant"
+	EventRemoveUser         = "RemoveUser"
+	EventSetMinimumGasPrice = "SetMinimumGasPrice"
+	EventMintStake          = "MintStake"
+	EventRedeemStake        = "RedeemStake"
+	EventTransfer           = "Transfer"
+	EventVersion            = "Version"
+)
+
+// Event is an event emitted by the Autonity contract, decoded with its ABI.
+type Event struct {
+	Name string
+	Args map[string]interface{} // Arguments by name, the indexed ones included
+	Log  *types.Log
+}
+
+// DecodeEvents decodes the events emitted by the Autonity contract among the
+// given logs, skipping the logs of other contracts and the ones the ABI of the
+// contract doesn't describe.
+func (ac *Contract) DecodeEvents(logs []*types.Log) ([]*Event, error) {
+	contractABI, err := ac.abi()
+	if err != nil {
+		return nil, err
+	}
+	address := ac.Address()
+
+	var events []*Event
+	for _, l := range logs {
+		if l.Address != address || len(l.Topics) == 0 {
+			continue
+		}
+		event, err := contractABI.EventByID(l.Topics[0])
+		if err != nil {
+			continue
+		}
+		args := make(map[string]interface{})
+		if err := event.Inputs.UnpackIntoMap(args, l.Data); err != nil {
+			log.Warn("Could not unpack Autonity contract event", "event", event.Name, "err", err)
+			continue
+		}
+		// Indexed arguments are only kept in the topics, hashed unless of a
+		// value type
+		topics := l.Topics[1:]
+		for _, input := range event.Inputs {
+			if !input.Indexed || len(topics) == 0 {
+				continue
+			}
+			if input.Type.T == abi.AddressTy {
+				args[input.Name] = common.BytesToAddress(topics[0].Bytes())
+			} else {
+				args[input.Name] = topics[0]
+			}
+			topics = topics[1:]
+		}
+		events = append(events, &Event{Name: event.Name, Args: args, Log: l})
+	}
+	return events, nil
+}
@@ -0,0 +1,142 @@
+package autonity
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/params"
+)
+
+var (
+	ErrUnauthorizedSender   = errors.New("sender is not a registered Autonity user")
+	ErrUnauthorizedDeployer = errors.New("sender is not allowed to deploy contracts")
+)
+
+// permissionsCacheSize is the number of blocks whose permissions are kept, enough
+// for the pool, the miner and the block processor to work on different heights.
+const permissionsCacheSize = 8
+
+// Permissions tells which accounts may submit transactions on top of a block,
+// according to the users registered in the Autonity contract at that block.
+// Membership is resolved lazily and cached for the lifetime of the block.
+type Permissions struct {
+	config   *params.Permissions
+	operator common.Address
+
+	call    func(function string, result interface{}, args ...interface{}) error // nil at genesis
+	genesis map[common.Address]params.UserType
+
+	members    map[common.Address]bool
+	validators map[common.Address]bool
+	stakers    map[common.Address]bool
+	mu         sync.Mutex
+}
+
+// GetPermissions returns the submission permissions on top of a block, given
+// the state after it, or nil if the chain isn't permissioned.
+func (ac *Contract) GetPermissions(header *types.Header, db *state.StateDB) (*Permissions, error) {
+	genesis := ac.bc.Config().AutonityContractConfig
+	if genesis == nil || genesis.Permissions == nil {
+		return nil, nil
+	}
+	hash := header.Hash()
+	if cached, ok := ac.permissions.Get(hash); ok {
+		return cached.(*Permissions), nil
+	}
+	p := &Permissions{
+		config:   genesis.Permissions,
+		operator: genesis.Operator,
+		members:  make(map[common.Address]bool),
+	}
+	if header.Number.Uint64() == 0 {
+		// The contract is deployed with the first block, use the genesis users
+		p.genesis = make(map[common.Address]params.UserType)
+		for _, user := range genesis.Users {
+			p.genesis[user.Address] = user.Type
+		}
+	} else {
+		// Work on a private copy as the state keeps changing with the block
+		statedb, header := db.Copy(), types.CopyHeader(header)
+		p.call = func(function string, result interface{}, args ...interface{}) error {
+			return ac.AutonityContractCall(statedb, header, function, result, args...)
+		}
+	}
+	ac.permissions.Add(hash, p)
+	return p, nil
+}
+
+// Check verifies that an account may send a transaction, which deploys a
+// contract if creation is set. A nil Permissions allows everything.
+func (p *Permissions) Check(from common.Address, creation bool) error {
+	if p == nil || from == p.operator {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	userType, member, err := p.userType(from)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrUnauthorizedSender
+	}
+	if creation && !p.config.CanDeploy(userType) {
+		return ErrUnauthorizedDeployer
+	}
+	return nil
+}
+
+// userType resolves the type of a user of the contract, reporting whether the
+// account is a user at all.
+func (p *Permissions) userType(addr common.Address) (params.UserType, bool, error) {
+	if p.call == nil {
+		userType, ok := p.genesis[addr]
+		return userType, ok, nil
+	}
+	member, ok := p.members[addr]
+	if !ok {
+		if err := p.call("checkMember", &member, addr); err != nil {
+			return "", false, err
+		}
+		p.members[addr] = member
+	}
+	if !member {
+		return "", false, nil
+	}
+	if p.validators == nil {
+		validators, err := p.addresses("getValidators")
+		if err != nil {
+			return "", false, err
+		}
+		stakers, err := p.addresses("getStakeholders")
+		if err != nil {
+			return "", false, err
+		}
+		p.validators, p.stakers = validators, stakers
+	}
+	switch {
+	case p.validators[addr]:
+		return params.UserValidator, true, nil
+	case p.stakers[addr]:
+		return params.UserStakeHolder, true, nil
+	default:
+		return params.UserParticipant, true, nil
+	}
+}
+
+// addresses calls a contract getter returning a list of addresses.
+func (p *Permissions) addresses(function string) (map[common.Address]bool, error) {
+	var list []common.Address
+	if err := p.call(function, &list); err != nil {
+		return nil, err
+	}
+	set := make(map[common.Address]bool, len(list))
+	for _, addr := range list {
+		set[addr] = true
+	}
+	return set, nil
+}
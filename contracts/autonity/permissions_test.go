@@ -0,0 +1,92 @@
+package autonity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/params"
+)
+
+func TestPermissions_Check(t *testing.T) {
+	var (
+		operator    = common.HexToAddress(testAddress1)
+		validator   = common.HexToAddress(testAddress2)
+		participant = common.HexToAddress(testAddress3)
+		stranger    = common.HexToAddress("0xff")
+		config      = &params.Permissions{Deployers: []params.UserType{params.UserValidator}}
+	)
+	expect := func(t *testing.T, p *Permissions, from common.Address, creation bool, want error) {
+		t.Helper()
+		if err := p.Check(from, creation); err != want {
+			t.Errorf("check of %x (creation %v) mismatch: have %v, want %v", from, creation, err, want)
+		}
+	}
+
+	t.Run("unrestricted chain", func(t *testing.T) {
+		var p *Permissions
+		expect(t, p, stranger, true, nil)
+	})
+
+	t.Run("genesis users", func(t *testing.T) {
+		p := &Permissions{
+			config:   config,
+			operator: operator,
+			genesis: map[common.Address]params.UserType{
+				validator:   params.UserValidator,
+				participant: params.UserParticipant,
+			},
+			members: make(map[common.Address]bool),
+		}
+		expect(t, p, operator, true, nil)
+		expect(t, p, validator, true, nil)
+		expect(t, p, participant, false, nil)
+		expect(t, p, participant, true, ErrUnauthorizedDeployer)
+		expect(t, p, stranger, false, ErrUnauthorizedSender)
+	})
+
+	t.Run("contract users are cached", func(t *testing.T) {
+		calls := make(map[string]int)
+		p := &Permissions{
+			config:   config,
+			operator: operator,
+			members:  make(map[common.Address]bool),
+			call: func(function string, result interface{}, args ...interface{}) error {
+				calls[function]++
+				switch function {
+				case "checkMember":
+					addr := args[0].(common.Address)
+					*result.(*bool) = addr == validator || addr == participant
+				case "getValidators":
+					*result.(*[]common.Address) = []common.Address{validator}
+				case "getStakeholders":
+					*result.(*[]common.Address) = nil
+				}
+				return nil
+			},
+		}
+		expect(t, p, validator, true, nil)
+		expect(t, p, validator, false, nil)
+		expect(t, p, participant, true, ErrUnauthorizedDeployer)
+		expect(t, p, stranger, false, ErrUnauthorizedSender)
+		expect(t, p, stranger, false, ErrUnauthorizedSender)
+
+		if calls["checkMember"] != 3 || calls["getValidators"] != 1 || calls["getStakeholders"] != 1 {
+			t.Errorf("contract calls mismatch: have %v", calls)
+		}
+	})
+
+	t.Run("contract failure", func(t *testing.T) {
+		failure := errors.New("call failed")
+		p := &Permissions{
+			config:   config,
+			operator: operator,
+			members:  make(map[common.Address]bool),
+			call: func(string, interface{}, ...interface{}) error {
+				return failure
+			},
+		}
+		expect(t, p, validator, false, failure)
+		expect(t, p, operator, false, nil)
+	})
+}
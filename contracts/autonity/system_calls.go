@@ -0,0 +1,180 @@
+package autonity
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/core/vm"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/tracing"
+)
+
+// systemCallRecorder is a VM tracer collecting the accounts and storage slots
+// a system call touches, to record the changes it made to the state.
+type systemCallRecorder struct {
+	pre      vm.StateDB // State to read the values from before the call
+	accounts []*types.AccountDiff
+	touched  map[common.Address]*types.AccountDiff
+	slots    map[common.Address]map[common.Hash]bool
+}
+
+// newSystemCallRecorder creates a recorder reading the values before the call
+// from the given state. It is either the state the call runs on, the values
+// being read before the call first modifies them, or a copy of it.
+func newSystemCallRecorder(pre vm.StateDB) *systemCallRecorder {
+	return &systemCallRecorder{
+		pre:     pre,
+		touched: make(map[common.Address]*types.AccountDiff),
+		slots:   make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+// touchAccount records the account as it is before the call, unless already
+// touched.
+func (r *systemCallRecorder) touchAccount(addr common.Address) *types.AccountDiff {
+	if account, ok := r.touched[addr]; ok {
+		return account
+	}
+	account := &types.AccountDiff{
+		Address:        addr,
+		BalanceBefore:  new(big.Int).Set(r.pre.GetBalance(addr)),
+		NonceBefore:    r.pre.GetNonce(addr),
+		CodeHashBefore: r.pre.GetCodeHash(addr),
+	}
+	r.touched[addr] = account
+	r.accounts = append(r.accounts, account)
+	r.slots[addr] = make(map[common.Hash]bool)
+	return account
+}
+
+// touchSlot records the storage slot of the account as it is before the call,
+// unless already touched.
+func (r *systemCallRecorder) touchSlot(addr common.Address, key common.Hash) {
+	account := r.touchAccount(addr)
+	if r.slots[addr][key] {
+		return
+	}
+	r.slots[addr][key] = true
+	account.Storage = append(account.Storage, &types.StorageDiff{Key: key, Before: r.pre.GetState(addr, key)})
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (r *systemCallRecorder) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+// CaptureState implements the Tracer interface, touching the accounts and the
+// storage slots the VM is about to modify.
+func (r *systemCallRecorder) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if err != nil {
+		return nil
+	}
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		r.touchAccount(contract.Address())
+		r.touchAccount(common.BigToAddress(stack.Back(1)))
+
+	case vm.CREATE:
+		from := contract.Address()
+		r.touchAccount(from)
+		r.touchAccount(crypto.CreateAddress(from, env.StateDB.GetNonce(from)))
+
+	case vm.CREATE2:
+		// stack: endowment, offset, size, salt
+		from := contract.Address()
+		r.touchAccount(from)
+
+		offset, size := stack.Back(1), stack.Back(2)
+		if offset.IsUint64() && size.IsUint64() && offset.Uint64()+size.Uint64() <= uint64(memory.Len()) {
+			code := memory.GetCopy(offset.Int64(), size.Int64())
+			r.touchAccount(crypto.CreateAddress2(from, common.BigToHash(stack.Back(3)), crypto.Keccak256(code)))
+		}
+
+	case vm.SELFDESTRUCT:
+		r.touchAccount(contract.Address())
+		r.touchAccount(common.BigToAddress(stack.Back(0)))
+
+	case vm.SSTORE:
+		r.touchSlot(contract.Address(), common.BigToHash(stack.Back(0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (r *systemCallRecorder) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (r *systemCallRecorder) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	return nil
+}
+
+// diff returns the changes the call made to the touched accounts, reading their
+// values after the call from the given state.
+func (r *systemCallRecorder) diff(post vm.StateDB) []*types.AccountDiff {
+	var diff []*types.AccountDiff
+	for _, account := range r.accounts {
+		account.BalanceAfter = new(big.Int).Set(post.GetBalance(account.Address))
+		account.NonceAfter = post.GetNonce(account.Address)
+		account.CodeHashAfter = post.GetCodeHash(account.Address)
+
+		var storage []*types.StorageDiff
+		for _, slot := range account.Storage {
+			if slot.After = post.GetState(account.Address, slot.Key); slot.After != slot.Before {
+				storage = append(storage, slot)
+			}
+		}
+		account.Storage = storage
+
+		if len(storage) > 0 || account.BalanceAfter.Cmp(account.BalanceBefore) != 0 ||
+			account.NonceAfter != account.NonceBefore || account.CodeHashAfter != account.CodeHashBefore {
+			diff = append(diff, account)
+		}
+	}
+	return diff
+}
+
+// recordSystemCall runs a system call with an EVM recording its execution, and
+// adds the record to the state unless the call succeeded without changing the
+// state nor emitting logs, like calls to views do. The values before the call
+// are read from the pre state, and the accounts of the caller and the callee are
+// touched before the call runs.
+func (ac *Contract) recordSystemCall(statedb *state.StateDB, pre vm.StateDB, header *types.Header, call *types.SystemCall, run func(evm *vm.EVM) ([]byte, uint64, error)) ([]byte, uint64, error) {
+	span := tracing.StartHeight(header.Number.Uint64(), "autonity.systemCall",
+		tracing.String("call.type", call.Type),
+		tracing.String("call.method", call.Method),
+	)
+	defer span.Finish()
+
+	recorder := newSystemCallRecorder(pre)
+	recorder.touchAccount(call.From)
+	recorder.touchAccount(call.To)
+
+	vmConfig := *ac.bc.GetVMConfig()
+	vmConfig.Debug, vmConfig.Tracer = true, recorder
+
+	// Attribute the logs of the call to its own pseudo transaction
+	hash := types.SystemCallHash(header.Number.Uint64(), len(statedb.SystemCalls()))
+	statedb.Prepare(hash, common.Hash{}, 0)
+
+	ret, gas, vmerr := run(ac.getEVMWithConfig(header, call.From, statedb, vmConfig))
+
+	call.Output, call.GasUsed = common.CopyBytes(ret), gas
+	if vmerr != nil {
+		call.Error = vmerr.Error()
+	}
+	span.SetAttributes(tracing.Uint64("call.gasUsed", gas))
+	span.SetError(vmerr)
+	call.Logs = statedb.GetLogs(hash)
+	call.StateDiff = recorder.diff(statedb)
+
+	if vmerr != nil || len(call.Logs) > 0 || len(call.StateDiff) > 0 {
+		statedb.AddSystemCall(call)
+	}
+	return ret, gas, vmerr
+}
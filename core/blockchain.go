@@ -34,9 +34,11 @@ import (
 	"github.com/clearmatics/autonity/common/mclock"
 	"github.com/clearmatics/autonity/common/prque"
 	"github.com/clearmatics/autonity/consensus"
+	"github.com/clearmatics/autonity/consensus/tendermint/participation"
 	"github.com/clearmatics/autonity/contracts/autonity"
 	"github.com/clearmatics/autonity/core/rawdb"
 	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/state/snapshot"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/core/vm"
 	"github.com/clearmatics/autonity/ethdb"
@@ -44,7 +46,9 @@ import (
 	"github.com/clearmatics/autonity/log"
 	"github.com/clearmatics/autonity/metrics"
 	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/private"
 	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/tracing"
 	"github.com/clearmatics/autonity/trie"
 )
 
@@ -83,6 +87,7 @@ const (
 	txLookupCacheLimit  = 1024
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
+	maxVerifiedBlocks   = 16
 	badBlockLimit       = 10
 	TriesInMemory       = 128
 
@@ -117,6 +122,9 @@ type CacheConfig struct {
 	TrieDirtyLimit      int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
 	TrieDirtyDisabled   bool          // Whether to disable trie write caching and GC altogether (archive node)
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
+	StateRetention      uint64        // Number of final blocks whose state is kept by the online pruner (0 = disabled)
+	PruneBloomSize      uint64        // Memory allowance (MB) of the online pruner reachability bloom
+	Snapshot            bool          // Whether to maintain a flat state snapshot for faster state reads
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -150,8 +158,14 @@ type BlockChain struct {
 	blockProcFeed event.Feed
 	glienickeFeed event.Feed
 	autonityFeed  event.Feed
-	scope         event.SubscriptionScope
-	genesisBlock  *types.Block
+
+	committeeFeed   event.Feed
+	minGasPriceFeed event.Feed
+	stakeFeed       event.Feed
+	upgradeFeed     event.Feed
+
+	scope        event.SubscriptionScope
+	genesisBlock *types.Block
 
 	chainmu sync.RWMutex // blockchain insertion lock
 
@@ -166,6 +180,8 @@ type BlockChain struct {
 	txLookupCache *lru.Cache     // Cache for the most recent transaction lookup data.
 	futureBlocks  *lru.Cache     // future blocks are blocks added for later processing
 
+	verifiedBlocks *lru.Cache // Execution results of committed blocks already verified by the consensus engine
+
 	quit    chan struct{} // blockchain quit channel
 	running int32         // running must be called atomically
 	// procInterrupt must be atomically called
@@ -184,6 +200,16 @@ type BlockChain struct {
 	terminateInsert func(common.Hash, uint64) bool // Testing hook used to terminate ancient receipt chain insertion.
 
 	autonityContract *autonity.Contract
+	participation    *participation.Tracker
+
+	privateManager    private.Manager // Manager of the private transaction payloads, nil if disabled
+	privateStateCache state.Database  // Database of the private states
+
+	pruneCh         chan struct{} // Online state pruning trigger, nil if disabled
+	pruning         bool          // Whether pruning is sweeping, which suspends state flushes (guarded by chainmu)
+	pendingPrivates []common.Hash // Private state roots awaiting the end of pruning to be flushed (guarded by chainmu)
+
+	snaps *snapshot.Tree // Flat state snapshot, nil if disabled
 
 	// senderCacher is a concurrent transaction sender recoverer and cacher
 	senderCacher *TxSenderCacher
@@ -200,12 +226,22 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			TrieTimeLimit:  5 * time.Minute,
 		}
 	}
+	if cacheConfig.StateRetention > 0 {
+		// Pruning relies on finality to never need the discarded states again
+		if chainConfig.Tendermint == nil {
+			return nil, ErrNoFinality
+		}
+		if cacheConfig.TrieDirtyDisabled {
+			return nil, errors.New("state pruning is incompatible with archive mode")
+		}
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	receiptsCache, _ := lru.New(receiptsCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
 	txLookupCache, _ := lru.New(txLookupCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
+	verifiedBlocks, _ := lru.New(maxVerifiedBlocks)
 	badBlocks, _ := lru.New(badBlockLimit)
 
 	bc := &BlockChain{
@@ -222,11 +258,15 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		blockCache:     blockCache,
 		txLookupCache:  txLookupCache,
 		futureBlocks:   futureBlocks,
+		verifiedBlocks: verifiedBlocks,
 		engine:         engine,
 		vmConfig:       vmConfig,
 		badBlocks:      badBlocks,
 		senderCacher:   senderCacher,
 	}
+	if cacheConfig.StateRetention > 0 {
+		bc.pruneCh = make(chan struct{}, 1)
+	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
@@ -262,6 +302,12 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			return GetHashFn(ref, chain)
 		})
 		bc.processor.SetAutonityContract(bc.autonityContract)
+
+		bc.participation = participation.NewTracker(chainConfig.Tendermint.ParticipationWindow)
+		bc.resetParticipation(bc.CurrentBlock().Header())
+	}
+	if cacheConfig.Snapshot {
+		bc.snaps = snapshot.New(bc.db, bc.stateCache.TrieDB(), bc.CurrentBlock().Root())
 	}
 	// The first thing the node will do is reconstruct the verification data for
 	// the head block (ethash cache or clique voting snapshot). Might as well do
@@ -477,7 +523,11 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.txLookupCache.Purge()
 	bc.futureBlocks.Purge()
 
-	return bc.loadLastState()
+	if err := bc.loadLastState(); err != nil {
+		return err
+	}
+	bc.resetParticipation(bc.CurrentBlock().Header())
+	return nil
 }
 
 // FastSyncCommitHead sets the current head block to the one defined by the hash
@@ -535,7 +585,12 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, bc.stateCache)
+	return state.NewWithSnapshot(root, bc.stateCache, bc.snaps)
+}
+
+// Snapshots returns the flat state snapshot tree, nil if disabled.
+func (bc *BlockChain) Snapshots() *snapshot.Tree {
+	return bc.snaps
 }
 
 // StateCache returns the caching database underpinning the blockchain instance.
@@ -792,6 +847,16 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	return receipts
 }
 
+// GetSystemCallsByHash retrieves the calls the protocol made to the Autonity
+// contract while processing the block with the given hash.
+func (bc *BlockChain) GetSystemCallsByHash(hash common.Hash) []*types.SystemCall {
+	number := rawdb.ReadHeaderNumber(bc.db, hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadSystemCalls(bc.db, hash, *number)
+}
+
 // GetBlocksFromHash returns the block corresponding to hash and up to n-1 ancestors.
 // [deprecated by eth/62]
 func (bc *BlockChain) GetBlocksFromHash(hash common.Hash, n int) (blocks []*types.Block) {
@@ -865,6 +930,13 @@ func (bc *BlockChain) Stop() {
 			log.Error("Dangling trie nodes after full cleanup")
 		}
 	}
+	// Flatten the snapshot into the head state, which is on disk by now
+	if bc.snaps != nil {
+		if err := bc.snaps.Cap(bc.CurrentBlock().Root(), 0); err != nil {
+			log.Error("Failed to persist state snapshot", "err", err)
+		}
+		bc.snaps.Stop()
+	}
 	bc.senderCacher.Close()
 	log.Info("Blockchain manager stopped")
 }
@@ -1312,6 +1384,11 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		return NonStatTy, err
 	}
 
+	// Retrieve the system calls made while processing the block, before the
+	// contract is called again below
+	systemCalls := state.SystemCalls()
+
+	var autonityEvents []interface{}
 	if bc.chainConfig.Tendermint != nil {
 		// Call network permissioning logic before committing the state
 		err = bc.GetAutonityContract().UpdateEnodesWhitelist(state, block)
@@ -1321,6 +1398,9 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 
 		// Measure network economic metrics.
 		bc.GetAutonityContract().MeasureMetricsOfNetworkEconomic(block.Header(), state)
+
+		// Gather the changes of the contract to post once the block is canonical.
+		autonityEvents = bc.autonityEvents(block, logs, systemCalls, state)
 	}
 
 	rawdb.WriteBlock(bc.db, block)
@@ -1329,6 +1409,16 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if err != nil {
 		return NonStatTy, err
 	}
+	// Keep the snapshot diffs of the tries held in memory, flattening older ones.
+	// This happens before the trie garbage collection below so the disk layer's
+	// trie is still around for a running snapshot generation.
+	if bc.snaps != nil {
+		if bc.snaps.Snapshot(root) == nil {
+			bc.snaps.Rebuild(root)
+		} else if err := bc.snaps.Cap(root, TriesInMemory-1); err != nil {
+			log.Warn("Failed to flatten state snapshot", "root", root, "err", err)
+		}
+	}
 	triedb := bc.stateCache.TrieDB()
 
 	// If we're running an archive node, always flush
@@ -1341,7 +1431,9 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 		bc.triegc.Push(root, -int64(block.NumberU64()))
 
-		if current := block.NumberU64(); current > TriesInMemory {
+		// Nothing is flushed while pruning, a node re-created by the new states
+		// could otherwise be swept away.
+		if current := block.NumberU64(); current > TriesInMemory && !bc.pruning {
 			// If we exceeded our memory allowance, flush matured singleton nodes to disk
 			var (
 				nodes, imgs = triedb.Size()
@@ -1387,6 +1479,15 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	// Write other block data using a batch.
 	batch := bc.db.NewBatch()
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	if len(systemCalls) > 0 {
+		rawdb.WriteSystemCalls(batch, block.Hash(), block.NumberU64(), systemCalls)
+	}
+
+	if bc.privateManager != nil {
+		if err := bc.processPrivateTransactions(batch, block); err != nil {
+			return NonStatTy, err
+		}
+	}
 
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
@@ -1432,10 +1533,19 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	bc.futureBlocks.Remove(block.Hash())
 
 	if status == CanonStatTy {
+		if bc.participation != nil {
+			if parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1); parent != nil {
+				if err := bc.participation.Process(block.Header(), parent); err != nil {
+					log.Debug("Failed to track validators participation", "number", block.NumberU64(), "err", err)
+				}
+			}
+		}
 		bc.chainFeed.Send(ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
 		if len(logs) > 0 {
 			bc.logsFeed.Send(logs)
 		}
+		bc.postAutonityEvents(autonityEvents)
+
 		// In theory we should fire a ChainHeadEvent when we inject
 		// a canonical block, but sometimes we can insert a batch of
 		// canonicial blocks. Avoid firing too much ChainHeadEvents,
@@ -1444,6 +1554,13 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		if emitHeadEvent {
 			bc.chainHeadFeed.Send(ChainHeadEvent{Block: block})
 		}
+		// Prune once per retention window, letting garbage accumulate meanwhile
+		if bc.pruneCh != nil && block.NumberU64()%bc.cacheConfig.StateRetention == 0 {
+			select {
+			case bc.pruneCh <- struct{}{}:
+			default:
+			}
+		}
 	} else {
 		bc.chainSideFeed.Send(ChainSideEvent{Block: block})
 	}
@@ -1462,6 +1579,34 @@ func (bc *BlockChain) addFutureBlock(block *types.Block) error {
 	return nil
 }
 
+// VerifiedBlock holds the result of executing a block on top of its parent
+// state, as computed by the consensus engine while verifying it.
+type VerifiedBlock struct {
+	State    *state.StateDB
+	Receipts types.Receipts
+	Logs     []*types.Log
+	UsedGas  uint64
+}
+
+// AddVerifiedBlock hands over the execution result of a block about to be
+// inserted, so that insertChain writes it without executing the block again.
+// The state must not be used by the caller afterwards.
+func (bc *BlockChain) AddVerifiedBlock(hash common.Hash, verified *VerifiedBlock) {
+	bc.verifiedBlocks.Add(hash, verified)
+}
+
+// takeVerifiedBlock returns and forgets the execution result handed over for
+// the block, if any. A state can only be committed once, hence a result is
+// never used twice.
+func (bc *BlockChain) takeVerifiedBlock(hash common.Hash) *VerifiedBlock {
+	verified, ok := bc.verifiedBlocks.Get(hash)
+	if !ok {
+		return nil
+	}
+	bc.verifiedBlocks.Remove(hash)
+	return verified.(*VerifiedBlock)
+}
+
 // InsertChain attempts to insert the given batch of blocks in to the canonical
 // chain or, otherwise, create a fork. If an error is returned it will return
 // the index number of the failing block as well an error describing what went
@@ -1657,8 +1802,13 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		if parent == nil {
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
 		}
-		statedb, err := state.New(parent.Root, bc.stateCache)
-		if err != nil {
+		// Reuse the execution result of a block already verified by the consensus engine
+		verified := bc.takeVerifiedBlock(block.Hash())
+
+		var statedb *state.StateDB
+		if verified != nil {
+			statedb = verified.State
+		} else if statedb, err = state.NewWithSnapshot(parent.Root, bc.stateCache, bc.snaps); err != nil {
 			return it.index, err
 		}
 		// If we have a followup block, run that against the current state to pre-cache
@@ -1680,10 +1830,23 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		}
 		// Process block using the parent state as reference point
 		substart := time.Now()
-		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
-		if err != nil {
+		span := tracing.StartHeight(block.NumberU64(), "core.insertBlock",
+			tracing.String("block.hash", block.Hash().Hex()),
+			tracing.Bool("block.verified", verified != nil),
+		)
+
+		var (
+			receipts types.Receipts
+			logs     []*types.Log
+			usedGas  uint64
+		)
+		if verified != nil {
+			receipts, logs, usedGas = verified.Receipts, verified.Logs, verified.UsedGas
+		} else if receipts, logs, usedGas, err = bc.processor.Process(block, statedb, bc.vmConfig); err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
+			span.SetError(err)
+			span.Finish()
 			return it.index, err
 		}
 		// Update the metrics touched during block processing
@@ -1703,6 +1866,8 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
+			span.SetError(err)
+			span.Finish()
 			return it.index, err
 		}
 		proctime := time.Since(start)
@@ -1716,6 +1881,8 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		// Write the block to the chain and get the status.
 		substart = time.Now()
 		status, err := bc.writeBlockWithState(block, receipts, logs, statedb, false)
+		span.SetError(err)
+		span.Finish()
 		if err != nil {
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return it.index, err
@@ -2051,6 +2218,13 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		rawdb.DeleteCanonicalHash(batch, i)
 	}
 	batch.Write()
+	// Rebuild the participation window up to the parent of the new head, which
+	// is processed once written.
+	if len(newChain) > 1 {
+		bc.resetParticipation(newChain[1].Header())
+	} else {
+		bc.resetParticipation(commonBlock.Header())
+	}
 	// If any logs need to be fired, do it now. In theory we could avoid creating
 	// this goroutine if there are no events to fire, but realistcally that only
 	// ever happens if we're reorging empty blocks, which will only happen on idle
@@ -2076,6 +2250,8 @@ func (bc *BlockChain) update() {
 		select {
 		case <-futureTimer.C:
 			bc.procFutureBlocks()
+		case <-bc.pruneCh:
+			bc.pruneState()
 		case <-bc.quit:
 			return
 		}
@@ -2239,6 +2415,18 @@ func (bc *BlockChain) GetTransactionLookup(hash common.Hash) *rawdb.LegacyTxLook
 func (bc *BlockChain) Config() *params.ChainConfig             { return bc.chainConfig }
 func (bc *BlockChain) GetAutonityContract() *autonity.Contract { return bc.autonityContract }
 
+// resetParticipation rebuilds the participation window ending at head from the
+// stored headers, discarding the records of the blocks no longer canonical.
+func (bc *BlockChain) resetParticipation(head *types.Header) {
+	if bc.participation != nil {
+		bc.participation.Backfill(bc, head)
+	}
+}
+
+// Participation returns the tracker of the committee members participation,
+// nil if the chain doesn't run tendermint.
+func (bc *BlockChain) Participation() *participation.Tracker { return bc.participation }
+
 // Engine retrieves the blockchain's consensus engine.
 func (bc *BlockChain) Engine() consensus.Engine { return bc.engine }
 
@@ -2273,6 +2461,8 @@ func (bc *BlockChain) SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscr
 	return bc.scope.Track(bc.blockProcFeed.Subscribe(ch))
 }
 
+// SubscribeAutonityEvents registers a subscription of WhitelistEvent. The other
+// changes of the Autonity contract have their own subscriptions.
 func (bc *BlockChain) SubscribeAutonityEvents(ch chan<- WhitelistEvent) event.Subscription {
 	return bc.scope.Track(bc.autonityFeed.Subscribe(ch))
 }
@@ -0,0 +1,106 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/ethdb"
+)
+
+// InsertTrustedChain writes blocks along with their receipts, neither verifying
+// the headers against the consensus engine nor executing the transactions. It
+// is meant for trusted archives whose committed seals were checked by the
+// caller. The head block isn't moved, see CommitTrustedHead.
+func (bc *BlockChain) InsertTrustedChain(blocks types.Blocks, receipts []types.Receipts) (int, error) {
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	n, err := bc.insertTrustedHeaders(headers)
+	if err != nil {
+		return n, err
+	}
+	return bc.InsertReceiptChain(blocks, receipts, 0)
+}
+
+// insertTrustedHeaders writes headers without verifying them.
+func (bc *BlockChain) insertTrustedHeaders(headers []*types.Header) (int, error) {
+	start := time.Now()
+
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	if err := bc.addJob(); err != nil {
+		return 0, err
+	}
+	defer bc.doneJob()
+
+	whFunc := func(header *types.Header) error {
+		_, err := bc.hc.WriteHeader(header)
+		return err
+	}
+	return bc.hc.InsertHeaderChain(headers, whFunc, start)
+}
+
+// InsertTrustedState writes state trie nodes and contract codes, both keyed by
+// the hash of their content.
+func (bc *BlockChain) InsertTrustedState(blobs [][]byte) error {
+	batch := bc.db.NewBatch()
+	for _, blob := range blobs {
+		if err := batch.Put(crypto.Keccak256(blob), blob); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
+
+// CommitTrustedHead sets the head block to a block written by InsertTrustedChain,
+// once its entire state is present in the database.
+func (bc *BlockChain) CommitTrustedHead(hash common.Hash) error {
+	block := bc.GetBlockByHash(hash)
+	if block == nil {
+		return fmt.Errorf("non existent block [%x…]", hash[:4])
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(bc.db))
+	if err != nil {
+		return err
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		return it.Error
+	}
+	if err := bc.FastSyncCommitHead(hash); err != nil {
+		return err
+	}
+	rawdb.WriteHeadBlockHash(bc.db, hash)
+	return nil
+}
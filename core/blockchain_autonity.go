@@ -0,0 +1,180 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/contracts/autonity"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/event"
+	"github.com/clearmatics/autonity/log"
+)
+
+// SubscribeCommitteeEvent registers a subscription of CommitteeEvent.
+func (bc *BlockChain) SubscribeCommitteeEvent(ch chan<- CommitteeEvent) event.Subscription {
+	return bc.scope.Track(bc.committeeFeed.Subscribe(ch))
+}
+
+// SubscribeMinGasPriceEvent registers a subscription of MinGasPriceEvent.
+func (bc *BlockChain) SubscribeMinGasPriceEvent(ch chan<- MinGasPriceEvent) event.Subscription {
+	return bc.scope.Track(bc.minGasPriceFeed.Subscribe(ch))
+}
+
+// SubscribeStakeEvent registers a subscription of StakeEvent.
+func (bc *BlockChain) SubscribeStakeEvent(ch chan<- StakeEvent) event.Subscription {
+	return bc.scope.Track(bc.stakeFeed.Subscribe(ch))
+}
+
+// SubscribeContractUpgradeEvent registers a subscription of ContractUpgradeEvent.
+func (bc *BlockChain) SubscribeContractUpgradeEvent(ch chan<- ContractUpgradeEvent) event.Subscription {
+	return bc.scope.Track(bc.upgradeFeed.Subscribe(ch))
+}
+
+// autonityEvents derives the changes a block made to the Autonity contract from
+// the events the contract emitted, in the transactions and the system calls of
+// the block. The values after the block are read from its state, which must not
+// be committed yet. The committee is compared with the one of the parent block
+// instead, as it also changes with the stakes and voting powers.
+func (bc *BlockChain) autonityEvents(block *types.Block, logs []*types.Log, calls []*types.SystemCall, state *state.StateDB) []interface{} {
+	contract := bc.GetAutonityContract()
+
+	var (
+		events    []interface{}
+		committee = block.Header().Committee
+	)
+	if parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1); parent != nil && !sameCommittee(parent.Committee, committee) {
+		events = append(events, CommitteeEvent{Block: block, Committee: committee})
+	}
+
+	all := make([]*types.Log, 0, len(logs))
+	all = append(all, logs...)
+	for _, call := range calls {
+		all = append(all, call.Logs...)
+	}
+	decoded, err := contract.DecodeEvents(all)
+	if err != nil {
+		log.Warn("Could not decode Autonity contract events", "number", block.NumberU64(), "err", err)
+		return events
+	}
+
+	var (
+		accounts []common.Address
+		removed  = make(map[common.Address]bool)
+		staked   = make(map[common.Address]bool)
+	)
+	stake := func(account common.Address) {
+		if !staked[account] {
+			staked[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+	for _, ev := range decoded {
+		switch ev.Name {
+		case autonity.EventAddValidator, autonity.EventAddStakeholder:
+			if account, ok := ev.Args["_address"].(common.Address); ok {
+				delete(removed, account)
+				stake(account)
+			}
+
+		case autonity.EventRemoveUser:
+			if account, ok := ev.Args["_address"].(common.Address); ok {
+				removed[account] = true
+				stake(account)
+			}
+
+		case autonity.EventMintStake, autonity.EventRedeemStake:
+			if account, ok := ev.Args["_address"].(common.Address); ok {
+				stake(account)
+			}
+
+		case autonity.EventTransfer:
+			for _, name := range []string{"from", "to"} {
+				if account, ok := ev.Args[name].(common.Address); ok {
+					stake(account)
+				}
+			}
+
+		case autonity.EventSetMinimumGasPrice:
+			if price, ok := ev.Args["_gasPrice"].(*big.Int); ok {
+				events = append(events, MinGasPriceEvent{Block: block, Price: new(big.Int).Set(price)})
+			}
+		}
+	}
+
+	for _, account := range accounts {
+		if removed[account] {
+			events = append(events, StakeEvent{Block: block, Account: account})
+			continue
+		}
+		amount, err := contract.GetAccountStake(block, state, account)
+		if err != nil {
+			log.Warn("Could not retrieve the stake", "number", block.NumberU64(), "account", account, "err", err)
+			continue
+		}
+		events = append(events, StakeEvent{Block: block, Account: account, Stake: amount})
+	}
+
+	// The contract is deployed by a system call in the first block, and
+	// redeployed by one when upgraded.
+	if block.NumberU64() > 1 {
+		for _, call := range calls {
+			if call.Type != types.SystemCallCreate || call.Error != "" {
+				continue
+			}
+			version, err := contract.GetVersion(block, state)
+			if err != nil {
+				log.Warn("Could not retrieve the contract version", "number", block.NumberU64(), "err", err)
+			}
+			events = append(events, ContractUpgradeEvent{Block: block, Version: version})
+		}
+	}
+	return events
+}
+
+// sameCommittee reports whether two committees have the same members with the
+// same voting powers, in the same order.
+func sameCommittee(a, b types.Committee) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Address != b[i].Address || a[i].VotingPower.Cmp(b[i].VotingPower) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// postAutonityEvents posts the changes of the Autonity contract made by a
+// canonical block.
+func (bc *BlockChain) postAutonityEvents(events []interface{}) {
+	for _, ev := range events {
+		switch ev := ev.(type) {
+		case CommitteeEvent:
+			bc.committeeFeed.Send(ev)
+		case MinGasPriceEvent:
+			bc.minGasPriceFeed.Send(ev)
+		case StakeEvent:
+			bc.stakeFeed.Send(ev)
+		case ContractUpgradeEvent:
+			bc.upgradeFeed.Send(ev)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/log"
+)
+
+// SeedBFTCheckpoint makes a committed header the operator trusts the head header
+// of a chain holding nothing but its genesis, so that it is synced forward from
+// there instead of from genesis. The checkpoint is the trust anchor of the chain:
+// it isn't verified itself, while the headers following it are verified against
+// the committee it carries. Seeding a chain already containing the checkpoint is
+// a no-op.
+func (bc *BlockChain) SeedBFTCheckpoint(header *types.Header) error {
+	if bc.chainConfig.Tendermint == nil {
+		return ErrNoFinality
+	}
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	var (
+		number = header.Number.Uint64()
+		hash   = header.Hash()
+	)
+	if number == 0 {
+		return errors.New("invalid BFT checkpoint: genesis")
+	}
+	if known := rawdb.ReadCanonicalHash(bc.db, number); known != (common.Hash{}) {
+		if known != hash {
+			return fmt.Errorf("BFT checkpoint %d conflicts with the local chain: have %x, want %x", number, known, hash)
+		}
+		return nil
+	}
+	if head := bc.CurrentHeader(); head.Number.Uint64() != 0 {
+		return fmt.Errorf("BFT checkpoint %d can't be seeded on a chain synced to %d", number, head.Number)
+	}
+	// Every BFT header carries the same difficulty, the total difficulty of the
+	// checkpoint follows from its height.
+	td := new(big.Int).Mul(header.Difficulty, new(big.Int).SetUint64(number))
+	td.Add(td, bc.genesisBlock.Difficulty())
+
+	batch := bc.db.NewBatch()
+	rawdb.WriteHeader(batch, header)
+	rawdb.WriteTd(batch, hash, number, td)
+	rawdb.WriteCanonicalHash(batch, hash, number)
+	rawdb.WriteBFTCheckpointHash(batch, hash)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	bc.hc.SetCurrentHeader(header)
+
+	log.Info("Seeded chain with BFT checkpoint", "number", number, "hash", hash)
+	return nil
+}
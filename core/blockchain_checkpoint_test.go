@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/ethash"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/vm"
+	"github.com/clearmatics/autonity/params"
+)
+
+// Tests that a chain seeded from a BFT checkpoint has it as head header, holds
+// nothing between genesis and it, and refuses conflicting checkpoints.
+func TestSeedBFTCheckpoint(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 8, nil)
+
+	chaindb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(chaindb)
+	chain, _ := NewBlockChain(chaindb, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, NewTxSenderCacher())
+	defer chain.Stop()
+
+	seed := blocks[5].Header()
+	if err := chain.SeedBFTCheckpoint(seed); err != ErrNoFinality {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoFinality)
+	}
+	// Seed behind the back of the finality check
+	config := *params.TestChainConfig
+	config.Tendermint = new(params.TendermintConfig)
+	chain.chainConfig = &config
+
+	if err := chain.SeedBFTCheckpoint(seed); err != nil {
+		t.Fatalf("failed to seed checkpoint: %v", err)
+	}
+	if head := chain.CurrentHeader(); head.Hash() != seed.Hash() {
+		t.Fatalf("head header mismatch: have %x, want %x", head.Hash(), seed.Hash())
+	}
+	if head := chain.CurrentBlock(); head.Hash() != genesis.Hash() {
+		t.Fatalf("head block mismatch: have %x, want %x", head.Hash(), genesis.Hash())
+	}
+	if td := chain.GetTd(seed.Hash(), seed.Number.Uint64()); td == nil {
+		t.Fatalf("checkpoint total difficulty missing")
+	}
+	if header := chain.GetHeaderByNumber(1); header != nil {
+		t.Fatalf("header #1 present below the checkpoint")
+	}
+	if hash := rawdb.ReadBFTCheckpointHash(chaindb); hash != seed.Hash() {
+		t.Fatalf("checkpoint marker mismatch: have %x, want %x", hash, seed.Hash())
+	}
+	// Seeding again is a no-op, a conflicting checkpoint is refused
+	if err := chain.SeedBFTCheckpoint(seed); err != nil {
+		t.Fatalf("failed to reseed checkpoint: %v", err)
+	}
+	conflict := blocks[5].Header()
+	conflict.Extra = common.CopyBytes([]byte("conflict"))
+	if err := chain.SeedBFTCheckpoint(conflict); err == nil {
+		t.Fatalf("conflicting checkpoint accepted")
+	}
+	if err := chain.SeedBFTCheckpoint(blocks[7].Header()); err == nil {
+		t.Fatalf("checkpoint accepted on a chain past genesis")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/private"
+)
+
+// errPrivateDisabled is returned when the private state is requested from a
+// node which doesn't execute private transactions.
+var errPrivateDisabled = errors.New("private transactions are disabled")
+
+// SetPrivateManager enables the execution of the private transactions the node
+// is a party of, with their payloads retrieved from the given manager. It must
+// be called before any block is inserted.
+func (bc *BlockChain) SetPrivateManager(manager private.Manager) {
+	bc.privateManager = manager
+	bc.privateStateCache = state.NewDatabase(bc.db)
+}
+
+// PrivateManager returns the manager of the private transaction payloads, nil
+// if private transactions are disabled.
+func (bc *BlockChain) PrivateManager() private.Manager { return bc.privateManager }
+
+// PrivateStateAt returns the private state of the node after the given block.
+func (bc *BlockChain) PrivateStateAt(hash common.Hash) (*state.StateDB, error) {
+	if bc.privateManager == nil {
+		return nil, errPrivateDisabled
+	}
+	return state.New(rawdb.ReadPrivateStateRoot(bc.db, hash), bc.privateStateCache)
+}
+
+// GetPrivateReceipt retrieves the private receipt of a transaction the node is
+// a party of, nil otherwise.
+func (bc *BlockChain) GetPrivateReceipt(hash common.Hash) *types.Receipt {
+	return rawdb.ReadPrivateReceipt(bc.db, hash)
+}
+
+// ValidatePrivateTx checks the public part of a private transaction. It may
+// only appear from the private transactions fork onwards and carry no value
+// and nothing else than the hash of its payload, for parties with a name.
+func ValidatePrivateTx(config *params.ChainConfig, number *big.Int, tx *types.Transaction) error {
+	if !tx.IsPrivate() {
+		return nil
+	}
+	if !config.IsPrivateTx(number) {
+		return ErrPrivateTxNotActive
+	}
+	return validatePrivatePayload(tx)
+}
+
+// validatePrivatePayload checks the fork independent rules of ValidatePrivateTx.
+func validatePrivatePayload(tx *types.Transaction) error {
+	if tx.Value().Sign() != 0 || len(tx.Data()) != common.HashLength {
+		return ErrInvalidPrivateTx
+	}
+	for _, party := range tx.PrivateFor() {
+		if party == "" {
+			return ErrInvalidPrivateTx
+		}
+	}
+	return nil
+}
+
+// processPrivateTransactions executes the private transactions of a block the
+// node is a party of on top of the private state of its parent, storing the
+// resulting private state and receipts.
+func (bc *BlockChain) processPrivateTransactions(batch ethdb.KeyValueWriter, block *types.Block) error {
+	var (
+		header  = block.Header()
+		signer  = types.MakeSigner(bc.chainConfig, header.Number)
+		root    = rawdb.ReadPrivateStateRoot(bc.db, block.ParentHash())
+		ran     = make(map[common.Hash]bool)
+		statedb *state.StateDB
+	)
+	for i, tx := range block.Transactions() {
+		if !tx.IsPrivate() {
+			continue
+		}
+		// The private layer must never reject a block, skip unusable payloads
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		payload, err := bc.privateManager.Receive(tx, sender)
+		switch {
+		case err == private.ErrNotParty || err == private.ErrNotFound:
+			log.Trace("Skipping foreign private transaction", "hash", tx.Hash(), "err", err)
+			continue
+		case err != nil:
+			log.Warn("Failed to retrieve private payload", "hash", tx.Hash(), "err", err)
+			continue
+		}
+		// A payload runs once, whoever replays its public hash
+		payloadHash := common.BytesToHash(tx.Data())
+		if prev := rawdb.ReadPrivatePayloadTx(bc.db, payloadHash); ran[payloadHash] || (prev != (common.Hash{}) && prev != tx.Hash()) {
+			log.Warn("Skipping replayed private payload", "hash", tx.Hash(), "payload", payloadHash)
+			continue
+		}
+		ran[payloadHash] = true
+
+		if statedb == nil {
+			if statedb, err = state.New(root, bc.privateStateCache); err != nil {
+				return err
+			}
+		}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		receipt, err := applyPrivateTransaction(bc.chainConfig, bc, statedb, header, tx, sender, payload, bc.vmConfig)
+		if err != nil {
+			return err
+		}
+		rawdb.WritePrivateReceipt(batch, tx.Hash(), receipt)
+		rawdb.WritePrivatePayloadTx(batch, payloadHash, tx.Hash())
+	}
+	if statedb != nil {
+		var err error
+		if root, err = statedb.Commit(bc.chainConfig.IsEIP158(block.Number())); err != nil {
+			return err
+		}
+		// Private states are small, keep them all instead of garbage collecting.
+		// While pruning, they're held in memory like the public ones.
+		if bc.pruning {
+			bc.privateStateCache.TrieDB().Reference(root, common.Hash{})
+			bc.pendingPrivates = append(bc.pendingPrivates, root)
+		} else if err := bc.privateStateCache.TrieDB().Commit(root, false); err != nil {
+			return err
+		}
+	}
+	rawdb.WritePrivateStateRoot(batch, block.Hash(), root)
+	return nil
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/ethash"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/core/vm"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/private"
+)
+
+// Tests that private transactions only change the private state of their
+// parties, leaving their public effect to the fee and nonce.
+func TestPrivateTransactions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "private-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		key, _      = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr        = crypto.PubkeyToAddress(key.PublicKey)
+		attacker, _ = crypto.GenerateKey()
+		// this code generates a log
+		code     = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+		gspec    = newPrivateGenesis(addr, crypto.PubkeyToAddress(attacker.PublicKey))
+		signer   = types.NewEIP155Signer(gspec.Config.ChainID)
+		parties  = []string{"bob", "alice"}
+		alice    = newPrivateChain(t, gspec, dir, "alice")
+		carol    = newPrivateChain(t, gspec, dir, "carol")
+		contract = crypto.CreateAddress(addr, 0)
+	)
+	defer alice.Stop()
+	defer carol.Stop()
+
+	hash, err := alice.PrivateManager().Send(code, addr, parties)
+	if err != nil {
+		t.Fatalf("failed to send payload: %v", err)
+	}
+	db := rawdb.NewMemoryDatabase()
+	blocks, _ := GenerateChain(gspec.Config, gspec.MustCommit(db), ethash.NewFaker(), db, 3, func(i int, gen *BlockGen) {
+		// The payload is executed once, replays by its sender or anyone else
+		// knowing its hash are ignored
+		switch i {
+		case 0, 2:
+			gen.AddTx(newPrivateTx(t, gen.TxNonce(addr), hash, parties, signer, key))
+		case 1:
+			from := crypto.PubkeyToAddress(attacker.PublicKey)
+			gen.AddTx(newPrivateTx(t, gen.TxNonce(from), hash, parties, signer, attacker))
+		}
+	})
+	for _, chain := range []*BlockChain{alice, carol} {
+		if _, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("failed to insert chain: %v", err)
+		}
+		public, _ := chain.State()
+		if public.GetNonce(addr) != 2 || len(public.GetCode(contract)) != 0 {
+			t.Errorf("public state mismatch: nonce %d, code %x", public.GetNonce(addr), public.GetCode(contract))
+		}
+	}
+	tx := blocks[0].Transactions()[0]
+
+	// The parties are paid for as intrinsic gas
+	dataGas, _ := IntrinsicGas(tx.Data(), false, true, false)
+	partiesGas, _ := PrivateForGas(parties, false)
+	if receipts := alice.GetReceiptsByHash(blocks[0].Hash()); receipts[0].GasUsed != dataGas+partiesGas || partiesGas == 0 {
+		t.Errorf("public gas mismatch: have %d, want %d", receipts[0].GasUsed, dataGas+partiesGas)
+	}
+	for _, block := range blocks[1:] {
+		if receipt := alice.GetPrivateReceipt(block.Transactions()[0].Hash()); receipt != nil {
+			t.Errorf("block %d: replayed payload executed", block.NumberU64())
+		}
+	}
+
+	// The sender executed the payload, on top of which the next block builds
+	receipt := alice.GetPrivateReceipt(tx.Hash())
+	if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful || receipt.ContractAddress != contract || len(receipt.Logs) != 1 {
+		t.Fatalf("private receipt mismatch: %+v", receipt)
+	}
+	for _, block := range blocks {
+		state, err := alice.PrivateStateAt(block.Hash())
+		if err != nil {
+			t.Fatalf("failed to open private state: %v", err)
+		}
+		if len(state.GetCode(contract)) == 0 {
+			t.Errorf("block %d: private contract missing", block.NumberU64())
+		}
+	}
+	// Foreign parties don't see anything
+	if receipt := carol.GetPrivateReceipt(tx.Hash()); receipt != nil {
+		t.Errorf("foreign private receipt: %+v", receipt)
+	}
+	state, err := carol.PrivateStateAt(carol.CurrentBlock().Hash())
+	if err != nil {
+		t.Fatalf("failed to open private state: %v", err)
+	}
+	if len(state.GetCode(contract)) != 0 {
+		t.Error("foreign private contract deployed")
+	}
+}
+
+// Tests that blocks breaking the private transaction rules are rejected.
+func TestInvalidPrivateTransactions(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		gspec  = newPrivateGenesis(crypto.PubkeyToAddress(key.PublicKey))
+		signer = types.NewEIP155Signer(gspec.Config.ChainID)
+		hash   = common.Hash{0xaa}
+	)
+	db := rawdb.NewMemoryDatabase()
+	blocks, _ := GenerateChain(gspec.Config, gspec.MustCommit(db), ethash.NewFaker(), db, 1, func(i int, gen *BlockGen) {
+		gen.AddTx(newPrivateTx(t, 0, hash, []string{"bob"}, signer, key))
+	})
+	// Private transactions can't be included before their fork
+	legacy := *gspec
+	legacy.Config = params.TestChainConfig
+	legacyDB := rawdb.NewMemoryDatabase()
+	legacy.MustCommit(legacyDB)
+	chain, err := NewBlockChain(legacyDB, nil, legacy.Config, ethash.NewFaker(), vm.Config{}, nil, NewTxSenderCacher())
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err == nil {
+		t.Error("private transaction accepted before its fork")
+	}
+
+	number := big.NewInt(1)
+	tests := []struct {
+		tx  *types.Transaction
+		err error
+	}{
+		{types.NewTransaction(0, common.Address{}, common.Big0, 50000, common.Big1, hash.Bytes()).WithPrivateFor([]string{"bob"}), nil},
+		{types.NewTransaction(0, common.Address{}, common.Big1, 50000, common.Big1, hash.Bytes()).WithPrivateFor([]string{"bob"}), ErrInvalidPrivateTx},
+		{types.NewTransaction(0, common.Address{}, common.Big0, 50000, common.Big1, append(hash.Bytes(), 1)).WithPrivateFor([]string{"bob"}), ErrInvalidPrivateTx},
+		{types.NewTransaction(0, common.Address{}, common.Big0, 50000, common.Big1, hash.Bytes()).WithPrivateFor([]string{""}), ErrInvalidPrivateTx},
+		{types.NewTransaction(0, common.Address{}, common.Big1, 50000, common.Big1, nil), nil},
+	}
+	for i, test := range tests {
+		if err := ValidatePrivateTx(gspec.Config, number, test.tx); err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+	if err := ValidatePrivateTx(params.TestChainConfig, number, tests[0].tx); err != ErrPrivateTxNotActive {
+		t.Errorf("fork error mismatch: have %v, want %v", err, ErrPrivateTxNotActive)
+	}
+}
+
+func newPrivateGenesis(funded ...common.Address) *Genesis {
+	config := *params.TestChainConfig
+	config.PrivateTxBlock = big.NewInt(0)
+	alloc := make(GenesisAlloc)
+	for _, addr := range funded {
+		alloc[addr] = GenesisAccount{Balance: big.NewInt(params.Ether)}
+	}
+	return &Genesis{Config: &config, Alloc: alloc}
+}
+
+func newPrivateTx(t *testing.T, nonce uint64, hash common.Hash, parties []string, signer types.Signer, key *ecdsa.PrivateKey) *types.Transaction {
+	tx := types.NewContractCreation(nonce, new(big.Int), 1000000, new(big.Int), hash.Bytes()).WithPrivateFor(parties)
+	signed, err := types.SignTx(tx, signer, key)
+	if err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+	return signed
+}
+
+func newPrivateChain(t *testing.T, gspec *Genesis, dir string, identity string) *BlockChain {
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, NewTxSenderCacher())
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	manager, err := private.NewLocalManager(dir, identity)
+	if err != nil {
+		t.Fatalf("failed to create private manager: %v", err)
+	}
+	chain.SetPrivateManager(manager)
+	return chain
+}
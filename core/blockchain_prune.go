@@ -0,0 +1,101 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/state/pruner"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/log"
+)
+
+// pruneState deletes the state which isn't reachable from the final blocks in
+// the retention window. The chain lock is only held to flush the head state and
+// collect the retained roots; blocks keep being imported while marking and
+// sweeping, their states being held in memory until pruning ends.
+func (bc *BlockChain) pruneState() {
+	if err := bc.addJob(); err != nil {
+		return
+	}
+	defer bc.doneJob()
+
+	start := time.Now()
+	head, roots, err := bc.preparePruning()
+	if err != nil {
+		log.Error("Failed to prepare state pruning", "err", err)
+		return
+	}
+	defer bc.finishPruning()
+
+	p, err := pruner.NewPruner(bc.db, bc.cacheConfig.PruneBloomSize)
+	if err != nil {
+		log.Error("Failed to create state pruner", "err", err)
+		return
+	}
+	if err := p.Prune(roots, bc.quit); err != nil {
+		log.Error("Failed to prune state", "err", err)
+		return
+	}
+	log.Info("Pruned state", "number", head.Number(), "retained", len(roots), "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// preparePruning flushes the head state, releases the states held in memory
+// and suspends further flushes, returning the head block and the roots of the
+// states to retain.
+func (bc *BlockChain) preparePruning() (*types.Block, []common.Hash, error) {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	head := bc.CurrentBlock()
+
+	// Only states on disk are retained: persist the head one and release the
+	// others held in memory, which may rely on flushed nodes about to be swept.
+	triedb := bc.stateCache.TrieDB()
+	if err := triedb.Commit(head.Root(), true); err != nil {
+		return nil, nil, err
+	}
+	for !bc.triegc.Empty() {
+		triedb.Dereference(bc.triegc.PopItem().(common.Hash))
+	}
+	atomic.StoreUint64(&lastWrite, head.NumberU64())
+	bc.gcproc = 0
+
+	roots, err := pruner.RetainedRoots(bc.db, bc.cacheConfig.StateRetention)
+	if err != nil {
+		return nil, nil, err
+	}
+	bc.pruning = true
+	return head, roots, nil
+}
+
+// finishPruning resumes state flushes, writing the private states held back
+// meanwhile. The public ones are flushed by the following imports.
+func (bc *BlockChain) finishPruning() {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	bc.pruning = false
+	for _, root := range bc.pendingPrivates {
+		if err := bc.privateStateCache.TrieDB().Commit(root, false); err != nil {
+			log.Error("Failed to commit private state after pruning", "root", root, "err", err)
+		}
+	}
+	bc.pendingPrivates = nil
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus/ethash"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/core/vm"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/params"
+)
+
+// Tests that online state pruning is refused without BFT finality.
+func TestStatePruningNeedsFinality(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	(&Genesis{Config: params.TestChainConfig}).MustCommit(db)
+
+	cacheConfig := &CacheConfig{
+		TrieCleanLimit: 256,
+		TrieDirtyLimit: 256,
+		TrieTimeLimit:  5 * time.Minute,
+		StateRetention: TriesInMemory,
+		PruneBloomSize: 1,
+	}
+	if _, err := NewBlockChain(db, cacheConfig, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, NewTxSenderCacher()); err != ErrNoFinality {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrNoFinality)
+	}
+}
+
+// Tests that online pruning keeps the head state intact and the chain able to
+// import on top of it.
+func TestStatePruning(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}}}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 16, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr), common.Address{byte(i)}, big.NewInt(1), params.TxGas, nil, nil), signer, key)
+		gen.AddTx(tx)
+	})
+	chaindb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(chaindb)
+	chain, _ := NewBlockChain(chaindb, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, NewTxSenderCacher())
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:8]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Persist a state falling out of the retention window
+	if err := chain.stateCache.TrieDB().Commit(blocks[2].Root(), false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	// Enable pruning behind the back of the finality check
+	chain.cacheConfig.StateRetention, chain.cacheConfig.PruneBloomSize = 4, 1
+	chain.pruneState()
+
+	if ok, _ := chaindb.Has(blocks[2].Root().Bytes()); ok {
+		t.Error("pruned state still on disk")
+	}
+	if ok, _ := chaindb.Has(genesis.Root().Bytes()); !ok {
+		t.Error("genesis state pruned")
+	}
+	head, err := chain.State()
+	if err != nil {
+		t.Fatalf("head state missing: %v", err)
+	}
+	it := state.NewNodeIterator(head)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("head state incomplete: %v", it.Error)
+	}
+	if _, err := chain.InsertChain(blocks[8:]); err != nil {
+		t.Fatalf("failed to import on top of pruned state: %v", err)
+	}
+	if balance := mustState(t, chain).GetBalance(common.Address{15}); balance.Cmp(common.Big1) != 0 {
+		t.Errorf("balance mismatch: have %v, want 1", balance)
+	}
+}
+
+func mustState(t *testing.T, chain *BlockChain) *state.StateDB {
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	return statedb
+}
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -30,6 +31,7 @@ import (
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/consensus"
 	"github.com/clearmatics/autonity/consensus/ethash"
+	"github.com/clearmatics/autonity/contracts/autonity"
 	"github.com/clearmatics/autonity/core/rawdb"
 	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/core/types"
@@ -2363,3 +2365,56 @@ func TestDeleteCreateRevert(t *testing.T) {
 		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
 	}
 }
+
+// failingProcessor is a Processor refusing to execute any block.
+type failingProcessor struct{}
+
+var errProcessed = errors.New("block executed")
+
+func (failingProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	return nil, nil, 0, errProcessed
+}
+
+func (failingProcessor) SetAutonityContract(contract *autonity.Contract) {}
+
+// Tests that a block verified by the consensus engine is inserted with the
+// handed over execution result, without being executed again.
+func TestInsertVerifiedBlock(t *testing.T) {
+	_, blockchain, err := newCanonical(ethash.NewFaker(), 0, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	blocks := makeBlockChain(blockchain.CurrentBlock(), 2, ethash.NewFaker(), blockchain.db, canonicalSeed)
+
+	statedb, err := state.New(blockchain.CurrentBlock().Root(), blockchain.stateCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipts, logs, usedGas, err := blockchain.processor.Process(blocks[0], statedb, blockchain.vmConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockchain.AddVerifiedBlock(blocks[0].Hash(), &VerifiedBlock{
+		State:    statedb,
+		Receipts: receipts,
+		Logs:     logs,
+		UsedGas:  usedGas,
+	})
+	blockchain.processor = failingProcessor{}
+
+	if _, err := blockchain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert verified block: %v", err)
+	}
+	if blockchain.CurrentBlock().Hash() != blocks[0].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", blockchain.CurrentBlock().Hash(), blocks[0].Hash())
+	}
+	if blockchain.verifiedBlocks.Len() != 0 {
+		t.Fatal("verified block wasn't removed once used")
+	}
+	// a block without execution result is executed
+	if _, err := blockchain.InsertChain(blocks[1:]); err != errProcessed {
+		t.Fatalf("error mismatch: have %v, want %v", err, errProcessed)
+	}
+}
@@ -35,4 +35,20 @@ var (
 
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
+
+	// ErrMinGasPrice is returned if a transaction of a block pays less than the
+	// minimum gas price set in the Autonity contract.
+	ErrMinGasPrice = errors.New("gas price must be greater minGasPrice")
+
+	// ErrNoFinality is returned when the finalized block is requested from a
+	// chain whose consensus engine doesn't provide finality.
+	ErrNoFinality = errors.New("finalized blocks are only available under BFT consensus")
+
+	// ErrInvalidPrivateTx is returned if a private transaction carries value or
+	// anything else than the hash of its private payload.
+	ErrInvalidPrivateTx = errors.New("private transaction must carry a payload hash and no value")
+
+	// ErrPrivateTxNotActive is returned if a private transaction is included
+	// before the private transactions fork.
+	ErrPrivateTxNotActive = errors.New("private transactions are not enabled")
 )
@@ -17,6 +17,8 @@
 package core
 
 import (
+	"math/big"
+
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/p2p/enode"
@@ -45,3 +47,32 @@ type ChainHeadEvent struct{ Block *types.Block }
 
 // WhitelistEvent is posted when the list of authorized enodes is updated.
 type WhitelistEvent struct{ Whitelist []*enode.Node }
+
+// CommitteeEvent is posted when the committee elected by a canonical block
+// differs from the one of its parent.
+type CommitteeEvent struct {
+	Block     *types.Block
+	Committee types.Committee // Committee elected after the block
+}
+
+// MinGasPriceEvent is posted when a canonical block changes the minimum gas
+// price set in the Autonity contract.
+type MinGasPriceEvent struct {
+	Block *types.Block
+	Price *big.Int
+}
+
+// StakeEvent is posted when a canonical block changes the stake of a user of
+// the Autonity contract.
+type StakeEvent struct {
+	Block   *types.Block
+	Account common.Address
+	Stake   *big.Int // Stake after the block, nil if the user was removed
+}
+
+// ContractUpgradeEvent is posted when a canonical block upgrades the Autonity
+// contract.
+type ContractUpgradeEvent struct {
+	Block   *types.Block
+	Version string // Version of the upgraded contract
+}
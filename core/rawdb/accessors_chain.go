@@ -225,6 +225,24 @@ func WriteFastTrieProgress(db ethdb.KeyValueWriter, count uint64) {
 	}
 }
 
+// ReadBFTCheckpointHash retrieves the hash of the BFT checkpoint the chain was
+// seeded with, if any. No header exists locally below it.
+func ReadBFTCheckpointHash(db ethdb.KeyValueReader) common.Hash {
+	data, _ := db.Get(bftCheckpointKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteBFTCheckpointHash stores the hash of the BFT checkpoint the chain was
+// seeded with.
+func WriteBFTCheckpointHash(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Put(bftCheckpointKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store BFT checkpoint hash", "err", err)
+	}
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
 	// First try to look up the data in ancient database. Extra hash
@@ -627,6 +645,7 @@ func WriteAncientBlock(db ethdb.AncientWriter, block *types.Block, receipts type
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
+	DeleteSystemCalls(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
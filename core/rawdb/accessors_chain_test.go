@@ -342,6 +342,73 @@ func TestBlockReceiptStorage(t *testing.T) {
 	}
 }
 
+func TestBlockSystemCallStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	tx := types.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil)
+	body := &types.Body{Transactions: types.Transactions{tx}}
+
+	call := &types.SystemCall{
+		Type:    types.SystemCallCall,
+		Method:  "finalize",
+		From:    common.HexToAddress("0x1336"),
+		To:      common.HexToAddress("0xc3d8"),
+		Input:   []byte{0x01, 0x02},
+		Output:  []byte{0x03},
+		GasUsed: 4,
+		Logs: []*types.Log{
+			{Address: common.HexToAddress("0xc3d8"), Index: 2},
+			{Address: common.HexToAddress("0xc3d8"), Index: 3},
+		},
+		StateDiff: []*types.AccountDiff{{
+			Address:       common.HexToAddress("0xc3d8"),
+			BalanceBefore: big.NewInt(5),
+			BalanceAfter:  big.NewInt(0),
+			NonceBefore:   1,
+			NonceAfter:    1,
+			Storage:       []*types.StorageDiff{{Key: common.Hash{1}, Before: common.Hash{2}, After: common.Hash{3}}},
+		}},
+	}
+	hash := common.BytesToHash([]byte{0x03, 0x14})
+	if calls := ReadSystemCalls(db, hash, 1); len(calls) != 0 {
+		t.Fatalf("non existent system calls returned: %v", calls)
+	}
+	WriteBody(db, hash, 1, body)
+	WriteSystemCalls(db, hash, 1, []*types.SystemCall{call})
+
+	calls := ReadSystemCalls(db, hash, 1)
+	if len(calls) != 1 {
+		t.Fatalf("system calls mismatch: have %d, want 1", len(calls))
+	}
+	have := calls[0]
+	if have.Method != call.Method || have.From != call.From || have.To != call.To || !bytes.Equal(have.Input, call.Input) || !bytes.Equal(have.Output, call.Output) || have.GasUsed != call.GasUsed {
+		t.Fatalf("system call mismatch: have %v, want %v", spew.Sdump(have), spew.Sdump(call))
+	}
+	if have.Hash != types.SystemCallHash(1, 0) || have.BlockHash != hash || have.BlockNumber != 1 {
+		t.Fatalf("derived fields mismatch: have %x, block %x #%d", have.Hash, have.BlockHash, have.BlockNumber)
+	}
+	for i, log := range have.Logs {
+		if log.TxHash != have.Hash || log.BlockHash != hash || log.TxIndex != 1 || log.Index != uint(2+i) {
+			t.Fatalf("log %d derived fields mismatch: have %v", i, spew.Sdump(log))
+		}
+	}
+	diff := have.StateDiff[0]
+	if diff.BalanceBefore.Int64() != 5 || diff.BalanceAfter.Int64() != 0 || *diff.Storage[0] != *call.StateDiff[0].Storage[0] {
+		t.Fatalf("state diff mismatch: have %v", spew.Sdump(diff))
+	}
+	bloom, ok := ReadSystemCallsBloom(db, hash, 1)
+	if !ok || !types.BloomLookup(bloom, call.To) || types.BloomLookup(bloom, call.From) {
+		t.Fatalf("system calls bloom mismatch: have %x", bloom)
+	}
+	DeleteBlock(db, hash, 1)
+	if calls := ReadSystemCalls(db, hash, 1); len(calls) != 0 {
+		t.Fatalf("deleted system calls returned: %v", calls)
+	}
+	if _, ok := ReadSystemCallsBloom(db, hash, 1); ok || HasSystemCalls(db, hash, 1) {
+		t.Fatalf("deleted system calls bloom returned")
+	}
+}
+
 func checkReceiptsRLP(have, want types.Receipts) error {
 	if len(have) != len(want) {
 		return fmt.Errorf("receipts sizes mismatch: have %d, want %d", len(have), len(want))
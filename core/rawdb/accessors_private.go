@@ -0,0 +1,107 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/rlp"
+)
+
+// ReadPrivateStateRoot retrieves the root of the private state after a block,
+// the empty hash if the node never computed it.
+func ReadPrivateStateRoot(db ethdb.KeyValueReader, hash common.Hash) common.Hash {
+	data, _ := db.Get(privateRootKey(hash))
+	return common.BytesToHash(data)
+}
+
+// WritePrivateStateRoot stores the root of the private state after a block.
+func WritePrivateStateRoot(db ethdb.KeyValueWriter, hash common.Hash, root common.Hash) {
+	if err := db.Put(privateRootKey(hash), root.Bytes()); err != nil {
+		log.Crit("Failed to store private state root", "err", err)
+	}
+}
+
+// storedPrivateReceipt is the storage encoding of a private receipt, the fields
+// derived from the transaction and its block being left out.
+type storedPrivateReceipt struct {
+	Status          uint64
+	GasUsed         uint64
+	ContractAddress common.Address
+	Logs            []*types.LogForStorage
+}
+
+// ReadPrivateReceipt retrieves the private receipt of a transaction, without
+// its block and transaction derived fields, or nil if not a party of it.
+func ReadPrivateReceipt(db ethdb.KeyValueReader, hash common.Hash) *types.Receipt {
+	data, _ := db.Get(privateReceiptKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var stored storedPrivateReceipt
+	if err := rlp.DecodeBytes(data, &stored); err != nil {
+		log.Error("Invalid private receipt RLP", "hash", hash, "err", err)
+		return nil
+	}
+	receipt := &types.Receipt{
+		Status:          stored.Status,
+		GasUsed:         stored.GasUsed,
+		ContractAddress: stored.ContractAddress,
+		Logs:            make([]*types.Log, len(stored.Logs)),
+	}
+	for i, l := range stored.Logs {
+		receipt.Logs[i] = (*types.Log)(l)
+	}
+	return receipt
+}
+
+// WritePrivateReceipt stores the private receipt of a transaction.
+func WritePrivateReceipt(db ethdb.KeyValueWriter, hash common.Hash, receipt *types.Receipt) {
+	stored := storedPrivateReceipt{
+		Status:          receipt.Status,
+		GasUsed:         receipt.GasUsed,
+		ContractAddress: receipt.ContractAddress,
+		Logs:            make([]*types.LogForStorage, len(receipt.Logs)),
+	}
+	for i, l := range receipt.Logs {
+		stored.Logs[i] = (*types.LogForStorage)(l)
+	}
+	data, err := rlp.EncodeToBytes(stored)
+	if err != nil {
+		log.Crit("Failed to encode private receipt", "err", err)
+	}
+	if err := db.Put(privateReceiptKey(hash), data); err != nil {
+		log.Crit("Failed to store private receipt", "err", err)
+	}
+}
+
+// ReadPrivatePayloadTx retrieves the hash of the transaction which executed a
+// private payload, the empty hash if it never ran.
+func ReadPrivatePayloadTx(db ethdb.KeyValueReader, hash common.Hash) common.Hash {
+	data, _ := db.Get(privatePayloadKey(hash))
+	return common.BytesToHash(data)
+}
+
+// WritePrivatePayloadTx stores the hash of the transaction which executed a
+// private payload.
+func WritePrivatePayloadTx(db ethdb.KeyValueWriter, hash common.Hash, txHash common.Hash) {
+	if err := db.Put(privatePayloadKey(hash), txHash.Bytes()); err != nil {
+		log.Crit("Failed to store private payload transaction", "err", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/rlp"
+)
+
+// storedSystemCall is the storage encoding of a system call, the fields derived
+// from its block being left out.
+type storedSystemCall struct {
+	Type      string
+	Method    string
+	From      common.Address
+	To        common.Address
+	Input     []byte
+	Output    []byte
+	GasUsed   uint64
+	Error     string
+	LogIndex  uint64 // Index of the first log of the call in the block
+	Logs      []*types.LogForStorage
+	StateDiff []*types.AccountDiff
+}
+
+// ReadSystemCalls retrieves the system calls made while processing a block,
+// including their derived fields. The logs of a call are attributed to its
+// pseudo transaction hash, and positioned after the transactions of the block.
+//
+// The current implementation populates the log positions by reading the block
+// body, so if it is not found nil is returned even if the calls are stored.
+func ReadSystemCalls(db ethdb.Reader, hash common.Hash, number uint64) []*types.SystemCall {
+	data, _ := db.Get(systemCallsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var stored []*storedSystemCall
+	if err := rlp.DecodeBytes(data, &stored); err != nil {
+		log.Error("Invalid system calls RLP", "hash", hash, "err", err)
+		return nil
+	}
+	body := ReadBody(db, hash, number)
+	if body == nil {
+		log.Error("Missing body but have system calls", "hash", hash, "number", number)
+		return nil
+	}
+	calls := make([]*types.SystemCall, len(stored))
+	for i, s := range stored {
+		calls[i] = &types.SystemCall{
+			Type:        s.Type,
+			Method:      s.Method,
+			From:        s.From,
+			To:          s.To,
+			Input:       s.Input,
+			Output:      s.Output,
+			GasUsed:     s.GasUsed,
+			Error:       s.Error,
+			Logs:        make([]*types.Log, len(s.Logs)),
+			StateDiff:   s.StateDiff,
+			Hash:        types.SystemCallHash(number, i),
+			BlockHash:   hash,
+			BlockNumber: number,
+			Index:       uint(i),
+		}
+		for j, l := range s.Logs {
+			calls[i].Logs[j] = (*types.Log)(l)
+			calls[i].Logs[j].BlockNumber = number
+			calls[i].Logs[j].BlockHash = hash
+			calls[i].Logs[j].TxHash = calls[i].Hash
+			calls[i].Logs[j].TxIndex = uint(len(body.Transactions) + i)
+			calls[i].Logs[j].Index = uint(s.LogIndex) + uint(j)
+		}
+	}
+	return calls
+}
+
+// HasSystemCalls verifies the existence of the system calls of a block.
+func HasSystemCalls(db ethdb.Reader, hash common.Hash, number uint64) bool {
+	ok, _ := db.Has(systemCallsKey(number, hash))
+	return ok
+}
+
+// ReadSystemCallsBloom retrieves the bloom of the logs of the system calls made
+// while processing a block. The header bloom doesn't cover them, this one being
+// checked instead before reading the calls. False is returned if the bloom isn't
+// stored, which is the case of blocks without system calls and of those whose
+// calls were stored before blooms.
+func ReadSystemCallsBloom(db ethdb.Reader, hash common.Hash, number uint64) (types.Bloom, bool) {
+	data, _ := db.Get(systemBloomKey(number, hash))
+	if len(data) != types.BloomByteLength {
+		return types.Bloom{}, false
+	}
+	return types.BytesToBloom(data), true
+}
+
+// WriteSystemCalls stores the system calls made while processing a block, along
+// with the bloom of their logs.
+func WriteSystemCalls(db ethdb.KeyValueWriter, hash common.Hash, number uint64, calls []*types.SystemCall) {
+	var logs []*types.Log
+	stored := make([]*storedSystemCall, len(calls))
+	for i, call := range calls {
+		logs = append(logs, call.Logs...)
+		stored[i] = &storedSystemCall{
+			Type:      call.Type,
+			Method:    call.Method,
+			From:      call.From,
+			To:        call.To,
+			Input:     call.Input,
+			Output:    call.Output,
+			GasUsed:   call.GasUsed,
+			Error:     call.Error,
+			Logs:      make([]*types.LogForStorage, len(call.Logs)),
+			StateDiff: call.StateDiff,
+		}
+		if len(call.Logs) > 0 {
+			stored[i].LogIndex = uint64(call.Logs[0].Index)
+		}
+		for j, l := range call.Logs {
+			stored[i].Logs[j] = (*types.LogForStorage)(l)
+		}
+	}
+	data, err := rlp.EncodeToBytes(stored)
+	if err != nil {
+		log.Crit("Failed to encode block system calls", "err", err)
+	}
+	if err := db.Put(systemCallsKey(number, hash), data); err != nil {
+		log.Crit("Failed to store block system calls", "err", err)
+	}
+	bloom := types.BytesToBloom(types.LogsBloom(logs).Bytes())
+	if err := db.Put(systemBloomKey(number, hash), bloom.Bytes()); err != nil {
+		log.Crit("Failed to store block system calls bloom", "err", err)
+	}
+}
+
+// DeleteSystemCalls removes the system calls associated with a block hash, along
+// with the bloom of their logs.
+func DeleteSystemCalls(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(systemCallsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block system calls", "err", err)
+	}
+	if err := db.Delete(systemBloomKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block system calls bloom", "err", err)
+	}
+}
@@ -25,7 +25,6 @@ import (
 
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/ethdb"
-	"github.com/clearmatics/autonity/ethdb/leveldb"
 	"github.com/clearmatics/autonity/ethdb/memorydb"
 	"github.com/clearmatics/autonity/log"
 	"github.com/olekukonko/tablewriter"
@@ -196,26 +195,13 @@ func NewMemoryDatabaseWithCap(size int) ethdb.Database {
 // NewLevelDBDatabase creates a persistent key-value database without a freezer
 // moving immutable chain segments into cold storage.
 func NewLevelDBDatabase(file string, cache int, handles int, namespace string) (ethdb.Database, error) {
-	db, err := leveldb.New(file, cache, handles, namespace)
-	if err != nil {
-		return nil, err
-	}
-	return NewDatabase(db), nil
+	return NewDiskDatabase(LevelDBEngine, file, cache, handles, namespace)
 }
 
 // NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
 // freezer moving immutable chain segments into cold storage.
 func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string) (ethdb.Database, error) {
-	kvdb, err := leveldb.New(file, cache, handles, namespace)
-	if err != nil {
-		return nil, err
-	}
-	frdb, err := NewDatabaseWithFreezer(kvdb, freezer, namespace)
-	if err != nil {
-		kvdb.Close()
-		return nil, err
-	}
-	return frdb, nil
+	return NewDiskDatabaseWithFreezer(LevelDBEngine, file, cache, handles, freezer, namespace)
 }
 
 // InspectDatabase traverses the entire database and checks the size
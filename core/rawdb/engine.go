@@ -0,0 +1,173 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/ethdb/leveldb"
+	"github.com/clearmatics/autonity/ethdb/pebble"
+	"github.com/clearmatics/autonity/log"
+)
+
+// Engines of the persistent key-value stores.
+const (
+	LevelDBEngine = "leveldb"
+	PebbleEngine  = "pebble"
+)
+
+// engineFile is the file recording the engine a persistent database was created
+// with, to refuse opening it with another one.
+const engineFile = "ENGINE"
+
+// DatabaseEngine returns the engine of the persistent database at the given path,
+// or an empty string if there is no database yet. Databases created before the
+// engine was recorded are told apart by their files.
+func DatabaseEngine(file string) (string, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(file, engineFile))
+	if err == nil {
+		return validateEngine(strings.TrimSpace(string(blob)))
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	if !common.FileExist(filepath.Join(file, "CURRENT")) {
+		return "", nil
+	}
+	if options, _ := filepath.Glob(filepath.Join(file, "OPTIONS-*")); len(options) > 0 {
+		return PebbleEngine, nil
+	}
+	return LevelDBEngine, nil
+}
+
+// validateEngine checks that the engine is a known one.
+func validateEngine(engine string) (string, error) {
+	switch engine {
+	case LevelDBEngine, PebbleEngine:
+		return engine, nil
+	default:
+		return "", fmt.Errorf("unknown database engine %q", engine)
+	}
+}
+
+// NewKeyValueStore opens a persistent key-value store with the given engine,
+// or creates one if no previous can be found. An empty engine opens an existing
+// store with the engine it was created with, and creates LevelDB ones.
+func NewKeyValueStore(engine string, file string, cache int, handles int, namespace string) (ethdb.KeyValueStore, error) {
+	existing, err := DatabaseEngine(file)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case engine == "" && existing == "":
+		engine = LevelDBEngine
+	case engine == "":
+		engine = existing
+	case existing != "" && existing != engine:
+		return nil, fmt.Errorf("database %s was created with %s, not %s, use migratedb to convert it", file, existing, engine)
+	}
+	if _, err := validateEngine(engine); err != nil {
+		return nil, err
+	}
+	var db ethdb.KeyValueStore
+	if engine == PebbleEngine {
+		db, err = pebble.New(file, cache, handles, namespace)
+	} else {
+		db, err = leveldb.New(file, cache, handles, namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(file, engineFile), []byte(engine), 0644); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// NewDiskDatabase creates a persistent key-value database with the given engine
+// without a freezer moving immutable chain segments into cold storage.
+func NewDiskDatabase(engine string, file string, cache int, handles int, namespace string) (ethdb.Database, error) {
+	db, err := NewKeyValueStore(engine, file, cache, handles, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return NewDatabase(db), nil
+}
+
+// NewDiskDatabaseWithFreezer creates a persistent key-value database with the
+// given engine, with a freezer moving immutable chain segments into cold storage.
+func NewDiskDatabaseWithFreezer(engine string, file string, cache int, handles int, freezer string, namespace string) (ethdb.Database, error) {
+	kvdb, err := NewKeyValueStore(engine, file, cache, handles, namespace)
+	if err != nil {
+		return nil, err
+	}
+	frdb, err := NewDatabaseWithFreezer(kvdb, freezer, namespace)
+	if err != nil {
+		kvdb.Close()
+		return nil, err
+	}
+	return frdb, nil
+}
+
+// MigrateKeyValueStore copies all the entries of a key-value store into another
+// one, and returns the number of entries copied.
+func MigrateKeyValueStore(src ethdb.KeyValueStore, dst ethdb.KeyValueStore) (int, error) {
+	it := src.NewIterator()
+	defer it.Release()
+
+	var (
+		batch  = dst.NewBatch()
+		count  int
+		size   common.StorageSize
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return count, err
+		}
+		count++
+		size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return count, err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Copying database entries", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return count, err
+	}
+	if err := batch.Write(); err != nil {
+		return count, err
+	}
+	log.Info("Copied database entries", "entries", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return count, nil
+}
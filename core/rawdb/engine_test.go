@@ -0,0 +1,96 @@
+package rawdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clearmatics/autonity/ethdb/leveldb"
+)
+
+func TestDatabaseEngine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "engine-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Databases predating the engine record are LevelDB ones
+	legacy := filepath.Join(dir, "legacy")
+	db, err := leveldb.New(legacy, 0, 0, "")
+	if err != nil {
+		t.Fatalf("failed to create legacy database: %v", err)
+	}
+	db.Close()
+	if engine, err := DatabaseEngine(legacy); err != nil || engine != LevelDBEngine {
+		t.Fatalf("legacy engine mismatch: have %q, want %q: %v", engine, LevelDBEngine, err)
+	}
+	// New databases record their engine and refuse to be opened with another one
+	path := filepath.Join(dir, "pebble")
+	if engine, err := DatabaseEngine(path); err != nil || engine != "" {
+		t.Fatalf("missing database engine mismatch: have %q, want none: %v", engine, err)
+	}
+	kvdb, err := NewKeyValueStore(PebbleEngine, path, 0, 0, "")
+	if err != nil {
+		t.Fatalf("failed to create pebble database: %v", err)
+	}
+	if err := kvdb.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	kvdb.Close()
+
+	if engine, err := DatabaseEngine(path); err != nil || engine != PebbleEngine {
+		t.Fatalf("engine mismatch: have %q, want %q: %v", engine, PebbleEngine, err)
+	}
+	if _, err := NewLevelDBDatabase(path, 0, 0, ""); err == nil {
+		t.Fatal("pebble database opened with leveldb")
+	}
+	kvdb, err = NewKeyValueStore("", path, 0, 0, "")
+	if err != nil {
+		t.Fatalf("failed to reopen pebble database: %v", err)
+	}
+	defer kvdb.Close()
+	if value, err := kvdb.Get([]byte("key")); err != nil || string(value) != "value" {
+		t.Fatalf("value mismatch: have %q, want %q: %v", value, "value", err)
+	}
+}
+
+func TestMigrateKeyValueStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := NewKeyValueStore(LevelDBEngine, filepath.Join(dir, "src"), 0, 0, "")
+	if err != nil {
+		t.Fatalf("failed to create source database: %v", err)
+	}
+	defer src.Close()
+	dst, err := NewKeyValueStore(PebbleEngine, filepath.Join(dir, "dst"), 0, 0, "")
+	if err != nil {
+		t.Fatalf("failed to create destination database: %v", err)
+	}
+	defer dst.Close()
+
+	for i := 0; i < 1000; i++ {
+		src.Put([]byte(fmt.Sprintf("key-%04d", i)), make([]byte, 1024))
+	}
+	if count, err := MigrateKeyValueStore(src, dst); err != nil || count != 1000 {
+		t.Fatalf("migrated entries mismatch: have %d, want %d: %v", count, 1000, err)
+	}
+	it := dst.NewIterator()
+	defer it.Release()
+
+	var count int
+	for ; it.Next(); count++ {
+		if want := fmt.Sprintf("key-%04d", count); string(it.Key()) != want || len(it.Value()) != 1024 {
+			t.Fatalf("entry %d mismatch: have %q (%d bytes), want %q", count, it.Key(), len(it.Value()), want)
+		}
+	}
+	if count != 1000 {
+		t.Fatalf("destination entries mismatch: have %d, want %d", count, 1000)
+	}
+}
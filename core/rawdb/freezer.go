@@ -271,6 +271,14 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 				}
 				continue
 			}
+			// Chains seeded from a BFT checkpoint lack the history to freeze
+			if ReadBFTCheckpointHash(nfdb) != (common.Hash{}) {
+				log.Debug("Chain seeded from BFT checkpoint, not freezing")
+				if err := f.sleep(); err != nil {
+					return
+				}
+				continue
+			}
 			number := ReadHeaderNumber(nfdb, hash)
 			switch {
 			case number == nil:
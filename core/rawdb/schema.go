@@ -41,9 +41,18 @@ var (
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
+	// bftCheckpointKey tracks the hash of the BFT checkpoint the chain was seeded with.
+	bftCheckpointKey = []byte("BFTCheckpoint")
+
 	// enodeWhiteList contains the latest block saved enodes whitelist
 	enodeWhiteList = []byte("EnodesWhitelist")
 
+	// snapshotRootKey tracks the state root the flat snapshot on disk belongs to.
+	snapshotRootKey = []byte("SnapshotRoot")
+
+	// snapshotGeneratorKey tracks the progress of a running snapshot generation.
+	snapshotGeneratorKey = []byte("SnapshotGenerator")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -56,9 +65,19 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
+	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
+	privateRootPrefix    = []byte("private-root-")    // privateRootPrefix + block hash -> private state root
+	privateReceiptPrefix = []byte("private-receipt-") // privateReceiptPrefix + tx hash -> private receipt
+	privatePayloadPrefix = []byte("private-payload-") // privatePayloadPrefix + payload hash -> hash of the tx executing it
+
+	systemCallsPrefix = []byte("system-calls-") // systemCallsPrefix + num (uint64 big endian) + hash -> block system calls
+	systemBloomPrefix = []byte("system-bloom-") // systemBloomPrefix + num (uint64 big endian) + hash -> bloom of the system call logs
+
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 
@@ -158,6 +177,21 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// accountSnapshotKey = SnapshotAccountPrefix + hash
+func accountSnapshotKey(hash common.Hash) []byte {
+	return append(SnapshotAccountPrefix, hash.Bytes()...)
+}
+
+// storageSnapshotKey = SnapshotStoragePrefix + account hash + storage hash
+func storageSnapshotKey(accountHash, storageHash common.Hash) []byte {
+	return append(append(SnapshotStoragePrefix, accountHash.Bytes()...), storageHash.Bytes()...)
+}
+
+// storageSnapshotsKey = SnapshotStoragePrefix + account hash
+func storageSnapshotsKey(accountHash common.Hash) []byte {
+	return append(SnapshotStoragePrefix, accountHash.Bytes()...)
+}
+
 // preimageKey = preimagePrefix + hash
 func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
@@ -167,3 +201,28 @@ func preimageKey(hash common.Hash) []byte {
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
 }
+
+// privateRootKey = privateRootPrefix + hash
+func privateRootKey(hash common.Hash) []byte {
+	return append(privateRootPrefix, hash.Bytes()...)
+}
+
+// privateReceiptKey = privateReceiptPrefix + hash
+func privateReceiptKey(hash common.Hash) []byte {
+	return append(privateReceiptPrefix, hash.Bytes()...)
+}
+
+// privatePayloadKey = privatePayloadPrefix + hash
+func privatePayloadKey(hash common.Hash) []byte {
+	return append(privatePayloadPrefix, hash.Bytes()...)
+}
+
+// systemCallsKey = systemCallsPrefix + num (uint64 big endian) + hash
+func systemCallsKey(number uint64, hash common.Hash) []byte {
+	return append(append(systemCallsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// systemBloomKey = systemBloomPrefix + num (uint64 big endian) + hash
+func systemBloomKey(number uint64, hash common.Hash) []byte {
+	return append(append(systemBloomPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
@@ -20,6 +20,7 @@ import (
 	"math/big"
 
 	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
 )
 
 // journalEntry is a modification entry in the state change journal that can be
@@ -90,7 +91,8 @@ type (
 		account *common.Address
 	}
 	resetObjectChange struct {
-		prev *stateObject
+		prev         *stateObject
+		prevdestruct bool
 	}
 	suicideChange struct {
 		account     *common.Address
@@ -123,6 +125,9 @@ type (
 	addLogChange struct {
 		txhash common.Hash
 	}
+	addSystemCallChange struct {
+		call *types.SystemCall
+	}
 	addPreimageChange struct {
 		hash common.Hash
 	}
@@ -142,6 +147,9 @@ func (ch createObjectChange) dirtied() *common.Address {
 
 func (ch resetObjectChange) revert(s *StateDB) {
 	s.setStateObject(ch.prev)
+	if !ch.prevdestruct && s.snap != nil {
+		delete(s.snapDestructs, ch.prev.addrHash)
+	}
 }
 
 func (ch resetObjectChange) dirtied() *common.Address {
@@ -223,6 +231,14 @@ func (ch addLogChange) dirtied() *common.Address {
 	return nil
 }
 
+func (ch addSystemCallChange) revert(s *StateDB) {
+	s.systemCalls = s.systemCalls[:len(s.systemCalls)-1]
+}
+
+func (ch addSystemCallChange) dirtied() *common.Address {
+	return nil
+}
+
 func (ch addPreimageChange) revert(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
@@ -0,0 +1,215 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pruner implements the deletion of state which can't be needed anymore
+// under BFT finality, i.e. the trie nodes and codes only reachable from states
+// older than a retention window behind the last committed block.
+package pruner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/steakknife/bloomfilter"
+)
+
+// ErrAborted is returned if pruning was aborted, which leaves the state intact.
+var ErrAborted = errors.New("state pruning aborted")
+
+// stateBloomHasher is a wrapper around a byte blob to satisfy the interface API
+// requirements of the bloom library used. It's used to convert a trie hash or a
+// code hash into a 64 bit mini hash.
+type stateBloomHasher []byte
+
+func (f stateBloomHasher) Write(p []byte) (n int, err error) { panic("not implemented") }
+func (f stateBloomHasher) Sum(b []byte) []byte               { panic("not implemented") }
+func (f stateBloomHasher) Reset()                            { panic("not implemented") }
+func (f stateBloomHasher) BlockSize() int                    { panic("not implemented") }
+func (f stateBloomHasher) Size() int                         { return 8 }
+func (f stateBloomHasher) Sum64() uint64                     { return binary.BigEndian.Uint64(f) }
+
+// Pruner marks the trie nodes and codes reachable from a set of retained state
+// roots in a bloom filter, then deletes every other one from the database. False
+// positives of the bloom merely leave some garbage behind.
+//
+// The database must not be written to while pruning, as a node re-created by
+// a new state may be deleted if it was unreachable when marking.
+type Pruner struct {
+	db    ethdb.Database
+	state state.Database
+	bloom *bloomfilter.Filter
+}
+
+// NewPruner creates a pruner over the given database, using a bloom filter of
+// the given size in megabytes. The bloom is hard coded to use 4 filters.
+func NewPruner(db ethdb.Database, bloomSize uint64) (*Pruner, error) {
+	if bloomSize == 0 {
+		return nil, errors.New("empty pruning bloom filter")
+	}
+	bloom, err := bloomfilter.New(bloomSize*1024*1024*8, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bloom: %v", err)
+	}
+	return &Pruner{db: db, state: state.NewDatabase(db), bloom: bloom}, nil
+}
+
+// Prune deletes the state which isn't reachable from the given roots. Closing
+// abort interrupts it, the state not swept yet being left intact.
+func (p *Pruner) Prune(roots []common.Hash, abort <-chan struct{}) error {
+	start := time.Now()
+	for _, root := range roots {
+		if err := p.mark(root, abort); err != nil {
+			return err
+		}
+	}
+	log.Info("Marked reachable state", "roots", len(roots), "nodes", p.bloom.N(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return p.sweep(abort)
+}
+
+// mark adds the trie nodes and codes of a state to the bloom.
+func (p *Pruner) mark(root common.Hash, abort <-chan struct{}) error {
+	statedb, err := state.New(root, p.state)
+	if err != nil {
+		return err
+	}
+	var (
+		it     = state.NewNodeIterator(statedb)
+		logged = time.Now()
+	)
+	for it.Next() {
+		if it.Hash != (common.Hash{}) {
+			p.bloom.Add(stateBloomHasher(it.Hash.Bytes()))
+		}
+		if time.Since(logged) > 8*time.Second {
+			select {
+			case <-abort:
+				return ErrAborted
+			default:
+			}
+			log.Info("Marking reachable state", "root", root, "nodes", p.bloom.N())
+			logged = time.Now()
+		}
+	}
+	return it.Error
+}
+
+// sweep deletes the trie nodes and codes missing from the bloom. Both are keyed
+// by the hash of their value, which tells them apart from other 32 byte keys.
+func (p *Pruner) sweep(abort <-chan struct{}) error {
+	var (
+		it      = p.db.NewIterator()
+		batch   = p.db.NewBatch()
+		start   = time.Now()
+		logged  = time.Now()
+		count   int
+		size    common.StorageSize
+		aborted bool
+	)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength || p.bloom.Contains(stateBloomHasher(key)) {
+			continue
+		}
+		if crypto.Keccak256Hash(it.Value()) != common.BytesToHash(key) {
+			continue
+		}
+		count++
+		size += common.StorageSize(len(key) + len(it.Value()))
+		batch.Delete(key)
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+
+			select {
+			case <-abort:
+				aborted = true
+			default:
+			}
+			if aborted {
+				break
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning unreachable state", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Pruned unreachable state", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	if aborted {
+		return ErrAborted
+	}
+	return it.Error()
+}
+
+// RetainedRoots returns the public and private state roots on disk for the
+// canonical blocks within retention of the head block, along with the genesis
+// state. With BFT finality, these are the only states ever needed again.
+func RetainedRoots(db ethdb.Reader, retention uint64) ([]common.Hash, error) {
+	head := rawdb.ReadHeadBlockHash(db)
+	number := rawdb.ReadHeaderNumber(db, head)
+	if number == nil {
+		return nil, errors.New("head block missing")
+	}
+	var (
+		roots []common.Hash
+		seen  = make(map[common.Hash]bool)
+	)
+	retain := func(root common.Hash) {
+		if root == (common.Hash{}) || seen[root] {
+			return
+		}
+		if ok, _ := db.Has(root.Bytes()); ok {
+			roots = append(roots, root)
+			seen[root] = true
+		}
+	}
+	first := uint64(0)
+	if *number > retention {
+		first = *number - retention
+		if genesis := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, 0), 0); genesis != nil {
+			retain(genesis.Root)
+		}
+	}
+	for n := first; n <= *number; n++ {
+		hash := rawdb.ReadCanonicalHash(db, n)
+		header := rawdb.ReadHeader(db, hash, n)
+		if header == nil {
+			return nil, fmt.Errorf("canonical header #%d missing", n)
+		}
+		retain(header.Root)
+		retain(rawdb.ReadPrivateStateRoot(db, hash))
+	}
+	if len(roots) == 0 {
+		return nil, errors.New("no retained state on disk")
+	}
+	return roots, nil
+}
@@ -0,0 +1,104 @@
+package pruner
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/ethdb"
+)
+
+// makeState commits a state on top of root changing the given accounts.
+func makeState(t *testing.T, db ethdb.Database, root common.Hash, seed byte, accounts int) common.Hash {
+	sdb := state.NewDatabase(db)
+	statedb, err := state.New(root, sdb)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	for i := 0; i < accounts; i++ {
+		addr := common.BytesToAddress([]byte{byte(i)})
+		statedb.SetBalance(addr, big.NewInt(int64(seed)+int64(i)))
+		statedb.SetState(addr, common.Hash{seed}, common.Hash{byte(i)})
+		statedb.SetCode(addr, []byte{0x60, byte(i)})
+	}
+	root, err = statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return root
+}
+
+func TestPrune(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	stale := makeState(t, db, common.Hash{}, 1, 32)
+	retained := makeState(t, db, stale, 2, 16)
+
+	// A hash keyed entry which isn't state must survive
+	foreign := common.Hash{0xff}
+	db.Put(foreign.Bytes(), []byte("not a trie node"))
+
+	p, err := NewPruner(db, 1)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	if err := p.Prune([]common.Hash{retained}, nil); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if ok, _ := db.Has(stale.Bytes()); ok {
+		t.Error("stale state root survived")
+	}
+	if ok, _ := db.Has(foreign.Bytes()); !ok {
+		t.Error("foreign entry deleted")
+	}
+	// The retained state must be complete
+	statedb, err := state.New(retained, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open retained state: %v", err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("retained state incomplete: %v", it.Error)
+	}
+	if code := statedb.GetCode(common.BytesToAddress([]byte{31})); len(code) == 0 {
+		t.Error("retained code missing")
+	}
+}
+
+func TestRetainedRoots(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+
+	roots := make([]common.Hash, 6)
+	for i := range roots {
+		roots[i] = makeState(t, db, common.Hash{}, byte(i), 1)
+	}
+	db.Delete(roots[4].Bytes()) // never flushed to disk
+	private := makeState(t, db, common.Hash{}, 0xaa, 1)
+
+	var hash common.Hash
+	for i, root := range roots {
+		header := &types.Header{Number: big.NewInt(int64(i)), Root: root, Difficulty: common.Big1}
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+		hash = header.Hash()
+	}
+	rawdb.WritePrivateStateRoot(db, hash, private)
+	rawdb.WriteHeadBlockHash(db, hash)
+
+	have, err := RetainedRoots(db, 2)
+	if err != nil {
+		t.Fatalf("failed to collect roots: %v", err)
+	}
+	want := []common.Hash{roots[0], roots[3], roots[5], private}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("retained roots mismatch: have %x, want %x", have, want)
+	}
+}
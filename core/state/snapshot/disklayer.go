@@ -0,0 +1,105 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/trie"
+)
+
+// diskLayer is the persistent base of the snapshot tree.
+type diskLayer struct {
+	diskdb ethdb.KeyValueStore
+	triedb *trie.Database
+	root   common.Hash
+
+	genMarker []byte             // Last account hash generated, nil once done
+	genAbort  chan chan struct{} // Channel to stop the generation, nil if not running
+	stale     bool               // Whether the layer was flattened or dropped
+	lock      sync.RWMutex
+}
+
+// Root returns the state root of the disk layer.
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Parent always returns nil as the disk layer is the base.
+func (dl *diskLayer) Parent() snapshot {
+	return nil
+}
+
+// Stale returns whether the disk layer was superseded.
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// generated returns whether the background generation is done.
+func (dl *diskLayer) generated() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.genMarker == nil
+}
+
+// Account retrieves the trie value of an account from the database.
+func (dl *diskLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if dl.genMarker != nil && !bytesLessEq(hash[:], dl.genMarker) {
+		return nil, ErrNotCoveredYet
+	}
+	return rawdb.ReadAccountSnapshot(dl.diskdb, hash), nil
+}
+
+// Storage retrieves the trie value of a storage slot from the database.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if dl.genMarker != nil && !bytesLessEq(accountHash[:], dl.genMarker) {
+		return nil, ErrNotCoveredYet
+	}
+	return rawdb.ReadStorageSnapshot(dl.diskdb, accountHash, storageHash), nil
+}
+
+// bytesLessEq returns whether a sorts before or equal to b.
+func bytesLessEq(a, b []byte) bool {
+	return bytes.Compare(a, b) <= 0
+}
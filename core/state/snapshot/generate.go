@@ -0,0 +1,249 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/trie"
+)
+
+// emptyRoot is the known root hash of an empty trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// account mirrors the trie encoding of state.Account, which can't be used here
+// as the state package depends on snapshots.
+type account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// generatorStatus is the persisted progress of a snapshot generation.
+type generatorStatus struct {
+	Marker []byte
+}
+
+// readGenerator retrieves the last account hash a running generation covered,
+// nil if the snapshot on disk is complete.
+func readGenerator(db ethdb.KeyValueReader) ([]byte, error) {
+	data := rawdb.ReadSnapshotGenerator(db)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var status generatorStatus
+	if err := rlp.DecodeBytes(data, &status); err != nil {
+		return nil, err
+	}
+	if status.Marker == nil {
+		status.Marker = []byte{}
+	}
+	return status.Marker, nil
+}
+
+// writeGenerator stores the last account hash a running generation covered.
+func writeGenerator(db ethdb.KeyValueWriter, marker []byte) {
+	data, err := rlp.EncodeToBytes(generatorStatus{Marker: marker})
+	if err != nil {
+		log.Crit("Failed to encode snapshot generator", "err", err)
+	}
+	rawdb.WriteSnapshotGenerator(db, data)
+}
+
+// startGeneration fills the snapshot from the account trie in the background,
+// starting after the generation marker.
+func (dl *diskLayer) startGeneration() {
+	dl.genAbort = make(chan chan struct{})
+	go dl.generate(dl.genMarker, dl.genAbort)
+}
+
+// stopGeneration aborts a running generation, waiting for it to persist its
+// progress.
+func (dl *diskLayer) stopGeneration() {
+	if dl.genAbort == nil {
+		return
+	}
+	stop := make(chan struct{})
+	dl.genAbort <- stop
+	<-stop
+	dl.genAbort = nil
+}
+
+// generate iterates the account trie of the layer root from the marker onwards,
+// writing every account and its storage into the database. The progress is
+// persisted at account granularity, so a layer only serves the entries of the
+// accounts up to the marker.
+func (dl *diskLayer) generate(marker []byte, abort chan chan struct{}) {
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		accounts int
+		slots    int
+		batch    = dl.diskdb.NewBatch()
+		stop     chan struct{}
+	)
+	// Wait to be stopped once done, whether the generation completed or not
+	defer func() {
+		if stop == nil {
+			stop = <-abort
+		}
+		close(stop)
+	}()
+	// Entries past the marker may be left from an interrupted run, drop them
+	if err := wipeFrom(dl.diskdb, marker); err != nil {
+		log.Error("Failed to wipe stale snapshot entries", "err", err)
+		return
+	}
+	origin, ok := nextHash(marker)
+	if !ok {
+		dl.flushGeneration(batch, nil)
+		return
+	}
+	accTrie, err := trie.NewSecure(dl.root, dl.triedb)
+	if err != nil {
+		log.Warn("State snapshot generation stalled", "root", dl.root, "err", err)
+		return
+	}
+	it := trie.NewIterator(accTrie.NodeIterator(origin))
+	for it.Next() {
+		select {
+		case stop = <-abort:
+			dl.flushGeneration(batch, marker)
+			return
+		default:
+		}
+		accountHash := common.BytesToHash(it.Key)
+		rawdb.WriteAccountSnapshot(batch, accountHash, it.Value)
+		accounts++
+
+		var acc account
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			log.Error("Invalid account in state trie", "hash", accountHash, "err", err)
+			dl.flushGeneration(batch, marker)
+			return
+		}
+		if acc.Root != emptyRoot {
+			storageTrie, err := trie.New(acc.Root, dl.triedb)
+			if err != nil {
+				log.Warn("State snapshot generation stalled", "root", dl.root, "err", err)
+				dl.flushGeneration(batch, marker)
+				return
+			}
+			storageIt := trie.NewIterator(storageTrie.NodeIterator(nil))
+			for storageIt.Next() {
+				rawdb.WriteStorageSnapshot(batch, accountHash, common.BytesToHash(storageIt.Key), storageIt.Value)
+				slots++
+			}
+			if storageIt.Err != nil {
+				log.Warn("State snapshot generation stalled", "root", dl.root, "err", storageIt.Err)
+				dl.flushGeneration(batch, marker)
+				return
+			}
+		}
+		marker = accountHash[:]
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			dl.flushGeneration(batch, marker)
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Generating state snapshot", "root", dl.root, "at", accountHash, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		log.Warn("State snapshot generation stalled", "root", dl.root, "err", it.Err)
+		dl.flushGeneration(batch, marker)
+		return
+	}
+	dl.flushGeneration(batch, nil)
+	log.Info("Generated state snapshot", "root", dl.root, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// flushGeneration writes the generated entries along with the new marker, nil
+// marking the generation as done.
+func (dl *diskLayer) flushGeneration(batch ethdb.Batch, marker []byte) {
+	if marker == nil {
+		rawdb.DeleteSnapshotGenerator(batch)
+	} else {
+		writeGenerator(batch, marker)
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write state snapshot", "err", err)
+	}
+	dl.lock.Lock()
+	dl.genMarker = marker
+	dl.lock.Unlock()
+}
+
+// wipeFrom deletes the snapshot entries of the accounts past the marker.
+func wipeFrom(db ethdb.KeyValueStore, marker []byte) error {
+	origin, ok := nextHash(marker)
+	if !ok {
+		return nil
+	}
+	batch := db.NewBatch()
+	for _, prefix := range [][]byte{rawdb.SnapshotAccountPrefix, rawdb.SnapshotStoragePrefix} {
+		keylen := len(prefix) + common.HashLength
+		if bytes.Equal(prefix, rawdb.SnapshotStoragePrefix) {
+			keylen += common.HashLength
+		}
+		it := db.NewIteratorWithStart(append(common.CopyBytes(prefix), origin...))
+		for it.Next() {
+			key := it.Key()
+			if !bytes.HasPrefix(key, prefix) {
+				break
+			}
+			if len(key) != keylen {
+				continue
+			}
+			batch.Delete(key)
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		it.Release()
+	}
+	return batch.Write()
+}
+
+// nextHash returns the hash following the marker, false if the marker is the
+// last possible hash. An empty marker yields the empty origin.
+func nextHash(marker []byte) ([]byte, bool) {
+	if len(marker) == 0 {
+		return nil, true
+	}
+	next := common.CopyBytes(marker)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			return next, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/ethdb"
+)
+
+// AccountRange returns the accounts of the given state root in hash order,
+// starting at origin, until the size of the returned values reaches the byte
+// limit. At least one account is returned if any exists past the origin.
+func (t *Tree) AccountRange(root, origin common.Hash, limit int) ([]common.Hash, [][]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	base, diffs, err := t.layerStack(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Collapse the diff layers into a single override set, oldest first
+	overrides := make(map[common.Hash][]byte)
+	for i := len(diffs) - 1; i >= 0; i-- {
+		for hash := range diffs[i].destructSet {
+			overrides[hash] = nil
+		}
+		for hash, data := range diffs[i].accountData {
+			overrides[hash] = data
+		}
+	}
+	prefix := rawdb.SnapshotAccountPrefix
+	it := base.diskdb.NewIteratorWithStart(append(common.CopyBytes(prefix), origin[:]...))
+	defer it.Release()
+
+	return mergeRange(it, prefix, len(prefix)+common.HashLength, overrides, origin, limit)
+}
+
+// StorageRange returns the storage slots of an account at the given state root
+// in hash order, starting at origin, until the size of the returned values
+// reaches the byte limit.
+func (t *Tree) StorageRange(root, accountHash, origin common.Hash, limit int) ([]common.Hash, [][]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	base, diffs, err := t.layerStack(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		overrides = make(map[common.Hash][]byte)
+		wiped     bool
+	)
+	for i := len(diffs) - 1; i >= 0; i-- {
+		if _, ok := diffs[i].destructSet[accountHash]; ok {
+			overrides = make(map[common.Hash][]byte)
+			wiped = true
+		}
+		for hash, data := range diffs[i].storageData[accountHash] {
+			overrides[hash] = data
+		}
+	}
+	prefix := append(common.CopyBytes(rawdb.SnapshotStoragePrefix), accountHash[:]...)
+
+	var it ethdb.Iterator
+	if wiped {
+		// The storage on disk predates the account's destruction
+		it = emptyIterator{}
+	} else {
+		it = base.diskdb.NewIteratorWithStart(append(common.CopyBytes(prefix), origin[:]...))
+		defer it.Release()
+	}
+	return mergeRange(it, prefix, len(prefix)+common.HashLength, overrides, origin, limit)
+}
+
+// layerStack returns the disk layer and the diff layers on top of it up to the
+// given root, newest first. Ranges can only be served by generated snapshots.
+func (t *Tree) layerStack(root common.Hash) (*diskLayer, []*diffLayer, error) {
+	snap, ok := t.layers[root]
+	if !ok {
+		return nil, nil, fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	var diffs []*diffLayer
+	for {
+		diff, ok := snap.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append(diffs, diff)
+		snap = diff.Parent()
+	}
+	base := snap.(*diskLayer)
+	if base.Stale() {
+		return nil, nil, ErrSnapshotStale
+	}
+	if !base.generated() {
+		return nil, nil, ErrNotCoveredYet
+	}
+	return base, diffs, nil
+}
+
+// mergeRange merges the entries of a database iterator with the overrides of
+// the diff layers, nil overrides hiding entries.
+func mergeRange(it ethdb.Iterator, prefix []byte, keylen int, overrides map[common.Hash][]byte, origin common.Hash, limit int) ([]common.Hash, [][]byte, error) {
+	var pending []common.Hash
+	for hash := range overrides {
+		if bytes.Compare(hash[:], origin[:]) >= 0 {
+			pending = append(pending, hash)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return bytes.Compare(pending[i][:], pending[j][:]) < 0 })
+
+	var (
+		hashes []common.Hash
+		values [][]byte
+		size   int
+	)
+	add := func(hash common.Hash, value []byte) bool {
+		if value != nil {
+			hashes = append(hashes, hash)
+			values = append(values, value)
+			size += common.HashLength + len(value)
+		}
+		return size < limit
+	}
+	// Advance the disk iterator to its next valid entry
+	var (
+		diskKey   *common.Hash
+		diskValue []byte
+	)
+	next := func() {
+		diskKey = nil
+		for it.Next() {
+			key := it.Key()
+			if !bytes.HasPrefix(key, prefix) {
+				return
+			}
+			if len(key) != keylen {
+				continue
+			}
+			hash := common.BytesToHash(key[len(prefix):])
+			diskKey, diskValue = &hash, common.CopyBytes(it.Value())
+			return
+		}
+	}
+	next()
+	for diskKey != nil || len(pending) > 0 {
+		switch {
+		case diskKey == nil || (len(pending) > 0 && bytes.Compare(pending[0][:], diskKey[:]) < 0):
+			if !add(pending[0], overrides[pending[0]]) {
+				return hashes, values, it.Error()
+			}
+			pending = pending[1:]
+
+		case len(pending) > 0 && pending[0] == *diskKey:
+			// The diff layers override the disk entry
+			if !add(pending[0], overrides[pending[0]]) {
+				return hashes, values, it.Error()
+			}
+			pending = pending[1:]
+			next()
+
+		default:
+			if !add(*diskKey, diskValue) {
+				return hashes, values, it.Error()
+			}
+			next()
+		}
+	}
+	return hashes, values, it.Error()
+}
+
+// emptyIterator is an iterator without any entry.
+type emptyIterator struct{}
+
+func (emptyIterator) Next() bool    { return false }
+func (emptyIterator) Error() error  { return nil }
+func (emptyIterator) Key() []byte   { return nil }
+func (emptyIterator) Value() []byte { return nil }
+func (emptyIterator) Release()      {}
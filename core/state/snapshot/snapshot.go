@@ -0,0 +1,315 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snapshot implements a flat key-value view of the state, kept next to
+// the Merkle tries so reads don't need to traverse them.
+//
+// The snapshot consists of a persistent disk layer, holding the accounts and
+// storage slots of one state root, and in-memory diff layers on top of it, one
+// per block, holding what the block changed. Old diff layers are flattened into
+// the disk layer once they fall out of the retained window.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/trie"
+)
+
+var (
+	// ErrSnapshotStale is returned when reading a layer which was flattened into
+	// its parent or dropped because it doesn't descend from the disk layer.
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	// ErrNotCoveredYet is returned when reading an entry the background
+	// generation didn't reach yet.
+	ErrNotCoveredYet = errors.New("not covered yet")
+
+	// errSnapshotCycle is returned when a layer would be its own parent.
+	errSnapshotCycle = errors.New("snapshot cycle")
+)
+
+// Snapshot is a flat view of the state at a given root. Values are returned in
+// their trie encoding, nil meaning the entry doesn't exist.
+type Snapshot interface {
+	// Root returns the state root the snapshot belongs to.
+	Root() common.Hash
+
+	// Account retrieves the trie value of the account with the given hash.
+	Account(hash common.Hash) ([]byte, error)
+
+	// Storage retrieves the trie value of a storage slot of an account.
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is the internal interface of the disk and diff layers.
+type snapshot interface {
+	Snapshot
+
+	// Parent returns the layer below, nil for the disk layer.
+	Parent() snapshot
+
+	// Stale returns whether the layer can't be read anymore.
+	Stale() bool
+
+	// markStale flags the layer as not readable anymore.
+	markStale()
+}
+
+// Tree is the collection of the disk layer and the diff layers built on top of
+// it, indexed by state root.
+type Tree struct {
+	diskdb ethdb.KeyValueStore
+	triedb *trie.Database
+	layers map[common.Hash]snapshot
+	lock   sync.RWMutex
+}
+
+// New loads the snapshot on disk if it belongs to the given root, resuming its
+// generation if needed. Otherwise the snapshot is generated from the tries in
+// the background.
+func New(diskdb ethdb.KeyValueStore, triedb *trie.Database, root common.Hash) *Tree {
+	snap := &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		layers: make(map[common.Hash]snapshot),
+	}
+	if rawdb.ReadSnapshotRoot(diskdb) != root {
+		snap.Rebuild(root)
+		return snap
+	}
+	marker, err := readGenerator(diskdb)
+	if err != nil {
+		log.Warn("Invalid snapshot generator, rebuilding", "err", err)
+		snap.Rebuild(root)
+		return snap
+	}
+	base := &diskLayer{
+		diskdb:    diskdb,
+		triedb:    triedb,
+		root:      root,
+		genMarker: marker,
+	}
+	if marker != nil {
+		log.Info("Resuming state snapshot generation", "root", root, "marker", common.BytesToHash(marker))
+		base.startGeneration()
+	}
+	snap.layers[root] = base
+	return snap
+}
+
+// Snapshot retrieves the layer of the given state root, nil if unknown.
+func (t *Tree) Snapshot(root common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if layer, ok := t.layers[root]; ok {
+		return layer
+	}
+	return nil
+}
+
+// Update adds a diff layer on top of the parent root's layer, holding the
+// changes a block made to the state. Destructed accounts have their previous
+// storage dropped before the account and storage changes are applied, nil
+// values deleting entries.
+func (t *Tree) Update(root, parent common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	if root == parent {
+		// Blocks not touching the state share the layer of their parent
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.layers[root]; ok {
+		return nil
+	}
+	base, ok := t.layers[parent]
+	if !ok {
+		return fmt.Errorf("parent snapshot [%#x] missing", parent)
+	}
+	t.layers[root] = newDiffLayer(base, root, destructs, accounts, storage)
+	return nil
+}
+
+// Cap flattens the diff layers below the given root into the disk layer until
+// at most the given number of diff layers remain, dropping the layers which
+// don't descend from the new disk layer.
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	snap, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	// Collect the diff layers from the top down to the disk layer
+	var diffs []*diffLayer
+	for {
+		diff, ok := snap.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append(diffs, diff)
+		snap = diff.Parent()
+	}
+	if len(diffs) <= layers {
+		return nil
+	}
+	if snap.Stale() {
+		return ErrSnapshotStale
+	}
+	base := snap.(*diskLayer)
+	for i := len(diffs) - 1; i >= layers; i-- {
+		base = diffToDisk(base, diffs[i])
+	}
+	if layers > 0 {
+		diffs[layers-1].setParent(base)
+	}
+	// Drop every layer not built on top of the new disk layer
+	for hash, layer := range t.layers {
+		if !descends(layer, base) {
+			layer.markStale()
+			delete(t.layers, hash)
+		}
+	}
+	t.layers[base.root] = base
+	return nil
+}
+
+// Rebuild drops all layers and generates the snapshot of the given root from
+// its trie in the background.
+func (t *Tree) Rebuild(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, layer := range t.layers {
+		if base, ok := layer.(*diskLayer); ok {
+			base.stopGeneration()
+		}
+		layer.markStale()
+	}
+	log.Info("Rebuilding state snapshot", "root", root)
+
+	batch := t.diskdb.NewBatch()
+	rawdb.WriteSnapshotRoot(batch, root)
+	writeGenerator(batch, []byte{})
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to reset snapshot", "err", err)
+	}
+	base := &diskLayer{
+		diskdb:    t.diskdb,
+		triedb:    t.triedb,
+		root:      root,
+		genMarker: []byte{},
+	}
+	base.startGeneration()
+	t.layers = map[common.Hash]snapshot{root: base}
+}
+
+// Stop aborts the background generation, persisting its progress.
+func (t *Tree) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, layer := range t.layers {
+		if base, ok := layer.(*diskLayer); ok {
+			base.stopGeneration()
+		}
+	}
+}
+
+// diffToDisk merges a diff layer into the disk layer below it, returning the
+// new disk layer. Entries the generation didn't reach yet are left to it.
+func diffToDisk(base *diskLayer, diff *diffLayer) *diskLayer {
+	base.stopGeneration()
+	base.markStale()
+	diff.markStale()
+
+	marker := base.genMarker
+	covered := func(hash common.Hash) bool {
+		return marker == nil || bytesLessEq(hash[:], marker)
+	}
+	batch := base.diskdb.NewBatch()
+	for hash := range diff.destructSet {
+		if !covered(hash) {
+			continue
+		}
+		rawdb.DeleteAccountSnapshot(batch, hash)
+		it := rawdb.IterateStorageSnapshots(base.diskdb, hash)
+		for it.Next() {
+			if len(it.Key()) == len(rawdb.SnapshotStoragePrefix)+2*common.HashLength {
+				batch.Delete(it.Key())
+			}
+		}
+		it.Release()
+	}
+	for hash, data := range diff.accountData {
+		if !covered(hash) {
+			continue
+		}
+		if data == nil {
+			rawdb.DeleteAccountSnapshot(batch, hash)
+		} else {
+			rawdb.WriteAccountSnapshot(batch, hash, data)
+		}
+	}
+	for accountHash, slots := range diff.storageData {
+		if !covered(accountHash) {
+			continue
+		}
+		for storageHash, data := range slots {
+			if data == nil {
+				rawdb.DeleteStorageSnapshot(batch, accountHash, storageHash)
+			} else {
+				rawdb.WriteStorageSnapshot(batch, accountHash, storageHash, data)
+			}
+		}
+	}
+	rawdb.WriteSnapshotRoot(batch, diff.root)
+	if marker != nil {
+		writeGenerator(batch, marker)
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write snapshot layer", "err", err)
+	}
+	res := &diskLayer{
+		diskdb:    base.diskdb,
+		triedb:    base.triedb,
+		root:      diff.root,
+		genMarker: marker,
+	}
+	if marker != nil {
+		res.startGeneration()
+	}
+	return res
+}
+
+// descends returns whether the layer is built on top of the given base.
+func descends(layer snapshot, base snapshot) bool {
+	for ; layer != nil; layer = layer.Parent() {
+		if layer == base {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/state/snapshot"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/ethdb/memorydb"
+	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/trie"
+)
+
+// commitState applies the changes on top of root, returning the new root.
+func commitState(t *testing.T, sdb state.Database, snaps *snapshot.Tree, root common.Hash, change func(*state.StateDB)) common.Hash {
+	statedb, err := state.NewWithSnapshot(root, sdb, snaps)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	change(statedb)
+	root, err = statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	return root
+}
+
+// populate creates accounts with a few storage slots each.
+func populate(accounts int, seed byte) func(*state.StateDB) {
+	return func(statedb *state.StateDB) {
+		for i := 0; i < accounts; i++ {
+			addr := common.BytesToAddress([]byte{byte(i)})
+			statedb.SetBalance(addr, big.NewInt(int64(seed)+int64(i)+1))
+			for j := 0; j < i%4; j++ {
+				statedb.SetState(addr, common.Hash{byte(j)}, common.Hash{seed, byte(i)})
+			}
+		}
+	}
+}
+
+// waitGenerated blocks until the snapshot on disk is complete.
+func waitGenerated(t *testing.T, db ethdb.KeyValueReader) {
+	for start := time.Now(); rawdb.ReadSnapshotGenerator(db) != nil; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("snapshot generation timed out")
+		}
+	}
+}
+
+// trieEntries returns the leaves of a trie in key order.
+func trieEntries(t *testing.T, triedb *trie.Database, root common.Hash) ([]common.Hash, [][]byte) {
+	tr, err := trie.New(root, triedb)
+	if err != nil {
+		t.Fatalf("failed to open trie: %v", err)
+	}
+	var (
+		keys   []common.Hash
+		values [][]byte
+	)
+	it := trie.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		keys = append(keys, common.BytesToHash(it.Key))
+		values = append(values, common.CopyBytes(it.Value))
+	}
+	if it.Err != nil {
+		t.Fatalf("failed to iterate trie: %v", it.Err)
+	}
+	return keys, values
+}
+
+// checkSnapshot verifies the snapshot of root against its trie, for the
+// accounts and their storage.
+func checkSnapshot(t *testing.T, snaps *snapshot.Tree, triedb *trie.Database, root common.Hash) {
+	snap := snaps.Snapshot(root)
+	if snap == nil {
+		t.Fatalf("snapshot %x missing", root)
+	}
+	keys, values := trieEntries(t, triedb, root)
+	for i, key := range keys {
+		data, err := snap.Account(key)
+		if err != nil {
+			t.Fatalf("failed to read account %x: %v", key, err)
+		}
+		if !bytes.Equal(data, values[i]) {
+			t.Fatalf("account %x mismatch: have %x, want %x", key, data, values[i])
+		}
+	}
+	// Compare through the ranges too, which catches surplus entries
+	hashes, data, err := snaps.AccountRange(root, common.Hash{}, 1<<20)
+	if err != nil {
+		t.Fatalf("failed to read account range: %v", err)
+	}
+	if len(hashes) != len(keys) {
+		t.Fatalf("account range length mismatch: have %d, want %d", len(hashes), len(keys))
+	}
+	for i, key := range keys {
+		if hashes[i] != key || !bytes.Equal(data[i], values[i]) {
+			t.Fatalf("account range entry %d mismatch", i)
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(data[i], &acc); err != nil {
+			t.Fatalf("invalid account: %v", err)
+		}
+		slotKeys, slotValues := trieEntries(t, triedb, acc.Root)
+		slots, slotData, err := snaps.StorageRange(root, key, common.Hash{}, 1<<20)
+		if err != nil {
+			t.Fatalf("failed to read storage range: %v", err)
+		}
+		if len(slots) != len(slotKeys) {
+			t.Fatalf("account %x storage length mismatch: have %d, want %d", key, len(slots), len(slotKeys))
+		}
+		for j := range slotKeys {
+			if slots[j] != slotKeys[j] || !bytes.Equal(slotData[j], slotValues[j]) {
+				t.Fatalf("account %x storage entry %d mismatch", key, j)
+			}
+			value, err := snap.Storage(key, slotKeys[j])
+			if err != nil || !bytes.Equal(value, slotValues[j]) {
+				t.Fatalf("account %x slot %x mismatch: have %x (%v), want %x", key, slotKeys[j], value, err, slotValues[j])
+			}
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := state.NewDatabase(db)
+	root := commitState(t, sdb, nil, common.Hash{}, populate(64, 1))
+
+	snaps := snapshot.New(db, sdb.TrieDB(), root)
+	waitGenerated(t, db)
+	checkSnapshot(t, snaps, sdb.TrieDB(), root)
+
+	// Reloading a complete snapshot doesn't regenerate it
+	snaps.Stop()
+	snaps = snapshot.New(db, sdb.TrieDB(), root)
+	if rawdb.ReadSnapshotGenerator(db) != nil {
+		t.Fatal("complete snapshot regenerated")
+	}
+	checkSnapshot(t, snaps, sdb.TrieDB(), root)
+}
+
+func TestDiffLayers(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := state.NewDatabase(db)
+	genesis := commitState(t, sdb, nil, common.Hash{}, populate(16, 1))
+
+	snaps := snapshot.New(db, sdb.TrieDB(), genesis)
+	waitGenerated(t, db)
+
+	// Change, delete and recreate accounts over a few blocks
+	roots := []common.Hash{genesis}
+	roots = append(roots, commitState(t, sdb, snaps, roots[len(roots)-1], populate(24, 2)))
+	roots = append(roots, commitState(t, sdb, snaps, roots[len(roots)-1], func(statedb *state.StateDB) {
+		statedb.Suicide(common.BytesToAddress([]byte{3}))
+		statedb.Suicide(common.BytesToAddress([]byte{7}))
+		statedb.SetState(common.BytesToAddress([]byte{6}), common.Hash{1}, common.Hash{})
+	}))
+	roots = append(roots, commitState(t, sdb, snaps, roots[len(roots)-1], func(statedb *state.StateDB) {
+		addr := common.BytesToAddress([]byte{7})
+		statedb.CreateAccount(addr)
+		statedb.SetBalance(addr, big.NewInt(1))
+		statedb.SetState(addr, common.Hash{9}, common.Hash{9})
+	}))
+	for _, root := range roots {
+		checkSnapshot(t, snaps, sdb.TrieDB(), root)
+	}
+	// A destructed account doesn't expose its old storage
+	head := snaps.Snapshot(roots[3])
+	if data, err := head.Storage(crypto.Keccak256Hash(common.BytesToAddress([]byte{7}).Bytes()), crypto.Keccak256Hash(common.Hash{2}.Bytes())); err != nil || data != nil {
+		t.Fatalf("destructed storage visible: %x, %v", data, err)
+	}
+	// Flattening drops the layers below the new disk layer
+	stale := snaps.Snapshot(roots[1])
+	if err := snaps.Cap(roots[3], 1); err != nil {
+		t.Fatalf("failed to cap: %v", err)
+	}
+	if snaps.Snapshot(roots[1]) != nil || snaps.Snapshot(genesis) != nil {
+		t.Fatal("flattened layers still retrievable")
+	}
+	if _, err := stale.Account(common.Hash{}); err != snapshot.ErrSnapshotStale {
+		t.Fatalf("stale layer read: have %v, want %v", err, snapshot.ErrSnapshotStale)
+	}
+	checkSnapshot(t, snaps, sdb.TrieDB(), roots[2])
+	checkSnapshot(t, snaps, sdb.TrieDB(), roots[3])
+
+	if err := snaps.Cap(roots[3], 0); err != nil {
+		t.Fatalf("failed to cap: %v", err)
+	}
+	if root := rawdb.ReadSnapshotRoot(db); root != roots[3] {
+		t.Fatalf("disk layer root mismatch: have %x, want %x", root, roots[3])
+	}
+	checkSnapshot(t, snaps, sdb.TrieDB(), roots[3])
+}
+
+func TestStateReads(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := state.NewDatabase(db)
+	root := commitState(t, sdb, nil, common.Hash{}, populate(32, 1))
+
+	snaps := snapshot.New(db, sdb.TrieDB(), root)
+	waitGenerated(t, db)
+	root = commitState(t, sdb, snaps, root, populate(8, 2))
+
+	statedb, _ := state.NewWithSnapshot(root, sdb, snaps)
+	trusted, _ := state.New(root, sdb)
+	for i := 0; i < 32; i++ {
+		addr := common.BytesToAddress([]byte{byte(i)})
+		if have, want := statedb.GetBalance(addr), trusted.GetBalance(addr); have.Cmp(want) != 0 {
+			t.Fatalf("account %d balance mismatch: have %v, want %v", i, have, want)
+		}
+		for j := 0; j < 4; j++ {
+			if have, want := statedb.GetState(addr, common.Hash{byte(j)}), trusted.GetState(addr, common.Hash{byte(j)}); have != want {
+				t.Fatalf("account %d slot %d mismatch: have %x, want %x", i, j, have, want)
+			}
+		}
+	}
+	// Tamper with the disk layer to prove reads are served from it
+	if err := snaps.Cap(root, 0); err != nil {
+		t.Fatalf("failed to cap: %v", err)
+	}
+	addr := common.BytesToAddress([]byte{20})
+	rawdb.WriteAccountSnapshot(db, crypto.Keccak256Hash(addr.Bytes()), mustEncode(t, state.Account{Nonce: 42, Balance: big.NewInt(0), CodeHash: crypto.Keccak256(nil)}))
+
+	statedb, _ = state.NewWithSnapshot(root, sdb, snaps)
+	if nonce := statedb.GetNonce(addr); nonce != 42 {
+		t.Fatalf("nonce mismatch: have %d, want 42", nonce)
+	}
+}
+
+func TestRangeProofs(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := state.NewDatabase(db)
+	root := commitState(t, sdb, nil, common.Hash{}, populate(200, 1))
+
+	snaps := snapshot.New(db, sdb.TrieDB(), root)
+	waitGenerated(t, db)
+	root = commitState(t, sdb, snaps, root, populate(100, 2))
+
+	tr, err := trie.New(root, sdb.TrieDB())
+	if err != nil {
+		t.Fatalf("failed to open trie: %v", err)
+	}
+	// Walk the account range in small chunks, proving each against the root
+	var (
+		origin common.Hash
+		total  int
+	)
+	for {
+		hashes, values, err := snaps.AccountRange(root, origin, 500)
+		if err != nil {
+			t.Fatalf("failed to read range: %v", err)
+		}
+		proof := memorydb.New()
+		tr.Prove(origin[:], 0, proof)
+		keys := make([][]byte, len(hashes))
+		for i, hash := range hashes {
+			keys[i] = common.CopyBytes(hash[:])
+		}
+		if len(keys) > 0 {
+			tr.Prove(keys[len(keys)-1], 0, proof)
+		}
+		more, err := trie.VerifyRangeProof(root, origin[:], keys, values, proof)
+		if err != nil {
+			t.Fatalf("range at %x rejected: %v", origin, err)
+		}
+		total += len(hashes)
+		if !more {
+			break
+		}
+		next := new(big.Int).Add(hashes[len(hashes)-1].Big(), common.Big1)
+		origin = common.BigToHash(next)
+	}
+	if keys, _ := trieEntries(t, sdb.TrieDB(), root); total != len(keys) {
+		t.Fatalf("ranges covered %d accounts, want %d", total, len(keys))
+	}
+}
+
+func mustEncode(t *testing.T, val interface{}) []byte {
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	return data
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/state/snapshot"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/crypto"
 	"github.com/clearmatics/autonity/log"
@@ -66,6 +67,14 @@ type StateDB struct {
 	db   Database
 	trie Trie
 
+	// Flat state snapshot serving reads, nil when not available. The changes
+	// to feed into the next diff layer are gathered next to it.
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects        map[common.Address]*stateObject
 	stateObjectsPending map[common.Address]struct{} // State objects finalized but not yet written to the trie
@@ -85,6 +94,7 @@ type StateDB struct {
 	txIndex      int
 	logs         map[common.Hash][]*types.Log
 	logSize      uint
+	systemCalls  []*types.SystemCall
 
 	preimages map[common.Hash][]byte
 
@@ -123,6 +133,31 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 	}, nil
 }
 
+// NewWithSnapshot creates a new state from a given trie, reading through the
+// flat snapshot of the root if the tree holds it.
+func NewWithSnapshot(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error) {
+	sdb, err := New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	sdb.snaps = snaps
+	sdb.resetSnapshot(root)
+	return sdb, nil
+}
+
+// resetSnapshot switches the snapshot over to the given root, dropping the
+// gathered changes.
+func (s *StateDB) resetSnapshot(root common.Hash) {
+	if s.snaps == nil {
+		return
+	}
+	if s.snap = s.snaps.Snapshot(root); s.snap != nil {
+		s.snapDestructs = make(map[common.Hash]struct{})
+		s.snapAccounts = make(map[common.Hash][]byte)
+		s.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+}
+
 // setError remembers the first non-nil error it is called with.
 func (s *StateDB) setError(err error) {
 	if s.dbErr == nil {
@@ -150,8 +185,10 @@ func (s *StateDB) Reset(root common.Hash) error {
 	s.txIndex = 0
 	s.logs = make(map[common.Hash][]*types.Log)
 	s.logSize = 0
+	s.systemCalls = nil
 	s.preimages = make(map[common.Hash][]byte)
 	s.clearJournalAndRefund()
+	s.resetSnapshot(root)
 	return nil
 }
 
@@ -178,6 +215,18 @@ func (s *StateDB) Logs() []*types.Log {
 	return logs
 }
 
+// AddSystemCall records a call the protocol made to the Autonity contract while
+// processing the block.
+func (s *StateDB) AddSystemCall(call *types.SystemCall) {
+	s.journal.append(addSystemCallChange{call: call})
+	s.systemCalls = append(s.systemCalls, call)
+}
+
+// SystemCalls returns the system calls recorded while processing the block.
+func (s *StateDB) SystemCalls() []*types.SystemCall {
+	return s.systemCalls
+}
+
 // AddPreimage records a SHA3 preimage seen by the VM.
 func (s *StateDB) AddPreimage(hash common.Hash, preimage []byte) {
 	if _, ok := s.preimages[hash]; !ok {
@@ -437,6 +486,10 @@ func (s *StateDB) updateStateObject(obj *stateObject) {
 		panic(fmt.Errorf("can't encode object at %x: %v", addr[:], err))
 	}
 	s.setError(s.trie.TryUpdate(addr[:], data))
+
+	if s.snap != nil {
+		s.snapAccounts[obj.addrHash] = data
+	}
 }
 
 // deleteStateObject removes the given object from the state trie.
@@ -448,6 +501,12 @@ func (s *StateDB) deleteStateObject(obj *stateObject) {
 	// Delete the account from the trie
 	addr := obj.Address()
 	s.setError(s.trie.TryDelete(addr[:]))
+
+	if s.snap != nil {
+		s.snapDestructs[obj.addrHash] = struct{}{}
+		delete(s.snapAccounts, obj.addrHash)
+		delete(s.snapStorage, obj.addrHash)
+	}
 }
 
 // getStateObject retrieves a state object given by the address, returning nil if
@@ -473,8 +532,17 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 	if metrics.EnabledExpensive {
 		defer func(start time.Time) { s.AccountReads += time.Since(start) }(time.Now())
 	}
-	// Load the object from the database
-	enc, err := s.trie.TryGet(addr[:])
+	// Load the object from the snapshot if it covers it, from the trie otherwise
+	var (
+		enc []byte
+		err error
+	)
+	if s.snap != nil {
+		enc, err = s.snap.Account(crypto.Keccak256Hash(addr[:]))
+	}
+	if s.snap == nil || err != nil {
+		enc, err = s.trie.TryGet(addr[:])
+	}
 	if len(enc) == 0 {
 		s.setError(err)
 		return nil
@@ -508,12 +576,20 @@ func (s *StateDB) GetOrNewStateObject(addr common.Address) *stateObject {
 func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject) {
 	prev = s.getDeletedStateObject(addr) // Note, prev might have been deleted, we need that!
 
+	// The storage of an overwritten account must not be read from the snapshot
+	var prevdestruct bool
+	if s.snap != nil && prev != nil {
+		_, prevdestruct = s.snapDestructs[prev.addrHash]
+		if !prevdestruct {
+			s.snapDestructs[prev.addrHash] = struct{}{}
+		}
+	}
 	newobj = newObject(s, addr, Account{})
 	newobj.setNonce(0) // sets the object to dirty
 	if prev == nil {
 		s.journal.append(createObjectChange{account: &addr})
 	} else {
-		s.journal.append(resetObjectChange{prev: prev})
+		s.journal.append(resetObjectChange{prev: prev, prevdestruct: prevdestruct})
 	}
 	s.setStateObject(newobj)
 	return newobj, prev
@@ -578,6 +654,7 @@ func (s *StateDB) Copy() *StateDB {
 		refund:              s.refund,
 		logs:                make(map[common.Hash][]*types.Log, len(s.logs)),
 		logSize:             s.logSize,
+		systemCalls:         append([]*types.SystemCall(nil), s.systemCalls...),
 		preimages:           make(map[common.Hash][]byte, len(s.preimages)),
 		journal:             newJournal(),
 	}
@@ -623,6 +700,26 @@ func (s *StateDB) Copy() *StateDB {
 	for hash, preimage := range s.preimages {
 		state.preimages[hash] = preimage
 	}
+	if s.snap != nil {
+		state.snaps = s.snaps
+		state.snap = s.snap
+		state.snapDestructs = make(map[common.Hash]struct{}, len(s.snapDestructs))
+		for hash := range s.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte, len(s.snapAccounts))
+		for hash, data := range s.snapAccounts {
+			state.snapAccounts[hash] = data
+		}
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte, len(s.snapStorage))
+		for hash, slots := range s.snapStorage {
+			cpy := make(map[common.Hash][]byte, len(slots))
+			for key, data := range slots {
+				cpy[key] = data
+			}
+			state.snapStorage[hash] = cpy
+		}
+	}
 	return state
 }
 
@@ -750,7 +847,7 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 	if metrics.EnabledExpensive {
 		defer func(start time.Time) { s.AccountCommits += time.Since(start) }(time.Now())
 	}
-	return s.trie.Commit(func(leaf []byte, parent common.Hash) error {
+	root, err := s.trie.Commit(func(leaf []byte, parent common.Hash) error {
 		var account Account
 		if err := rlp.DecodeBytes(leaf, &account); err != nil {
 			return nil
@@ -764,4 +861,15 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 		}
 		return nil
 	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// Stack the changes as a new diff layer on the snapshot of the parent
+	if s.snap != nil {
+		if err := s.snaps.Update(root, s.snap.Root(), s.snapDestructs, s.snapAccounts, s.snapStorage); err != nil {
+			log.Debug("Failed to update state snapshot", "root", root, "err", err)
+		}
+		s.snap, s.snapDestructs, s.snapAccounts, s.snapStorage = nil, nil, nil, nil
+	}
+	return root, nil
 }
@@ -17,7 +17,6 @@
 package core
 
 import (
-	"errors"
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/consensus"
 	"github.com/clearmatics/autonity/consensus/misc"
@@ -26,7 +25,9 @@ import (
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/core/vm"
 	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/log"
 	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/tracing"
 	"math/big"
 )
 
@@ -69,37 +70,71 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		allLogs  []*types.Log
 		gp       = new(GasPool).AddGas(block.GasLimit())
 	)
+	span := tracing.StartHeight(block.NumberU64(), "core.processBlock",
+		tracing.String("block.hash", block.Hash().Hex()),
+		tracing.Int64("block.txs", int64(len(block.Transactions()))),
+	)
+	defer span.Finish()
+
 	// Mutate the block and state according to any hard-fork specs
 	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
 		misc.ApplyDAOHardFork(statedb)
 	}
 
-	var contractMinGasPrice = new(big.Int)
+	var (
+		contractMinGasPrice = new(big.Int)
+		permissions         *autonity.Permissions
+	)
 	if p.autonityContract != nil {
 		minGasPrice, err := p.autonityContract.GetMinimumGasPrice(block, statedb)
 		if err == nil {
 			contractMinGasPrice.SetUint64(minGasPrice)
 		}
+		// Permissions are resolved against the parent state, before any change
+		parent := p.bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+		if parent == nil {
+			return nil, nil, 0, consensus.ErrUnknownAncestor
+		}
+		if permissions, err = p.autonityContract.GetPermissions(parent, statedb); err != nil {
+			return nil, nil, 0, err
+		}
 	}
+	signer := types.MakeSigner(p.config, header.Number)
 	// Iterate over and process the individual transactions
 	for i, tx := range block.Transactions() {
 		if contractMinGasPrice.Uint64() != 0 {
 			if tx.GasPrice().Cmp(contractMinGasPrice) == -1 {
-				return nil, nil, 0, errors.New("gas price must be greater minGasPrice")
+				return nil, nil, 0, ErrMinGasPrice
 			}
 		}
 
+		if permissions != nil {
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			if err := permissions.Check(from, tx.To() == nil); err != nil {
+				return nil, nil, 0, err
+			}
+		}
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		txSpan := tracing.StartChild(span, "core.applyTransaction", tracing.String("tx.hash", tx.Hash().Hex()))
 		receipt, err := ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
 		if err != nil {
+			txSpan.SetError(err)
+			txSpan.Finish()
+			span.SetError(err)
 			return nil, nil, 0, err
 		}
+		txSpan.SetAttributes(tracing.Uint64("tx.gasUsed", receipt.GasUsed), tracing.Uint64("tx.status", receipt.Status))
+		txSpan.Finish()
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, receipt.Logs...)
 	}
 	if p.autonityContract != nil {
 		err := p.autonityContract.ApplyFinalize(block.Transactions(), receipts, block.Header(), statedb)
 		if err != nil {
+			span.SetError(err)
 			return nil, nil, 0, err
 		}
 	}
@@ -114,6 +149,9 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
 func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, error) {
+	if err := ValidatePrivateTx(config, header.Number, tx); err != nil {
+		return nil, err
+	}
 	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
 	if err != nil {
 		return nil, err
@@ -155,3 +193,33 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 
 	return receipt, err
 }
+
+// applyPrivateTransaction executes the payload of a private transaction on the
+// private state of the node. Private execution can't invalidate the block the
+// transaction is in, failures are recorded in the returned receipt instead.
+func applyPrivateTransaction(config *params.ChainConfig, bc ChainContext, statedb *state.StateDB, header *types.Header, tx *types.Transaction, from common.Address, payload []byte, cfg vm.Config) (*types.Receipt, error) {
+	// Gas is paid on the public state, the private nonce follows the public one
+	statedb.SetNonce(from, tx.Nonce())
+	msg := types.NewMessage(from, tx.To(), tx.Nonce(), tx.Value(), tx.Gas(), new(big.Int), payload, false)
+
+	context := NewEVMContext(msg, header, bc, nil)
+	vmenv := vm.NewEVM(context, statedb, config, cfg)
+
+	receipt := &types.Receipt{TxHash: tx.Hash(), Status: types.ReceiptStatusFailed}
+	_, gas, failed, err := ApplyMessage(vmenv, msg, new(GasPool).AddGas(tx.Gas()))
+	if err != nil {
+		log.Debug("Private transaction failed", "hash", tx.Hash(), "err", err)
+		return receipt, nil
+	}
+	statedb.Finalise(true)
+
+	if !failed {
+		receipt.Status = types.ReceiptStatusSuccessful
+	}
+	receipt.GasUsed = gas
+	if msg.To() == nil {
+		receipt.ContractAddress = crypto.CreateAddress(from, tx.Nonce())
+	}
+	receipt.Logs = statedb.GetLogs(tx.Hash())
+	return receipt, nil
+}
@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/core/vm"
 	"github.com/clearmatics/autonity/log"
 	"github.com/clearmatics/autonity/params"
@@ -75,6 +76,12 @@ type Message interface {
 	Data() []byte
 }
 
+// privateMessage is implemented by messages of private transactions, whose
+// parties are paid for as intrinsic gas.
+type privateMessage interface {
+	PrivateFor() []string
+}
+
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
 func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 bool) (uint64, error) {
 	// Set the starting gas for the raw transaction
@@ -112,6 +119,38 @@ func IntrinsicGas(data []byte, contractCreation, isHomestead bool, isEIP2028 boo
 	return gas, nil
 }
 
+// PrivateForGas computes the gas charged on top of the intrinsic gas of a
+// private transaction for the parties it carries on-chain, priced as data.
+func PrivateForGas(parties []string, isEIP2028 bool) (uint64, error) {
+	var data []byte
+	for _, party := range parties {
+		data = append(data, party...)
+	}
+	gas, err := IntrinsicGas(data, false, false, isEIP2028)
+	if err != nil {
+		return 0, err
+	}
+	return gas - params.TxGas, nil
+}
+
+// TxIntrinsicGas computes the intrinsic gas of a transaction, including the
+// parties of private transactions. The public part of the latter is never a
+// contract creation.
+func TxIntrinsicGas(tx *types.Transaction, isHomestead, isEIP2028 bool) (uint64, error) {
+	gas, err := IntrinsicGas(tx.Data(), tx.To() == nil && !tx.IsPrivate(), isHomestead, isEIP2028)
+	if err != nil || !tx.IsPrivate() {
+		return gas, err
+	}
+	partiesGas, err := PrivateForGas(tx.PrivateFor(), isEIP2028)
+	if err != nil {
+		return 0, err
+	}
+	if math.MaxUint64-gas < partiesGas {
+		return 0, vm.ErrOutOfGas
+	}
+	return gas + partiesGas, nil
+}
+
 // NewStateTransition initialises and returns a new state transition object.
 func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition {
 	return &StateTransition{
@@ -202,6 +241,15 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	if err = st.useGas(gas); err != nil {
 		return nil, 0, false, err
 	}
+	if msg, ok := msg.(privateMessage); ok && len(msg.PrivateFor()) > 0 {
+		partiesGas, err := PrivateForGas(msg.PrivateFor(), istanbul)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		if err = st.useGas(partiesGas); err != nil {
+			return nil, 0, false, err
+		}
+	}
 
 	var (
 		evm = st.evm
@@ -397,16 +397,18 @@ func (h *priceHeap) Pop() interface{} {
 // txPricedList is a price-sorted heap to allow operating on transactions pool
 // contents in a price-incrementing way.
 type txPricedList struct {
-	all    *txLookup  // Pointer to the map of all transactions
-	items  *priceHeap // Heap of prices of all the stored transactions
-	stales int        // Number of stale price points to (re-heap trigger)
+	all      *txLookup   // Pointer to the map of all transactions
+	items    *priceHeap  // Heap of prices of all the stored transactions
+	stales   int         // Number of stale price points to (re-heap trigger)
+	priority *TxPriority // Priority lane whose transactions are never evicted
 }
 
 // newTxPricedList creates a new price-sorted transaction heap.
-func newTxPricedList(all *txLookup) *txPricedList {
+func newTxPricedList(all *txLookup, priority *TxPriority) *txPricedList {
 	return &txPricedList{
-		all:   all,
-		items: new(priceHeap),
+		all:      all,
+		items:    new(priceHeap),
+		priority: priority,
 	}
 }
 
@@ -453,8 +455,8 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 			save = append(save, tx)
 			break
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless local or prioritised
+		if local.containsTx(tx) || l.priority.Contains(tx) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
@@ -469,8 +471,8 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 // Underpriced checks whether a transaction is cheaper than (or as cheap as) the
 // lowest priced transaction currently being tracked.
 func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) bool {
-	// Local transactions cannot be underpriced
-	if local.containsTx(tx) {
+	// Local and prioritised transactions cannot be underpriced
+	if local.containsTx(tx) || l.priority.Contains(tx) {
 		return false
 	}
 	// Discard stale price points if found at the heap start
@@ -505,8 +507,8 @@ func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions
 			l.stales--
 			continue
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless local or prioritised
+		if local.containsTx(tx) || l.priority.Contains(tx) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
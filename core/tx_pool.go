@@ -132,10 +132,11 @@ type blockChain interface {
 
 // TxPoolConfig are the configuration parameters of the transaction pool.
 type TxPoolConfig struct {
-	Locals    []common.Address // Addresses that should be treated by default as local
-	NoLocals  bool             // Whether local transaction handling should be disabled
-	Journal   string           // Journal of local transactions to survive node restarts
-	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
+	Locals     []common.Address // Addresses that should be treated by default as local
+	NoLocals   bool             // Whether local transaction handling should be disabled
+	NoPriority bool             // Whether Autonity operator transactions should be handled like any other
+	Journal    string           // Journal of local transactions to survive node restarts
+	Rejournal  time.Duration    // Time interval to regenerate the local transaction journal
 
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
@@ -221,14 +222,17 @@ type TxPool struct {
 	signer      types.Signer
 	mu          sync.RWMutex
 
-	istanbul bool // Fork indicator whether we are in the istanbul stage.
+	istanbul  bool // Fork indicator whether we are in the istanbul stage.
+	privateTx bool // Fork indicator whether private transactions are enabled.
 
+	currentHead   *types.Header  // Current head of the blockchain
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals   *accountSet // Set of local transaction to exempt from eviction rules
+	priority *TxPriority // Priority lane of operator transactions, also exempt from eviction rules
+	journal  *txJournal  // Journal of local transaction to back up to disk
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -283,7 +287,10 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
-	pool.priced = newTxPricedList(pool.all)
+	if !config.NoPriority {
+		pool.priority = NewTxPriority(chainconfig)
+	}
+	pool.priced = newTxPricedList(pool.all, pool.priority)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// Start the reorg loop early so it can handle requests generated during journal loading.
@@ -528,6 +535,15 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.Value().Sign() < 0 {
 		return ErrNegativeValue
 	}
+	// Private transactions can only execute a payload, not transfer value.
+	if tx.IsPrivate() {
+		if !pool.privateTx {
+			return ErrPrivateTxNotActive
+		}
+		if err := validatePrivatePayload(tx); err != nil {
+			return err
+		}
+	}
 	// Ensure the transaction doesn't exceed the current block limit gas.
 	if pool.currentMaxGas < tx.Gas() {
 		return ErrGasLimit
@@ -537,11 +553,20 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return ErrInvalidSender
 	}
-	// Drop non-local transactions under our own minimal accepted gas price
+	// Drop non-local transactions under our own minimal accepted gas price. The
+	// contract minimum gas price is a consensus rule, enforced for all below.
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
-	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
+	if !local && !pool.priority.Contains(tx) && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrUnderpriced
 	}
+	// Ensure the sender is allowed to submit such transaction
+	perms, err := pool.permissions()
+	if err != nil {
+		return err
+	}
+	if err := perms.Check(from, tx.To() == nil); err != nil {
+		return err
+	}
 	// Ensure the transaction adheres to nonce ordering
 	if pool.currentState.GetNonce(from) > tx.Nonce() {
 		return ErrNonceTooLow
@@ -552,7 +577,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrInsufficientFunds
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul)
+	intrGas, err := TxIntrinsicGas(tx, true, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -573,6 +598,16 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	return nil
 }
 
+// permissions returns the submission permissions on top of the current head,
+// nil if the chain isn't permissioned.
+func (pool *TxPool) permissions() (*autonity.Permissions, error) {
+	contract := pool.chain.GetAutonityContract()
+	if contract == nil {
+		return nil, nil
+	}
+	return contract.GetPermissions(pool.currentHead, pool.currentState)
+}
+
 // add validates a transaction and inserts it into the non-executable queue for later
 // pending promotion and execution. If the transaction is a replacement for an already
 // pending or queued one, it overwrites the previous transaction if its price is higher.
@@ -1159,6 +1194,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 		log.Error("Failed to reset txpool state", "err", err)
 		return
 	}
+	pool.currentHead = newHead
 	pool.currentState = statedb
 	pool.pendingNonces = newTxNoncer(statedb)
 	pool.currentMaxGas = newHead.GasLimit
@@ -1171,6 +1207,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
+	pool.privateTx = pool.chainconfig.IsPrivateTx(next)
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -1374,9 +1411,17 @@ func (pool *TxPool) truncateQueue() {
 // executable/pending queue and any subsequent transactions that become unexecutable
 // are moved back into the future queue.
 func (pool *TxPool) demoteUnexecutables() {
+	perms, err := pool.permissions()
+	if err != nil {
+		log.Warn("Failed to retrieve submission permissions", "err", err)
+	}
 	// Iterate over all accounts and demote any non-executable transactions
 	for addr, list := range pool.pending {
 		nonce := pool.currentState.GetNonce(addr)
+		if err == nil && perms.Check(addr, false) == autonity.ErrUnauthorizedSender {
+			// The sender lost its permission, all its transactions are too old
+			nonce = math.MaxUint64
+		}
 
 		// Drop all transactions that are deemed too old (low nonce)
 		olds := list.Forward(nonce)
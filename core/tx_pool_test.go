@@ -1837,3 +1837,75 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.AddRemotes(batch)
 	}
 }
+
+// Tests that transactions from the Autonity operator to the contract are exempt
+// from the local price limit and from price based eviction, while the rest of
+// the operator transactions are handled like any other.
+func TestTransactionPoolPriorityLane(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	operatorKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	richKey, _ := crypto.GenerateKey()
+
+	chainConfig := *params.TestChainConfig
+	chainConfig.AutonityContractConfig = &params.AutonityContractGenesis{
+		Deployer: common.HexToAddress("0xdeadbeef"),
+		Operator: crypto.PubkeyToAddress(operatorKey.PublicKey),
+	}
+	contract, _ := chainConfig.AutonityContractConfig.GetContractAddress()
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 1
+	config.GlobalQueue = 1
+
+	pool := NewTxPool(config, &chainConfig, blockchain, NewTxSenderCacher())
+	defer pool.Stop()
+	pool.SetGasPrice(big.NewInt(2))
+
+	for _, key := range []*ecdsa.PrivateKey{operatorKey, otherKey, richKey} {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	signed := func(nonce uint64, to common.Address, price int64, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(0), 100000, big.NewInt(price), nil), types.HomesteadSigner{}, key)
+		return tx
+	}
+	// Only operator transactions to the contract may go below the price limit
+	if err := pool.AddRemote(signed(0, contract, 1, otherKey)); err != ErrUnderpriced {
+		t.Fatalf("third party transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := pool.AddRemote(signed(0, common.Address{}, 1, operatorKey)); err != ErrUnderpriced {
+		t.Fatalf("operator transfer error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := pool.AddRemote(signed(0, contract, 1, operatorKey)); err != nil {
+		t.Fatalf("failed to add operator transaction: %v", err)
+	}
+	if err := pool.AddRemote(signed(0, common.Address{}, 2, otherKey)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	// A better paying transaction must evict the remote one, not the operator one
+	if err := pool.AddRemote(signed(0, common.Address{}, 3, richKey)); err != nil {
+		t.Fatalf("failed to add well priced transaction: %v", err)
+	}
+	if pool.all.Get(signed(0, contract, 1, operatorKey).Hash()) == nil {
+		t.Errorf("operator transaction evicted")
+	}
+	if pool.all.Get(signed(0, common.Address{}, 2, otherKey).Hash()) != nil {
+		t.Errorf("underpriced remote transaction not evicted")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Disabling the lane makes the operator like any other account
+	config.NoPriority = true
+	plain := NewTxPool(config, &chainConfig, blockchain, NewTxSenderCacher())
+	defer plain.Stop()
+	plain.SetGasPrice(big.NewInt(2))
+
+	if err := plain.AddRemote(signed(0, contract, 1, operatorKey)); err != ErrUnderpriced {
+		t.Fatalf("operator transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+}
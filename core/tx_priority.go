@@ -0,0 +1,69 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/params"
+)
+
+// TxPriority identifies the transactions of the priority lane: the ones sent by
+// the Autonity contract operator to the contract itself. They carry governance
+// operations (minimum gas price updates, user removals, upgrades) which must
+// land even when the network is congested.
+type TxPriority struct {
+	operator common.Address
+	contract common.Address
+	signer   types.Signer
+}
+
+// NewTxPriority creates the priority lane classifier of a chain, or returns nil
+// if the chain has no Autonity contract to operate.
+func NewTxPriority(config *params.ChainConfig) *TxPriority {
+	genesis := config.AutonityContractConfig
+	if genesis == nil || genesis.Operator == (common.Address{}) {
+		return nil
+	}
+	contract, err := genesis.GetContractAddress()
+	if err != nil {
+		return nil
+	}
+	return &TxPriority{
+		operator: genesis.Operator,
+		contract: contract,
+		signer:   types.NewEIP155Signer(config.ChainID),
+	}
+}
+
+// Operator returns the account whose transactions may be prioritised.
+func (p *TxPriority) Operator() common.Address {
+	return p.operator
+}
+
+// Contains checks whether a transaction belongs to the priority lane. A nil
+// classifier doesn't prioritise anything.
+func (p *TxPriority) Contains(tx *types.Transaction) bool {
+	if p == nil {
+		return false
+	}
+	if to := tx.To(); to == nil || *to != p.contract {
+		return false
+	}
+	from, err := types.Sender(p.signer, tx)
+	return err == nil && from == p.operator
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/hexutil"
+)
+
+// Types of system calls.
+const (
+	SystemCallCall   = "CALL"
+	SystemCallCreate = "CREATE"
+)
+
+// SystemCall is a call the protocol made to the Autonity contract on behalf of
+// its deployer while processing a block, like the finalization of the block or
+// the upgrade of the contract. System calls are not transactions, they are
+// recorded by every node alongside the block they were made in.
+type SystemCall struct {
+	Type      string         // Type of the call, CALL or CREATE
+	Method    string         // Contract method called, empty for contract creations
+	From      common.Address // Deployer of the Autonity contract
+	To        common.Address // Autonity contract
+	Input     []byte
+	Output    []byte
+	GasUsed   uint64
+	Error     string // Execution error, empty if the call succeeded
+	Logs      []*Log
+	StateDiff []*AccountDiff // Accounts changed by the call, in the order they were touched
+
+	// Derived fields. These fields are filled in when reading the calls of a block.
+	Hash        common.Hash // Pseudo transaction hash the logs of the call are attributed to
+	BlockHash   common.Hash
+	BlockNumber uint64
+	Index       uint // Index of the call among the system calls of the block
+}
+
+// AccountDiff is the change a system call made to an account.
+type AccountDiff struct {
+	Address        common.Address
+	BalanceBefore  *big.Int
+	BalanceAfter   *big.Int
+	NonceBefore    uint64
+	NonceAfter     uint64
+	CodeHashBefore common.Hash
+	CodeHashAfter  common.Hash
+	Storage        []*StorageDiff // Storage slots changed, in the order they were written
+}
+
+// StorageDiff is the change a system call made to a storage slot.
+type StorageDiff struct {
+	Key    common.Hash
+	Before common.Hash
+	After  common.Hash
+}
+
+// SystemCallHash returns the pseudo transaction hash the logs of the index-th
+// system call of a block are attributed to.
+func SystemCallHash(number uint64, index int) common.Hash {
+	return rlpHash([]interface{}{"system call", number, uint64(index)})
+}
+
+// MarshalJSON marshals the system call as JSON, the way the RPC APIs report it.
+func (c *SystemCall) MarshalJSON() ([]byte, error) {
+	type SystemCall struct {
+		Hash        common.Hash    `json:"hash"`
+		BlockHash   common.Hash    `json:"blockHash"`
+		BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		Index       hexutil.Uint   `json:"index"`
+		Type        string         `json:"type"`
+		Method      string         `json:"method,omitempty"`
+		From        common.Address `json:"from"`
+		To          common.Address `json:"to"`
+		Input       hexutil.Bytes  `json:"input"`
+		Output      hexutil.Bytes  `json:"output"`
+		GasUsed     hexutil.Uint64 `json:"gasUsed"`
+		Error       string         `json:"error,omitempty"`
+		Logs        []*Log         `json:"logs"`
+		StateDiff   []*AccountDiff `json:"stateDiff"`
+	}
+	enc := SystemCall{
+		Hash:        c.Hash,
+		BlockHash:   c.BlockHash,
+		BlockNumber: hexutil.Uint64(c.BlockNumber),
+		Index:       hexutil.Uint(c.Index),
+		Type:        c.Type,
+		Method:      c.Method,
+		From:        c.From,
+		To:          c.To,
+		Input:       c.Input,
+		Output:      c.Output,
+		GasUsed:     hexutil.Uint64(c.GasUsed),
+		Error:       c.Error,
+		Logs:        c.Logs,
+		StateDiff:   c.StateDiff,
+	}
+	if enc.Logs == nil {
+		enc.Logs = []*Log{}
+	}
+	if enc.StateDiff == nil {
+		enc.StateDiff = []*AccountDiff{}
+	}
+	return json.Marshal(&enc)
+}
+
+// MarshalJSON marshals the account change as JSON, the way the RPC APIs report it.
+func (d *AccountDiff) MarshalJSON() ([]byte, error) {
+	type AccountDiff struct {
+		Address        common.Address `json:"address"`
+		BalanceBefore  *hexutil.Big   `json:"balanceBefore"`
+		BalanceAfter   *hexutil.Big   `json:"balanceAfter"`
+		NonceBefore    hexutil.Uint64 `json:"nonceBefore"`
+		NonceAfter     hexutil.Uint64 `json:"nonceAfter"`
+		CodeHashBefore common.Hash    `json:"codeHashBefore"`
+		CodeHashAfter  common.Hash    `json:"codeHashAfter"`
+		Storage        []*StorageDiff `json:"storage"`
+	}
+	enc := AccountDiff{
+		Address:        d.Address,
+		BalanceBefore:  (*hexutil.Big)(d.BalanceBefore),
+		BalanceAfter:   (*hexutil.Big)(d.BalanceAfter),
+		NonceBefore:    hexutil.Uint64(d.NonceBefore),
+		NonceAfter:     hexutil.Uint64(d.NonceAfter),
+		CodeHashBefore: d.CodeHashBefore,
+		CodeHashAfter:  d.CodeHashAfter,
+		Storage:        d.Storage,
+	}
+	if enc.Storage == nil {
+		enc.Storage = []*StorageDiff{}
+	}
+	return json.Marshal(&enc)
+}
+
+// MarshalJSON marshals the storage slot change as JSON, the way the RPC APIs
+// report it.
+func (d *StorageDiff) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Key    common.Hash `json:"key"`
+		Before common.Hash `json:"before"`
+		After  common.Hash `json:"after"`
+	}{d.Key, d.Before, d.After})
+}
@@ -58,6 +58,11 @@ type txdata struct {
 
 	// This is only used when marshaling to JSON.
 	Hash *common.Hash `json:"hash" rlp:"-"`
+
+	// Parties of a private transaction, whose payload is then the hash of the
+	// actual one held by the private transaction manager. Empty when public,
+	// leaving the encoding of public transactions unchanged.
+	PrivateFor []string `json:"privateFor,omitempty" rlp:"tail"`
 }
 
 type txdataMarshaling struct {
@@ -179,6 +184,25 @@ func (tx *Transaction) Value() *big.Int    { return new(big.Int).Set(tx.data.Amo
 func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool   { return true }
 
+// PrivateFor returns the parties of a private transaction, nil if public.
+func (tx *Transaction) PrivateFor() []string {
+	if len(tx.data.PrivateFor) == 0 {
+		return nil
+	}
+	return append([]string(nil), tx.data.PrivateFor...)
+}
+
+// IsPrivate returns whether the transaction payload is private to some parties.
+func (tx *Transaction) IsPrivate() bool { return len(tx.data.PrivateFor) > 0 }
+
+// WithPrivateFor returns a copy of an unsigned transaction, made private to
+// the given parties. Its payload must be the hash of the private one.
+func (tx *Transaction) WithPrivateFor(parties []string) *Transaction {
+	cpy := &Transaction{data: tx.data}
+	cpy.data.PrivateFor = append([]string(nil), parties...)
+	return cpy
+}
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *Transaction) To() *common.Address {
@@ -230,6 +254,13 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 
 	var err error
 	msg.from, err = Sender(s, tx)
+	if tx.IsPrivate() {
+		// Only the hash of the private payload is public, the transaction merely
+		// pays its fee and bumps the sender nonce on the public state by calling
+		// the sender account, which holds no code.
+		msg.to = &msg.from
+		msg.privateFor = tx.data.PrivateFor
+	}
 	return msg, err
 }
 
@@ -394,6 +425,7 @@ type Message struct {
 	gasPrice   *big.Int
 	data       []byte
 	checkNonce bool
+	privateFor []string
 }
 
 func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, checkNonce bool) Message {
@@ -417,3 +449,7 @@ func (m Message) Gas() uint64          { return m.gasLimit }
 func (m Message) Nonce() uint64        { return m.nonce }
 func (m Message) Data() []byte         { return m.data }
 func (m Message) CheckNonce() bool     { return m.checkNonce }
+
+// PrivateFor returns the parties of the private transaction the message was
+// made from, nil if public.
+func (m Message) PrivateFor() []string { return m.privateFor }
@@ -153,7 +153,7 @@ func (s EIP155Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
-	return rlpHash([]interface{}{
+	return rlpHash(withPrivateFor([]interface{}{
 		tx.data.AccountNonce,
 		tx.data.Price,
 		tx.data.GasLimit,
@@ -161,7 +161,7 @@ func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
 		tx.data.Amount,
 		tx.data.Payload,
 		s.chainId, uint(0), uint(0),
-	})
+	}, tx))
 }
 
 // HomesteadTransaction implements TransactionInterface using the
@@ -205,14 +205,24 @@ func (fs FrontierSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v *
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
-	return rlpHash([]interface{}{
+	return rlpHash(withPrivateFor([]interface{}{
 		tx.data.AccountNonce,
 		tx.data.Price,
 		tx.data.GasLimit,
 		tx.data.Recipient,
 		tx.data.Amount,
 		tx.data.Payload,
-	})
+	}, tx))
+}
+
+// withPrivateFor appends the parties of a private transaction to the fields it
+// signs, such that they can't be tampered with. Public transactions are left
+// untouched.
+func withPrivateFor(fields []interface{}, tx *Transaction) []interface{} {
+	if !tx.IsPrivate() {
+		return fields
+	}
+	return append(fields, tx.data.PrivateFor)
 }
 
 func (fs FrontierSigner) Sender(tx *Transaction) (common.Address, error) {
@@ -219,3 +219,67 @@ func TestTransactionJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestPrivateTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := NewEIP155Signer(common.Big1)
+
+	public := NewTransaction(0, common.Address{1}, common.Big0, 50000, common.Big2, common.Hash{0xaa}.Bytes())
+	tx, err := SignTx(public.WithPrivateFor([]string{"alice", "bob"}), signer, key)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	if !tx.IsPrivate() || public.IsPrivate() {
+		t.Fatalf("privacy mismatch: have %v/%v, want true/false", tx.IsPrivate(), public.IsPrivate())
+	}
+	// The parties are covered by the signature
+	if signer.Hash(tx) == signer.Hash(public) {
+		t.Error("signature hash doesn't cover the parties")
+	}
+	stripped := &Transaction{data: tx.data}
+	stripped.data.PrivateFor = nil
+	if from, _ := Sender(signer, stripped); from == crypto.PubkeyToAddress(key.PublicKey) {
+		t.Error("parties stripped without invalidating the signature")
+	}
+
+	// Messages only carry the public effect, a call to the sender itself
+	msg, err := tx.AsMessage(signer)
+	if err != nil {
+		t.Fatalf("failed to convert to message: %v", err)
+	}
+	if from := crypto.PubkeyToAddress(key.PublicKey); msg.To() == nil || *msg.To() != from || len(msg.PrivateFor()) != 2 || !bytes.Equal(msg.Data(), tx.Data()) {
+		t.Errorf("private message mismatch: to %v, parties %v", msg.To(), msg.PrivateFor())
+	}
+
+	// The parties survive the RLP and JSON round trips
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("rlp encoding failed: %v", err)
+	}
+	var decoded Transaction
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatalf("rlp decoding failed: %v", err)
+	}
+	if decoded.Hash() != tx.Hash() || len(decoded.PrivateFor()) != 2 {
+		t.Errorf("rlp round trip mismatch: have %v, want %v", decoded.PrivateFor(), tx.PrivateFor())
+	}
+	if from, err := Sender(signer, &decoded); err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("sender mismatch: have %x (%v), want %x", from, err, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("json encoding failed: %v", err)
+	}
+	var parsed Transaction
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("json decoding failed: %v", err)
+	}
+	if parsed.Hash() != tx.Hash() {
+		t.Errorf("json round trip mismatch: have %v, want %v", parsed.PrivateFor(), tx.PrivateFor())
+	}
+	// Public transactions keep their encoding
+	enc, _ = rlp.EncodeToBytes(rightvrsTx)
+	if !bytes.Equal(enc, common.FromHex("f86103018207d094b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a8255441ca098ff921201554726367d2be8c804a7ff89ccf285ebc57dff8ae4c44b9c19ac4aa08887321be575c8095f789dd4c743dfe42c1820f9231f98a962b210e3ac2452a3")) {
+		t.Errorf("public encoding changed: %x", enc)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/hexutil"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/rpc"
+)
+
+// PublicAutonityAPI provides subscriptions to the changes canonical blocks make
+// to the Autonity contract, so that services don't have to parse its logs.
+type PublicAutonityAPI struct {
+	e *Ethereum
+}
+
+// NewPublicAutonityAPI creates a new Autonity contract subscription API.
+func NewPublicAutonityAPI(e *Ethereum) *PublicAutonityAPI {
+	return &PublicAutonityAPI{e}
+}
+
+// committeeChange is the notification sent for a CommitteeEvent.
+type committeeChange struct {
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	Committee   types.Committee `json:"committee"`
+}
+
+// minGasPriceChange is the notification sent for a MinGasPriceEvent.
+type minGasPriceChange struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Price       *hexutil.Big   `json:"price"`
+}
+
+// stakeChange is the notification sent for a StakeEvent.
+type stakeChange struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Account     common.Address `json:"account"`
+	Stake       *hexutil.Big   `json:"stake"` // null if the user was removed
+}
+
+// contractUpgrade is the notification sent for a ContractUpgradeEvent.
+type contractUpgrade struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Version     string         `json:"version"`
+}
+
+// Committee sends a notification each time a canonical block changes the
+// committee, with the committee after the block.
+func (api *PublicAutonityAPI) Committee(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.CommitteeEvent)
+		eventsSub := api.e.blockchain.SubscribeCommitteeEvent(events)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, &committeeChange{
+					BlockNumber: hexutil.Uint64(ev.Block.NumberU64()),
+					BlockHash:   ev.Block.Hash(),
+					Committee:   ev.Committee,
+				})
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// MinGasPrice sends a notification each time a canonical block changes the
+// minimum gas price.
+func (api *PublicAutonityAPI) MinGasPrice(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.MinGasPriceEvent)
+		eventsSub := api.e.blockchain.SubscribeMinGasPriceEvent(events)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, &minGasPriceChange{
+					BlockNumber: hexutil.Uint64(ev.Block.NumberU64()),
+					BlockHash:   ev.Block.Hash(),
+					Price:       (*hexutil.Big)(ev.Price),
+				})
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// Stake sends a notification each time a canonical block changes the stake of
+// a user, with the stake after the block.
+func (api *PublicAutonityAPI) Stake(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.StakeEvent)
+		eventsSub := api.e.blockchain.SubscribeStakeEvent(events)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, &stakeChange{
+					BlockNumber: hexutil.Uint64(ev.Block.NumberU64()),
+					BlockHash:   ev.Block.Hash(),
+					Account:     ev.Account,
+					Stake:       (*hexutil.Big)(ev.Stake),
+				})
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// ContractUpgrade sends a notification each time a canonical block upgrades the
+// Autonity contract.
+func (api *PublicAutonityAPI) ContractUpgrade(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.ContractUpgradeEvent)
+		eventsSub := api.e.blockchain.SubscribeContractUpgradeEvent(events)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, &contractUpgrade{
+					BlockNumber: hexutil.Uint64(ev.Block.NumberU64()),
+					BlockHash:   ev.Block.Hash(),
+					Version:     ev.Version,
+				})
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
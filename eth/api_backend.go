@@ -36,6 +36,7 @@ import (
 	"github.com/clearmatics/autonity/ethdb"
 	"github.com/clearmatics/autonity/event"
 	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/private"
 	"github.com/clearmatics/autonity/rpc"
 )
 
@@ -66,6 +67,13 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumb
 		block := b.eth.miner.PendingBlock()
 		return block.Header(), nil
 	}
+	// Committed BFT blocks can't be reverted, so the head is final
+	if number.IsFinalized() {
+		if b.ChainConfig().Tendermint == nil {
+			return nil, core.ErrNoFinality
+		}
+		return b.eth.blockchain.CurrentBlock().Header(), nil
+	}
 	// Otherwise resolve and return the block
 	if number == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock().Header(), nil
@@ -100,6 +108,13 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 		block := b.eth.miner.PendingBlock()
 		return block, nil
 	}
+	// Committed BFT blocks can't be reverted, so the head is final
+	if number.IsFinalized() {
+		if b.ChainConfig().Tendermint == nil {
+			return nil, core.ErrNoFinality
+		}
+		return b.eth.blockchain.CurrentBlock(), nil
+	}
 	// Otherwise resolve and return the block
 	if number == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock(), nil
@@ -187,6 +202,10 @@ func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*typ
 	return logs, nil
 }
 
+func (b *EthAPIBackend) GetSystemCalls(ctx context.Context, hash common.Hash) ([]*types.SystemCall, error) {
+	return b.eth.blockchain.GetSystemCallsByHash(hash), nil
+}
+
 func (b *EthAPIBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.eth.blockchain.GetTdByHash(blockHash)
 }
@@ -300,6 +319,30 @@ func (b *EthAPIBackend) RPCGasCap() *big.Int {
 	return b.eth.config.RPCGasCap
 }
 
+func (b *EthAPIBackend) PrivateManager() private.Manager {
+	return b.eth.blockchain.PrivateManager()
+}
+
+func (b *EthAPIBackend) PrivateStateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok && blockNr == rpc.PendingBlockNumber {
+		// Private transactions are only executed once sealed, use the latest state
+		blockNrOrHash = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, errors.New("header not found")
+	}
+	stateDb, err := b.eth.blockchain.PrivateStateAt(header.Hash())
+	return stateDb, header, err
+}
+
+func (b *EthAPIBackend) GetPrivateReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return b.eth.blockchain.GetPrivateReceipt(txHash), nil
+}
+
 func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	sections, _, _ := b.eth.bloomIndexer.Sections()
 	return params.BloomBitsBlocks, sections
@@ -69,10 +69,12 @@ type StdTraceConfig struct {
 	TxHash common.Hash
 }
 
-// txTraceResult is the result of a single transaction trace.
+// txTraceResult is the result of a single transaction trace, or the record of a
+// system call made after the transactions of a block.
 type txTraceResult struct {
-	Result interface{} `json:"result,omitempty"` // Trace results produced by the tracer
-	Error  string      `json:"error,omitempty"`  // Trace failure produced by the tracer
+	Result     interface{}       `json:"result,omitempty"`     // Trace results produced by the tracer
+	Error      string            `json:"error,omitempty"`      // Trace failure produced by the tracer
+	SystemCall *types.SystemCall `json:"systemCall,omitempty"` // System call recorded by the node
 }
 
 // blockTraceTask represents a single block trace task when an entire chain is
@@ -218,6 +220,8 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 					task.statedb.Finalise(api.eth.blockchain.Config().IsEIP158(task.block.Number()))
 					task.results[i] = &txTraceResult{Result: res}
 				}
+				task.results = append(task.results, api.systemCallResults(task.block)...)
+
 				// Stream the result back to the user or abort on teardown
 				select {
 				case results <- task:
@@ -517,7 +521,17 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 	if failed != nil {
 		return nil, failed
 	}
-	return results, nil
+	return append(results, api.systemCallResults(block)...), nil
+}
+
+// systemCallResults returns the system calls recorded for a block, to report them
+// after the traces of its transactions.
+func (api *PrivateDebugAPI) systemCallResults(block *types.Block) []*txTraceResult {
+	var results []*txTraceResult
+	for _, call := range api.eth.blockchain.GetSystemCallsByHash(block.Hash()) {
+		results = append(results, &txTraceResult{SystemCall: call})
+	}
+	return results
 }
 
 // standardTraceBlockToFile configures a new tracer which uses standard JSON output,
@@ -719,50 +733,138 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 	return api.traceTx(ctx, msg, vmctx, statedb, config)
 }
 
+// TraceFinalize returns the trace of the finalize call the consensus engine makes
+// to the Autonity contract at the end of a block, which distributes the block
+// rewards and may trigger a contract upgrade.
+func (api *PrivateDebugAPI) TraceFinalize(ctx context.Context, hash common.Hash, config *TraceConfig) (interface{}, error) {
+	contract := api.eth.blockchain.GetAutonityContract()
+	if contract == nil {
+		return nil, errors.New("autonity contract not available")
+	}
+	block := api.eth.blockchain.GetBlockByHash(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", hash)
+	}
+	if block.NumberU64() <= 1 {
+		return nil, fmt.Errorf("block %#x does not finalize the autonity contract", hash)
+	}
+	parent := api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, err := api.computeStateDB(parent, reexec)
+	if err != nil {
+		return nil, err
+	}
+	// Apply all the transactions, gathering the gas they used for the block fees
+	var (
+		signer   = types.MakeSigner(api.eth.blockchain.Config(), block.Number())
+		receipts = make(types.Receipts, 0, len(block.Transactions()))
+	)
+	for _, tx := range block.Transactions() {
+		msg, _ := tx.AsMessage(signer)
+		vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+
+		vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{})
+		_, gas, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+		if err != nil {
+			return nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
+		}
+		receipts = append(receipts, &types.Receipt{GasUsed: gas})
+
+		// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+		statedb.Finalise(vmenv.ChainConfig().IsEIP158(block.Number()))
+	}
+	// Trace the finalize call on top of the block transactions and return
+	tracer, cancel, err := api.newTracer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	ret, gas, failed, err := contract.TraceFinalize(block.Transactions(), receipts, block.Header(), statedb, tracer)
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	return formatTrace(tracer, ret, gas, failed)
+}
+
+// TraceSystemCalls returns the calls the protocol made to the Autonity contract
+// on behalf of its deployer while processing a block, with the data they were
+// called with and returned, the gas they used, their logs and the state changes
+// they made.
+func (api *PrivateDebugAPI) TraceSystemCalls(ctx context.Context, hash common.Hash) ([]*types.SystemCall, error) {
+	if header := api.eth.blockchain.GetHeaderByHash(hash); header == nil {
+		return nil, fmt.Errorf("block %#x not found", hash)
+	}
+	calls := api.eth.blockchain.GetSystemCallsByHash(hash)
+	if calls == nil {
+		calls = []*types.SystemCall{}
+	}
+	return calls, nil
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
 func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, vmctx vm.Context, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
-	// Assemble the structured logger or the JavaScript tracer
-	var (
-		tracer vm.Tracer
-		err    error
-	)
+	tracer, cancel, err := api.newTracer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	// Run the transaction with tracing enabled.
+	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{Debug: true, Tracer: tracer})
+
+	ret, gas, failed, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()))
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	return formatTrace(tracer, ret, gas, failed)
+}
+
+// newTracer assembles the structured logger, or the native or JavaScript tracer
+// requested by the configuration. The returned function releases the resources
+// of the tracer timeout.
+func (api *PrivateDebugAPI) newTracer(ctx context.Context, config *TraceConfig) (vm.Tracer, context.CancelFunc, error) {
 	switch {
 	case config != nil && config.Tracer != nil:
 		// Define a meaningful timeout of a single transaction trace
 		timeout := defaultTraceTimeout
 		if config.Timeout != nil {
+			var err error
 			if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
-			return nil, err
+		// Constuct the tracer to execute with
+		tracer, err := tracers.NewTracer(*config.Tracer)
+		if err != nil {
+			return nil, nil, err
 		}
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			tracer.Stop(errors.New("execution timeout"))
 		}()
-		defer cancel()
+		return tracer, cancel, nil
 
 	case config == nil:
-		tracer = vm.NewStructLogger(nil)
+		return vm.NewStructLogger(nil), func() {}, nil
 
 	default:
-		tracer = vm.NewStructLogger(config.LogConfig)
+		return vm.NewStructLogger(config.LogConfig), func() {}, nil
 	}
-	// Run the transaction with tracing enabled.
-	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{Debug: true, Tracer: tracer})
+}
 
-	ret, gas, failed, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()))
-	if err != nil {
-		return nil, fmt.Errorf("tracing failed: %v", err)
-	}
-	// Depending on the tracer type, format and return the output
+// formatTrace returns the output of a tracer, depending on its type.
+func formatTrace(tracer vm.Tracer, ret []byte, gas uint64, failed bool) (interface{}, error) {
 	switch tracer := tracer.(type) {
 	case *vm.StructLogger:
 		return &ethapi.ExecutionResult{
@@ -772,7 +874,7 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
 		}, nil
 
-	case *tracers.Tracer:
+	case tracers.ResultTracer:
 		return tracer.GetResult()
 
 	default:
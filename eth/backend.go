@@ -52,6 +52,7 @@ import (
 	"github.com/clearmatics/autonity/p2p"
 	"github.com/clearmatics/autonity/p2p/enr"
 	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/private"
 	"github.com/clearmatics/autonity/rlp"
 	"github.com/clearmatics/autonity/rpc"
 )
@@ -159,10 +160,26 @@ func New(ctx *node.ServiceContext, config *Config, cons func(basic consensus.Eng
 			TrieDirtyLimit:      config.TrieDirtyCache,
 			TrieDirtyDisabled:   config.NoPruning,
 			TrieTimeLimit:       config.TrieTimeout,
+			StateRetention:      config.StateRetention,
+			PruneBloomSize:      config.PruneBloomSize,
+			Snapshot:            !config.NoSnapshot,
 		}
 	)
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
+	if chainConfig.Tendermint == nil && (config.SyncMode == downloader.BFTSync || config.BFTCheckpoint != nil) {
+		return nil, core.ErrNoFinality
+	}
+	if cp := config.BFTCheckpoint; cp != nil {
+		if cp.Header == nil {
+			return nil, errors.New("invalid BFT checkpoint: missing header")
+		}
+		// The chain is synced forward from the checkpoint, which only BFT sync can do
+		if config.SyncMode != downloader.BFTSync {
+			return nil, fmt.Errorf("BFT checkpoint requires %v sync, have %v", downloader.BFTSync, config.SyncMode)
+		}
+	}
+
 	consEngine := CreateConsensusEngine(ctx, chainConfig, config, config.Miner.Notify, config.Miner.Noverify, chainDb, &vmConfig, backs)
 	if cons != nil {
 		consEngine = cons(consEngine)
@@ -216,6 +233,20 @@ func New(ctx *node.ServiceContext, config *Config, cons func(basic consensus.Eng
 		eth.blockchain.SetHead(compat.RewindTo)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
+	// Headers are final under BFT consensus, so the checkpoint trusted by the
+	// operator anchors the chain, which is synced forward from it.
+	if cp := config.BFTCheckpoint; cp != nil {
+		if err := eth.blockchain.SeedBFTCheckpoint(cp.Header); err != nil {
+			return nil, err
+		}
+	}
+	if config.PrivateManager != "" {
+		manager, err := private.NewLocalManager(ctx.ResolvePath(config.PrivateManager), config.PrivateIdentity)
+		if err != nil {
+			return nil, err
+		}
+		eth.blockchain.SetPrivateManager(manager)
+	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
 	if config.TxPool.Journal != "" {
@@ -229,7 +260,7 @@ func New(ctx *node.ServiceContext, config *Config, cons func(basic consensus.Eng
 	if checkpoint == nil {
 		checkpoint = params.TrustedCheckpoints[genesisHash]
 	}
-	if eth.protocolManager, err = NewProtocolManager(chainConfig, checkpoint, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb, cacheLimit, config.Whitelist); err != nil {
+	if eth.protocolManager, err = NewProtocolManager(chainConfig, checkpoint, config.BFTCheckpoint, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb, cacheLimit, config.Whitelist); err != nil {
 		return nil, err
 	}
 	eth.miner = miner.New(eth, &config.Miner, chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock)
@@ -342,6 +373,11 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   filters.NewPublicFilterAPI(s.APIBackend, false),
 			Public:    true,
+		}, {
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicAutonityAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "admin",
 			Version:   "1.0",
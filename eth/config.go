@@ -28,6 +28,7 @@ import (
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/consensus/ethash"
 	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/eth/downloader"
 	"github.com/clearmatics/autonity/eth/gasprice"
 	"github.com/clearmatics/autonity/miner"
@@ -51,11 +52,13 @@ var DefaultConfig = Config{
 	TrieCleanCache:     256,
 	TrieDirtyCache:     256,
 	TrieTimeout:        60 * time.Minute,
+	PruneBloomSize:     256,
 	Miner: miner.Config{
-		GasFloor: 100000000,
-		GasCeil:  100000000,
-		GasPrice: big.NewInt(params.GWei),
-		Recommit: 3 * time.Second,
+		GasFloor:    100000000,
+		GasCeil:     100000000,
+		GasPrice:    big.NewInt(params.GWei),
+		Recommit:    3 * time.Second,
+		PriorityGas: 10000000,
 	},
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -98,10 +101,15 @@ type Config struct {
 
 	NoPruning  bool // Whether to disable pruning and flush everything to disk
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
+	NoSnapshot bool // Whether to disable the flat state snapshot and snapshot sync serving
 
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
+	// BFTCheckpoint is a committed header the chain is seeded with and synced
+	// forward from. Peers must serve it, like the hardcoded checkpoint.
+	BFTCheckpoint *BFTCheckpoint `toml:"-"`
+
 	// Light client options
 	LightServ    int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightIngress int `toml:",omitempty"` // Incoming bandwidth limit for light servers
@@ -123,6 +131,10 @@ type Config struct {
 	TrieDirtyCache int
 	TrieTimeout    time.Duration
 
+	// State pruning options, relying on BFT finality
+	StateRetention uint64 `toml:",omitempty"` // Number of final blocks whose state is kept (0 = disabled)
+	PruneBloomSize uint64 // Memory allowance (MB) of the reachability bloom
+
 	// Mining options
 	Miner miner.Config
 
@@ -159,9 +171,24 @@ type Config struct {
 	// CheckpointOracle is the configuration for checkpoint oracle.
 	CheckpointOracle *params.CheckpointOracleConfig `toml:",omitempty"`
 
+	// PrivateManager is the directory through which the payloads of private
+	// transactions are exchanged with their parties, empty to disable them.
+	PrivateManager string `toml:",omitempty"`
+
+	// PrivateIdentity is the party the node acts for in private transactions.
+	PrivateIdentity string `toml:",omitempty"`
+
 	// Istanbul block override (TODO: remove after the fork)
 	OverrideIstanbul *big.Int
 
 	// MuirGlacier block override (TODO: remove after the fork)
 	OverrideMuirGlacier *big.Int
 }
+
+// BFTCheckpoint is a committed header trusted by the operator. It isn't
+// verified, being the trust anchor the following headers are verified from
+// through the committee it carries. Peers which don't serve it are rejected
+// while syncing.
+type BFTCheckpoint struct {
+	Header *types.Header `json:"header"`
+}
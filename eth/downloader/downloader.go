@@ -69,6 +69,8 @@ var (
 	fsHeaderForceVerify    = 24              // Number of headers to verify before and after the pivot to accept it
 	fsHeaderContCheck      = 3 * time.Second // Time interval to check for header continuations during state download
 	fsMinFullBlocks        = 64              // Number of blocks to retrieve fully even in fast sync
+
+	bftMinFullBlocks = 1 // Number of blocks to retrieve fully in BFT sync, any committed header is a safe pivot
 )
 
 var (
@@ -90,6 +92,7 @@ var (
 	errCanceled                = errors.New("syncing canceled (requested)")
 	errNoSyncActive            = errors.New("no sync active")
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version (need version >= 62)")
+	errBelowCheckpoint         = errors.New("remote chain doesn't extend beyond the BFT checkpoint")
 )
 
 type Downloader struct {
@@ -101,6 +104,8 @@ type Downloader struct {
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
 	mode SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
+	bft  bool           // Whether the fast sync relies on BFT finality (per sync cycle)
+	seed *types.Header  // BFT checkpoint the chain was seeded with, nothing exists locally below it (per sync cycle)
 	mux  *event.TypeMux // Event multiplexer to announce sync operation events
 
 	checkpoint uint64   // Checkpoint block number to enforce head against (e.g. fast sync)
@@ -142,6 +147,7 @@ type Downloader struct {
 	stateSyncStart chan *stateSync
 	trackStateReq  chan *stateReq
 	stateCh        chan dataPack // [eth/63] Channel receiving inbound node state data
+	rangeCh        chan dataPack // [eth/65] Channel receiving inbound snapshot ranges
 
 	// Cancellation and termination
 	cancelPeer string         // Identifier of the peer currently being used as the master (cancel on drop)
@@ -234,6 +240,7 @@ func New(checkpoint uint64, stateDb ethdb.Database, stateBloom *trie.SyncBloom,
 		headerProcCh:   make(chan []*types.Header, 1),
 		quitCh:         make(chan struct{}),
 		stateCh:        make(chan dataPack),
+		rangeCh:        make(chan dataPack),
 		stateSyncStart: make(chan *stateSync),
 		syncStatsState: stateSyncStats{
 			processed: rawdb.ReadFastTrieProgress(stateDb),
@@ -323,7 +330,7 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 	err := d.synchronise(id, head, td, mode)
 	switch err {
 	case nil:
-	case errBusy, errCanceled:
+	case errBusy, errCanceled, errBelowCheckpoint:
 
 	case errTimeout, errBadPeer, errStallingPeer, errUnsyncedPeer,
 		errEmptyHeaderSet, errPeersUnavailable, errTooOld,
@@ -400,7 +407,17 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 
 	defer d.Cancel() // No matter what, we can't leave the cancel channel open
 
-	// Set the requested sync mode, unless it's forbidden
+	// Set the requested sync mode, unless it's forbidden. BFT sync is a fast
+	// sync which doesn't need to cater for reorgs, every committed header
+	// being final.
+	d.bft = mode == BFTSync
+	d.seed = nil
+	if d.bft {
+		mode = FastSync
+		if hash := rawdb.ReadBFTCheckpointHash(d.stateDB); hash != (common.Hash{}) {
+			d.seed = d.lightchain.GetHeaderByHash(hash)
+		}
+	}
 	d.mode = mode
 
 	// Retrieve the origin peer and initiate the downloading process
@@ -454,13 +471,10 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	// Ensure our origin point is below any fast sync pivot point
 	pivot := uint64(0)
 	if d.mode == FastSync {
-		if height <= uint64(fsMinFullBlocks) {
+		if pivot = d.pivot(height); pivot == 0 {
 			origin = 0
-		} else {
-			pivot = height - uint64(fsMinFullBlocks)
-			if pivot <= origin {
-				origin = pivot - 1
-			}
+		} else if pivot <= origin {
+			origin = pivot - 1
 		}
 	}
 	d.committed = 1
@@ -482,7 +496,12 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		// The peer would start to feed us valid blocks until head, resulting in all of
 		// the blocks might be written into the ancient store. A following mini-reorg
 		// could cause issues.
-		if d.checkpoint != 0 && d.checkpoint > maxForkAncestry+1 {
+		//
+		// A chain seeded from a BFT checkpoint has no history below it, which the
+		// ancient store can't cope with, so everything is kept in the active store.
+		if d.seed != nil {
+			atomic.StoreUint64(&d.ancientLimit, 0)
+		} else if d.checkpoint != 0 && d.checkpoint > maxForkAncestry+1 {
 			atomic.StoreUint64(&d.ancientLimit, d.checkpoint)
 		} else if height > maxForkAncestry+1 {
 			atomic.StoreUint64(&d.ancientLimit, height-maxForkAncestry-1)
@@ -497,7 +516,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 			log.Debug("Enabling direct-ancient mode", "ancient", atomic.LoadUint64(&d.ancientLimit))
 		}
 		// Rewind the ancient store and blockchain if reorg happens.
-		if origin+1 < frozen {
+		if origin+1 < frozen && !d.bft {
 			var hashes []common.Hash
 			for i := origin + 1; i < d.lightchain.CurrentHeader().Number.Uint64(); i++ {
 				hashes = append(hashes, rawdb.ReadCanonicalHash(d.stateDB, i))
@@ -715,6 +734,22 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 	}
 	p.log.Debug("Looking for common ancestor", "local", localHeight, "remote", remoteHeight)
 
+	// Committed BFT headers can't be reorganised, so our head is the ancestor.
+	// If the chain was seeded from a checkpoint not synced past yet, that's our
+	// head, nothing below it being available to search.
+	if d.bft {
+		local := d.blockchain.CurrentFastBlock().Header()
+		if d.seed != nil && d.seed.Number.Uint64() > localHeight {
+			if remoteHeight <= d.seed.Number.Uint64() {
+				return 0, errBelowCheckpoint
+			}
+			return d.findFinalAncestor(p, d.seed)
+		}
+		if localHeight <= remoteHeight {
+			return d.findFinalAncestor(p, local)
+		}
+	}
+
 	// Recap floor value for binary search
 	if localHeight >= maxForkAncestry {
 		// We're above the max reorg threshold, find the earliest fork point
@@ -900,6 +935,49 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 	return start, nil
 }
 
+// findFinalAncestor checks that the remote peer agrees with the given local
+// head, which is then the common ancestor as final chains can't fork.
+func (d *Downloader) findFinalAncestor(p *peerConnection, local *types.Header) (uint64, error) {
+	height := local.Number.Uint64()
+	go p.peer.RequestHeadersByNumber(height, 1, 0, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return 0, errCanceled
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			// Make sure the peer actually gave something valid
+			headers := packet.(*headerPack).headers
+			if len(headers) != 1 {
+				p.log.Debug("Multiple headers for single request", "headers", len(headers))
+				return 0, errBadPeer
+			}
+			if hash := headers[0].Hash(); hash != local.Hash() {
+				p.log.Warn("Remote chain conflicts with final head", "number", height, "hash", hash, "local", local.Hash())
+				return 0, errInvalidAncestor
+			}
+			p.log.Debug("Found common ancestor", "number", height, "hash", local.Hash())
+			return height, nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for ancestor header timed out", "elapsed", ttl)
+			return 0, errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
+
 // fetchHeaders keeps retrieving headers concurrently from the number
 // requested, until no more are returned, potentially throttling on the way. To
 // facilitate concurrency but still protect against malicious nodes sending bad
@@ -1461,21 +1539,24 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 							unknown = append(unknown, header)
 						}
 					}
-					// If we're importing pure headers, verify based on their recentness
+					// If we're importing pure headers, verify based on their recentness.
+					// BFT headers are all verified, each of them being final.
 					frequency := fsHeaderCheckFrequency
-					if chunk[len(chunk)-1].Number.Uint64()+uint64(fsHeaderForceVerify) > pivot {
+					if d.bft || chunk[len(chunk)-1].Number.Uint64()+uint64(fsHeaderForceVerify) > pivot {
 						frequency = 1
 					}
 					if n, err := d.lightchain.InsertHeaderChain(chunk, frequency); err != nil {
 						// If some headers were inserted, add them too to the rollback list
-						if n > 0 {
+						if n > 0 && !d.bft {
 							rollback = append(rollback, chunk[:n]...)
 						}
 						log.Debug("Invalid header encountered", "number", chunk[n].Number, "hash", chunk[n].Hash(), "err", err)
 						return errInvalidChain
 					}
 					// All verifications passed, store newly found uncertain headers
-					rollback = append(rollback, unknown...)
+					if !d.bft {
+						rollback = append(rollback, unknown...)
+					}
 					if len(rollback) > fsHeaderSafetyNet {
 						rollback = append(rollback[:0], rollback[len(rollback)-fsHeaderSafetyNet:]...)
 					}
@@ -1584,10 +1665,7 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 	go closeOnErr(sync)
 	// Figure out the ideal pivot block. Note, that this goalpost may move if the
 	// sync takes long enough for the chain head to move significantly.
-	pivot := uint64(0)
-	if height := latest.Number.Uint64(); height > uint64(fsMinFullBlocks) {
-		pivot = height - uint64(fsMinFullBlocks)
-	}
+	pivot := d.pivot(latest.Number.Uint64())
 	// To cater for moving pivot points, track the pivot block and subsequently
 	// accumulated download results separately.
 	var (
@@ -1617,8 +1695,9 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 		if oldPivot != nil {
 			results = append(append([]*fetchResult{oldPivot}, oldTail...), results...)
 		}
-		// Split around the pivot block and process the two sides via fast/full sync
-		if atomic.LoadInt32(&d.committed) == 0 {
+		// Split around the pivot block and process the two sides via fast/full sync.
+		// A final pivot never needs to move.
+		if atomic.LoadInt32(&d.committed) == 0 && !d.bft {
 			latest = results[len(results)-1].Header
 			if height := latest.Number.Uint64(); height > pivot+2*uint64(fsMinFullBlocks) {
 				log.Warn("Pivot became stale, moving", "old", pivot, "new", height-uint64(fsMinFullBlocks))
@@ -1662,6 +1741,23 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 	}
 }
 
+// pivot returns the block whose state is downloaded by fast sync for a given
+// chain height, or 0 if the chain is too short to fast sync. The pivot is kept
+// above a BFT checkpoint the chain was seeded with, whose ancestors are missing.
+func (d *Downloader) pivot(height uint64) uint64 {
+	minFullBlocks := uint64(fsMinFullBlocks)
+	if d.bft {
+		minFullBlocks = uint64(bftMinFullBlocks)
+	}
+	if d.seed != nil && height > d.seed.Number.Uint64() && height <= d.seed.Number.Uint64()+minFullBlocks {
+		return d.seed.Number.Uint64() + 1
+	}
+	if height <= minFullBlocks {
+		return 0
+	}
+	return height - minFullBlocks
+}
+
 func splitAroundPivot(pivot uint64, results []*fetchResult) (p *fetchResult, before, after []*fetchResult) {
 	for _, result := range results {
 		num := result.Header.Number.Uint64()
@@ -1755,6 +1851,18 @@ func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
 	return d.deliver(id, d.stateCh, &statePack{id, data}, stateInMeter, stateDropMeter)
 }
 
+// DeliverAccountRange injects a new snapshot account range received from a
+// remote node.
+func (d *Downloader) DeliverAccountRange(id string, hashes []common.Hash, values [][]byte, proof [][]byte) (err error) {
+	return d.deliver(id, d.rangeCh, &rangePack{id, hashes, values, proof}, rangeInMeter, rangeDropMeter)
+}
+
+// DeliverStorageRange injects a new snapshot storage range received from a
+// remote node.
+func (d *Downloader) DeliverStorageRange(id string, hashes []common.Hash, values [][]byte, proof [][]byte) (err error) {
+	return d.deliver(id, d.rangeCh, &rangePack{id, hashes, values, proof}, rangeInMeter, rangeDropMeter)
+}
+
 // deliver injects a new batch of data received from a remote node.
 func (d *Downloader) deliver(id string, destCh chan dataPack, packet dataPack, inMeter, dropMeter metrics.Meter) (err error) {
 	// Update the delivery metrics for both good and failed deliveries
@@ -300,7 +300,8 @@ func (dl *downloadTester) InsertReceiptChain(blocks types.Blocks, receipts []typ
 			return i, errors.New("unknown owner")
 		}
 		if _, ok := dl.ancientBlocks[blocks[i].ParentHash()]; !ok {
-			if _, ok := dl.ownBlocks[blocks[i].ParentHash()]; !ok {
+			// A BFT checkpoint the chain was seeded with is a header only
+			if _, ok := dl.ownBlocks[blocks[i].ParentHash()]; !ok && blocks[i].ParentHash() != rawdb.ReadBFTCheckpointHash(dl.stateDb) {
 				return i, errors.New("unknown parent")
 			}
 		}
@@ -440,6 +441,20 @@ func (dlp *downloadTesterPeer) RequestNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// RequestAccountRange answers snapshot account range requests with empty ranges,
+// the tester state not being snapshotted.
+func (dlp *downloadTesterPeer) RequestAccountRange(root common.Hash, origin common.Hash, bytes uint64) error {
+	go dlp.dl.downloader.DeliverAccountRange(dlp.id, nil, nil, nil)
+	return nil
+}
+
+// RequestStorageRange answers snapshot storage range requests with empty ranges,
+// the tester state not being snapshotted.
+func (dlp *downloadTesterPeer) RequestStorageRange(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error {
+	go dlp.dl.downloader.DeliverStorageRange(dlp.id, nil, nil, nil)
+	return nil
+}
+
 // assertOwnChain checks if the local chain contains the correct number of items
 // of the various chain components.
 func assertOwnChain(t *testing.T, tester *downloadTester, length int) {
@@ -487,6 +502,7 @@ func TestCanonicalSynchronisation63Fast(t *testing.T)  { testCanonicalSynchronis
 func TestCanonicalSynchronisation64Full(t *testing.T)  { testCanonicalSynchronisation(t, 64, FullSync) }
 func TestCanonicalSynchronisation64Fast(t *testing.T)  { testCanonicalSynchronisation(t, 64, FastSync) }
 func TestCanonicalSynchronisation64Light(t *testing.T) { testCanonicalSynchronisation(t, 64, LightSync) }
+func TestCanonicalSynchronisation64BFT(t *testing.T)   { testCanonicalSynchronisation(t, 64, BFTSync) }
 
 func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -686,6 +702,69 @@ func testBoundedForkedSync(t *testing.T, protocol int, mode SyncMode) {
 	}
 }
 
+// Tests that BFT sync refuses to reorg away from already synced blocks, since
+// every committed block is final and a diverging peer can only be lying.
+func TestBFTForkedSync64(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chainA := testChainForkLightA.shorten(testChainBase.len() + 80)
+	chainB := testChainForkLightB.shorten(testChainBase.len() + 80)
+	tester.newPeer("fork A", 64, chainA)
+	tester.newPeer("fork B", 64, chainB)
+
+	if err := tester.sync("fork A", nil, BFTSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chainA.len())
+
+	if err := tester.sync("fork B", nil, BFTSync); err != errInvalidAncestor {
+		t.Fatalf("sync failure mismatch: have %v, want %v", err, errInvalidAncestor)
+	}
+	assertOwnChain(t, tester, chainA.len())
+}
+
+// Tests that BFT sync of a chain seeded from a checkpoint starts right after it,
+// never reaching below, and that peers not extending it are no sync targets.
+func TestBFTCheckpointSync64(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheItems - 15)
+	seed := chain.headerm[chain.chain[chain.len()/2]]
+
+	tester.lock.Lock()
+	tester.ownHashes = append(tester.ownHashes, seed.Hash())
+	tester.ownHeaders[seed.Hash()] = seed
+	tester.ownChainTd[seed.Hash()] = chain.td(seed.Hash())
+	tester.lock.Unlock()
+	rawdb.WriteBFTCheckpointHash(tester.stateDb, seed.Hash())
+
+	tester.newPeer("short", 64, chain.shorten(int(seed.Number.Uint64())+1))
+	if err := tester.sync("short", nil, BFTSync); err != errBelowCheckpoint {
+		t.Fatalf("sync failure mismatch: have %v, want %v", err, errBelowCheckpoint)
+	}
+	tester.newPeer("peer", 64, chain)
+	tester.downloader.syncInitHook = func(origin, latest uint64) {
+		if origin != seed.Number.Uint64() {
+			t.Errorf("origin mismatch: have %d, want %d", origin, seed.Number)
+		}
+	}
+	if err := tester.sync("peer", nil, BFTSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	if head := tester.CurrentBlock().NumberU64(); head != uint64(chain.len()-1) {
+		t.Fatalf("head block mismatch: have %d, want %d", head, chain.len()-1)
+	}
+	if header := tester.GetHeaderByHash(chain.chain[1]); header != nil {
+		t.Fatalf("header #1 retrieved below the checkpoint")
+	}
+}
+
 // Tests that chain forks are contained within a certain interval of the current
 // chain head for short but heavy forks too. These are a bit special because they
 // take different ancestor lookup paths.
@@ -796,6 +875,7 @@ func TestMultiSynchronisation63Fast(t *testing.T)  { testMultiSynchronisation(t,
 func TestMultiSynchronisation64Full(t *testing.T)  { testMultiSynchronisation(t, 64, FullSync) }
 func TestMultiSynchronisation64Fast(t *testing.T)  { testMultiSynchronisation(t, 64, FastSync) }
 func TestMultiSynchronisation64Light(t *testing.T) { testMultiSynchronisation(t, 64, LightSync) }
+func TestMultiSynchronisation64BFT(t *testing.T)   { testMultiSynchronisation(t, 64, BFTSync) }
 
 func testMultiSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -863,6 +943,7 @@ func TestEmptyShortCircuit63Fast(t *testing.T)  { testEmptyShortCircuit(t, 63, F
 func TestEmptyShortCircuit64Full(t *testing.T)  { testEmptyShortCircuit(t, 64, FullSync) }
 func TestEmptyShortCircuit64Fast(t *testing.T)  { testEmptyShortCircuit(t, 64, FastSync) }
 func TestEmptyShortCircuit64Light(t *testing.T) { testEmptyShortCircuit(t, 64, LightSync) }
+func TestEmptyShortCircuit64BFT(t *testing.T)   { testEmptyShortCircuit(t, 64, BFTSync) }
 
 func testEmptyShortCircuit(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -896,7 +977,7 @@ func testEmptyShortCircuit(t *testing.T, protocol int, mode SyncMode) {
 		}
 	}
 	for _, receipt := range chain.receiptm {
-		if mode == FastSync && len(receipt) > 0 {
+		if (mode == FastSync || mode == BFTSync) && len(receipt) > 0 {
 			receiptsNeeded++
 		}
 	}
@@ -1513,6 +1594,12 @@ func (ftp *floodingTestPeer) RequestReceipts(hashes []common.Hash) error {
 func (ftp *floodingTestPeer) RequestNodeData(hashes []common.Hash) error {
 	return ftp.peer.RequestNodeData(hashes)
 }
+func (ftp *floodingTestPeer) RequestAccountRange(root common.Hash, origin common.Hash, bytes uint64) error {
+	return ftp.peer.RequestAccountRange(root, origin, bytes)
+}
+func (ftp *floodingTestPeer) RequestStorageRange(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error {
+	return ftp.peer.RequestStorageRange(root, account, origin, bytes)
+}
 
 func (ftp *floodingTestPeer) RequestHeadersByNumber(from uint64, count, skip int, reverse bool) error {
 	deliveriesDone := make(chan struct{}, 500)
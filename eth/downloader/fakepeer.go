@@ -159,3 +159,17 @@ func (p *FakePeer) RequestNodeData(hashes []common.Hash) error {
 	p.dl.DeliverNodeData(p.id, data)
 	return nil
 }
+
+// RequestAccountRange implements downloader.Peer, returning an empty range as
+// fake peers don't serve snapshots.
+func (p *FakePeer) RequestAccountRange(root common.Hash, origin common.Hash, bytes uint64) error {
+	p.dl.DeliverAccountRange(p.id, nil, nil, nil)
+	return nil
+}
+
+// RequestStorageRange implements downloader.Peer, returning an empty range as
+// fake peers don't serve snapshots.
+func (p *FakePeer) RequestStorageRange(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error {
+	p.dl.DeliverStorageRange(p.id, nil, nil, nil)
+	return nil
+}
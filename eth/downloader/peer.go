@@ -88,6 +88,8 @@ type Peer interface {
 	RequestBodies([]common.Hash) error
 	RequestReceipts([]common.Hash) error
 	RequestNodeData([]common.Hash) error
+	RequestAccountRange(root common.Hash, origin common.Hash, bytes uint64) error
+	RequestStorageRange(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error
 }
 
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
@@ -111,6 +113,12 @@ func (w *lightPeerWrapper) RequestReceipts([]common.Hash) error {
 func (w *lightPeerWrapper) RequestNodeData([]common.Hash) error {
 	panic("RequestNodeData not supported in light client mode sync")
 }
+func (w *lightPeerWrapper) RequestAccountRange(common.Hash, common.Hash, uint64) error {
+	panic("RequestAccountRange not supported in light client mode sync")
+}
+func (w *lightPeerWrapper) RequestStorageRange(common.Hash, common.Hash, common.Hash, uint64) error {
+	panic("RequestStorageRange not supported in light client mode sync")
+}
 
 // newPeerConnection creates a new downloader peer.
 func newPeerConnection(id string, version int, peer Peer, logger log.Logger) *peerConnection {
@@ -222,6 +230,26 @@ func (p *peerConnection) FetchNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// FetchAccountRange sends a snapshot account range retrieval request to the
+// remote peer.
+func (p *peerConnection) FetchAccountRange(root, origin common.Hash, bytes uint64) error {
+	// Sanity check the protocol version
+	if p.version < 65 {
+		panic(fmt.Sprintf("account range fetch [eth/65+] requested on eth/%d", p.version))
+	}
+	return p.peer.RequestAccountRange(root, origin, bytes)
+}
+
+// FetchStorageRange sends a snapshot storage range retrieval request to the
+// remote peer.
+func (p *peerConnection) FetchStorageRange(root, account, origin common.Hash, bytes uint64) error {
+	// Sanity check the protocol version
+	if p.version < 65 {
+		panic(fmt.Sprintf("storage range fetch [eth/65+] requested on eth/%d", p.version))
+	}
+	return p.peer.RequestStorageRange(root, account, origin, bytes)
+}
+
 // SetHeadersIdle sets the peer to idle, allowing it to execute new header retrieval
 // requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
@@ -470,7 +498,7 @@ func (ps *peerSet) HeaderIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.headerThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 65, idle, throughput)
 }
 
 // BodyIdlePeers retrieves a flat list of all the currently body-idle peers within
@@ -484,7 +512,7 @@ func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.blockThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 65, idle, throughput)
 }
 
 // ReceiptIdlePeers retrieves a flat list of all the currently receipt-idle peers
@@ -498,7 +526,7 @@ func (ps *peerSet) ReceiptIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.receiptThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 65, idle, throughput)
 }
 
 // NodeDataIdlePeers retrieves a flat list of all the currently node-data-idle
@@ -512,7 +540,7 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.stateThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 65, idle, throughput)
 }
 
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
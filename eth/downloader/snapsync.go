@@ -0,0 +1,208 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/ethdb/memorydb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/trie"
+)
+
+// rangeFetchBytes is the soft size limit of the snapshot ranges requested.
+const rangeFetchBytes = 512 * 1024
+
+// emptyRoot is the known root hash of an empty trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// syncStorageRanges fills the storage tries of the state being synced from the
+// snapshot ranges served by eth/65 peers, before the trie node sync runs. The
+// storage makes up the bulk of the state and transfers far more efficiently as
+// proven ranges than node by node. The account trie and the contract code are
+// left to the trie node sync, which skips the storage tries found on disk and
+// fetches whatever the ranges didn't cover, so a failure here only costs time.
+func (d *Downloader) syncStorageRanges(s *stateSync) {
+	var peers []*peerConnection
+	for _, p := range d.peers.AllPeers() {
+		if p.version >= 65 {
+			peers = append(peers, p)
+		}
+	}
+	var (
+		start    = time.Now()
+		origin   common.Hash
+		accounts int
+		storages int
+	)
+	for len(peers) > 0 {
+		p := peers[0]
+		pack, err := d.fetchRange(p, func() error { return p.FetchAccountRange(s.root, origin, rangeFetchBytes) }, s.cancel)
+		if err == errCancelStateFetch {
+			return
+		}
+		var more bool
+		if err == nil {
+			more, err = verifyRange(s.root, origin, pack)
+		}
+		if err != nil {
+			p.log.Debug("Snapshot account range failed", "root", s.root, "origin", origin, "err", err)
+			peers = peers[1:]
+			continue
+		}
+		// Spread the requests over the peers
+		peers = append(peers[1:], p)
+
+		for i, hash := range pack.hashes {
+			var acc state.Account
+			if err := rlp.DecodeBytes(pack.values[i], &acc); err != nil {
+				continue
+			}
+			if acc.Root == emptyRoot {
+				continue
+			}
+			if ok, _ := d.stateDB.Has(acc.Root[:]); ok {
+				continue
+			}
+			for len(peers) > 0 {
+				err := d.fillStorage(peers[0], s, hash, acc.Root)
+				if err == nil {
+					storages++
+					break
+				}
+				if err == errCancelStateFetch {
+					return
+				}
+				peers[0].log.Debug("Snapshot storage range failed", "root", s.root, "account", hash, "err", err)
+				peers = peers[1:]
+			}
+		}
+		accounts += len(pack.hashes)
+		if !more {
+			log.Info("Filled state storage from snapshot ranges", "root", s.root, "accounts", accounts, "storages", storages, "elapsed", common.PrettyDuration(time.Since(start)))
+			return
+		}
+		origin, _ = incHash(pack.hashes[len(pack.hashes)-1])
+	}
+	log.Debug("No peers left serving snapshot ranges", "root", s.root, "accounts", accounts, "storages", storages)
+}
+
+// fillStorage retrieves the storage of an account through snapshot ranges and
+// writes its trie to disk.
+func (d *Downloader) fillStorage(p *peerConnection, s *stateSync, account, root common.Hash) error {
+	var (
+		triedb = trie.NewDatabase(d.stateDB)
+		origin common.Hash
+	)
+	tr, _ := trie.New(common.Hash{}, triedb)
+	for {
+		pack, err := d.fetchRange(p, func() error { return p.FetchStorageRange(s.root, account, origin, rangeFetchBytes) }, s.cancel)
+		if err != nil {
+			return err
+		}
+		more, err := verifyRange(root, origin, pack)
+		if err != nil {
+			return err
+		}
+		for i, hash := range pack.hashes {
+			if err := tr.TryUpdate(hash[:], pack.values[i]); err != nil {
+				return err
+			}
+		}
+		if !more {
+			break
+		}
+		origin, _ = incHash(pack.hashes[len(pack.hashes)-1])
+	}
+	if have, err := tr.Commit(nil); err != nil {
+		return err
+	} else if have != root {
+		return fmt.Errorf("storage root mismatch: have %x, want %x", have, root)
+	}
+	if err := triedb.Commit(root, false); err != nil {
+		return err
+	}
+	// The trie node sync relies on the bloom to find the nodes already on disk
+	if d.stateBloom != nil {
+		for it := tr.NodeIterator(nil); it.Next(true); {
+			if hash := it.Hash(); hash != (common.Hash{}) {
+				d.stateBloom.Add(hash[:])
+			}
+		}
+	}
+	return nil
+}
+
+// fetchRange sends a snapshot range request to a peer and waits for its answer.
+func (d *Downloader) fetchRange(p *peerConnection, request func() error, cancel <-chan struct{}) (*rangePack, error) {
+	if err := request(); err != nil {
+		return nil, err
+	}
+	timeout := time.NewTimer(d.requestTTL())
+	defer timeout.Stop()
+
+	for {
+		select {
+		case pack := <-d.rangeCh:
+			// Discard the late answers of requests which timed out
+			if pack.PeerId() != p.id {
+				continue
+			}
+			return pack.(*rangePack), nil
+
+		case <-timeout.C:
+			return nil, errTimeout
+
+		case <-cancel:
+			return nil, errCancelStateFetch
+		}
+	}
+}
+
+// verifyRange checks a snapshot range against the root of its trie, returning
+// whether more entries follow it.
+func verifyRange(root, origin common.Hash, pack *rangePack) (bool, error) {
+	if len(pack.hashes) != len(pack.values) {
+		return false, errors.New("range hash and value count mismatch")
+	}
+	proof := memorydb.New()
+	for _, node := range pack.proof {
+		proof.Put(crypto.Keccak256(node), node)
+	}
+	keys := make([][]byte, len(pack.hashes))
+	for i, hash := range pack.hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	return trie.VerifyRangeProof(root, origin[:], keys, pack.values, proof)
+}
+
+// incHash returns the hash following the given one, false if it's the last.
+func incHash(hash common.Hash) (common.Hash, bool) {
+	for i := len(hash) - 1; i >= 0; i-- {
+		hash[i]++
+		if hash[i] != 0 {
+			return hash, true
+		}
+	}
+	return hash, false
+}
@@ -0,0 +1,162 @@
+package downloader
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/core/state/snapshot"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/trie"
+)
+
+// rangeTestPeer serves snapshot ranges and trie nodes of a source state.
+type rangeTestPeer struct {
+	dl     *Downloader
+	id     string
+	db     ethdb.Database
+	snaps  *snapshot.Tree
+	triedb *trie.Database
+	limit  int // Response size cap, forcing multiple ranges
+
+	lock      sync.Mutex
+	requested map[common.Hash]bool // Trie nodes requested through node data
+}
+
+func (p *rangeTestPeer) Head() (common.Hash, *big.Int)                          { return common.Hash{}, nil }
+func (p *rangeTestPeer) RequestHeadersByHash(common.Hash, int, int, bool) error { return nil }
+func (p *rangeTestPeer) RequestHeadersByNumber(uint64, int, int, bool) error    { return nil }
+func (p *rangeTestPeer) RequestBodies([]common.Hash) error                      { return nil }
+func (p *rangeTestPeer) RequestReceipts([]common.Hash) error                    { return nil }
+
+func (p *rangeTestPeer) RequestNodeData(hashes []common.Hash) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var data [][]byte
+	for _, hash := range hashes {
+		p.requested[hash] = true
+		if blob, err := p.db.Get(hash[:]); err == nil {
+			data = append(data, blob)
+		}
+	}
+	go p.dl.DeliverNodeData(p.id, data)
+	return nil
+}
+
+func (p *rangeTestPeer) RequestAccountRange(root common.Hash, origin common.Hash, bytes uint64) error {
+	hashes, values, _ := p.snaps.AccountRange(root, origin, p.limit)
+	tr, _ := trie.New(root, p.triedb)
+	go p.dl.DeliverAccountRange(p.id, hashes, values, p.prove(tr, origin, hashes))
+	return nil
+}
+
+func (p *rangeTestPeer) RequestStorageRange(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error {
+	var acc state.Account
+	enc, _ := p.snaps.Snapshot(root).Account(account)
+	rlp.DecodeBytes(enc, &acc)
+
+	hashes, values, _ := p.snaps.StorageRange(root, account, origin, p.limit)
+	tr, _ := trie.New(acc.Root, p.triedb)
+	go p.dl.DeliverStorageRange(p.id, hashes, values, p.prove(tr, origin, hashes))
+	return nil
+}
+
+func (p *rangeTestPeer) prove(tr *trie.Trie, origin common.Hash, hashes []common.Hash) [][]byte {
+	proof := rawdb.NewMemoryDatabase()
+	tr.Prove(origin[:], 0, proof)
+	if len(hashes) > 0 {
+		tr.Prove(hashes[len(hashes)-1][:], 0, proof)
+	}
+	var nodes [][]byte
+	it := proof.NewIterator()
+	for it.Next() {
+		nodes = append(nodes, common.CopyBytes(it.Value()))
+	}
+	it.Release()
+	return nodes
+}
+
+// Tests that the storage tries of a state are filled from snapshot ranges, the
+// trie node sync only retrieving the rest of the state.
+func TestSyncStorageRanges(t *testing.T) {
+	// Create a source state with a few contracts holding sizeable storage
+	srcdb := rawdb.NewMemoryDatabase()
+	sdb := state.NewDatabase(srcdb)
+	statedb, _ := state.New(common.Hash{}, sdb)
+	for i := 0; i < 64; i++ {
+		addr := common.BytesToAddress([]byte{byte(i)})
+		statedb.SetBalance(addr, big.NewInt(int64(i)+1))
+		if i%8 == 0 {
+			statedb.SetCode(addr, []byte{0x60, byte(i)})
+			for j := 0; j < 200; j++ {
+				statedb.SetState(addr, common.BytesToHash([]byte{byte(j), 1}), common.BytesToHash([]byte{byte(i), byte(j)}))
+			}
+		}
+	}
+	root, _ := statedb.Commit(false)
+	sdb.TrieDB().Commit(root, false)
+
+	snaps := snapshot.New(srcdb, sdb.TrieDB(), root)
+	for start := time.Now(); rawdb.ReadSnapshotGenerator(srcdb) != nil; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("snapshot generation timed out")
+		}
+	}
+	tester := newTester()
+	defer tester.terminate()
+
+	peer := &rangeTestPeer{
+		dl:        tester.downloader,
+		id:        "snap",
+		db:        srcdb,
+		snaps:     snaps,
+		triedb:    sdb.TrieDB(),
+		limit:     1024,
+		requested: make(map[common.Hash]bool),
+	}
+	if err := tester.downloader.RegisterPeer(peer.id, 65, peer); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	// Deliveries are only accepted while syncing
+	tester.downloader.cancelLock.Lock()
+	tester.downloader.cancelCh = make(chan struct{})
+	tester.downloader.cancelLock.Unlock()
+
+	if err := tester.downloader.syncState(root).Wait(); err != nil {
+		t.Fatalf("state sync failed: %v", err)
+	}
+	// The whole state must be present, without storage nodes fetched one by one
+	dststate, err := state.New(root, state.NewDatabase(tester.stateDb))
+	if err != nil {
+		t.Fatalf("synced state missing: %v", err)
+	}
+	for i := 0; i < 64; i++ {
+		addr := common.BytesToAddress([]byte{byte(i)})
+		if balance := dststate.GetBalance(addr); balance.Int64() != int64(i)+1 {
+			t.Fatalf("account %d balance mismatch: have %v, want %d", i, balance, i+1)
+		}
+		if i%8 != 0 {
+			continue
+		}
+		if code := dststate.GetCode(addr); len(code) != 2 || code[1] != byte(i) {
+			t.Fatalf("account %d code mismatch: %x", i, code)
+		}
+		for j := 0; j < 200; j++ {
+			if have, want := dststate.GetState(addr, common.BytesToHash([]byte{byte(j), 1})), common.BytesToHash([]byte{byte(i), byte(j)}); have != want {
+				t.Fatalf("account %d slot %d mismatch: have %x, want %x", i, j, have, want)
+			}
+		}
+		it := dststate.StorageTrie(addr).NodeIterator(nil)
+		for it.Next(true) {
+			if peer.requested[it.Hash()] {
+				t.Fatalf("account %d storage node %x fetched as node data", i, it.Hash())
+			}
+		}
+	}
+}
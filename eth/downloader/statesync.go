@@ -77,10 +77,13 @@ func (d *Downloader) stateFetcher() {
 		select {
 		case s := <-d.stateSyncStart:
 			for next := s; next != nil; {
+				d.syncStorageRanges(next)
 				next = d.runStateSync(next)
 			}
 		case <-d.stateCh:
 			// Ignore state responses while no sync is running.
+		case <-d.rangeCh:
+			// Ignore snapshot ranges while no sync is running.
 		case <-d.quitCh:
 			return
 		}
@@ -131,6 +134,9 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 		case <-s.done:
 			return nil
 
+		// Late snapshot ranges of an aborted storage fill are of no use
+		case <-d.rangeCh:
+
 		// Send the next finished request to the current sync:
 		case deliverReqCh <- deliverReq:
 			// Shift out the first request, but also set the emptied slot to nil for GC
@@ -213,7 +219,8 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 // stateSync schedules requests for downloading a particular state trie defined
 // by a given state root.
 type stateSync struct {
-	d *Downloader // Downloader instance to access and manage current peerset
+	d    *Downloader // Downloader instance to access and manage current peerset
+	root common.Hash // State root being synced
 
 	sched  *trie.Sync                 // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
@@ -240,6 +247,7 @@ type stateTask struct {
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
 	return &stateSync{
 		d:       d,
+		root:    root,
 		sched:   state.NewStateSync(root, d.stateDB, d.stateBloom),
 		keccak:  sha3.NewLegacyKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
@@ -19,6 +19,7 @@ package downloader
 import (
 	"fmt"
 
+	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/core/types"
 )
 
@@ -77,3 +78,16 @@ type statePack struct {
 func (p *statePack) PeerId() string { return p.peerID }
 func (p *statePack) Items() int     { return len(p.states) }
 func (p *statePack) Stats() string  { return fmt.Sprintf("%d", len(p.states)) }
+
+// rangePack is a range of snapshot entries returned by a peer, along with the
+// proof of its edges.
+type rangePack struct {
+	peerID string
+	hashes []common.Hash
+	values [][]byte
+	proof  [][]byte
+}
+
+func (p *rangePack) PeerId() string { return p.peerID }
+func (p *rangePack) Items() int     { return len(p.hashes) }
+func (p *rangePack) Stats() string  { return fmt.Sprintf("%d", len(p.hashes)) }
@@ -20,10 +20,12 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"sort"
 
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/core"
 	"github.com/clearmatics/autonity/core/bloombits"
+	"github.com/clearmatics/autonity/core/rawdb"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/ethdb"
 	"github.com/clearmatics/autonity/event"
@@ -36,6 +38,7 @@ type Backend interface {
 	HeaderByHash(ctx context.Context, blockHash common.Hash) (*types.Header, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
 	GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error)
+	GetSystemCalls(ctx context.Context, blockHash common.Hash) ([]*types.SystemCall, error)
 
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
@@ -126,7 +129,28 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		if header == nil {
 			return nil, errors.New("unknown block")
 		}
-		return f.blockLogs(ctx, header)
+		logs, err := f.blockLogs(ctx, header)
+		if err != nil {
+			return logs, err
+		}
+		system, err := f.blockSystemCallLogs(ctx, header.Hash())
+		return mergeLogs(logs, system), err
+	}
+	// Resolve the finalized block if the range is bound to it
+	if rpc.BlockNumber(f.begin).IsFinalized() || rpc.BlockNumber(f.end).IsFinalized() {
+		header, err := f.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, nil
+		}
+		if rpc.BlockNumber(f.begin).IsFinalized() {
+			f.begin = header.Number.Int64()
+		}
+		if rpc.BlockNumber(f.end).IsFinalized() {
+			f.end = header.Number.Int64()
+		}
 	}
 	// Figure out the limits of the filter range
 	header, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
@@ -142,6 +166,8 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
+	begin := uint64(f.begin)
+
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs []*types.Log
@@ -160,7 +186,12 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 	rest, err := f.unindexedLogs(ctx, end)
 	logs = append(logs, rest...)
-	return logs, err
+	if err != nil {
+		return logs, err
+	}
+	// Add the logs of the system calls, which aren't covered by the blooms
+	system, err := f.systemCallLogs(ctx, begin, end)
+	return mergeLogs(logs, system), err
 }
 
 // indexedLogs returns the logs matching the filter criteria based on the bloom
@@ -241,6 +272,61 @@ func (f *Filter) blockLogs(ctx context.Context, header *types.Header) (logs []*t
 	return logs, nil
 }
 
+// systemCallLogs returns the logs of the system calls matching the filter
+// criteria within a range of blocks. The header blooms only cover the logs of
+// the transactions, so the calls of a block are only read if the bloom stored
+// along with them matches.
+func (f *Filter) systemCallLogs(ctx context.Context, begin, end uint64) ([]*types.Log, error) {
+	var logs []*types.Log
+
+	for number := begin; number <= end; number++ {
+		hash := rawdb.ReadCanonicalHash(f.db, number)
+		if bloom, ok := rawdb.ReadSystemCallsBloom(f.db, hash, number); ok {
+			if !bloomFilter(bloom, f.addresses, f.topics) {
+				continue
+			}
+		} else if !rawdb.HasSystemCalls(f.db, hash, number) {
+			continue
+		}
+		found, err := f.blockSystemCallLogs(ctx, hash)
+		if err != nil {
+			return logs, err
+		}
+		logs = append(logs, found...)
+	}
+	return logs, nil
+}
+
+// blockSystemCallLogs returns the logs of the system calls matching the filter
+// criteria within a single block.
+func (f *Filter) blockSystemCallLogs(ctx context.Context, hash common.Hash) ([]*types.Log, error) {
+	calls, err := f.backend.GetSystemCalls(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	var unfiltered []*types.Log
+	for _, call := range calls {
+		unfiltered = append(unfiltered, call.Logs...)
+	}
+	return filterLogs(unfiltered, nil, nil, f.addresses, f.topics), nil
+}
+
+// mergeLogs merges the logs of system calls into the logs of transactions,
+// ordering them by block and position within the block.
+func mergeLogs(logs []*types.Log, system []*types.Log) []*types.Log {
+	if len(system) == 0 {
+		return logs
+	}
+	logs = append(logs, system...)
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+	return logs
+}
+
 // checkMatches checks if the receipts belonging to the given header contain any log events that
 // match the filter criteria. This function is called when the bloom filter signals a potential match.
 func (f *Filter) checkMatches(ctx context.Context, header *types.Header) (logs []*types.Log, err error) {
@@ -47,6 +47,8 @@ type testBackend struct {
 	rmLogsFeed      event.Feed
 	pendingLogsFeed event.Feed
 	chainFeed       event.Feed
+	finality        bool // the head is final, as under BFT consensus
+	systemCallReads int  // number of blocks whose system calls were read
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
@@ -58,7 +60,10 @@ func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumbe
 		hash common.Hash
 		num  uint64
 	)
-	if blockNr == rpc.LatestBlockNumber {
+	if blockNr.IsFinalized() && !b.finality {
+		return nil, core.ErrNoFinality
+	}
+	if blockNr == rpc.LatestBlockNumber || blockNr.IsFinalized() {
 		hash = rawdb.ReadHeadBlockHash(b.db)
 		number := rawdb.ReadHeaderNumber(b.db, hash)
 		if number == nil {
@@ -101,6 +106,14 @@ func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types
 	return logs, nil
 }
 
+func (b *testBackend) GetSystemCalls(ctx context.Context, hash common.Hash) ([]*types.SystemCall, error) {
+	b.systemCallReads++
+	if number := rawdb.ReadHeaderNumber(b.db, hash); number != nil {
+		return rawdb.ReadSystemCalls(b.db, hash, *number), nil
+	}
+	return nil, nil
+}
+
 func (b *testBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.txFeed.Subscribe(ch)
 }
@@ -468,6 +481,55 @@ func TestLogFilter(t *testing.T) {
 	}
 }
 
+// TestFinalizedLogsSubscription tests that a subscription up to the finalized
+// block receives mined logs but never removed ones.
+func TestFinalizedLogsSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db, finality: true}
+		api     = NewPublicFilterAPI(backend, false)
+
+		addr    = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		logs    = []*types.Log{{Address: addr, BlockNumber: 1}, {Address: addr, BlockNumber: 2}}
+		removed = []*types.Log{{Address: addr, BlockNumber: 2, Removed: true}}
+	)
+
+	id, err := api.NewFilter(FilterCriteria{ToBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64())})
+	if err != nil {
+		t.Fatalf("expected filter creation to succeed, got %v", err)
+	}
+
+	time.Sleep(1 * time.Second)
+	if nsend := backend.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: removed}); nsend == 0 {
+		t.Fatal("Removed logs event not delivered")
+	}
+	if nsend := backend.logsFeed.Send(logs); nsend == 0 {
+		t.Fatal("Logs event not delivered")
+	}
+
+	var fetched []*types.Log
+	timeout := time.Now().Add(1 * time.Second)
+	for len(fetched) < len(logs) && time.Now().Before(timeout) {
+		results, err := api.GetFilterChanges(id)
+		if err != nil {
+			t.Fatalf("Unable to fetch logs: %v", err)
+		}
+		fetched = append(fetched, results.([]*types.Log)...)
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !reflect.DeepEqual(fetched, logs) {
+		t.Fatalf("invalid logs, want %v, got %v", logs, fetched)
+	}
+
+	// Without finality there are no finalized blocks to filter on.
+	api = NewPublicFilterAPI(&testBackend{db: db}, false)
+	if _, err := api.NewFilter(FilterCriteria{ToBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64())}); err != core.ErrNoFinality {
+		t.Fatalf("expected %v, got %v", core.ErrNoFinality, err)
+	}
+}
+
 // TestPendingLogsSubscription tests if a subscription receives the correct pending logs that are posted to the event feed.
 func TestPendingLogsSubscription(t *testing.T) {
 	t.Parallel()
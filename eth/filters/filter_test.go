@@ -30,6 +30,7 @@ import (
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/crypto"
 	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/rpc"
 )
 
 func makeReceipt(addr common.Address) *types.Receipt {
@@ -200,6 +201,21 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 2 log, got", len(logs))
 	}
 
+	backend.finality = true
+	filter = NewRangeFilter(backend, 0, rpc.FinalizedBlockNumber.Int64(), nil, [][]common.Hash{{hash3}})
+
+	logs, _ = filter.Logs(context.Background())
+	if len(logs) != 1 {
+		t.Error("expected 1 log, got", len(logs))
+	}
+
+	backend.finality = false
+	filter = NewRangeFilter(backend, 0, rpc.FinalizedBlockNumber.Int64(), nil, [][]common.Hash{{hash3}})
+
+	if _, err := filter.Logs(context.Background()); err != core.ErrNoFinality {
+		t.Errorf("expected %v, got %v", core.ErrNoFinality, err)
+	}
+
 	failHash := common.BytesToHash([]byte("fail"))
 	filter = NewRangeFilter(backend, 0, -1, nil, [][]common.Hash{{failHash}})
 
@@ -223,3 +239,72 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+func TestFilterSystemCallLogs(t *testing.T) {
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		backend  = &testBackend{db: db}
+		key1, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key1.PublicKey)
+		contract = common.HexToAddress("0xc3d854209ef19803954916f2fe4712448094363e")
+
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		if i == 1 {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{hash1}}}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	// Record system calls emitting logs after the transactions of two blocks
+	rawdb.WriteSystemCalls(db, chain[1].Hash(), chain[1].NumberU64(), []*types.SystemCall{{
+		Type: types.SystemCallCall,
+		Logs: []*types.Log{{Address: contract, Topics: []common.Hash{hash2}, Index: 1}},
+	}})
+	rawdb.WriteSystemCalls(db, chain[4].Hash(), chain[4].NumberU64(), []*types.SystemCall{{
+		Type: types.SystemCallCall,
+		Logs: []*types.Log{{Address: contract, Topics: []common.Hash{hash2}, Index: 0}},
+	}})
+
+	logs, err := NewRangeFilter(backend, 0, -1, nil, [][]common.Hash{{hash1, hash2}}).Logs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to filter logs: %v", err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 logs, got %d", len(logs))
+	}
+	if logs[0].Topics[0] != hash1 || logs[1].Topics[0] != hash2 || logs[2].Topics[0] != hash2 {
+		t.Errorf("log order mismatch: have %x, %x, %x", logs[0].Topics[0], logs[1].Topics[0], logs[2].Topics[0])
+	}
+	system := logs[1]
+	if system.BlockHash != chain[1].Hash() || system.BlockNumber != chain[1].NumberU64() {
+		t.Errorf("system call log block mismatch: have %x #%d", system.BlockHash, system.BlockNumber)
+	}
+	if system.TxHash != types.SystemCallHash(chain[1].NumberU64(), 0) || system.TxIndex != 1 || system.Index != 1 {
+		t.Errorf("system call log position mismatch: have tx %x #%d, index %d", system.TxHash, system.TxIndex, system.Index)
+	}
+	logs, _ = NewRangeFilter(backend, 3, 10, []common.Address{contract}, nil).Logs(context.Background())
+	if len(logs) != 1 || logs[0].BlockHash != chain[4].Hash() {
+		t.Errorf("expected 1 log of block %x, got %d", chain[4].Hash(), len(logs))
+	}
+	// Only the calls of blocks whose bloom matches are read
+	backend.systemCallReads = 0
+	logs, _ = NewRangeFilter(backend, 0, -1, []common.Address{addr}, nil).Logs(context.Background())
+	if len(logs) != 1 || backend.systemCallReads != 0 {
+		t.Errorf("expected 1 log without reading system calls, got %d reading %d blocks", len(logs), backend.systemCallReads)
+	}
+	logs, _ = NewBlockFilter(backend, chain[1].Hash(), nil, nil).Logs(context.Background())
+	if len(logs) != 2 {
+		t.Errorf("expected 2 logs, got %d", len(logs))
+	}
+}
@@ -24,7 +24,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SyncMode                downloader.SyncMode
 		NoPruning               bool
 		NoPrefetch              bool
+		NoSnapshot              bool
 		Whitelist               map[uint64]common.Hash `toml:"-"`
+		BFTCheckpoint           *BFTCheckpoint         `toml:"-"`
 		LightServ               int                    `toml:",omitempty"`
 		LightIngress            int                    `toml:",omitempty"`
 		LightEgress             int                    `toml:",omitempty"`
@@ -39,6 +41,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TrieCleanCache          int
 		TrieDirtyCache          int
 		TrieTimeout             time.Duration
+		StateRetention          uint64 `toml:",omitempty"`
+		PruneBloomSize          uint64
 		Miner                   miner.Config
 		Ethash                  ethash.Config
 		Tendermint              config.Config
@@ -51,6 +55,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		RPCGasCap               *big.Int                       `toml:",omitempty"`
 		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
+		PrivateManager          string                         `toml:",omitempty"`
+		PrivateIdentity         string                         `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -58,7 +64,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SyncMode = c.SyncMode
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
+	enc.NoSnapshot = c.NoSnapshot
 	enc.Whitelist = c.Whitelist
+	enc.BFTCheckpoint = c.BFTCheckpoint
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
 	enc.LightEgress = c.LightEgress
@@ -73,6 +81,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieDirtyCache = c.TrieDirtyCache
 	enc.TrieTimeout = c.TrieTimeout
+	enc.StateRetention = c.StateRetention
+	enc.PruneBloomSize = c.PruneBloomSize
 	enc.Miner = c.Miner
 	enc.Ethash = c.Ethash
 	enc.Tendermint = c.Tendermint
@@ -85,6 +95,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.RPCGasCap = c.RPCGasCap
 	enc.Checkpoint = c.Checkpoint
 	enc.CheckpointOracle = c.CheckpointOracle
+	enc.PrivateManager = c.PrivateManager
+	enc.PrivateIdentity = c.PrivateIdentity
 	return &enc, nil
 }
 
@@ -96,7 +108,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SyncMode                *downloader.SyncMode
 		NoPruning               *bool
 		NoPrefetch              *bool
+		NoSnapshot              *bool
 		Whitelist               map[uint64]common.Hash `toml:"-"`
+		BFTCheckpoint           *BFTCheckpoint         `toml:"-"`
 		LightServ               *int                   `toml:",omitempty"`
 		LightIngress            *int                   `toml:",omitempty"`
 		LightEgress             *int                   `toml:",omitempty"`
@@ -111,6 +125,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TrieCleanCache          *int
 		TrieDirtyCache          *int
 		TrieTimeout             *time.Duration
+		StateRetention          *uint64 `toml:",omitempty"`
+		PruneBloomSize          *uint64
 		Miner                   *miner.Config
 		Ethash                  *ethash.Config
 		Tendermint              *config.Config
@@ -123,6 +139,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		RPCGasCap               *big.Int                       `toml:",omitempty"`
 		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
+		PrivateManager          *string                        `toml:",omitempty"`
+		PrivateIdentity         *string                        `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -143,9 +161,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.NoPrefetch != nil {
 		c.NoPrefetch = *dec.NoPrefetch
 	}
+	if dec.NoSnapshot != nil {
+		c.NoSnapshot = *dec.NoSnapshot
+	}
 	if dec.Whitelist != nil {
 		c.Whitelist = dec.Whitelist
 	}
+	if dec.BFTCheckpoint != nil {
+		c.BFTCheckpoint = dec.BFTCheckpoint
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -188,6 +212,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TrieTimeout != nil {
 		c.TrieTimeout = *dec.TrieTimeout
 	}
+	if dec.StateRetention != nil {
+		c.StateRetention = *dec.StateRetention
+	}
+	if dec.PruneBloomSize != nil {
+		c.PruneBloomSize = *dec.PruneBloomSize
+	}
 	if dec.Miner != nil {
 		c.Miner = *dec.Miner
 	}
@@ -224,5 +254,11 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.CheckpointOracle != nil {
 		c.CheckpointOracle = dec.CheckpointOracle
 	}
+	if dec.PrivateManager != nil {
+		c.PrivateManager = *dec.PrivateManager
+	}
+	if dec.PrivateIdentity != nil {
+		c.PrivateIdentity = *dec.PrivateIdentity
+	}
 	return nil
 }
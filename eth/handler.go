@@ -42,6 +42,7 @@ import (
 	"github.com/clearmatics/autonity/p2p/enode"
 	"github.com/clearmatics/autonity/params"
 	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/tracing"
 	"github.com/clearmatics/autonity/trie"
 )
 
@@ -71,6 +72,7 @@ type ProtocolManager struct {
 	forkFilter forkid.Filter // Fork ID filter, constant across the lifetime of the node
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	bftSync   bool   // Flag whether fast sync should trust committed seals instead of pivot heuristics
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	checkpointNumber uint64      // Block number for the sync progress validator to cross reference
@@ -110,7 +112,7 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new Ethereum sub protocol manager. The Ethereum sub protocol manages peers capable
 // with the Ethereum network.
-func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCheckpoint, mode downloader.SyncMode, networkID uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb ethdb.Database, cacheLimit int, whitelist map[uint64]common.Hash) (*ProtocolManager, error) {
+func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCheckpoint, bftCheckpoint *BFTCheckpoint, mode downloader.SyncMode, networkID uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb ethdb.Database, cacheLimit int, whitelist map[uint64]common.Hash) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkID:   networkID,
@@ -126,6 +128,7 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 		quitSync:    make(chan struct{}),
 		engine:      engine,
 		whitelistCh: make(chan core.WhitelistEvent, 64),
+		bftSync:     mode == downloader.BFTSync,
 	}
 
 	if handler, ok := manager.engine.(consensus.Handler); ok {
@@ -160,6 +163,11 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 		manager.checkpointNumber = (checkpoint.SectionIndex+1)*params.CHTFrequency - 1
 		manager.checkpointHash = checkpoint.SectionHead
 	}
+	// A BFT checkpoint is final by construction, so it supersedes any CHT one
+	if bftCheckpoint != nil {
+		manager.checkpointNumber = bftCheckpoint.Header.Number.Uint64()
+		manager.checkpointHash = bftCheckpoint.Header.Hash()
+	}
 
 	// Construct the downloader (long sync) and its backing state bloom if fast
 	// sync is requested. The downloader is responsible for deallocating the state
@@ -437,7 +445,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
-func (pm *ProtocolManager) handleMsg(p *peer) error {
+func (pm *ProtocolManager) handleMsg(p *peer) (err error) {
 	// Read the next message from the remote peer, and ensure it's fully consumed
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
@@ -448,6 +456,16 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	}
 	defer msg.Discard()
 
+	span := tracing.StartKind(tracing.KindConsumer, "eth.handleMsg",
+		tracing.Uint64("msg.code", msg.Code),
+		tracing.Uint64("msg.size", uint64(msg.Size)),
+		tracing.String("peer", p.id),
+	)
+	defer func() {
+		span.SetError(err)
+		span.Finish()
+	}()
+
 	if handler, ok := pm.engine.(consensus.Handler); ok {
 		pubKey := p.Node().Pubkey()
 		if pubKey == nil {
@@ -745,6 +763,40 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			log.Debug("Failed to deliver receipts", "err", err)
 		}
 
+	case p.version >= eth65 && msg.Code == GetAccountRangeMsg:
+		var query getAccountRangeData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendAccountRange(pm.serveAccountRange(&query))
+
+	case p.version >= eth65 && msg.Code == AccountRangeMsg:
+		// A snapshot account range arrived to one of our previous requests
+		var res rangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverAccountRange(p.id, res.Hashes, res.Values, res.Proof); err != nil {
+			log.Debug("Failed to deliver account range", "err", err)
+		}
+
+	case p.version >= eth65 && msg.Code == GetStorageRangeMsg:
+		var query getStorageRangeData
+		if err := msg.Decode(&query); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendStorageRange(pm.serveStorageRange(&query))
+
+	case p.version >= eth65 && msg.Code == StorageRangeMsg:
+		// A snapshot storage range arrived to one of our previous requests
+		var res rangeData
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if err := pm.downloader.DeliverStorageRange(p.id, res.Hashes, res.Values, res.Proof); err != nil {
+			log.Debug("Failed to deliver storage range", "err", err)
+		}
+
 	case msg.Code == NewBlockHashesMsg:
 		var announces newBlockHashesData
 		if err := msg.Decode(&announces); err != nil {
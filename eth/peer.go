@@ -311,6 +311,16 @@ func (p *peer) SendNodeData(data [][]byte) error {
 	return p2p.Send(p.rw, NodeDataMsg, data)
 }
 
+// SendAccountRange sends a snapshot account range to a remote node.
+func (p *peer) SendAccountRange(data *rangeData) error {
+	return p2p.Send(p.rw, AccountRangeMsg, data)
+}
+
+// SendStorageRange sends a snapshot storage range to a remote node.
+func (p *peer) SendStorageRange(data *rangeData) error {
+	return p2p.Send(p.rw, StorageRangeMsg, data)
+}
+
 // SendReceiptsRLP sends a batch of transaction receipts, corresponding to the
 // ones requested from an already RLP encoded format.
 func (p *peer) SendReceiptsRLP(receipts []rlp.RawValue) error {
@@ -352,6 +362,19 @@ func (p *peer) RequestNodeData(hashes []common.Hash) error {
 	return p2p.Send(p.rw, GetNodeDataMsg, hashes)
 }
 
+// RequestAccountRange fetches a snapshot account range from a remote node.
+func (p *peer) RequestAccountRange(root common.Hash, origin common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching snapshot account range", "root", root, "origin", origin)
+	return p2p.Send(p.rw, GetAccountRangeMsg, &getAccountRangeData{Root: root, Origin: origin, Bytes: bytes})
+}
+
+// RequestStorageRange fetches a snapshot storage range of an account from a
+// remote node.
+func (p *peer) RequestStorageRange(root common.Hash, account common.Hash, origin common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching snapshot storage range", "root", root, "account", account, "origin", origin)
+	return p2p.Send(p.rw, GetStorageRangeMsg, &getStorageRangeData{Root: root, Account: account, Origin: origin, Bytes: bytes})
+}
+
 // RequestReceipts fetches a batch of transaction receipts from a remote node.
 func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	p.Log().Debug("Fetching batch of receipts", "count", len(hashes))
@@ -378,7 +401,7 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 				CurrentBlock:    head,
 				GenesisBlock:    genesis,
 			})
-		case p.version == eth64:
+		case p.version >= eth64:
 			errc <- p2p.Send(p.rw, StatusMsg, &statusData{
 				ProtocolVersion: uint32(p.version),
 				NetworkID:       network,
@@ -395,7 +418,7 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		switch {
 		case p.version == eth63:
 			errc <- p.readStatusLegacy(network, &status63, genesis)
-		case p.version == eth64:
+		case p.version >= eth64:
 			errc <- p.readStatus(network, &status, genesis, forkFilter)
 		default:
 			panic(fmt.Sprintf("unsupported eth protocol version: %d", p.version))
@@ -416,7 +439,7 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 	switch {
 	case p.version == eth63:
 		p.td, p.head = status63.TD, status63.CurrentBlock
-	case p.version == eth64:
+	case p.version >= eth64:
 		p.td, p.head = status.TD, status.Head
 	default:
 		panic(fmt.Sprintf("unsupported eth protocol version: %d", p.version))
@@ -33,16 +33,17 @@ import (
 const (
 	eth63 = 63
 	eth64 = 64
+	eth65 = 65
 )
 
 // protocolName is the official short name of the protocol used during capability negotiation.
 const protocolName = "eth"
 
 // ProtocolVersions are the supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth64, eth63}
+var ProtocolVersions = []uint{eth65, eth64, eth63}
 
 // protocolLengths are the number of implemented message corresponding to different protocol versions.
-var protocolLengths = map[uint]uint64{eth64: 19, eth63: 19}
+var protocolLengths = map[uint]uint64{eth65: 23, eth64: 19, eth63: 19}
 
 const protocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -81,6 +82,12 @@ const (
 	NodeDataMsg        = 0x0e
 	GetReceiptsMsg     = 0x0f
 	ReceiptsMsg        = 0x10
+
+	// Snapshot range messages of eth/65, following the Tendermint ones
+	GetAccountRangeMsg = 0x13
+	AccountRangeMsg    = 0x14
+	GetStorageRangeMsg = 0x15
+	StorageRangeMsg    = 0x16
 )
 
 type errCode int
@@ -216,6 +223,29 @@ func (request *newBlockData) sanityCheck() error {
 	return nil
 }
 
+// getAccountRangeData represents a snapshot account range query.
+type getAccountRangeData struct {
+	Root   common.Hash // State root of the snapshot to serve
+	Origin common.Hash // Account hash the range starts at
+	Bytes  uint64      // Soft limit of the response size
+}
+
+// getStorageRangeData represents a snapshot storage range query.
+type getStorageRangeData struct {
+	Root    common.Hash // State root of the snapshot to serve
+	Account common.Hash // Hash of the account whose storage to serve
+	Origin  common.Hash // Storage slot hash the range starts at
+	Bytes   uint64      // Soft limit of the response size
+}
+
+// rangeData is the network packet for snapshot ranges, the entries being given
+// in hash order along with the Merkle proofs of the range edges.
+type rangeData struct {
+	Hashes []common.Hash // Account or storage slot hashes
+	Values [][]byte      // Trie values of the entries
+	Proof  [][]byte      // Trie nodes proving the origin and the last entry
+}
+
 // blockBody represents the data content of a single block.
 type blockBody struct {
 	Transactions []*types.Transaction // Transactions contained within a block
@@ -225,7 +225,7 @@ func testSendTransactions(t *testing.T, protocol int) {
 
 	const txCount = 100
 	txAdded := make(chan []*types.Transaction, txCount)
-	pm, err := NewProtocolManager(config, nil, downloader.FullSync, DefaultConfig.NetworkId, evmux, &testTxPool{added: txAdded}, pow, blockchain, db, 1, nil)
+	pm, err := NewProtocolManager(config, nil, nil, downloader.FullSync, DefaultConfig.NetworkId, evmux, &testTxPool{added: txAdded}, pow, blockchain, db, 1, nil)
 	if err != nil {
 		t.Fatalf("failed to start test protocol manager: %v", err)
 	}
@@ -0,0 +1,111 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/state"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/trie"
+)
+
+// proofList collects the nodes of Merkle proofs.
+type proofList [][]byte
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *proofList) Delete(key []byte) error {
+	return errors.New("not supported")
+}
+
+// serveAccountRange answers a snapshot account range query, with an empty range
+// if the snapshot of the root can't be served.
+func (pm *ProtocolManager) serveAccountRange(query *getAccountRangeData) *rangeData {
+	snaps := pm.blockchain.Snapshots()
+	if snaps == nil {
+		return &rangeData{}
+	}
+	hashes, values, err := snaps.AccountRange(query.Root, query.Origin, responseLimit(query.Bytes))
+	if err != nil {
+		log.Debug("Failed to serve account range", "root", query.Root, "err", err)
+		return &rangeData{}
+	}
+	tr, err := trie.New(query.Root, pm.blockchain.StateCache().TrieDB())
+	if err != nil {
+		return &rangeData{}
+	}
+	return proveRange(tr, query.Origin, hashes, values)
+}
+
+// serveStorageRange answers a snapshot storage range query, with an empty range
+// if the snapshot of the root can't be served.
+func (pm *ProtocolManager) serveStorageRange(query *getStorageRangeData) *rangeData {
+	snaps := pm.blockchain.Snapshots()
+	if snaps == nil {
+		return &rangeData{}
+	}
+	snap := snaps.Snapshot(query.Root)
+	if snap == nil {
+		return &rangeData{}
+	}
+	enc, err := snap.Account(query.Account)
+	if err != nil || enc == nil {
+		return &rangeData{}
+	}
+	var acc state.Account
+	if err := rlp.DecodeBytes(enc, &acc); err != nil {
+		return &rangeData{}
+	}
+	hashes, values, err := snaps.StorageRange(query.Root, query.Account, query.Origin, responseLimit(query.Bytes))
+	if err != nil {
+		log.Debug("Failed to serve storage range", "root", query.Root, "account", query.Account, "err", err)
+		return &rangeData{}
+	}
+	tr, err := trie.New(acc.Root, pm.blockchain.StateCache().TrieDB())
+	if err != nil {
+		return &rangeData{}
+	}
+	return proveRange(tr, query.Origin, hashes, values)
+}
+
+// proveRange attaches the proofs of the range edges to the range.
+func proveRange(tr *trie.Trie, origin common.Hash, hashes []common.Hash, values [][]byte) *rangeData {
+	var proof proofList
+	if err := tr.Prove(origin[:], 0, &proof); err != nil {
+		return &rangeData{}
+	}
+	if len(hashes) > 0 {
+		if err := tr.Prove(hashes[len(hashes)-1][:], 0, &proof); err != nil {
+			return &rangeData{}
+		}
+	}
+	return &rangeData{Hashes: hashes, Values: values, Proof: proof}
+}
+
+// responseLimit caps the requested response size to the network limits.
+func responseLimit(bytes uint64) int {
+	if bytes > softResponseLimit {
+		return softResponseLimit
+	}
+	return int(bytes)
+}
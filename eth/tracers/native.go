@@ -0,0 +1,119 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"sync/atomic"
+
+	"github.com/clearmatics/autonity/core/vm"
+)
+
+// ResultTracer is a transaction tracer assembling a JSON result out of the
+// execution it captured, implemented either in JavaScript or natively in Go.
+type ResultTracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON result of the trace, or any error that occurred.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the tracing at the first opportune moment.
+	Stop(err error)
+}
+
+// native contains all the built in Go tracers by name. They take precedence over
+// the JavaScript tracers of the same name, which can still be selected with the
+// "Js" suffix (e.g. callTracerJs).
+var native = map[string]func() ResultTracer{
+	"callTracer":     func() ResultTracer { return newCallTracer() },
+	"flatCallTracer": func() ResultTracer { return newFlatCallTracer() },
+	"prestateTracer": func() ResultTracer { return newPrestateTracer() },
+}
+
+// NewTracer instantiates the native tracer of the given name, falling back to
+// the JavaScript tracer of that name or source code.
+func NewTracer(code string) (ResultTracer, error) {
+	if constructor, ok := native[code]; ok {
+		return constructor(), nil
+	}
+	if name := strings.TrimSuffix(code, "Js"); name != code && native[name] != nil {
+		if _, ok := tracer(name); ok {
+			code = name
+		}
+	}
+	return New(code)
+}
+
+// interrupter implements the interruption of native tracers.
+type interrupter struct {
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+	err       error  // Error, if one has occurred
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (i *interrupter) Stop(err error) {
+	i.reason = err
+	atomic.StoreUint32(&i.interrupt, 1)
+}
+
+// interrupted reports whether the tracing failed or was interrupted, in which
+// case the execution is not captured any more.
+func (i *interrupter) interrupted() bool {
+	if i.err != nil {
+		return true
+	}
+	if atomic.LoadUint32(&i.interrupt) > 0 {
+		i.err = i.reason
+		return true
+	}
+	return false
+}
+
+// memorySlice returns a copy of a chunk of the memory, or an empty slice for out
+// of bound accesses like the JavaScript tracers do.
+func memorySlice(memory *vm.Memory, offset, size uint64) []byte {
+	if end := offset + size; end < offset || end > uint64(memory.Len()) {
+		return []byte{}
+	}
+	return memory.GetCopy(int64(offset), int64(size))
+}
+
+// stackUint64 returns the n-th item from the top of the stack, saturated to the
+// range of a uint64.
+func stackUint64(stack *vm.Stack, n int) uint64 {
+	value := stack.Back(n)
+	if !value.IsUint64() {
+		return ^uint64(0)
+	}
+	return value.Uint64()
+}
+
+// hexBig formats a big integer the way the JavaScript tracers do.
+func hexBig(value *big.Int) string {
+	if value == nil {
+		return "0x0"
+	}
+	return "0x" + value.Text(16)
+}
+
+// hexUint64 formats an integer the way the JavaScript tracers do.
+func hexUint64(value uint64) string {
+	return hexBig(new(big.Int).SetUint64(value))
+}
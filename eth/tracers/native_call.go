@@ -0,0 +1,372 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/hexutil"
+	"github.com/clearmatics/autonity/core/vm"
+)
+
+// callFrame is a call reported by the call tracer, with its fields in the order
+// and format of the JavaScript call tracer.
+type callFrame struct {
+	Type    string       `json:"type"`
+	From    string       `json:"from,omitempty"`
+	To      string       `json:"to,omitempty"`
+	Value   string       `json:"value,omitempty"`
+	Gas     string       `json:"gas,omitempty"`
+	GasUsed string       `json:"gasUsed,omitempty"`
+	Input   string       `json:"input,omitempty"`
+	Output  string       `json:"output,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Time    string       `json:"time,omitempty"`
+	Calls   []*callFrame `json:"calls,omitempty"`
+
+	gas     uint64 // Gas allowance inside the call, once known
+	hasGas  bool   // Whether the allowance inside the call is known
+	gasIn   uint64 // Gas available before the calling opcode
+	gasCost uint64 // Cost of the calling opcode
+	outOff  uint64 // Memory offset of the call output
+	outLen  uint64 // Memory size of the call output
+
+	destructed  string // Address of a self destructed contract
+	beneficiary string // Beneficiary of a self destruct
+	balance     string // Balance of the self destructed contract
+}
+
+// callTracer is a native implementation of the JavaScript callTracer, which
+// extracts and reports all the internal calls made by a transaction.
+type callTracer struct {
+	interrupter
+
+	callstack []*callFrame // Current recursive call stack of the EVM execution
+	descended bool         // Whether an inner call was just entered
+
+	typ     string // Type of the outer call
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	output  []byte
+	gasUsed uint64
+	time    time.Duration
+	callErr error // Error the outer call failed with
+}
+
+// newCallTracer creates a native call tracer.
+func newCallTracer() *callTracer {
+	return &callTracer{callstack: []*callFrame{{}}}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.typ = "CALL"
+	if create {
+		t.typ = "CREATE"
+	}
+	t.from, t.to, t.input, t.gas, t.value = from, to, common.CopyBytes(input), gas, value
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.interrupted() {
+		return nil
+	}
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		// A new contract is being created, add to the call stack
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			Input:   hexutil.Encode(memorySlice(memory, stackUint64(stack, 1), stackUint64(stack, 2))),
+			Value:   hexBig(stack.Back(0)),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		// A contract is being self destructed, gather that as a subcall too
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, &callFrame{
+			Type:        op.String(),
+			destructed:  hexutil.Encode(contract.Address().Bytes()),
+			beneficiary: hexutil.Encode(common.BigToAddress(stack.Back(0)).Bytes()),
+			balance:     hexBig(env.StateDB.GetBalance(contract.Address())),
+		})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := common.BigToAddress(stack.Back(1))
+		if _, ok := vm.PrecompiledContractsIstanbul[to]; ok {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		call := &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			To:      hexutil.Encode(to.Bytes()),
+			Input:   hexutil.Encode(memorySlice(memory, stackUint64(stack, 2+off), stackUint64(stack, 3+off))),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stackUint64(stack, 4+off),
+			outLen:  stackUint64(stack, 5+off),
+		}
+		if off == 1 {
+			call.Value = hexBig(stack.Back(2))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance. Calls
+	// to plain accounts never descend, so their allowance stays unknown.
+	if t.descended {
+		if depth >= len(t.callstack) {
+			call := t.callstack[len(t.callstack)-1]
+			call.gas, call.hasGas = gas, true
+		}
+		t.descended = false
+	}
+	if op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return nil
+	}
+	if depth != len(t.callstack)-1 {
+		return nil
+	}
+	// An inner call returned, pop it off the call stack and gather its results
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	if call.Type == "CREATE" || call.Type == "CREATE2" {
+		call.GasUsed = hexUint64(call.gasIn - call.gasCost - gas)
+
+		if ret := stack.Back(0); ret.Sign() != 0 {
+			address := common.BigToAddress(ret)
+			call.To = hexutil.Encode(address.Bytes())
+			call.Output = hexutil.Encode(env.StateDB.GetCode(address))
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	} else if call.hasGas {
+		call.GasUsed = hexUint64(call.gasIn - call.gasCost + call.gas - gas)
+
+		if ret := stack.Back(0); ret.Sign() != 0 {
+			call.Output = hexutil.Encode(memorySlice(memory, call.outOff, call.outLen))
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	}
+	if call.hasGas {
+		call.Gas = hexUint64(call.gas)
+	}
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, call)
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err == nil {
+		t.fault(err)
+	}
+	return nil
+}
+
+// fault pops the call that just failed off the call stack, flattening it into
+// its parent.
+func (t *callTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	call.Error = err.Error()
+
+	// Consume all available gas
+	if call.hasGas {
+		call.Gas = hexUint64(call.gas)
+		call.GasUsed = call.Gas
+	}
+	if len(t.callstack) > 0 {
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+		return
+	}
+	// Last call failed too, leave it in the stack
+	t.callstack = append(t.callstack, call)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.output, t.gasUsed, t.time, t.callErr = common.CopyBytes(output), gasUsed, d, err
+	return nil
+}
+
+// result assembles the outer call, with all the inner calls it made.
+func (t *callTracer) result() (*callFrame, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	result := &callFrame{
+		Type:    t.typ,
+		From:    hexutil.Encode(t.from.Bytes()),
+		To:      hexutil.Encode(t.to.Bytes()),
+		Value:   hexBig(t.value),
+		Gas:     hexUint64(t.gas),
+		GasUsed: hexUint64(t.gasUsed),
+		Input:   hexutil.Encode(t.input),
+		Output:  hexutil.Encode(t.output),
+		Time:    t.time.String(),
+		Calls:   t.callstack[0].Calls,
+	}
+	if t.callstack[0].Error != "" {
+		result.Error = t.callstack[0].Error
+	} else if t.callErr != nil {
+		result.Error = t.callErr.Error()
+	}
+	if result.Error != "" {
+		result.Output = ""
+	}
+	return result, nil
+}
+
+// GetResult returns the outer call, with all the inner calls it made, in the
+// format of the JavaScript call tracer.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	result, err := t.result()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// flatCallFrame is a call reported by the flat call tracer, in the format of the
+// parity trace module.
+type flatCallFrame struct {
+	Action       flatCallAction  `json:"action"`
+	Error        string          `json:"error,omitempty"`
+	Result       *flatCallResult `json:"result,omitempty"`
+	Subtraces    int             `json:"subtraces"`
+	TraceAddress []int           `json:"traceAddress"`
+	Type         string          `json:"type"`
+}
+
+// flatCallAction is the invocation of a call reported by the flat call tracer.
+type flatCallAction struct {
+	Address       string `json:"address,omitempty"`
+	Balance       string `json:"balance,omitempty"`
+	CallType      string `json:"callType,omitempty"`
+	From          string `json:"from,omitempty"`
+	Gas           string `json:"gas,omitempty"`
+	Init          string `json:"init,omitempty"`
+	Input         string `json:"input,omitempty"`
+	RefundAddress string `json:"refundAddress,omitempty"`
+	To            string `json:"to,omitempty"`
+	Value         string `json:"value,omitempty"`
+}
+
+// flatCallResult is the outcome of a successful call reported by the flat call
+// tracer.
+type flatCallResult struct {
+	Address string `json:"address,omitempty"`
+	Code    string `json:"code,omitempty"`
+	GasUsed string `json:"gasUsed,omitempty"`
+	Output  string `json:"output,omitempty"`
+}
+
+// flatCallTracer reports the calls gathered by the call tracer as a flat list,
+// each call addressed by its path in the call tree.
+type flatCallTracer struct {
+	*callTracer
+}
+
+// newFlatCallTracer creates a native flat call tracer.
+func newFlatCallTracer() *flatCallTracer {
+	return &flatCallTracer{newCallTracer()}
+}
+
+// GetResult returns the calls made by the transaction, outer call first, in the
+// format of the parity trace module.
+func (t *flatCallTracer) GetResult() (json.RawMessage, error) {
+	result, err := t.result()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(flattenCall(result, []int{}, nil))
+}
+
+// flattenCall appends a call and all the inner calls it made to the flat list.
+func flattenCall(call *callFrame, address []int, flat []*flatCallFrame) []*flatCallFrame {
+	frame := &flatCallFrame{
+		Error:        call.Error,
+		Subtraces:    len(call.Calls),
+		TraceAddress: address,
+	}
+	switch call.Type {
+	case "CREATE", "CREATE2":
+		frame.Type = "create"
+		frame.Action = flatCallAction{From: call.From, Gas: call.Gas, Init: call.Input, Value: call.Value}
+		if call.Error == "" {
+			frame.Result = &flatCallResult{Address: call.To, Code: call.Output, GasUsed: call.GasUsed}
+		}
+	case "SELFDESTRUCT":
+		frame.Type = "suicide"
+		frame.Action = flatCallAction{Address: call.destructed, Balance: call.balance, RefundAddress: call.beneficiary}
+	default:
+		frame.Type = "call"
+		frame.Action = flatCallAction{
+			CallType: strings.ToLower(call.Type),
+			From:     call.From,
+			To:       call.To,
+			Gas:      call.Gas,
+			Input:    call.Input,
+			Value:    call.Value,
+		}
+		if frame.Action.Value == "" {
+			frame.Action.Value = "0x0"
+		}
+		if call.Error == "" {
+			frame.Result = &flatCallResult{GasUsed: call.GasUsed, Output: call.Output}
+		}
+	}
+	flat = append(flat, frame)
+	for i, inner := range call.Calls {
+		flat = flattenCall(inner, append(append([]int{}, address...), i), flat)
+	}
+	return flat
+}
@@ -0,0 +1,240 @@
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/core/vm"
+	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/tests"
+)
+
+// loadCallTracerTests reads all the call tracer test cases from the test harness.
+func loadCallTracerTests(t *testing.T) map[string]*callTracerTest {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	suite := make(map[string]*callTracerTest)
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+		if err != nil {
+			t.Fatalf("failed to read testcase: %v", err)
+		}
+		test := new(callTracerTest)
+		if err := json.Unmarshal(blob, test); err != nil {
+			t.Fatalf("failed to parse testcase: %v", err)
+		}
+		suite[camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json"))] = test
+	}
+	return suite
+}
+
+// runCallTracerTest executes the transaction of a test case with the given tracer
+// and returns the trace result.
+func runCallTracerTest(t *testing.T, test *callTracerTest, tracer ResultTracer) json.RawMessage {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+	origin, _ := signer.Sender(tx)
+
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+		Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+		GasPrice:    tx.GasPrice(),
+	}
+	statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc)
+	evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, _, _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return res
+}
+
+// dropTime removes the execution time from a call trace, the only field which
+// differs between runs.
+func dropTime(t *testing.T, res json.RawMessage) map[string]interface{} {
+	trace := make(map[string]interface{})
+	if err := json.Unmarshal(res, &trace); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	delete(trace, "time")
+	return trace
+}
+
+// Tests that the native call tracer reports the calls of the test harness, the
+// same way the JavaScript one does.
+func TestNativeCallTracer(t *testing.T) {
+	for name, test := range loadCallTracerTests(t) {
+		test := test // capture range variable
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			res := runCallTracerTest(t, test, newCallTracer())
+			ret := new(callTrace)
+			if err := json.Unmarshal(res, ret); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			if !reflect.DeepEqual(ret, test.Result) {
+				t.Fatalf("trace mismatch: \nhave %+v\nwant %+v", ret, test.Result)
+			}
+			js, err := New("callTracer")
+			if err != nil {
+				t.Fatalf("failed to create JavaScript call tracer: %v", err)
+			}
+			if have, want := dropTime(t, res), dropTime(t, runCallTracerTest(t, test, js)); !reflect.DeepEqual(have, want) {
+				t.Fatalf("output mismatch with the JavaScript tracer: \nhave %v\nwant %v", have, want)
+			}
+		})
+	}
+}
+
+// Tests that the native prestate tracer reports the same accounts and storage
+// as the JavaScript one does.
+func TestNativePrestateTracer(t *testing.T) {
+	for name, test := range loadCallTracerTests(t) {
+		test := test // capture range variable
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			js, err := New("prestateTracer")
+			if err != nil {
+				t.Fatalf("failed to create JavaScript prestate tracer: %v", err)
+			}
+			want := runCallTracerTest(t, test, js)
+			have := runCallTracerTest(t, test, newPrestateTracer())
+
+			if !reflect.DeepEqual(dropTime(t, have), dropTime(t, want)) {
+				t.Fatalf("output mismatch with the JavaScript tracer: \nhave %s\nwant %s", have, want)
+			}
+		})
+	}
+}
+
+// Tests that the flat call tracer reports every call of the call tree, addressed
+// by its path.
+func TestFlatCallTracer(t *testing.T) {
+	test := loadCallTracerTests(t)["deepCalls"]
+	if test == nil {
+		t.Fatalf("deep calls test case missing")
+	}
+	var flat []*flatCallFrame
+	if err := json.Unmarshal(runCallTracerTest(t, test, newFlatCallTracer()), &flat); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	// Walk the nested trace in the same order, checking every call got flattened
+	var (
+		index int
+		walk  func(call *callTrace, address []int)
+	)
+	walk = func(call *callTrace, address []int) {
+		if index >= len(flat) {
+			t.Fatalf("call %v missing from the flat trace", address)
+		}
+		frame := flat[index]
+		index++
+
+		if !reflect.DeepEqual(frame.TraceAddress, address) {
+			t.Errorf("trace address mismatch: have %v, want %v", frame.TraceAddress, address)
+		}
+		if frame.Subtraces != len(call.Calls) {
+			t.Errorf("call %v subtraces mismatch: have %d, want %d", address, frame.Subtraces, len(call.Calls))
+		}
+		if frame.Type != "call" || frame.Action.CallType != strings.ToLower(call.Type) {
+			t.Errorf("call %v type mismatch: have %s/%s, want %s", address, frame.Type, frame.Action.CallType, call.Type)
+		}
+		if frame.Action.From != strings.ToLower(call.From.Hex()) || frame.Action.To != strings.ToLower(call.To.Hex()) {
+			t.Errorf("call %v endpoints mismatch: have %s->%s, want %x->%x", address, frame.Action.From, frame.Action.To, call.From, call.To)
+		}
+		for i := range call.Calls {
+			walk(&call.Calls[i], append(append([]int{}, address...), i))
+		}
+	}
+	walk(test.Result, []int{})
+
+	if index != len(flat) {
+		t.Fatalf("flat trace length mismatch: have %d, want %d", len(flat), index)
+	}
+}
+
+// Tests that stopped native tracers report the reason they were stopped for.
+func TestNativeTracerStop(t *testing.T) {
+	for name := range native {
+		tracer, err := NewTracer(name)
+		if err != nil {
+			t.Fatalf("%s: failed to create tracer: %v", name, err)
+		}
+		stop := errors.New("stopped")
+		tracer.Stop(stop)
+
+		env := vm.NewEVM(vm.Context{BlockNumber: big.NewInt(1)}, &dummyStatedb{}, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+
+		contract := vm.NewContract(account{}, account{}, big.NewInt(0), 10000)
+		contract.Code = []byte{byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x1, 0x0}
+
+		tracer.CaptureStart(common.Address{}, common.Address{}, false, nil, 10000, big.NewInt(0))
+		if _, err := env.Interpreter().Run(contract, []byte{}, false); err != nil {
+			t.Fatalf("%s: failed to run contract: %v", name, err)
+		}
+		tracer.CaptureEnd(nil, 0, 0, nil)
+
+		if _, err := tracer.GetResult(); err != stop {
+			t.Errorf("%s: error mismatch: have %v, want %v", name, err, stop)
+		}
+	}
+}
+
+// Tests that tracers shadowed by native ones can still be created in JavaScript.
+func TestNewTracerJavaScript(t *testing.T) {
+	tracer, err := NewTracer("callTracer")
+	if err != nil {
+		t.Fatalf("failed to create native tracer: %v", err)
+	}
+	if _, ok := tracer.(*callTracer); !ok {
+		t.Errorf("native tracer type mismatch: have %T", tracer)
+	}
+	if tracer, err = NewTracer("callTracerJs"); err != nil {
+		t.Fatalf("failed to create JavaScript tracer: %v", err)
+	}
+	if _, ok := tracer.(*Tracer); !ok {
+		t.Errorf("JavaScript tracer type mismatch: have %T", tracer)
+	}
+	if tracer, err = NewTracer("opcountTracer"); err != nil {
+		t.Fatalf("failed to create JavaScript tracer: %v", err)
+	}
+	if _, ok := tracer.(*Tracer); !ok {
+		t.Errorf("JavaScript tracer type mismatch: have %T", tracer)
+	}
+}
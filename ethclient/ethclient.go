@@ -282,6 +282,14 @@ func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
 	}
+	switch rpc.BlockNumber(number.Int64()) {
+	case rpc.PendingBlockNumber:
+		return "pending"
+	case rpc.FinalizedBlockNumber:
+		return "finalized"
+	case rpc.SafeBlockNumber:
+		return "safe"
+	}
 	return hexutil.EncodeBig(number)
 }
 
@@ -0,0 +1,468 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !js
+
+// Package pebble implements the key-value database layer based on Pebble.
+package pebble
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/ethdb"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/metrics"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+)
+
+const (
+	// degradationWarnInterval specifies how often warning should be printed if the
+	// pebble database cannot keep up with requested writes.
+	degradationWarnInterval = time.Minute
+
+	// minCache is the minimum amount of memory in megabytes to allocate to pebble
+	// read and write caching, split half and half.
+	minCache = 16
+
+	// minHandles is the minimum number of files handles to allocate to the open
+	// database files.
+	minHandles = 16
+
+	// metricsGatheringInterval specifies the interval to retrieve pebble database
+	// compaction, io and pause stats to report to the user.
+	metricsGatheringInterval = 3 * time.Second
+
+	// statsProperty is the only property served by Stat, the pebble metrics table.
+	statsProperty = "pebble.stats"
+)
+
+// Database is a persistent key-value store. Apart from basic data storage
+// functionality it also supports batch writes and iterating over the keyspace in
+// binary-alphabetical order.
+type Database struct {
+	fn string     // filename for reporting
+	db *pebble.DB // Pebble instance
+
+	compTimeMeter    metrics.Meter // Meter for measuring the total time spent in database compaction
+	compReadMeter    metrics.Meter // Meter for measuring the data read during compaction
+	compWriteMeter   metrics.Meter // Meter for measuring the data written during compaction
+	writeDelayNMeter metrics.Meter // Meter for measuring the write delay number due to database compaction
+	writeDelayMeter  metrics.Meter // Meter for measuring the write delay duration due to database compaction
+	diskSizeGauge    metrics.Gauge // Gauge for tracking the size of all the levels in the database
+	diskWriteMeter   metrics.Meter // Meter for measuring the effective amount of data written
+	memCompGauge     metrics.Gauge // Gauge for tracking the number of memory compaction
+	compGauge        metrics.Gauge // Gauge for tracking the number of table compactions
+	readAmpGauge     metrics.Gauge // Gauge for tracking the read amplification of the database
+
+	compTime   int64 // Total time spent in compaction in ns, updated atomically
+	writeDelay int64 // Total time writes were stalled in ns, updated atomically
+	delayCount int64 // Number of write stalls, updated atomically
+	stallStart int64 // Start of the current write stall in unix ns, updated atomically
+
+	quitLock sync.Mutex      // Mutex protecting the quit channel access
+	quitChan chan chan error // Quit channel to stop the metrics collection before closing the database
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// New returns a wrapped Pebble object. The namespace is the prefix that the
+// metrics reporting should use for surfacing internal stats.
+func New(file string, cache int, handles int, namespace string) (*Database, error) {
+	// Ensure we have some minimal caching and file guarantees
+	if cache < minCache {
+		cache = minCache
+	}
+	if handles < minHandles {
+		handles = minHandles
+	}
+	logger := log.New("database", file)
+	logger.Info("Allocated cache and file handles", "cache", common.StorageSize(cache*1024*1024), "handles", handles)
+
+	pdb := &Database{
+		fn:       file,
+		log:      logger,
+		quitChan: make(chan chan error),
+	}
+	blockCache := pebble.NewCache(int64(cache / 2 * 1024 * 1024))
+	defer blockCache.Unref()
+
+	db, err := pebble.Open(file, &pebble.Options{
+		Cache:        blockCache,
+		MaxOpenFiles: handles,
+		MemTableSize: cache / 4 * 1024 * 1024, // Two of these are used internally
+		Levels:       []pebble.LevelOptions{{FilterPolicy: bloom.FilterPolicy(10)}},
+		Logger:       pebbleLogger{logger},
+		EventListener: pebble.EventListener{
+			CompactionEnd:   pdb.onCompactionEnd,
+			WriteStallBegin: pdb.onWriteStallBegin,
+			WriteStallEnd:   pdb.onWriteStallEnd,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	pdb.db = db
+
+	// Assemble the wrapper with all the registered metrics
+	pdb.compTimeMeter = metrics.NewRegisteredMeter(namespace+"compact/time", nil)
+	pdb.compReadMeter = metrics.NewRegisteredMeter(namespace+"compact/input", nil)
+	pdb.compWriteMeter = metrics.NewRegisteredMeter(namespace+"compact/output", nil)
+	pdb.diskSizeGauge = metrics.NewRegisteredGauge(namespace+"disk/size", nil)
+	pdb.diskWriteMeter = metrics.NewRegisteredMeter(namespace+"disk/write", nil)
+	pdb.writeDelayMeter = metrics.NewRegisteredMeter(namespace+"compact/writedelay/duration", nil)
+	pdb.writeDelayNMeter = metrics.NewRegisteredMeter(namespace+"compact/writedelay/counter", nil)
+	pdb.memCompGauge = metrics.NewRegisteredGauge(namespace+"compact/memory", nil)
+	pdb.compGauge = metrics.NewRegisteredGauge(namespace+"compact/tables", nil)
+	pdb.readAmpGauge = metrics.NewRegisteredGauge(namespace+"read/amplification", nil)
+
+	// Start up the metrics gathering and return
+	go pdb.meter(metricsGatheringInterval)
+	return pdb, nil
+}
+
+// onCompactionEnd accumulates the time spent in compaction.
+func (db *Database) onCompactionEnd(info pebble.CompactionInfo) {
+	atomic.AddInt64(&db.compTime, int64(info.Duration))
+}
+
+// onWriteStallBegin records the start of a write stall.
+func (db *Database) onWriteStallBegin(info pebble.WriteStallBeginInfo) {
+	atomic.StoreInt64(&db.stallStart, time.Now().UnixNano())
+	atomic.AddInt64(&db.delayCount, 1)
+}
+
+// onWriteStallEnd accumulates the time writes were stalled.
+func (db *Database) onWriteStallEnd() {
+	if start := atomic.SwapInt64(&db.stallStart, 0); start != 0 {
+		atomic.AddInt64(&db.writeDelay, time.Now().UnixNano()-start)
+	}
+}
+
+// Close stops the metrics collection, flushes any pending data to disk and closes
+// all io accesses to the underlying key-value store.
+func (db *Database) Close() error {
+	db.quitLock.Lock()
+	defer db.quitLock.Unlock()
+
+	if db.quitChan != nil {
+		errc := make(chan error)
+		db.quitChan <- errc
+		if err := <-errc; err != nil {
+			db.log.Error("Metrics collection failed", "err", err)
+		}
+		db.quitChan = nil
+	}
+	return db.db.Close()
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *Database) Has(key []byte) (bool, error) {
+	_, closer, err := db.db.Get(key)
+	if err == pebble.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	closer.Close()
+	return true, nil
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *Database) Get(key []byte) ([]byte, error) {
+	dat, closer, err := db.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	return common.CopyBytes(dat), nil
+}
+
+// Put inserts the given value into the key-value store.
+func (db *Database) Put(key []byte, value []byte) error {
+	return db.db.Set(key, value, pebble.NoSync)
+}
+
+// Delete removes the key from the key-value store.
+func (db *Database) Delete(key []byte) error {
+	return db.db.Delete(key, pebble.NoSync)
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to its host
+// database until a final write is called.
+func (db *Database) NewBatch() ethdb.Batch {
+	return &batch{
+		db: db.db,
+		b:  db.db.NewBatch(),
+	}
+}
+
+// NewIterator creates a binary-alphabetical iterator over the entire keyspace
+// contained within the pebble database.
+func (db *Database) NewIterator() ethdb.Iterator {
+	return newIterator(db.db.NewIter(nil), nil)
+}
+
+// NewIteratorWithStart creates a binary-alphabetical iterator over a subset of
+// database content starting at a particular initial key (or after, if it does
+// not exist).
+func (db *Database) NewIteratorWithStart(start []byte) ethdb.Iterator {
+	return newIterator(db.db.NewIter(nil), start)
+}
+
+// NewIteratorWithPrefix creates a binary-alphabetical iterator over a subset
+// of database content with a particular key prefix.
+func (db *Database) NewIteratorWithPrefix(prefix []byte) ethdb.Iterator {
+	return newIterator(db.db.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: upperBound(prefix),
+	}), prefix)
+}
+
+// upperBound returns the smallest key above all the keys with the given prefix,
+// nil if there is none.
+func upperBound(prefix []byte) []byte {
+	limit := common.CopyBytes(prefix)
+	for i := len(limit) - 1; i >= 0; i-- {
+		if limit[i] < 0xff {
+			limit[i]++
+			return limit[:i+1]
+		}
+	}
+	return nil
+}
+
+// Stat returns a particular internal stat of the database. The only property
+// served is "pebble.stats", the metrics table of the database.
+func (db *Database) Stat(property string) (string, error) {
+	if property != statsProperty {
+		return "", fmt.Errorf("unknown property: %s", property)
+	}
+	return db.db.Metrics().String(), nil
+}
+
+// Compact flattens the underlying data store for the given key range. In essence,
+// deleted and overwritten versions are discarded, and the data is rearranged to
+// reduce the cost of operations needed to access them.
+//
+// A nil start is treated as a key before all keys in the data store; a nil limit
+// is treated as a key after all keys in the data store. If both is nil then it
+// will compact entire data store.
+func (db *Database) Compact(start []byte, limit []byte) error {
+	// Pebble has no key to mark the end of the keyspace, use one larger than any
+	// key of the database: all keys are either shorter or hashes.
+	if limit == nil {
+		limit = bytes.Repeat([]byte{0xff}, 64)
+	}
+	return db.db.Compact(start, limit)
+}
+
+// Path returns the path to the database directory.
+func (db *Database) Path() string {
+	return db.fn
+}
+
+// meter periodically retrieves internal pebble counters and reports them to
+// the metrics subsystem.
+func (db *Database) meter(refresh time.Duration) {
+	var (
+		errc chan error
+
+		compTime, compRead, compWrite, diskWrite int64
+		delayCount, delayTime                    int64
+		lastWritePaused                          time.Time
+	)
+	for errc == nil {
+		stats := db.db.Metrics()
+
+		var read, written, size int64
+		for _, level := range stats.Levels {
+			read += int64(level.BytesRead)
+			written += int64(level.BytesCompacted)
+			size += level.Size
+		}
+		nCompTime := atomic.LoadInt64(&db.compTime)
+		nDelayCount, nDelayTime := atomic.LoadInt64(&db.delayCount), atomic.LoadInt64(&db.writeDelay)
+
+		db.diskSizeGauge.Update(size)
+		db.compTimeMeter.Mark(nCompTime - compTime)
+		db.compReadMeter.Mark(read - compRead)
+		db.compWriteMeter.Mark(written - compWrite)
+		db.diskWriteMeter.Mark(int64(stats.WAL.BytesWritten) - diskWrite)
+		db.writeDelayNMeter.Mark(nDelayCount - delayCount)
+		db.writeDelayMeter.Mark(nDelayTime - delayTime)
+		db.memCompGauge.Update(stats.Flush.Count)
+		db.compGauge.Update(stats.Compact.Count)
+		db.readAmpGauge.Update(int64(stats.ReadAmp()))
+
+		// If a warning that db is performing compaction has been displayed, any subsequent
+		// warnings will be withheld for one minute not to overwhelm the user.
+		if atomic.LoadInt64(&db.stallStart) != 0 && time.Now().After(lastWritePaused.Add(degradationWarnInterval)) {
+			db.log.Warn("Database compacting, degraded performance")
+			lastWritePaused = time.Now()
+		}
+		compTime, compRead, compWrite, diskWrite = nCompTime, read, written, int64(stats.WAL.BytesWritten)
+		delayCount, delayTime = nDelayCount, nDelayTime
+
+		// Sleep a bit, then repeat the stats collection
+		select {
+		case errc = <-db.quitChan:
+			// Quit requesting, stop hammering the database
+		case <-time.After(refresh):
+			// Timeout, gather a new set of stats
+		}
+	}
+	errc <- nil
+}
+
+// pebbleLogger routes the pebble logs into the database logger.
+type pebbleLogger struct {
+	log log.Logger
+}
+
+func (l pebbleLogger) Infof(format string, args ...interface{}) {
+	l.log.Debug(fmt.Sprintf(format, args...))
+}
+
+func (l pebbleLogger) Fatalf(format string, args ...interface{}) {
+	l.log.Crit(fmt.Sprintf(format, args...))
+}
+
+// iterator wraps a pebble iterator, positioning it on the first key on the first
+// call to Next.
+type iterator struct {
+	it       *pebble.Iterator
+	start    []byte
+	moved    bool
+	released bool
+}
+
+func newIterator(it *pebble.Iterator, start []byte) *iterator {
+	return &iterator{it: it, start: start}
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	if it.released {
+		return false
+	}
+	if it.moved {
+		return it.it.Next()
+	}
+	it.moved = true
+	if it.start == nil {
+		return it.it.First()
+	}
+	return it.it.SeekGE(it.start)
+}
+
+// Error returns any accumulated error.
+func (it *iterator) Error() error {
+	if it.released {
+		return nil
+	}
+	return it.it.Error()
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *iterator) Key() []byte {
+	if it.released || !it.moved || !it.it.Valid() {
+		return nil
+	}
+	return it.it.Key()
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *iterator) Value() []byte {
+	if it.released || !it.moved || !it.it.Valid() {
+		return nil
+	}
+	return it.it.Value()
+}
+
+// Release releases associated resources.
+func (it *iterator) Release() {
+	if !it.released {
+		it.it.Close()
+		it.released = true
+	}
+}
+
+// batch is a write-only pebble batch that commits changes to its host database
+// when Write is called. A batch cannot be used concurrently.
+type batch struct {
+	db   *pebble.DB
+	b    *pebble.Batch
+	size int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.b.Set(key, value, nil)
+	b.size += len(value)
+	return nil
+}
+
+// Delete inserts the a key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.b.Delete(key, nil)
+	b.size++
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to disk.
+func (b *batch) Write() error {
+	return b.db.Apply(b.b, pebble.NoSync)
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.b.Reset()
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	reader := b.b.Reader()
+	for {
+		kind, key, value, ok := reader.Next()
+		if !ok {
+			return nil
+		}
+		var err error
+		switch kind {
+		case pebble.InternalKeyKindSet:
+			err = w.Put(key, value)
+		case pebble.InternalKeyKindDelete:
+			err = w.Delete(key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/common/mclock"
 	"github.com/clearmatics/autonity/consensus"
+	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
 	"github.com/clearmatics/autonity/core"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/eth"
@@ -66,6 +67,12 @@ type blockChain interface {
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 }
 
+// consensusEngine is implemented by the tendermint engine to expose its current
+// consensus view.
+type consensusEngine interface {
+	CoreState() tendermintCore.ConsensusState
+}
+
 // Service implements an Ethereum netstats reporting daemon that pushes local
 // chain statistics up to a monitoring server.
 type Service struct {
@@ -262,6 +269,9 @@ func (s *Service) loop() {
 				if err = s.reportPending(conn); err != nil {
 					log.Warn("Post-block transaction stats report failed", "err", err)
 				}
+				if err = s.reportConsensus(conn); err != nil {
+					log.Warn("Post-block consensus stats report failed", "err", err)
+				}
 			case <-txCh:
 				if err = s.reportPending(conn); err != nil {
 					log.Warn("Transaction stats report failed", "err", err)
@@ -432,6 +442,9 @@ func (s *Service) report(conn *websocket.Conn) error {
 	if err := s.reportStats(conn); err != nil {
 		return err
 	}
+	if err := s.reportConsensus(conn); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -487,6 +500,13 @@ type blockStats struct {
 	TxHash     common.Hash    `json:"transactionsRoot"`
 	Root       common.Hash    `json:"stateRoot"`
 	Uncles     uncleStats     `json:"uncles"`
+
+	// Tendermint specific fields, the committee is the one which decided the block
+	Round          *big.Int        `json:"round,omitempty"`
+	Proposer       *common.Address `json:"proposer,omitempty"`
+	CommitteeSize  int             `json:"committeeSize,omitempty"`
+	VotingPower    *big.Int        `json:"votingPower,omitempty"`
+	CommittedSeals int             `json:"committedSeals,omitempty"`
 }
 
 // txStats is the information to report about individual transactions.
@@ -559,7 +579,7 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 	// Assemble and return the block stats
 	author, _ := s.engine.Author(header)
 
-	return &blockStats{
+	stats := &blockStats{
 		Number:     header.Number,
 		Hash:       header.Hash(),
 		ParentHash: header.ParentHash,
@@ -574,6 +594,34 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 		Root:       header.Root,
 		Uncles:     uncles,
 	}
+	if header.MixDigest == types.BFTDigest && header.Number.Sign() > 0 {
+		var parent *types.Header
+		if s.eth != nil {
+			parent = s.eth.BlockChain().GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		} else {
+			parent = s.les.BlockChain().GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		}
+		assembleBFTStats(stats, header, parent, author)
+	}
+	return stats
+}
+
+// assembleBFTStats fills in the tendermint specific fields of the block stats,
+// the committee which decided the block is taken from its parent if known.
+func assembleBFTStats(stats *blockStats, header, parent *types.Header, proposer common.Address) {
+	stats.Round = new(big.Int)
+	if header.Round != nil {
+		stats.Round.Set(header.Round)
+	}
+	stats.Proposer = &proposer
+	stats.CommittedSeals = len(header.CommittedSeals)
+	if parent != nil {
+		stats.CommitteeSize = len(parent.Committee)
+		stats.VotingPower = new(big.Int)
+		for _, member := range parent.Committee {
+			stats.VotingPower.Add(stats.VotingPower, member.VotingPower)
+		}
+	}
 }
 
 // reportHistory retrieves the most recent batch of blocks and reports it to the
@@ -637,6 +685,68 @@ func (s *Service) reportHistory(conn *websocket.Conn, list []uint64) error {
 	return conn.WriteJSON(report)
 }
 
+// reportConsensus retrieves the current height, round and step of the local
+// tendermint engine and the vote latency of the committee members, and reports
+// them to the stats server. Engines without such a view are skipped.
+func (s *Service) reportConsensus(conn *websocket.Conn) error {
+	engine, ok := s.engine.(consensusEngine)
+	if !ok {
+		return nil
+	}
+	state := engine.CoreState()
+
+	latencies := make([]*voteLatencyStats, len(state.VoteLatencies))
+	for i, l := range state.VoteLatencies {
+		latencies[i] = &voteLatencyStats{
+			Address:   l.Address,
+			Height:    l.Height,
+			Round:     l.Round,
+			Prevote:   int64(l.Prevote / time.Millisecond),
+			Precommit: int64(l.Precommit / time.Millisecond),
+		}
+	}
+	// Assemble the consensus stats and send it to the server
+	log.Trace("Sending consensus state to ethstats", "height", state.Height, "round", state.Round, "step", state.Step)
+
+	stats := map[string]interface{}{
+		"id": s.node,
+		"consensus": &consensusStats{
+			Height:        state.Height,
+			Round:         state.Round,
+			Step:          state.Step,
+			Proposer:      state.Proposer,
+			IsProposer:    state.IsProposer,
+			CommitteeSize: state.CommitteeSize,
+			VoteLatencies: latencies,
+		},
+	}
+	report := map[string][]interface{}{
+		"emit": {"consensus", stats},
+	}
+	return conn.WriteJSON(report)
+}
+
+// consensusStats is the information to report about the local consensus view.
+type consensusStats struct {
+	Height        *big.Int            `json:"height"`
+	Round         *big.Int            `json:"round"`
+	Step          string              `json:"step"`
+	Proposer      common.Address      `json:"proposer"`
+	IsProposer    bool                `json:"isProposer"`
+	CommitteeSize int                 `json:"committeeSize"`
+	VoteLatencies []*voteLatencyStats `json:"voteLatencies"`
+}
+
+// voteLatencyStats is the delay in milliseconds between the start of a round
+// and the reception of the votes of a committee member.
+type voteLatencyStats struct {
+	Address   common.Address `json:"address"`
+	Height    *big.Int       `json:"height"`
+	Round     *big.Int       `json:"round"`
+	Prevote   int64          `json:"prevote"`
+	Precommit int64          `json:"precommit"`
+}
+
 // pendStats is the information to report about pending transactions.
 type pendStats struct {
 	Pending int `json:"pending"`
@@ -0,0 +1,187 @@
+package ethstats
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/consensus"
+	tendermintCore "github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/core/types"
+)
+
+func TestAssembleBFTStats(t *testing.T) {
+	proposer := common.HexToAddress("0x01")
+	committee := types.Committee{
+		{Address: common.HexToAddress("0x01"), VotingPower: big.NewInt(3)},
+		{Address: common.HexToAddress("0x02"), VotingPower: big.NewInt(4)},
+	}
+	header := &types.Header{
+		Number:         big.NewInt(2),
+		Round:          big.NewInt(1),
+		CommittedSeals: [][]byte{{1}, {2}},
+	}
+
+	cases := []struct {
+		name   string
+		parent *types.Header
+		want   blockStats
+	}{
+		{
+			name:   "parent known, committee reported",
+			parent: &types.Header{Number: big.NewInt(1), Committee: committee},
+			want: blockStats{
+				Round:          big.NewInt(1),
+				Proposer:       &proposer,
+				CommitteeSize:  2,
+				VotingPower:    big.NewInt(7),
+				CommittedSeals: 2,
+			},
+		},
+		{
+			name: "parent unknown, committee skipped",
+			want: blockStats{
+				Round:          big.NewInt(1),
+				Proposer:       &proposer,
+				CommittedSeals: 2,
+			},
+		},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := blockStats{}
+			assembleBFTStats(&got, header, testCase.parent, proposer)
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("want %+v, got %+v", testCase.want, got)
+			}
+		})
+	}
+}
+
+type testEngine struct {
+	consensus.Engine
+	state tendermintCore.ConsensusState
+}
+
+func (e *testEngine) CoreState() tendermintCore.ConsensusState {
+	return e.state
+}
+
+// newTestConn returns a client connection whose messages received by the server
+// are fed into the returned channel.
+func newTestConn(t *testing.T) (*websocket.Conn, <-chan []byte, func()) {
+	t.Helper()
+	received := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- msg
+		}
+	}))
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return conn, received, func() {
+		conn.Close()
+		server.Close()
+	}
+}
+
+func TestReportConsensus(t *testing.T) {
+	t.Run("tendermint engine, consensus state reported", func(t *testing.T) {
+		conn, received, closeFn := newTestConn(t)
+		defer closeFn()
+
+		validator := common.HexToAddress("0x02")
+		s := &Service{
+			node: "node",
+			engine: &testEngine{state: tendermintCore.ConsensusState{
+				Height:        big.NewInt(5),
+				Round:         big.NewInt(1),
+				Step:          "Prevote",
+				Proposer:      validator,
+				CommitteeSize: 4,
+				VoteLatencies: []tendermintCore.VoteLatency{
+					{Address: validator, Height: big.NewInt(5), Round: big.NewInt(1), Prevote: 1500 * time.Millisecond},
+				},
+			}},
+		}
+		if err := s.reportConsensus(conn); err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+
+		var report struct {
+			Emit []json.RawMessage `json:"emit"`
+		}
+		select {
+		case msg := <-received:
+			if err := json.Unmarshal(msg, &report); err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no report received")
+		}
+		if len(report.Emit) != 2 || string(report.Emit[0]) != `"consensus"` {
+			t.Fatalf("unexpected report %s", report.Emit)
+		}
+		var payload struct {
+			ID        string         `json:"id"`
+			Consensus consensusStats `json:"consensus"`
+		}
+		if err := json.Unmarshal(report.Emit[1], &payload); err != nil {
+			t.Fatal(err)
+		}
+		want := consensusStats{
+			Height:        big.NewInt(5),
+			Round:         big.NewInt(1),
+			Step:          "Prevote",
+			Proposer:      validator,
+			CommitteeSize: 4,
+			VoteLatencies: []*voteLatencyStats{
+				{Address: validator, Height: big.NewInt(5), Round: big.NewInt(1), Prevote: 1500},
+			},
+		}
+		if payload.ID != "node" || !reflect.DeepEqual(payload.Consensus, want) {
+			t.Fatalf("want %+v, got %s", want, report.Emit[1])
+		}
+	})
+
+	t.Run("other engine, nothing reported", func(t *testing.T) {
+		conn, received, closeFn := newTestConn(t)
+		defer closeFn()
+
+		s := &Service{node: "node", engine: struct{ consensus.Engine }{}}
+		if err := s.reportConsensus(conn); err != nil {
+			t.Fatalf("expected <nil>, got %v", err)
+		}
+		if err := conn.WriteJSON("sentinel"); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case msg := <-received:
+			if string(msg) != "\"sentinel\"\n" {
+				t.Fatalf("unexpected report %s", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no message received")
+		}
+	})
+}
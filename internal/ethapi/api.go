@@ -37,6 +37,7 @@ import (
 	"github.com/clearmatics/autonity/contracts/autonity"
 	"github.com/clearmatics/autonity/core"
 	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/core/types"
 	"github.com/clearmatics/autonity/core/vm"
 	"github.com/clearmatics/autonity/crypto"
@@ -746,6 +747,7 @@ type CallArgs struct {
 	GasPrice *hexutil.Big    `json:"gasPrice"`
 	Value    *hexutil.Big    `json:"value"`
 	Data     *hexutil.Bytes  `json:"data"`
+	Private  bool            `json:"private"` // execute against the private state
 }
 
 // account indicates the overriding fields of account during the execution of
@@ -765,7 +767,16 @@ type account struct {
 func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]account, vmCfg vm.Config, timeout time.Duration, globalGasCap *big.Int) ([]byte, uint64, bool, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
-	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	var (
+		state  *state.StateDB
+		header *types.Header
+		err    error
+	)
+	if args.Private {
+		state, header, err = b.PrivateStateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	} else {
+		state, header, err = b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	}
 	if state == nil || err != nil {
 		return nil, 0, false, err
 	}
@@ -821,6 +832,10 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 	if args.GasPrice != nil {
 		gasPrice = args.GasPrice.ToInt()
 	}
+	if args.Private {
+		// Gas is paid on the public state, private execution is free
+		gasPrice = new(big.Int)
+	}
 
 	value := new(big.Int)
 	if args.Value != nil {
@@ -1349,9 +1364,40 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	if tx.IsPrivate() {
+		fields["privateFor"] = tx.PrivateFor()
+		if err := s.overlayPrivateReceipt(ctx, fields, tx, blockHash, blockNumber, index); err != nil {
+			return nil, err
+		}
+	}
 	return fields, nil
 }
 
+// overlayPrivateReceipt replaces the public outcome of a private transaction
+// in its receipt fields with the private one, if the node is a party of it.
+func (s *PublicTransactionPoolAPI) overlayPrivateReceipt(ctx context.Context, fields map[string]interface{}, tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) error {
+	private, err := s.b.GetPrivateReceipt(ctx, tx.Hash())
+	if private == nil || err != nil {
+		return err
+	}
+	// Private logs don't have their block derived fields stored
+	logs := make([]*types.Log, len(private.Logs))
+	for i, l := range private.Logs {
+		cpy := *l
+		cpy.TxHash, cpy.TxIndex = tx.Hash(), uint(index)
+		cpy.BlockHash, cpy.BlockNumber = blockHash, blockNumber
+		cpy.Index = uint(i)
+		logs[i] = &cpy
+	}
+	fields["privateGasUsed"] = hexutil.Uint64(private.GasUsed)
+	fields["status"] = hexutil.Uint(private.Status)
+	fields["logs"] = logs
+	if private.ContractAddress != (common.Address{}) {
+		fields["contractAddress"] = private.ContractAddress
+	}
+	return nil
+}
+
 // sign is a helper function that signs a transaction with the private key of the given address.
 func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
 	// Look up the wallet containing the requested signer
@@ -1377,6 +1423,9 @@ type SendTxArgs struct {
 	// newer name and should be preferred by clients.
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+	// Parties of a private transaction, its payload being then handed over to
+	// the private transaction manager and replaced with its hash.
+	PrivateFor []string `json:"privateFor"`
 }
 
 // setDefaults is a helper function that fills in default values for unspecified tx fields.
@@ -1427,6 +1476,7 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 			GasPrice: args.GasPrice,
 			Value:    args.Value,
 			Data:     input,
+			Private:  len(args.PrivateFor) > 0,
 		}
 		pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 		estimated, err := DoEstimateGas(ctx, b, callArgs, pendingBlockNr, b.RPCGasCap())
@@ -1436,6 +1486,61 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 		args.Gas = &estimated
 		log.Trace("Estimate gas usage automatically", "gas", args.Gas)
 	}
+	if len(args.PrivateFor) > 0 {
+		return args.setPrivatePayload(b)
+	}
+	return nil
+}
+
+func containsParty(parties []string, party string) bool {
+	for _, p := range parties {
+		if p == party {
+			return true
+		}
+	}
+	return false
+}
+
+// setPrivatePayload hands the payload of a private transaction over to the
+// private transaction manager, replacing it with the hash it's stored under.
+func (args *SendTxArgs) setPrivatePayload(b Backend) error {
+	manager := b.PrivateManager()
+	if manager == nil {
+		return errors.New("private transactions are disabled")
+	}
+	if args.Value != nil && args.Value.ToInt().Sign() != 0 {
+		return errors.New("private transactions can't transfer value")
+	}
+	var input []byte
+	if args.Input != nil {
+		input = *args.Input
+	} else if args.Data != nil {
+		input = *args.Data
+	}
+	// The sending node is a party too, executing the payload as well
+	if !containsParty(args.PrivateFor, manager.Identity()) {
+		args.PrivateFor = append(args.PrivateFor, manager.Identity())
+	}
+	hash, err := manager.Send(input, args.From, args.PrivateFor)
+	if err != nil {
+		return err
+	}
+	payload := hexutil.Bytes(hash.Bytes())
+	args.Data, args.Input = nil, &payload
+
+	// The public transaction must at least afford carrying the hash and parties
+	intrinsic, err := core.IntrinsicGas(payload, false, true, true)
+	if err != nil {
+		return err
+	}
+	partiesGas, err := core.PrivateForGas(args.PrivateFor, true)
+	if err != nil {
+		return err
+	}
+	intrinsic += partiesGas
+	if uint64(*args.Gas) < intrinsic {
+		args.Gas = (*hexutil.Uint64)(&intrinsic)
+	}
 	return nil
 }
 
@@ -1446,10 +1551,16 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	} else if args.Data != nil {
 		input = *args.Data
 	}
+	var tx *types.Transaction
 	if args.To == nil {
-		return types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
+		tx = types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
+	} else {
+		tx = types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
+	}
+	if len(args.PrivateFor) > 0 {
+		tx = tx.WithPrivateFor(args.PrivateFor)
 	}
-	return types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
+	return tx
 }
 
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
@@ -33,6 +33,7 @@ import (
 	"github.com/clearmatics/autonity/ethdb"
 	"github.com/clearmatics/autonity/event"
 	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/private"
 	"github.com/clearmatics/autonity/rpc"
 )
 
@@ -76,9 +77,15 @@ type Backend interface {
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
 
+	// Private transaction API
+	PrivateManager() private.Manager
+	PrivateStateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error)
+	GetPrivateReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+
 	// Filter API
 	BloomStatus() (uint64, uint64)
 	GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error)
+	GetSystemCalls(ctx context.Context, blockHash common.Hash) ([]*types.SystemCall, error)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription
@@ -370,6 +370,17 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceFinalize',
+			call: 'debug_traceFinalize',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'traceSystemCalls',
+			call: 'debug_traceSystemCalls',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -829,6 +840,34 @@ web3._extend({
 			name: 'getWhitelist',
 			call: 'tendermint_getWhitelist',
 			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getParticipation',
+			call: 'tendermint_getParticipation',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorParticipation',
+			call: 'tendermint_getValidatorParticipation',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorActivity',
+			call: 'tendermint_getValidatorActivity',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getParticipationRecord',
+			call: 'tendermint_getParticipationRecord',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getFinalityProof',
+			call: 'tendermint_getFinalityProof',
+			params: 1
 		})
 	]
 });
@@ -37,6 +37,7 @@ import (
 	"github.com/clearmatics/autonity/event"
 	"github.com/clearmatics/autonity/light"
 	"github.com/clearmatics/autonity/params"
+	"github.com/clearmatics/autonity/private"
 	"github.com/clearmatics/autonity/rpc"
 )
 
@@ -60,6 +61,13 @@ func (b *LesApiBackend) SetHead(number uint64) {
 }
 
 func (b *LesApiBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	// Committed BFT blocks can't be reverted, so the head is final
+	if number.IsFinalized() {
+		if b.ChainConfig().Tendermint == nil {
+			return nil, core.ErrNoFinality
+		}
+		return b.eth.blockchain.CurrentHeader(), nil
+	}
 	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
 		return b.eth.blockchain.CurrentHeader(), nil
 	}
@@ -164,6 +172,12 @@ func (b *LesApiBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*typ
 	return nil, nil
 }
 
+// GetSystemCalls returns no system calls, light clients don't process the blocks
+// the calls are recorded by.
+func (b *LesApiBackend) GetSystemCalls(ctx context.Context, hash common.Hash) ([]*types.SystemCall, error) {
+	return nil, nil
+}
+
 func (b *LesApiBackend) GetTd(hash common.Hash) *big.Int {
 	return b.eth.blockchain.GetTdByHash(hash)
 }
@@ -269,6 +283,18 @@ func (b *LesApiBackend) RPCGasCap() *big.Int {
 	return b.eth.config.RPCGasCap
 }
 
+func (b *LesApiBackend) PrivateManager() private.Manager {
+	return nil
+}
+
+func (b *LesApiBackend) PrivateStateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	return nil, nil, errors.New("private transactions are not supported by light clients")
+}
+
+func (b *LesApiBackend) GetPrivateReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return nil, nil
+}
+
 func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	if b.eth.bloomIndexer == nil {
 		return 0, 0
@@ -373,6 +373,9 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	if tx.Value().Sign() < 0 {
 		return core.ErrNegativeValue
 	}
+	if err := core.ValidatePrivateTx(pool.config, new(big.Int).Add(header.Number, big.NewInt(1)), tx); err != nil {
+		return err
+	}
 
 	// Transactor should have enough funds to cover the costs
 	// cost == V + GP * GL
@@ -381,7 +384,7 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	}
 
 	// Should supply enough intrinsic gas
-	gas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul)
+	gas, err := core.TxIntrinsicGas(tx, true, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,330 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package prometheus
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/clearmatics/autonity/metrics"
+)
+
+var (
+	typeHistogramTpl = "# TYPE %s histogram\n"
+	labelValueTpl    = "%s=\"%s\""
+	labeledValueTpl  = "%s{%s} %v\n"
+	unlabeledTpl     = "%s %v\n"
+)
+
+// labelEscaper escapes label values as the Prometheus text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labeled is a metric exported with labels, which the go-metrics registry has
+// no notion of. Labeled metrics are registered in this package and exported by
+// the Handler along with the metrics of the registry.
+type labeled interface {
+	write(c *collector)
+}
+
+var (
+	labeledLock sync.Mutex
+	labeledSet  = make(map[string]labeled) // labeled metrics by name
+	excluded    []string                   // prefixes of the registry metrics not exported
+)
+
+// getOrRegister returns the labeled metric registered under the name, or
+// registers the given one.
+func getOrRegister(name string, m labeled) labeled {
+	labeledLock.Lock()
+	defer labeledLock.Unlock()
+
+	if existing, ok := labeledSet[name]; ok {
+		return existing
+	}
+	labeledSet[name] = m
+	return m
+}
+
+// Exclude stops exporting the metrics of the registry whose name starts with
+// the prefix. It is meant for the dynamically named metrics that a labeled
+// metric exports in a way fit for Prometheus, while the other reporters keep
+// reading them from the registry.
+func Exclude(prefix string) {
+	labeledLock.Lock()
+	defer labeledLock.Unlock()
+
+	excluded = append(excluded, prefix)
+}
+
+// isExcluded reports whether the metric of the registry is not exported.
+func isExcluded(name string) bool {
+	labeledLock.Lock()
+	defer labeledLock.Unlock()
+
+	for _, prefix := range excluded {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeLabeled writes the labeled metrics, sorted by name.
+func (c *collector) writeLabeled() {
+	labeledLock.Lock()
+	names := make([]string, 0, len(labeledSet))
+	for name := range labeledSet {
+		names = append(names, name)
+	}
+	all := make([]labeled, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		all[i] = labeledSet[name]
+	}
+	labeledLock.Unlock()
+
+	for _, m := range all {
+		m.write(c)
+	}
+}
+
+// labelSet is a set of label values, keyed by their joined values.
+type labelSet struct {
+	names  []string
+	values map[string][]string
+}
+
+func newLabelSet(names []string) labelSet {
+	return labelSet{names: names, values: make(map[string][]string)}
+}
+
+// key returns the key of the label values, which must match the label names.
+func (s labelSet) key(values []string) string {
+	if len(values) != len(s.names) {
+		panic(fmt.Sprintf("label values mismatch: have %d, want %d", len(values), len(s.names)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// sorted returns the keys of the label values in a stable order.
+func (s labelSet) sorted() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// format formats the label values with the extra label pairs appended.
+func (s labelSet) format(values []string, extra ...string) string {
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+	for i, value := range values {
+		pairs = append(pairs, fmt.Sprintf(labelValueTpl, s.names[i], labelEscaper.Replace(value)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(labelValueTpl, extra[i], labelEscaper.Replace(extra[i+1])))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// GaugeVec is a set of float64 gauges sharing a name, told apart by the values
+// of their labels.
+type GaugeVec struct {
+	name   string
+	labels labelSet
+	gauges map[string]float64
+	lock   sync.Mutex
+}
+
+// NewRegisteredGaugeVec constructs and registers a new GaugeVec with the given
+// label names, or returns the one already registered under the name.
+func NewRegisteredGaugeVec(name string, labels ...string) *GaugeVec {
+	return getOrRegister(name, &GaugeVec{
+		name:   name,
+		labels: newLabelSet(labels),
+		gauges: make(map[string]float64),
+	}).(*GaugeVec)
+}
+
+// Update sets the value of the gauge with the given label values.
+func (g *GaugeVec) Update(value float64, labels ...string) {
+	if !metrics.Enabled {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	key := g.labels.key(labels)
+	g.labels.values[key] = labels
+	g.gauges[key] = value
+}
+
+// Delete removes the gauge with the given label values.
+func (g *GaugeVec) Delete(labels ...string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	key := g.labels.key(labels)
+	delete(g.labels.values, key)
+	delete(g.gauges, key)
+}
+
+// Reset removes all the gauges.
+func (g *GaugeVec) Reset() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.labels.values = make(map[string][]string)
+	g.gauges = make(map[string]float64)
+}
+
+// Value returns the value of the gauge with the given label values, and whether
+// it is set.
+func (g *GaugeVec) Value(labels ...string) (float64, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	value, ok := g.gauges[g.labels.key(labels)]
+	return value, ok
+}
+
+func (g *GaugeVec) write(c *collector) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if len(g.gauges) == 0 {
+		return
+	}
+	name := mutateKey(g.name)
+	c.buff.WriteString(fmt.Sprintf(typeGaugeTpl, name))
+	for _, key := range g.labels.sorted() {
+		c.writeLabeledValue(name, g.labels.format(g.labels.values[key]), g.gauges[key])
+	}
+	c.buff.WriteString("\n")
+}
+
+// histogram is the state of a histogram of a HistogramVec.
+type histogram struct {
+	counts []uint64 // cumulative count of the observations per bucket
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec is a set of histograms sharing a name and buckets, told apart by
+// the values of their labels. Unlike the sampled go-metrics histograms, it
+// counts every observation in fixed buckets, which Prometheus can aggregate.
+type HistogramVec struct {
+	name       string
+	buckets    []float64 // upper bounds of the buckets, sorted
+	labels     labelSet
+	histograms map[string]*histogram
+	lock       sync.Mutex
+}
+
+// NewRegisteredHistogramVec constructs and registers a new HistogramVec with
+// the given bucket upper bounds and label names, or returns the one already
+// registered under the name.
+func NewRegisteredHistogramVec(name string, buckets []float64, labels ...string) *HistogramVec {
+	// The +Inf bucket is always exported
+	var sorted []float64
+	for _, bound := range buckets {
+		if !math.IsInf(bound, 1) {
+			sorted = append(sorted, bound)
+		}
+	}
+	sort.Float64s(sorted)
+
+	return getOrRegister(name, &HistogramVec{
+		name:       name,
+		buckets:    sorted,
+		labels:     newLabelSet(labels),
+		histograms: make(map[string]*histogram),
+	}).(*HistogramVec)
+}
+
+// Observe adds an observation to the histogram with the given label values.
+func (h *HistogramVec) Observe(value float64, labels ...string) {
+	if !metrics.Enabled {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	key := h.labels.key(labels)
+	hist, ok := h.histograms[key]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.histograms[key] = hist
+		h.labels.values[key] = labels
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += value
+}
+
+// Count returns the number of observations of the histogram with the given
+// label values.
+func (h *HistogramVec) Count(labels ...string) uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if hist, ok := h.histograms[h.labels.key(labels)]; ok {
+		return hist.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(c *collector) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if len(h.histograms) == 0 {
+		return
+	}
+	name := mutateKey(h.name)
+	c.buff.WriteString(fmt.Sprintf(typeHistogramTpl, name))
+	for _, key := range h.labels.sorted() {
+		var (
+			hist   = h.histograms[key]
+			values = h.labels.values[key]
+		)
+		for i, bound := range h.buckets {
+			c.writeLabeledValue(name+"_bucket", h.labels.format(values, "le", strconv.FormatFloat(bound, 'g', -1, 64)), hist.counts[i])
+		}
+		c.writeLabeledValue(name+"_bucket", h.labels.format(values, "le", "+Inf"), hist.count)
+		c.writeLabeledValue(name+"_sum", h.labels.format(values), hist.sum)
+		c.writeLabeledValue(name+"_count", h.labels.format(values), hist.count)
+	}
+	c.buff.WriteString("\n")
+}
+
+func (c *collector) writeLabeledValue(name, labels string, value interface{}) {
+	if labels == "" {
+		c.buff.WriteString(fmt.Sprintf(unlabeledTpl, name, value))
+		return
+	}
+	c.buff.WriteString(fmt.Sprintf(labeledValueTpl, name, labels, value))
+}
@@ -0,0 +1,64 @@
+package prometheus
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clearmatics/autonity/metrics"
+)
+
+func TestHandlerLabeled(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	reg := metrics.NewRegistry()
+	metrics.NewRegisteredGauge("test/kept", reg).Update(1)
+	metrics.NewRegisteredGauge("test/dynamic/0xabc/stake", reg).Update(2)
+	Exclude("test/dynamic/")
+
+	gauge := NewRegisteredGaugeVec("test/labeled/stake", "address", "role")
+	gauge.Update(2, "0xabc", "validator")
+	gauge.Update(3, "0xdef", "participant")
+	gauge.Update(4, "0xdef", "stake\"holder")
+	gauge.Delete("0xdef", "participant")
+
+	hist := NewRegisteredHistogramVec("test/labeled/latency", []float64{1, 0.5}, "step")
+	hist.Observe(0.2, "prevote")
+	hist.Observe(0.7, "prevote")
+	hist.Observe(3, "prevote")
+
+	if same := NewRegisteredGaugeVec("test/labeled/stake", "address", "role"); same != gauge {
+		t.Fatal("registering the same name twice should return the existing gauge")
+	}
+	if have := hist.Count("prevote"); have != 3 {
+		t.Fatalf("observations mismatch: have %d, want 3", have)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body, _ := ioutil.ReadAll(rec.Body)
+	have := string(body)
+
+	for _, want := range []string{
+		"test_kept 1\n",
+		"# TYPE test_labeled_stake gauge\n" +
+			"test_labeled_stake{address=\"0xabc\",role=\"validator\"} 2\n" +
+			"test_labeled_stake{address=\"0xdef\",role=\"stake\\\"holder\"} 4\n",
+		"# TYPE test_labeled_latency histogram\n" +
+			"test_labeled_latency_bucket{step=\"prevote\",le=\"0.5\"} 1\n" +
+			"test_labeled_latency_bucket{step=\"prevote\",le=\"1\"} 2\n" +
+			"test_labeled_latency_bucket{step=\"prevote\",le=\"+Inf\"} 3\n" +
+			"test_labeled_latency_sum{step=\"prevote\"} 3.9\n" +
+			"test_labeled_latency_count{step=\"prevote\"} 3\n",
+	} {
+		if !strings.Contains(have, want) {
+			t.Errorf("missing output:\n%s\nhave:\n%s", want, have)
+		}
+	}
+	if strings.Contains(have, "test_dynamic") || strings.Contains(have, "participant") {
+		t.Errorf("excluded or deleted metrics exported:\n%s", have)
+	}
+}
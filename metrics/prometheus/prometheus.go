@@ -40,6 +40,9 @@ func Handler(reg metrics.Registry) http.Handler {
 		c := newCollector()
 
 		for _, name := range names {
+			if isExcluded(name) {
+				continue
+			}
 			i := reg.Get(name)
 
 			switch m := i.(type) {
@@ -61,6 +64,8 @@ func Handler(reg metrics.Registry) http.Handler {
 				log.Warn("Unknown Prometheus metric type", "type", fmt.Sprintf("%T", i))
 			}
 		}
+		c.writeLabeled()
+
 		w.Header().Add("Content-Type", "text/plain")
 		w.Header().Add("Content-Length", fmt.Sprint(c.buff.Len()))
 		w.Write(c.buff.Bytes())
@@ -0,0 +1,63 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/types"
+)
+
+// pipelinedChain is the local chain extended with a block which isn't decided
+// yet, the next block is built on top of it while it is being decided.
+type pipelinedChain struct {
+	*core.BlockChain
+	parent *types.Block
+}
+
+// GetHeader retrieves a block header by hash and number, including the parent.
+func (c *pipelinedChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if hash == c.parent.Hash() && number == c.parent.NumberU64() {
+		return c.parent.Header()
+	}
+	return c.BlockChain.GetHeader(hash, number)
+}
+
+// GetHeaderByHash retrieves a block header by hash, including the parent.
+func (c *pipelinedChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	if hash == c.parent.Hash() {
+		return c.parent.Header()
+	}
+	return c.BlockChain.GetHeaderByHash(hash)
+}
+
+// GetHeaderByNumber retrieves a block header by number, the parent takes the
+// place of any block committed at its height.
+func (c *pipelinedChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number == c.parent.NumberU64() {
+		return c.parent.Header()
+	}
+	return c.BlockChain.GetHeaderByNumber(number)
+}
+
+// GetBlock retrieves a block by hash and number, including the parent.
+func (c *pipelinedChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	if hash == c.parent.Hash() && number == c.parent.NumberU64() {
+		return c.parent
+	}
+	return c.BlockChain.GetBlock(hash, number)
+}
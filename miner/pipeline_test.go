@@ -0,0 +1,52 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/clearmatics/autonity/consensus/ethash"
+	"github.com/clearmatics/autonity/core"
+	"github.com/clearmatics/autonity/core/rawdb"
+	"github.com/clearmatics/autonity/core/vm"
+	"github.com/clearmatics/autonity/params"
+)
+
+func TestPipelinedChain(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		engine  = ethash.NewFaker()
+		gspec   = core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil, core.NewTxSenderCacher())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 1, nil)
+	parent := blocks[0]
+	pipelined := &pipelinedChain{BlockChain: chain, parent: parent}
+
+	if h := pipelined.GetHeader(parent.Hash(), parent.NumberU64()); h == nil || h.Hash() != parent.Hash() {
+		t.Fatalf("parent header mismatch: have %v, want %v", h, parent.Header())
+	}
+	if h := pipelined.GetHeaderByHash(parent.Hash()); h == nil || h.Hash() != parent.Hash() {
+		t.Fatalf("parent header mismatch: have %v, want %v", h, parent.Header())
+	}
+	if h := pipelined.GetHeaderByNumber(parent.NumberU64()); h == nil || h.Hash() != parent.Hash() {
+		t.Fatalf("parent header mismatch: have %v, want %v", h, parent.Header())
+	}
+	if b := pipelined.GetBlock(parent.Hash(), parent.NumberU64()); b != parent {
+		t.Fatalf("parent mismatch: have %v, want %v", b, parent)
+	}
+	// the committed blocks are read from the chain
+	if h := pipelined.GetHeaderByNumber(0); h == nil || h.Hash() != genesis.Hash() {
+		t.Fatalf("genesis header mismatch: have %v, want %v", h, genesis.Header())
+	}
+	if b := pipelined.GetBlock(genesis.Hash(), 0); b == nil || b.Hash() != genesis.Hash() {
+		t.Fatalf("genesis mismatch: have %v, want %v", b, genesis)
+	}
+	if chain.GetHeaderByNumber(parent.NumberU64()) != nil {
+		t.Fatal("parent inserted in the chain")
+	}
+}
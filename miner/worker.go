@@ -30,6 +30,7 @@ import (
 	"github.com/clearmatics/autonity/common"
 	"github.com/clearmatics/autonity/consensus"
 	"github.com/clearmatics/autonity/consensus/misc"
+	"github.com/clearmatics/autonity/contracts/autonity"
 	"github.com/clearmatics/autonity/core"
 	"github.com/clearmatics/autonity/core/state"
 	"github.com/clearmatics/autonity/core/types"
@@ -89,6 +90,9 @@ type environment struct {
 	tcount    int            // tx count in cycle
 	gasPool   *core.GasPool  // available gas used to pack transactions
 
+	permissions *autonity.Permissions // accounts allowed to submit transactions, nil if unrestricted
+
+	chain    consensus.ChainReader // chain the block is built on, may hold a pipelined parent
 	header   *types.Header
 	txs      []*types.Transaction
 	receipts []*types.Receipt
@@ -99,6 +103,7 @@ type task struct {
 	receipts  []*types.Receipt
 	state     *state.StateDB
 	block     *types.Block
+	chain     consensus.ChainReader
 	createdAt time.Time
 }
 
@@ -141,6 +146,8 @@ type worker struct {
 	chainHeadSub event.Subscription
 	chainSideCh  chan core.ChainSideEvent
 	chainSideSub event.Subscription
+	pipelineCh   chan consensus.PipelineEvent
+	pipelineSub  event.Subscription
 
 	// Channels
 	newWorkCh          chan *newWorkReq
@@ -155,6 +162,8 @@ type worker struct {
 	localUncles  map[common.Hash]*types.Block // A set of side blocks generated locally as the possible uncle blocks.
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
+	pipelined    *environment                 // Environment of the block built on top of a parent not decided yet.
+	priority     *core.TxPriority             // Priority lane of the Autonity operator transactions.
 
 	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
 	coinbase common.Address
@@ -210,6 +219,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		startCh:            make(chan struct{}, 1),
 		resubmitIntervalCh: make(chan time.Duration),
 		resubmitAdjustCh:   make(chan *intervalAdjust, resubmitAdjustChanSize),
+		priority:           core.NewTxPriority(chainConfig),
 	}
 
 	// Submit first work to initialize pending state.
@@ -266,6 +276,12 @@ func (w *worker) init() {
 		w.chainHeadSub = w.eth.BlockChain().SubscribeChainHeadEvent(w.chainHeadCh)
 		w.chainSideSub = w.eth.BlockChain().SubscribeChainSideEvent(w.chainSideCh)
 
+		// Subscribe the parents announced by the consensus engine to build blocks in advance
+		if p, ok := w.engine.(consensus.Pipeliner); ok && w.config.Pipeline {
+			w.pipelineCh = make(chan consensus.PipelineEvent, chainHeadChanSize)
+			w.pipelineSub = p.SubscribePipelineEvent(w.pipelineCh)
+		}
+
 		// Sanitize recommit interval if the user-specified one is too short.
 		recommit := w.config.Recommit
 		if recommit < minRecommitInterval {
@@ -446,12 +462,20 @@ func (w *worker) mainLoop() {
 	defer w.txsSub.Unsubscribe()
 	defer w.chainHeadSub.Unsubscribe()
 	defer w.chainSideSub.Unsubscribe()
+	if w.pipelineSub != nil {
+		defer w.pipelineSub.Unsubscribe()
+	}
 
 	for {
 		select {
 		case req := <-w.newWorkCh:
 			w.commitNewWork(req.interrupt, req.noempty, req.timestamp)
 
+		case ev := <-w.pipelineCh:
+			if w.isRunning() {
+				w.commitPipelinedWork(ev.Parent, ev.State)
+			}
+
 		case ev := <-w.chainSideCh:
 			// Short circuit for duplicate side blocks
 			if _, exist := w.localUncles[ev.Block.Hash()]; exist {
@@ -573,7 +597,7 @@ func (w *worker) taskLoop() {
 			w.pendingTasks[w.engine.SealHash(task.block.Header())] = task
 			w.pendingMu.Unlock()
 
-			if err := w.engine.Seal(w.chain, task.block, w.resultCh, stopCh); err != nil {
+			if err := w.engine.Seal(task.chain, task.block, w.resultCh, stopCh); err != nil {
 				log.Warn("Block sealing failed", "err", err)
 			}
 		case <-w.exitCh:
@@ -649,21 +673,35 @@ func (w *worker) resultLoop() {
 	}
 }
 
-// makeCurrent creates a new environment for the current cycle.
-func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
-	state, err := w.chain.StateAt(parent.Root())
-	if err != nil {
-		return err
+// makeCurrent creates a new environment for the current cycle. The parent state
+// is given if the parent isn't committed yet.
+func (w *worker) makeCurrent(chain consensus.ChainReader, parent *types.Block, parentState *state.StateDB, header *types.Header) error {
+	state := parentState
+	if state == nil {
+		var err error
+		if state, err = w.chain.StateAt(parent.Root()); err != nil {
+			return err
+		}
 	}
 	env := &environment{
 		signer:    types.NewEIP155Signer(w.chainConfig.ChainID),
 		state:     state,
+		chain:     chain,
 		ancestors: mapset.NewSet(),
 		family:    mapset.NewSet(),
 		uncles:    mapset.NewSet(),
 		header:    header,
 	}
 
+	// Resolve the submission permissions before any transaction changes the state
+	if contract := w.chain.GetAutonityContract(); contract != nil {
+		permissions, err := contract.GetPermissions(parent.Header(), state)
+		if err != nil {
+			return err
+		}
+		env.permissions = permissions
+	}
+
 	// when 08 is processed ancestors contain 07 (quick block)
 	for _, ancestor := range w.chain.GetBlocksFromHash(parent.Hash(), 7) {
 		for _, uncle := range ancestor.Uncles() {
@@ -734,7 +772,7 @@ func (w *worker) updateSnapshot() {
 func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Address) ([]*types.Log, error) {
 	snap := w.current.state.Snapshot()
 
-	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &coinbase, w.current.gasPool, w.current.state, w.current.header, tx, &w.current.header.GasUsed, *w.chain.GetVMConfig())
+	receipt, err := core.ApplyTransaction(w.chainConfig, w.current.chain, &coinbase, w.current.gasPool, w.current.state, w.current.header, tx, &w.current.header.GasUsed, *w.chain.GetVMConfig())
 	if err != nil {
 		w.current.state.RevertToSnapshot(snap)
 		return nil, err
@@ -801,6 +839,13 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			txs.Pop()
 			continue
 		}
+		// Skip the senders which aren't allowed to submit the transaction
+		if err := w.current.permissions.Check(from, tx.To() == nil); err != nil {
+			log.Trace("Ignoring unauthorized transaction", "hash", tx.Hash(), "sender", from, "err", err)
+
+			txs.Pop()
+			continue
+		}
 		// Start executing the transaction
 		w.current.state.Prepare(tx.Hash(), common.Hash{}, w.current.tcount)
 
@@ -858,13 +903,48 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 	return false
 }
 
-// commitNewWork generates several new sealing tasks based on the parent block.
+// commitNewWork generates several new sealing tasks based on the current head.
 func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64) {
+	parent := w.chain.CurrentBlock()
+
+	if w.pipelined != nil {
+		if w.pipelined.header.ParentHash == parent.Hash() && !noempty {
+			// The block built in advance on top of the new head is being sealed
+			log.Debug("Pipelined block extends the new head", "number", w.pipelined.header.Number)
+			w.current, w.pipelined = w.pipelined, nil
+			w.updateSnapshot()
+			return
+		}
+		if noempty && w.pipelined.header.ParentHash != parent.Hash() {
+			// Don't replace the pipelined block while its parent is decided
+			return
+		}
+		w.pipelined = nil
+	}
+	w.commitWork(w.chain, parent, nil, interrupt, noempty, timestamp)
+}
+
+// commitPipelinedWork generates a sealing task on top of a parent not decided
+// yet, so that it is proposed as soon as the parent is committed.
+func (w *worker) commitPipelinedWork(parent *types.Block, parentState *state.StateDB) {
+	// Only a parent on top of the head and not committed yet is worth building on
+	if parent.NumberU64() != w.chain.CurrentBlock().NumberU64()+1 || w.chain.HasBlock(parent.Hash(), parent.NumberU64()) {
+		return
+	}
+	log.Debug("Building pipelined block", "parent", parent.Hash(), "number", parent.NumberU64()+1)
+	if w.commitWork(&pipelinedChain{BlockChain: w.chain, parent: parent}, parent, parentState, nil, true, time.Now().Unix()) {
+		w.pipelined = w.current
+	}
+}
+
+// commitWork generates several new sealing tasks based on the parent block and
+// reports whether the full block was submitted.
+func (w *worker) commitWork(chain consensus.ChainReader, parent *types.Block, parentState *state.StateDB, interrupt *int32, noempty bool, timestamp int64) bool {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
 	tstart := time.Now()
-	parent := w.chain.CurrentBlock()
+	pipelined := parentState != nil
 
 	if parent.Time() >= uint64(timestamp) {
 		timestamp = int64(parent.Time() + 1)
@@ -888,13 +968,13 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	if w.isRunning() {
 		if w.coinbase == (common.Address{}) {
 			log.Error("Refusing to mine without etherbase")
-			return
+			return false
 		}
 		header.Coinbase = w.coinbase
 	}
-	if err := w.engine.Prepare(w.chain, header); err != nil {
+	if err := w.engine.Prepare(chain, header); err != nil {
 		log.Error("Failed to prepare header for mining", "err", err)
-		return
+		return false
 	}
 	// If we are care about TheDAO hard-fork check whether to override the extra-data or not
 	if daoBlock := w.chainConfig.DAOForkBlock; daoBlock != nil {
@@ -910,10 +990,10 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		}
 	}
 	// Could potentially happen if starting to mine in an odd state.
-	err := w.makeCurrent(parent, header)
+	err := w.makeCurrent(chain, parent, parentState, header)
 	if err != nil {
 		log.Error("Failed to create mining context", "err", err)
-		return
+		return false
 	}
 	// Create the current work task and check any fork transitions needed
 	env := w.current
@@ -955,12 +1035,20 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	pending, err := w.eth.TxPool().Pending()
 	if err != nil {
 		log.Error("Failed to fetch pending transactions", "err", err)
-		return
+		return false
 	}
 	// Short circuit if there is no available pending transactions
 	if len(pending) == 0 && w.chainConfig.Tendermint == nil {
 		w.updateSnapshot()
-		return
+		return false
+	}
+	// Commit the priority lane first. Its transactions are left in the pending
+	// set too: the ones which didn't fit compete on price with the others and
+	// the included ones are skipped on nonce.
+	if lane := w.priorityTxs(pending); len(lane) > 0 {
+		if w.commitPriorityTransactions(lane, w.coinbase, interrupt) {
+			return false
+		}
 	}
 	// Split the pending transactions into locals and remotes
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
@@ -973,16 +1061,55 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	if len(localTxs) > 0 {
 		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
-			return
+			return false
 		}
 	}
 	if len(remoteTxs) > 0 {
 		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, remoteTxs)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
-			return
+			return false
 		}
 	}
-	w.commit(uncles, w.fullTaskHook, true, tstart)
+	// The pending block is still the one on top of the current head
+	return w.commit(uncles, w.fullTaskHook, !pipelined, tstart) == nil
+}
+
+// priorityTxs returns the leading pending transactions of the Autonity operator
+// which belong to the priority lane, if the lane is enabled.
+func (w *worker) priorityTxs(pending map[common.Address]types.Transactions) map[common.Address]types.Transactions {
+	if w.priority == nil || w.config.PriorityGas == 0 {
+		return nil
+	}
+	operator := w.priority.Operator()
+	txs := pending[operator]
+	n := 0
+	for n < len(txs) && w.priority.Contains(txs[n]) {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	return map[common.Address]types.Transactions{operator: txs[:n]}
+}
+
+// commitPriorityTransactions commits the priority lane within the block gas
+// reserved to it, releasing what's left of the reservation afterwards.
+func (w *worker) commitPriorityTransactions(txs map[common.Address]types.Transactions, coinbase common.Address, interrupt *int32) bool {
+	if w.current == nil {
+		return true
+	}
+	available := w.current.header.GasLimit
+	if w.current.gasPool != nil {
+		available = w.current.gasPool.Gas()
+	}
+	reserved := w.config.PriorityGas
+	if reserved > available {
+		reserved = available
+	}
+	w.current.gasPool = new(core.GasPool).AddGas(reserved)
+	done := w.commitTransactions(types.NewTransactionsByPriceAndNonce(w.current.signer, txs), coinbase, interrupt)
+	w.current.gasPool.AddGas(available - reserved)
+	return done
 }
 
 // commit runs any post-transaction state modifications, assembles the final block
@@ -995,7 +1122,7 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 		*receipts[i] = *l
 	}
 	s := w.current.state.Copy()
-	block, err := w.engine.FinalizeAndAssemble(w.chain, w.current.header, s, w.current.txs, uncles, w.current.receipts)
+	block, err := w.engine.FinalizeAndAssemble(w.current.chain, w.current.header, s, w.current.txs, uncles, w.current.receipts)
 	if err != nil {
 		return err
 	}
@@ -1004,7 +1131,7 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 			interval()
 		}
 		select {
-		case w.taskCh <- &task{receipts: receipts, state: s, block: block, createdAt: time.Now()}:
+		case w.taskCh <- &task{receipts: receipts, state: s, block: block, chain: w.current.chain, createdAt: time.Now()}:
 			w.unconfirmed.Shift(block.NumberU64() - 1)
 
 			feesWei := new(big.Int)
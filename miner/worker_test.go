@@ -575,3 +575,52 @@ func testAdjustInterval(t *testing.T, testCase *testCase, chainConfig *params.Ch
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestPriorityLane(t *testing.T) {
+	operatorKey, _ := crypto.GenerateKey()
+	operator := crypto.PubkeyToAddress(operatorKey.PublicKey)
+
+	chainConfig := *params.TestChainConfig
+	chainConfig.AutonityContractConfig = &params.AutonityContractGenesis{
+		Deployer: common.HexToAddress("0xdeadbeef"),
+		Operator: operator,
+	}
+	contract, _ := chainConfig.AutonityContractConfig.GetContractAddress()
+
+	signed := func(nonce uint64, to common.Address) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(0), params.TxGas, big.NewInt(1), nil), types.HomesteadSigner{}, operatorKey)
+		return tx
+	}
+	pending := map[common.Address]types.Transactions{
+		operator: {signed(0, contract), signed(1, contract), signed(2, common.Address{}), signed(3, contract)},
+	}
+	w := &worker{
+		config:      &Config{PriorityGas: 30000},
+		chainConfig: &chainConfig,
+		priority:    core.NewTxPriority(&chainConfig),
+	}
+	// Only the leading contract calls of the operator make the lane
+	lane := w.priorityTxs(pending)
+	if len(lane) != 1 || len(lane[operator]) != 2 {
+		t.Fatalf("priority lane mismatch: have %v", lane)
+	}
+	if len(pending[operator]) != 4 {
+		t.Fatalf("pending set altered: have %d transactions, want 4", len(pending[operator]))
+	}
+	// An unused reservation goes back to the rest of the block
+	w.current = &environment{
+		signer: types.NewEIP155Signer(chainConfig.ChainID),
+		header: &types.Header{GasLimit: 100000},
+	}
+	if w.commitPriorityTransactions(map[common.Address]types.Transactions{}, common.Address{}, nil) {
+		t.Fatalf("priority lane commit interrupted")
+	}
+	if gas := w.current.gasPool.Gas(); gas != 100000 {
+		t.Errorf("block gas mismatch: have %d, want %d", gas, 100000)
+	}
+	// A disabled lane holds nothing
+	w.config.PriorityGas = 0
+	if lane := w.priorityTxs(pending); lane != nil {
+		t.Errorf("disabled priority lane not empty: have %v", lane)
+	}
+}
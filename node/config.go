@@ -70,6 +70,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the engine of the persistent databases, "leveldb" or "pebble".
+	// If empty, existing databases are opened with the engine they were created
+	// with, and new ones are created with LevelDB.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -162,6 +167,11 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCAuth enables the authentication of the HTTP and websocket RPC clients,
+	// restricting each credential to its namespaces and methods, rate limiting
+	// and audit logging its calls. Nil leaves the endpoints open.
+	RPCAuth *rpc.AuthConfig `toml:",omitempty"`
+
 	// GraphQLHost is the host interface on which to start the GraphQL server. If this
 	// field is empty, no GraphQL API endpoint will be started.
 	GraphQLHost string `toml:",omitempty"`
@@ -51,6 +51,25 @@ type AutonityContractGenesis struct {
 	MinGasPrice uint64         `json:"minGasPrice" toml:",omitempty"`
 	Operator    common.Address `json:"operator" toml:",omitempty"`
 	Users       []User         `json:"users" toml:",omitempty"`
+	// Opt-in restriction of transaction submission to the contract users
+	Permissions *Permissions `json:"permissions,omitempty" toml:",omitempty"`
+}
+
+// Permissions restricts the accounts allowed to submit transactions to the
+// users registered in the Autonity contract. The operator is always allowed.
+type Permissions struct {
+	// User types allowed to deploy contracts, none if empty
+	Deployers []UserType `json:"deployers" toml:",omitempty"`
+}
+
+// CanDeploy checks whether users of the given type may deploy contracts.
+func (p *Permissions) CanDeploy(ut UserType) bool {
+	for _, deployer := range p.Deployers {
+		if deployer == ut {
+			return true
+		}
+	}
+	return false
 }
 
 func (ac *AutonityContractGenesis) AddDefault() *AutonityContractGenesis {
@@ -101,6 +120,14 @@ func (ac *AutonityContractGenesis) Validate() error {
 		}
 	}
 
+	if ac.Permissions != nil {
+		for _, deployer := range ac.Permissions.Deployers {
+			if !deployer.IsValid() {
+				return fmt.Errorf("incorrect deployer user type %q", deployer)
+			}
+		}
+	}
+
 	if len(ac.GetValidatorUsers()) == 0 {
 		return errors.New("validators list is empty")
 	}
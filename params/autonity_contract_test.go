@@ -121,6 +121,34 @@ func TestValidateAutonityContract_GovernanceOperatorNotExisted_Fail(t *testing.T
 		t.FailNow()
 	}
 }
+func TestValidateAutonityContract_InvalidDeployerType_Fail(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	node := enode.NewV4(&key.PublicKey, net.ParseIP("127.0.0.1"), 30303, 0)
+
+	contractConfig := AutonityContractGenesis{
+		Deployer: common.HexToAddress("0xff"),
+		Operator: common.HexToAddress("0xff"),
+		Bytecode: "some code",
+		ABI:      "some abi",
+		Users: []User{
+			{
+				Enode:   node.String(),
+				Type:    UserValidator,
+				Address: crypto.PubkeyToAddress(key.PublicKey),
+			},
+		},
+		Permissions: &Permissions{Deployers: []UserType{UserValidator, "operator"}},
+	}
+	err := contractConfig.Validate()
+	if err == nil {
+		t.FailNow()
+	}
+	contractConfig.Permissions.Deployers = []UserType{UserValidator}
+	if err := contractConfig.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestValidateAutonityContract_AddDefaulTest_Success(t *testing.T) {
 	contractConfig := &AutonityContractGenesis{
 		Deployer: common.HexToAddress("0xff"),
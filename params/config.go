@@ -212,9 +212,9 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, new(EthashConfig), nil, nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -286,6 +286,7 @@ type ChainConfig struct {
 	IstanbulBlock       *big.Int `json:"istanbulBlock,omitempty"`       // Istanbul switch block (nil = no fork, 0 = already on istanbul)
 	MuirGlacierBlock    *big.Int `json:"muirGlacierBlock,omitempty"`    // Eip-2384 (bomb delay) switch block (nil = no fork, 0 = already activated)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
+	PrivateTxBlock      *big.Int `json:"privateTxBlock,omitempty"`      // Private transactions switch block (nil = no fork, 0 = already activated)
 
 	// Various consensus engines
 	Ethash                 *EthashConfig            `json:"ethash,omitempty"`
@@ -307,6 +308,8 @@ type TendermintConfig struct {
 	ProposerPolicy uint64 `json:"policy"` // The policy for proposer selection
 	BlockPeriod    uint64 `json:"block-period"`
 	RequestTimeout uint64 `json:"request-timeout"`
+	// Number of heights over which the validators participation is tracked
+	ParticipationWindow uint64 `json:"participation-window,omitempty"`
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -394,6 +397,11 @@ func (c *ChainConfig) IsIstanbul(num *big.Int) bool {
 	return isForked(c.IstanbulBlock, num)
 }
 
+// IsPrivateTx returns whether num is either equal to the private transactions fork block or greater.
+func (c *ChainConfig) IsPrivateTx(num *big.Int) bool {
+	return isForked(c.PrivateTxBlock, num)
+}
+
 // IsEWASM returns whether num represents a block number after the EWASM fork
 func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
@@ -494,6 +502,9 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.PrivateTxBlock, newcfg.PrivateTxBlock, head) {
+		return newCompatError("private transactions fork block", c.PrivateTxBlock, newcfg.PrivateTxBlock)
+	}
 	return nil
 }
 
@@ -593,6 +604,9 @@ func (c *ChainConfig) Copy() *ChainConfig {
 	if c.EWASMBlock != nil {
 		cfg.EWASMBlock = big.NewInt(0).Set(c.EWASMBlock)
 	}
+	if c.PrivateTxBlock != nil {
+		cfg.PrivateTxBlock = big.NewInt(0).Set(c.PrivateTxBlock)
+	}
 
 	return cfg
 }
@@ -0,0 +1,174 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package private implements the off-chain distribution of the payloads of
+// private transactions among their parties.
+package private
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+)
+
+var (
+	// ErrNotFound is returned if no payload is known for a hash.
+	ErrNotFound = errors.New("private payload not found")
+
+	// ErrNotParty is returned if the node isn't a party of a private transaction.
+	ErrNotParty = errors.New("not a party of the private transaction")
+
+	// ErrMismatch is returned if a private payload was stored for another sender
+	// or other parties than the ones of the transaction carrying its hash.
+	ErrMismatch = errors.New("private payload doesn't match its transaction")
+)
+
+// Manager stores the payloads of private transactions and hands them over to
+// their parties only. Parties are identified by name.
+type Manager interface {
+	// Identity returns the name of the party the node acts for.
+	Identity() string
+
+	// Send stores a payload sent by the given account for the given parties and
+	// returns the hash under which it's retrievable, the one going on-chain.
+	Send(payload []byte, from common.Address, to []string) (common.Hash, error)
+
+	// Receive returns the payload whose hash a private transaction carries, if
+	// the node is one of its parties and the payload was stored by its sender
+	// for the same parties.
+	Receive(tx *types.Transaction, sender common.Address) ([]byte, error)
+}
+
+// envelope is a stored payload along with its sender and parties.
+type envelope struct {
+	From    string         `json:"from"`
+	Sender  common.Address `json:"sender"`
+	To      []string       `json:"to"`
+	Payload []byte         `json:"payload"`
+	Salt    []byte         `json:"salt"`
+}
+
+// LocalManager is a stand-in transaction manager keeping the payloads in a
+// directory, one file per hash. Nodes sharing the directory, e.g. on the same
+// host, exchange payloads through it.
+type LocalManager struct {
+	dir      string
+	identity string
+	lock     sync.Mutex
+}
+
+// NewLocalManager creates a stand-in manager storing payloads in dir on behalf
+// of the given party.
+func NewLocalManager(dir string, identity string) (*LocalManager, error) {
+	if identity == "" {
+		return nil, errors.New("private manager identity is empty")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &LocalManager{dir: dir, identity: identity}, nil
+}
+
+// Identity implements Manager.
+func (m *LocalManager) Identity() string {
+	return m.identity
+}
+
+// Send implements Manager, salting the payload so that identical payloads
+// can't be linked on-chain.
+func (m *LocalManager) Send(payload []byte, from common.Address, to []string) (common.Hash, error) {
+	if len(to) == 0 {
+		return common.Hash{}, errors.New("private payload without parties")
+	}
+	env := envelope{From: m.identity, Sender: from, To: to, Payload: payload, Salt: make([]byte, 32)}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return common.Hash{}, err
+	}
+	blob, err := json.Marshal(env)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash := crypto.Keccak256Hash(blob)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Write atomically as other nodes may read the directory concurrently
+	tmp := m.path(hash) + ".tmp"
+	if err := ioutil.WriteFile(tmp, blob, 0600); err != nil {
+		return common.Hash{}, err
+	}
+	return hash, os.Rename(tmp, m.path(hash))
+}
+
+// Receive implements Manager.
+func (m *LocalManager) Receive(tx *types.Transaction, sender common.Address) ([]byte, error) {
+	if !contains(tx.PrivateFor(), m.identity) {
+		return nil, ErrNotParty
+	}
+	hash := common.BytesToHash(tx.Data())
+	blob, err := ioutil.ReadFile(m.path(hash))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if crypto.Keccak256Hash(blob) != hash {
+		return nil, errors.New("corrupted private payload")
+	}
+	var env envelope
+	if err := json.Unmarshal(blob, &env); err != nil {
+		return nil, err
+	}
+	if env.Sender != sender || !equal(env.To, tx.PrivateFor()) {
+		return nil, ErrMismatch
+	}
+	return env.Payload, nil
+}
+
+func (m *LocalManager) path(hash common.Hash) string {
+	return filepath.Join(m.dir, hash.Hex())
+}
+
+func contains(parties []string, party string) bool {
+	for _, p := range parties {
+		if p == party {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package private
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/core/types"
+)
+
+func TestLocalManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "private-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	alice, _ := NewLocalManager(dir, "alice")
+	bob, _ := NewLocalManager(dir, "bob")
+	carol, _ := NewLocalManager(dir, "carol")
+
+	var (
+		payload = []byte("secret")
+		sender  = common.Address{0xaa}
+		parties = []string{"alice", "bob"}
+	)
+	hash, err := alice.Send(payload, sender, parties)
+	if err != nil {
+		t.Fatalf("failed to send payload: %v", err)
+	}
+	tx := newPrivateTx(hash, parties)
+	for _, m := range []*LocalManager{alice, bob} {
+		have, err := m.Receive(tx, sender)
+		if err != nil || !bytes.Equal(have, payload) {
+			t.Errorf("%s: payload mismatch: have %q (%v), want %q", m.Identity(), have, err, payload)
+		}
+	}
+	if _, err := carol.Receive(tx, sender); err != ErrNotParty {
+		t.Errorf("foreign payload error mismatch: have %v, want %v", err, ErrNotParty)
+	}
+	// Replaying the hash in another transaction doesn't hand the payload over
+	if _, err := bob.Receive(tx, common.Address{0xbb}); err != ErrMismatch {
+		t.Errorf("foreign sender error mismatch: have %v, want %v", err, ErrMismatch)
+	}
+	if _, err := carol.Receive(newPrivateTx(hash, []string{"alice", "bob", "carol"}), sender); err != ErrMismatch {
+		t.Errorf("foreign parties error mismatch: have %v, want %v", err, ErrMismatch)
+	}
+	if _, err := bob.Receive(newPrivateTx(common.Hash{1}, parties), sender); err != ErrNotFound {
+		t.Errorf("missing payload error mismatch: have %v, want %v", err, ErrNotFound)
+	}
+	// Identical payloads are salted apart
+	if other, _ := alice.Send(payload, sender, parties); other == hash {
+		t.Error("identical payloads share their hash")
+	}
+}
+
+func TestLocalManagerCorruption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "private-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, _ := NewLocalManager(dir, "alice")
+	hash, _ := m.Send([]byte("secret"), common.Address{}, []string{"alice"})
+	if err := ioutil.WriteFile(m.path(hash), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Receive(newPrivateTx(hash, []string{"alice"}), common.Address{}); err == nil {
+		t.Error("corrupted payload accepted")
+	}
+	if _, err := NewLocalManager(dir, ""); err == nil {
+		t.Error("anonymous manager created")
+	}
+}
+
+func newPrivateTx(hash common.Hash, parties []string) *types.Transaction {
+	return types.NewTransaction(0, common.Address{1}, common.Big0, 50000, common.Big1, hash.Bytes()).WithPrivateFor(parties)
+}
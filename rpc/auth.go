@@ -0,0 +1,367 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// jwtMaxClockDrift is how far the issuance time of a token without expiry may
+// deviate from the local clock.
+const jwtMaxClockDrift = 60 * time.Second
+
+// DefaultAuditNamespaces are the namespaces whose calls are audit logged when no
+// explicit list is configured.
+var DefaultAuditNamespaces = []string{"admin", "debug", "miner", "personal", "tendermint"}
+
+var (
+	errMissingCredential = errors.New("missing credential")
+	errInvalidToken      = errors.New("invalid token")
+	errExpiredToken      = errors.New("token expired or issued at an invalid time")
+	errUnknownCredential = errors.New("unknown credential")
+)
+
+// Credential identifies an RPC client and what it is allowed to call.
+type Credential struct {
+	// Name identifies the credential. It must match the "sub" claim of the JWTs
+	// or the common name of the TLS client certificates presented by the client.
+	Name string
+
+	// Secret is the hex encoded shared secret the client signs its HS256 JWTs
+	// with. Credentials without a secret can only authenticate with mTLS.
+	Secret string `toml:",omitempty"`
+
+	// Namespaces lists the API namespaces the credential may call, "*" allowing
+	// all of them.
+	Namespaces []string `toml:",omitempty"`
+
+	// Methods lists single methods, e.g. "admin_nodeInfo", the credential may call
+	// in addition to its namespaces.
+	Methods []string `toml:",omitempty"`
+
+	// RateLimit is the number of calls per second the credential may issue, zero
+	// meaning unlimited. RateBurst is the number of calls allowed at once.
+	RateLimit float64 `toml:",omitempty"`
+	RateBurst int     `toml:",omitempty"`
+}
+
+// AuthConfig configures the authentication and authorisation of the HTTP and
+// websocket RPC endpoints. Requests are authenticated either with a bearer JWT
+// or with a TLS client certificate.
+type AuthConfig struct {
+	// Credentials lists the clients allowed to access the endpoints.
+	Credentials []Credential
+
+	// PublicNamespaces lists the namespaces which can be called without any
+	// credential. Unauthenticated requests are rejected if it is empty.
+	PublicNamespaces []string `toml:",omitempty"`
+
+	// AuditNamespaces lists the namespaces whose calls are audit logged. Nil
+	// means DefaultAuditNamespaces.
+	AuditNamespaces []string `toml:",omitempty"`
+
+	// TLSCert and TLSKey are the certificate and key files the endpoints serve
+	// TLS with. TLSClientCA is the file of the CA certificates client
+	// certificates are verified against, enabling mTLS.
+	TLSCert     string `toml:",omitempty"`
+	TLSKey      string `toml:",omitempty"`
+	TLSClientCA string `toml:",omitempty"`
+}
+
+// credential is a Credential prepared for authenticating requests.
+type credential struct {
+	name       string
+	secret     []byte
+	namespaces map[string]bool
+	methods    map[string]bool
+	limiter    *rate.Limiter
+	audit      map[string]bool
+}
+
+type credentialKey struct{}
+
+// credentialFromContext returns the credential a call was authenticated with,
+// or nil if authentication is disabled.
+func credentialFromContext(ctx context.Context) *credential {
+	cred, _ := ctx.Value(credentialKey{}).(*credential)
+	return cred
+}
+
+// authorize checks whether the credential may call the method of msg. Calls
+// exceeding the rate limit of the credential are rejected.
+func (c *credential) authorize(msg *jsonrpcMessage) error {
+	if !c.namespaces["*"] && !c.namespaces[msg.namespace()] && !c.methods[msg.Method] {
+		return &unauthorizedError{method: msg.Method}
+	}
+	if c.limiter != nil && !c.limiter.Allow() {
+		return &rateLimitError{}
+	}
+	return nil
+}
+
+// authenticator authenticates HTTP requests against the configured credentials.
+type authenticator struct {
+	credentials map[string]*credential
+	public      *credential
+}
+
+func newAuthenticator(config *AuthConfig) (*authenticator, error) {
+	audit := make(map[string]bool)
+	namespaces := config.AuditNamespaces
+	if namespaces == nil {
+		namespaces = DefaultAuditNamespaces
+	}
+	for _, namespace := range namespaces {
+		audit[namespace] = true
+	}
+	a := &authenticator{credentials: make(map[string]*credential)}
+	for _, c := range config.Credentials {
+		if c.Name == "" {
+			return nil, errors.New("credential without name")
+		}
+		if _, ok := a.credentials[c.Name]; ok {
+			return nil, fmt.Errorf("duplicate credential %q", c.Name)
+		}
+		cred := &credential{
+			name:       c.Name,
+			namespaces: toSet(c.Namespaces),
+			methods:    toSet(c.Methods),
+			audit:      audit,
+		}
+		if c.Secret != "" {
+			secret, err := hex.DecodeString(strings.TrimPrefix(c.Secret, "0x"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid secret of credential %q: %v", c.Name, err)
+			}
+			if len(secret) < 32 {
+				return nil, fmt.Errorf("secret of credential %q is shorter than 32 bytes", c.Name)
+			}
+			cred.secret = secret
+		}
+		if c.RateLimit > 0 {
+			burst := c.RateBurst
+			if burst <= 0 {
+				burst = 1
+			}
+			cred.limiter = rate.NewLimiter(rate.Limit(c.RateLimit), burst)
+		}
+		a.credentials[c.Name] = cred
+	}
+	if len(config.PublicNamespaces) > 0 {
+		a.public = &credential{
+			name:       "public",
+			namespaces: toSet(config.PublicNamespaces),
+			audit:      audit,
+		}
+	}
+	return a, nil
+}
+
+// authenticate returns the credential of the request. A verified TLS client
+// certificate takes precedence over a bearer token.
+func (a *authenticator) authenticate(r *http.Request) (*credential, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		name := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		if cred, ok := a.credentials[name]; ok {
+			return cred, nil
+		}
+		return nil, errUnknownCredential
+	}
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		if a.public != nil {
+			return a.public, nil
+		}
+		return nil, errMissingCredential
+	}
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, errInvalidToken
+	}
+	return a.verifyToken(strings.TrimPrefix(header, "Bearer "), time.Now())
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Sub string `json:"sub"`
+	Iat *int64 `json:"iat"`
+	Exp *int64 `json:"exp"`
+}
+
+// verifyToken checks a HS256 JWT against the secret of the credential named by
+// its subject. Tokens must either carry an expiry in the future or have been
+// issued within jwtMaxClockDrift of now.
+func (a *authenticator) verifyToken(token string, now time.Time) (*credential, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+	var (
+		header jwtHeader
+		claims jwtClaims
+	)
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, errInvalidToken
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errInvalidToken
+	}
+	cred, ok := a.credentials[claims.Sub]
+	if !ok || cred.secret == nil {
+		return nil, errUnknownCredential
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	mac := hmac.New(sha256.New, cred.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errInvalidToken
+	}
+	switch {
+	case claims.Exp != nil:
+		if now.Unix() >= *claims.Exp {
+			return nil, errExpiredToken
+		}
+		if claims.Iat != nil && time.Unix(*claims.Iat, 0).After(now.Add(jwtMaxClockDrift)) {
+			return nil, errExpiredToken
+		}
+	case claims.Iat != nil:
+		drift := now.Sub(time.Unix(*claims.Iat, 0))
+		if drift > jwtMaxClockDrift || drift < -jwtMaxClockDrift {
+			return nil, errExpiredToken
+		}
+	default:
+		return nil, errExpiredToken
+	}
+	return cred, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	blob, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(blob, v)
+}
+
+// NewJWT creates a HS256 JWT for the credential name, signed with the hex encoded
+// secret and issued now. An expiry is added if lifetime is non-zero.
+func NewJWT(name string, secret string, lifetime time.Duration) (string, error) {
+	key, err := hex.DecodeString(strings.TrimPrefix(secret, "0x"))
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := map[string]interface{}{"sub": name, "iat": now.Unix()}
+	if lifetime != 0 {
+		claims["exp"] = now.Add(lifetime).Unix()
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token))
+	return token + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// authHandler authenticates requests before passing them on, storing the
+// credential in the request context.
+type authHandler struct {
+	auth *authenticator
+	next http.Handler
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cred, err := h.auth.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	h.next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), credentialKey{}, cred)))
+}
+
+// newAuthHandler wraps next with authentication if config is set.
+func newAuthHandler(config *AuthConfig, next http.Handler) (http.Handler, error) {
+	if config == nil {
+		return next, nil
+	}
+	auth, err := newAuthenticator(config)
+	if err != nil {
+		return nil, err
+	}
+	return &authHandler{auth, next}, nil
+}
+
+// newAuthListener wraps the listener with TLS if a certificate is configured.
+func newAuthListener(config *AuthConfig, listener net.Listener) (net.Listener, error) {
+	if config == nil || config.TLSCert == "" {
+		return listener, nil
+	}
+	cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if config.TLSClientCA != "" {
+		pem, err := ioutil.ReadFile(config.TLSClientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", config.TLSClientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		// Clients without certificate may still authenticate with a JWT.
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tls.NewListener(listener, tlsConfig), nil
+}
+
+func toSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, item := range list {
+		set[item] = true
+	}
+	return set
+}
@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	testAliceSecret = "0x0102030405060708091011121314151617181920212223242526272829303132"
+	testBobSecret   = "0x3132333435363738394041424344454647484950515253545556575859606162"
+)
+
+func newTestAuthConfig() *AuthConfig {
+	return &AuthConfig{
+		Credentials: []Credential{
+			{Name: "alice", Secret: testAliceSecret, Namespaces: []string{"test"}},
+			{Name: "bob", Secret: testBobSecret, Methods: []string{"nftest_echo"}, RateLimit: 0.001, RateBurst: 1},
+		},
+		PublicNamespaces: []string{"rpc"},
+		AuditNamespaces:  []string{"test"},
+	}
+}
+
+func newTestAuthServer(t *testing.T, ws bool) *httptest.Server {
+	server := newTestServer()
+	var handler http.Handler = server
+	if ws {
+		handler = server.WebsocketHandler([]string{"*"})
+	}
+	handler, err := newAuthHandler(newTestAuthConfig(), handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(handler)
+}
+
+func postAuth(t *testing.T, url, token, method string, params string) (int, *jsonrpcMessage) {
+	body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":` + params + `}`
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("content-type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	var msg jsonrpcMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, &msg
+}
+
+func TestAuthHTTP(t *testing.T) {
+	srv := newTestAuthServer(t, false)
+	defer srv.Close()
+
+	alice, err := NewJWT("alice", testAliceSecret, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewJWT("bob", testBobSecret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := NewJWT("alice", testBobSecret, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		token, method, params string
+		status, code          int
+	}{
+		{"", "rpc_modules", "[]", http.StatusOK, 0},
+		{"", "test_echo", `["x", 1]`, http.StatusOK, -32001},
+		{alice, "test_echo", `["x", 1]`, http.StatusOK, 0},
+		{alice, "nftest_echo", `[1]`, http.StatusOK, -32001},
+		{forged, "test_echo", `["x", 1]`, http.StatusUnauthorized, 0},
+		{"garbage", "test_echo", `["x", 1]`, http.StatusUnauthorized, 0},
+		{bob, "nftest_echo", `[1]`, http.StatusOK, 0},
+		{bob, "nftest_echo", `[1]`, http.StatusOK, -32005},
+	}
+	for i, test := range tests {
+		status, msg := postAuth(t, srv.URL, test.token, test.method, test.params)
+		if status != test.status {
+			t.Fatalf("test %d: status mismatch: have %d, want %d", i, status, test.status)
+		}
+		if msg == nil {
+			continue
+		}
+		switch {
+		case test.code == 0 && msg.Error != nil:
+			t.Fatalf("test %d: unexpected error: %v", i, msg.Error)
+		case test.code != 0 && (msg.Error == nil || msg.Error.Code != test.code):
+			t.Fatalf("test %d: error mismatch: have %v, want code %d", i, msg.Error, test.code)
+		}
+	}
+}
+
+func TestAuthWebsocket(t *testing.T) {
+	srv := newTestAuthServer(t, true)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer garbage"}}); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized handshake, have %v", err)
+	}
+	token, err := NewJWT("alice", testAliceSecret, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + token}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, test := range []struct {
+		method, params string
+		code           int
+	}{
+		{"test_echo", `["x", 1]`, 0},
+		{"nftest_someSubscription", `[1, 2]`, -32001},
+		{"nftest_subscribe", `["someSubscription", 1, 2]`, -32001},
+	} {
+		req := `{"jsonrpc":"2.0","id":1,"method":"` + test.method + `","params":` + test.params + `}`
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(req)); err != nil {
+			t.Fatal(err)
+		}
+		var msg jsonrpcMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if (test.code == 0) != (msg.Error == nil) || (msg.Error != nil && msg.Error.Code != test.code) {
+			t.Fatalf("%s: error mismatch: have %v, want code %d", test.method, msg.Error, test.code)
+		}
+	}
+}
+
+func TestVerifyTokenTime(t *testing.T) {
+	auth, err := newAuthenticator(newTestAuthConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := NewJWT("alice", testAliceSecret, 0)
+	lasting, _ := NewJWT("alice", testAliceSecret, time.Hour)
+
+	now := time.Now()
+	if _, err := auth.verifyToken(fresh, now); err != nil {
+		t.Fatalf("fresh token rejected: %v", err)
+	}
+	if _, err := auth.verifyToken(fresh, now.Add(2*jwtMaxClockDrift)); err != errExpiredToken {
+		t.Fatalf("stale token accepted: %v", err)
+	}
+	if _, err := auth.verifyToken(lasting, now.Add(30*time.Minute)); err != nil {
+		t.Fatalf("unexpired token rejected: %v", err)
+	}
+	if _, err := auth.verifyToken(lasting, now.Add(2*time.Hour)); err != errExpiredToken {
+		t.Fatalf("expired token accepted: %v", err)
+	}
+}
@@ -18,12 +18,14 @@ package rpc
 
 import (
 	"net"
+	"net/http"
 
 	"github.com/clearmatics/autonity/log"
 )
 
-// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts) (net.Listener, *Server, error) {
+// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules.
+// Requests are authenticated and authorised if auth is set.
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts, auth *AuthConfig) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -44,15 +46,20 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 		listener net.Listener
 		err      error
 	)
-	if listener, err = net.Listen("tcp", endpoint); err != nil {
+	srv, err := newAuthHandler(auth, handler)
+	if err != nil {
+		return nil, nil, err
+	}
+	if listener, err = listenAuth(endpoint, auth); err != nil {
 		return nil, nil, err
 	}
-	go NewHTTPServer(cors, vhosts, timeouts, handler).Serve(listener)
+	go NewHTTPServer(cors, vhosts, timeouts, srv).Serve(listener)
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// StartWSEndpoint starts a websocket endpoint. Connections are authenticated and
+// their calls authorised if auth is set.
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, auth *AuthConfig) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -74,10 +81,14 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 		listener net.Listener
 		err      error
 	)
-	if listener, err = net.Listen("tcp", endpoint); err != nil {
+	srv, err := newAuthHandler(auth, handler.WebsocketHandler(wsOrigins))
+	if err != nil {
+		return nil, nil, err
+	}
+	if listener, err = listenAuth(endpoint, auth); err != nil {
 		return nil, nil, err
 	}
-	go NewWSServer(wsOrigins, handler).Serve(listener)
+	go (&http.Server{Handler: srv}).Serve(listener)
 	return listener, handler, err
 
 }
@@ -100,3 +111,17 @@ func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, er
 	go handler.ServeListener(listener)
 	return listener, handler, nil
 }
+
+// listenAuth listens on the TCP endpoint, serving TLS if configured by auth.
+func listenAuth(endpoint string, auth *AuthConfig) (net.Listener, error) {
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	tlsListener, err := newAuthListener(auth, listener)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return tlsListener, nil
+}
@@ -36,6 +36,22 @@ func (e *subscriptionNotFoundError) Error() string {
 	return fmt.Sprintf("no %q subscription in %s namespace", e.subscription, e.namespace)
 }
 
+// the credential of the caller doesn't allow the method
+type unauthorizedError struct{ method string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string {
+	return fmt.Sprintf("the method %s is not authorized", e.method)
+}
+
+// the credential of the caller exceeded its rate limit
+type rateLimitError struct{}
+
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitError) Error() string { return "rate limit exceeded" }
+
 // Invalid JSON was received by the server.
 type parseError struct{ message string }
 
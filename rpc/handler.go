@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/tracing"
 )
 
 // handler handles JSON-RPC messages. There is one handler per connection. Note that
@@ -311,6 +312,12 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if cred := credentialFromContext(cp.ctx); cred != nil {
+		if err := cred.authorize(msg); err != nil {
+			h.audit(cred, msg, err)
+			return msg.errorResponse(err)
+		}
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -366,13 +373,34 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 
 // runMethod runs the Go callback for an RPC method.
 func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+	ctx, span := tracing.Start(ctx, tracing.KindServer, msg.Method,
+		tracing.String("rpc.system", "jsonrpc"), tracing.String("rpc.method", msg.Method))
+	defer span.Finish()
+
 	result, err := callb.call(ctx, msg.Method, args)
+	if cred := credentialFromContext(ctx); cred != nil {
+		h.audit(cred, msg, err)
+	}
 	if err != nil {
+		span.SetError(err)
 		return msg.errorResponse(err)
 	}
 	return msg.response(result)
 }
 
+// audit logs the outcome of privileged calls along with the credential of the
+// caller.
+func (h *handler) audit(cred *credential, msg *jsonrpcMessage, err error) {
+	if !cred.audit[msg.namespace()] {
+		return
+	}
+	if err != nil {
+		h.log.Info("Audited RPC call", "credential", cred.name, "method", msg.Method, "reqid", idForLog{msg.ID}, "err", err)
+	} else {
+		h.log.Info("Audited RPC call", "credential", cred.name, "method", msg.Method, "reqid", idForLog{msg.ID})
+	}
+}
+
 // unsubscribe is the callback function for all *_unsubscribe calls.
 func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	h.subLock.Lock()
@@ -64,7 +64,12 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			return
 		}
 		codec := newWebsocketCodec(conn)
-		s.ServeCodec(codec, OptionMethodInvocation|OptionSubscriptions)
+		// Calls outlive the upgrade request, keep only its credential.
+		ctx := context.Background()
+		if cred := credentialFromContext(r.Context()); cred != nil {
+			ctx = context.WithValue(ctx, credentialKey{}, cred)
+		}
+		s.serveCodec(ctx, codec)
 	})
 }
 
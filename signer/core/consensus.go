@@ -0,0 +1,323 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/clearmatics/autonity/accounts"
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/hexutil"
+	tendermint "github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/rlp"
+	"github.com/clearmatics/autonity/signer/storage"
+)
+
+var (
+	errNoSlashingProtection = errors.New("consensus signing requires a slashing protection storage")
+	errUnknownStep          = errors.New("unknown consensus message code")
+	errInvalidHeight        = errors.New("invalid consensus message height")
+	errInvalidRound         = errors.New("invalid consensus message round")
+	errInvalidValidRound    = errors.New("invalid proposal valid round")
+	errUnexpectedSeal       = errors.New("committed seal on a message other than a precommit")
+	errDoubleSign           = errors.New("conflicting consensus message already signed")
+)
+
+// consensusSteps names the consensus message codes, ordered the way a validator
+// signs them within a round.
+var consensusSteps = map[uint64]string{
+	tendermint.MsgProposal:  "proposal",
+	tendermint.MsgPrevote:   "prevote",
+	tendermint.MsgPrecommit: "precommit",
+}
+
+// consensusProposal is the encoding of a Tendermint proposal.
+type consensusProposal struct {
+	Round           *big.Int
+	Height          *big.Int
+	ValidRound      *big.Int
+	IsValidRoundNil *big.Int
+	ProposalBlock   *types.Block
+}
+
+// ConsensusMark is the high-water mark of the consensus messages signed for a
+// validator, kept to refuse signing conflicting or older messages.
+type ConsensusMark struct {
+	Height uint64      `json:"height"`
+	Round  uint64      `json:"round"`
+	Step   uint64      `json:"step"`
+	Value  common.Hash `json:"value"` // Hash of the signed message content
+}
+
+// cmp orders the marks by height, round and step.
+func (m *ConsensusMark) cmp(other *ConsensusMark) int {
+	switch {
+	case m.Height != other.Height:
+		return cmpUint64(m.Height, other.Height)
+	case m.Round != other.Round:
+		return cmpUint64(m.Round, other.Round)
+	default:
+		return cmpUint64(m.Step, other.Step)
+	}
+}
+
+func cmpUint64(a, b uint64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// consensusMessage is a consensus message decoded and validated for signing.
+type consensusMessage struct {
+	msg   *tendermint.Message
+	mark  *ConsensusMark
+	block *types.Block // Proposed block, nil for votes
+	hash  common.Hash  // Hash of the proposed or voted block
+}
+
+// decodeConsensusMessage decodes a consensus message to be signed by the given
+// account, and validates its height, round and step.
+func decodeConsensusMessage(addr common.Address, data []byte) (*consensusMessage, error) {
+	msg := new(tendermint.Message)
+	if err := rlp.DecodeBytes(data, msg); err != nil {
+		return nil, err
+	}
+	if msg.Address != addr {
+		return nil, fmt.Errorf("consensus message from %x, signing with %x", msg.Address, addr)
+	}
+	if _, ok := consensusSteps[msg.Code]; !ok {
+		return nil, errUnknownStep
+	}
+	if msg.Code != tendermint.MsgPrecommit && len(msg.CommittedSeal) != 0 {
+		return nil, errUnexpectedSeal
+	}
+	var (
+		height, round *big.Int
+		result        = &consensusMessage{msg: msg}
+	)
+	if msg.Code == tendermint.MsgProposal {
+		var proposal consensusProposal
+		if err := rlp.DecodeBytes(msg.Msg, &proposal); err != nil {
+			return nil, err
+		}
+		if proposal.Height == nil || proposal.Round == nil || proposal.ValidRound == nil || proposal.IsValidRoundNil == nil || proposal.ProposalBlock == nil {
+			return nil, errors.New("incomplete proposal")
+		}
+		height, round = proposal.Height, proposal.Round
+		if proposal.ProposalBlock.Number().Cmp(height) != 0 {
+			return nil, fmt.Errorf("proposed block %d at height %d", proposal.ProposalBlock.Number(), height)
+		}
+		if proposal.IsValidRoundNil.Sign() == 0 && proposal.ValidRound.Cmp(round) >= 0 {
+			return nil, errInvalidValidRound
+		}
+		result.block = proposal.ProposalBlock
+		result.hash = proposal.ProposalBlock.Hash()
+	} else {
+		var vote tendermint.Vote
+		if err := rlp.DecodeBytes(msg.Msg, &vote); err != nil {
+			return nil, err
+		}
+		if vote.Height == nil || vote.Round == nil {
+			return nil, errors.New("incomplete vote")
+		}
+		height, round = vote.Height, vote.Round
+		result.hash = vote.ProposedBlockHash
+	}
+	if height.Sign() <= 0 || !height.IsUint64() {
+		return nil, errInvalidHeight
+	}
+	if round.Sign() < 0 || !round.IsUint64() || round.Uint64() > math.MaxInt64 {
+		return nil, errInvalidRound
+	}
+	result.mark = &ConsensusMark{
+		Height: height.Uint64(),
+		Round:  round.Uint64(),
+		Step:   msg.Code,
+	}
+	return result, nil
+}
+
+// messages describes the consensus message for approval.
+func (m *consensusMessage) messages() []*NameValueType {
+	return []*NameValueType{
+		{Name: "step", Typ: "string", Value: consensusSteps[m.mark.Step]},
+		{Name: "height", Typ: "uint64", Value: m.mark.Height},
+		{Name: "round", Typ: "uint64", Value: m.mark.Round},
+		{Name: "block", Typ: "hash", Value: m.hash.Hex()},
+	}
+}
+
+// readConsensusMark retrieves the high-water mark of a validator, nil if it never
+// signed any consensus message.
+func readConsensusMark(db storage.Storage, addr common.Address) (*ConsensusMark, error) {
+	blob, err := db.Get(addr.Hex())
+	if err == storage.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	mark := new(ConsensusMark)
+	if err := json.Unmarshal([]byte(blob), mark); err != nil {
+		return nil, err
+	}
+	return mark, nil
+}
+
+// writeConsensusMark stores the high-water mark of a validator. As storages drop
+// write failures, the mark is read back before any signature is released.
+func writeConsensusMark(db storage.Storage, addr common.Address, mark *ConsensusMark) error {
+	blob, err := json.Marshal(mark)
+	if err != nil {
+		return err
+	}
+	db.Put(addr.Hex(), string(blob))
+	if stored, err := db.Get(addr.Hex()); err != nil || stored != string(blob) {
+		return errors.New("failed to persist the consensus high-water mark")
+	}
+	return nil
+}
+
+// SignConsensus signs a Tendermint consensus message, given as the encoding of
+// the message without its signature, and returns the encoding of the signed one.
+// Proposals lacking a proposer seal are sealed, and precommits get their
+// committed seal.
+//
+// Messages are refused if they are below the height, round and step of the last
+// one signed for the validator, or conflict with it.
+func (api *SignerAPI) SignConsensus(ctx context.Context, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if api.consensus == nil {
+		return nil, errNoSlashingProtection
+	}
+	msg, err := decodeConsensusMessage(addr.Address(), data)
+	if err != nil {
+		return nil, err
+	}
+	// Check the message against the high-water mark until it's moved past it
+	api.consensusLock.Lock()
+	defer api.consensusLock.Unlock()
+
+	last, err := readConsensusMark(api.consensus, addr.Address())
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && msg.mark.cmp(last) < 0 {
+		return nil, fmt.Errorf("consensus message at height %d round %d step %d below the signed one at height %d round %d step %d",
+			msg.mark.Height, msg.mark.Round, msg.mark.Step, last.Height, last.Round, last.Step)
+	}
+	req := &SignDataRequest{
+		ContentType: accounts.MimetypeTendermint,
+		Address:     addr,
+		Rawdata:     data,
+		Messages:    msg.messages(),
+		Meta:        MetadataFromContext(ctx),
+	}
+	res, err := api.UI.ApproveSignData(req)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: addr.Address()}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := api.lookupOrQueryPassword(account.Address,
+		"Password for consensus signing",
+		fmt.Sprintf("Please enter password for signing consensus messages with account %s", account.Address.Hex()))
+	if err != nil {
+		return nil, err
+	}
+	sign := func(data []byte) ([]byte, error) {
+		return wallet.SignDataWithPassphrase(account, pw, accounts.MimetypeTendermint, data)
+	}
+	if err := msg.seal(sign); err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	// Sign the message, refusing to contradict the last one signed
+	payload, err := msg.msg.PayloadNoSig()
+	if err != nil {
+		return nil, err
+	}
+	msg.mark.Value = crypto.Keccak256Hash(payload)
+	if last != nil && msg.mark.cmp(last) == 0 && msg.mark.Value != last.Value {
+		return nil, errDoubleSign
+	}
+	if msg.msg.Signature, err = sign(payload); err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	if err := writeConsensusMark(api.consensus, addr.Address(), msg.mark); err != nil {
+		return nil, err
+	}
+	return msg.msg.Payload()
+}
+
+// seal adds the proposer seal to a proposed block which lacks one, and the
+// committed seal to a precommit.
+func (m *consensusMessage) seal(sign func([]byte) ([]byte, error)) error {
+	switch m.mark.Step {
+	case tendermint.MsgProposal:
+		header := m.block.Header()
+		if len(header.ProposerSeal) == 0 {
+			seal, err := sign(types.SigHash(header).Bytes())
+			if err != nil {
+				return err
+			}
+			if err := types.WriteSeal(header, seal); err != nil {
+				return err
+			}
+			m.block = m.block.WithSeal(header)
+		} else if proposer, err := types.Ecrecover(header); err != nil || proposer != m.msg.Address {
+			return fmt.Errorf("proposal sealed by %x, not %x", proposer, m.msg.Address)
+		}
+		m.hash = m.block.Hash()
+
+		var proposal consensusProposal
+		if err := rlp.DecodeBytes(m.msg.Msg, &proposal); err != nil {
+			return err
+		}
+		proposal.ProposalBlock = m.block
+		encoded, err := rlp.EncodeToBytes(&proposal)
+		if err != nil {
+			return err
+		}
+		m.msg.Msg = encoded
+
+	case tendermint.MsgPrecommit:
+		seal, err := sign(tendermint.PrepareCommittedSeal(m.hash, new(big.Int).SetUint64(m.mark.Round), new(big.Int).SetUint64(m.mark.Height)))
+		if err != nil {
+			return err
+		}
+		m.msg.CommittedSeal = seal
+	}
+	return nil
+}
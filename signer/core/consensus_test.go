@@ -0,0 +1,116 @@
+package core_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/clearmatics/autonity/common"
+	tendermint "github.com/clearmatics/autonity/consensus/tendermint/core"
+	"github.com/clearmatics/autonity/core/types"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/log"
+	"github.com/clearmatics/autonity/rlp"
+)
+
+// consensusMessage encodes an unsigned consensus message of a validator.
+func consensusMessage(t *testing.T, addr common.Address, code uint64, content interface{}) []byte {
+	encoded, err := rlp.EncodeToBytes(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &tendermint.Message{Code: code, Msg: encoded, Address: addr, CommittedSeal: []byte{}}
+	payload, err := msg.PayloadNoSig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func consensusVote(t *testing.T, addr common.Address, code uint64, height, round int64, hash common.Hash) []byte {
+	return consensusMessage(t, addr, code, &tendermint.Vote{Round: big.NewInt(round), Height: big.NewInt(height), ProposedBlockHash: hash})
+}
+
+// decodeSigned decodes a signed consensus message, checking its signature.
+func decodeSigned(t *testing.T, addr common.Address, signed []byte) *tendermint.Message {
+	msg := new(tendermint.Message)
+	if err := rlp.DecodeBytes(signed, msg); err != nil {
+		t.Fatalf("failed to decode signed message: %v", err)
+	}
+	payload, _ := msg.PayloadNoSig()
+	pub, err := crypto.SigToPub(crypto.Keccak256(payload), msg.Signature)
+	if err != nil || crypto.PubkeyToAddress(*pub) != addr {
+		t.Fatalf("message not signed by %x: %v", addr, err)
+	}
+	return msg
+}
+
+func TestSignConsensus(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := list[0]
+	account := common.NewMixedcaseAddress(addr)
+
+	sign := func(data []byte) ([]byte, error) {
+		control.approveCh <- "Y"
+		control.inputCh <- "a_long_password"
+		return api.SignConsensus(context.Background(), account, data)
+	}
+	// Proposals get sealed by the validator
+	block := types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(5),
+		Difficulty: big.NewInt(1),
+		MixDigest:  types.BFTDigest,
+		Committee:  types.Committee{{Address: addr, VotingPower: big.NewInt(1)}},
+	})
+	proposal := consensusMessage(t, addr, tendermint.MsgProposal, tendermint.NewProposal(big.NewInt(0), big.NewInt(5), big.NewInt(-1), block, log.New()))
+	signed, err := sign(proposal)
+	if err != nil {
+		t.Fatalf("failed to sign proposal: %v", err)
+	}
+	var decoded tendermint.Proposal
+	if err := decodeSigned(t, addr, signed).Decode(&decoded); err != nil {
+		t.Fatalf("failed to decode proposal: %v", err)
+	}
+	if proposer, err := types.Ecrecover(decoded.ProposalBlock.Header()); err != nil || proposer != addr {
+		t.Fatalf("proposal sealed by %x, want %x: %v", proposer, addr, err)
+	}
+	// Precommits get a committed seal, and may be signed again
+	hash := decoded.ProposalBlock.Hash()
+	precommit := consensusVote(t, addr, tendermint.MsgPrecommit, 5, 0, hash)
+	if signed, err = sign(precommit); err != nil {
+		t.Fatalf("failed to sign precommit: %v", err)
+	}
+	seal := decodeSigned(t, addr, signed).CommittedSeal
+	pub, err := crypto.SigToPub(crypto.Keccak256(tendermint.PrepareCommittedSeal(hash, big.NewInt(0), big.NewInt(5))), seal)
+	if err != nil || crypto.PubkeyToAddress(*pub) != addr {
+		t.Fatalf("committed seal not signed by %x: %v", addr, err)
+	}
+	if again, err := sign(precommit); err != nil || string(again) != string(signed) {
+		t.Fatalf("failed to sign precommit again: %v", err)
+	}
+	// Conflicting and older messages are refused
+	if _, err := sign(consensusVote(t, addr, tendermint.MsgPrecommit, 5, 0, common.Hash{1})); err == nil {
+		t.Fatal("conflicting precommit signed")
+	}
+	for _, vote := range [][]byte{
+		consensusVote(t, addr, tendermint.MsgPrevote, 5, 0, hash),
+		consensusVote(t, addr, tendermint.MsgPrecommit, 4, 3, hash),
+	} {
+		if _, err := api.SignConsensus(context.Background(), account, vote); err == nil {
+			t.Fatal("message below the high-water mark signed")
+		}
+	}
+	if _, err := sign(consensusVote(t, addr, tendermint.MsgPrevote, 5, 1, common.Hash{})); err != nil {
+		t.Fatalf("failed to sign prevote of the next round: %v", err)
+	}
+	// Messages of other validators are refused
+	if _, err := api.SignConsensus(context.Background(), account, consensusVote(t, common.Address{1}, tendermint.MsgPrevote, 6, 0, hash)); err == nil {
+		t.Fatal("message of another validator signed")
+	}
+}
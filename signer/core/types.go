@@ -33,6 +33,25 @@ type ValidationInfo struct {
 }
 type ValidationMessages struct {
 	Messages []ValidationInfo
+	Call     *ContractCall // Decoded call to a system contract, if any
+}
+
+// ContractCall is a transaction call to a system contract, decoded with the
+// contract ABI so that the UI and the rules can inspect it.
+type ContractCall struct {
+	Contract  string            `json:"contract"`
+	Address   common.Address    `json:"address"`
+	Method    string            `json:"method"`
+	Signature string            `json:"signature"`
+	Args      []ContractCallArg `json:"args"`
+}
+
+// ContractCallArg is a named argument of a decoded contract call. The value is
+// formatted as text, so that large integers survive the trip to the rules.
+type ContractCallArg struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
 }
 
 const (
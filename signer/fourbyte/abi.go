@@ -42,16 +42,22 @@ type decodedArgument struct {
 	value   interface{}
 }
 
-// String implements stringer interface, tries to use the underlying value-type
-func (arg decodedArgument) String() string {
-	var value string
+// valueString formats the argument value, trying to use the underlying value-type.
+func (arg decodedArgument) valueString() string {
 	switch val := arg.value.(type) {
 	case fmt.Stringer:
-		value = val.String()
+		return val.String()
 	default:
-		value = fmt.Sprintf("%v", val)
+		return fmt.Sprintf("%v", val)
 	}
-	return fmt.Sprintf("%v: %v", arg.soltype.Type.String(), value)
+}
+
+// String implements stringer interface, naming the argument if the ABI does
+func (arg decodedArgument) String() string {
+	if arg.soltype.Name != "" {
+		return fmt.Sprintf("%v %v: %v", arg.soltype.Type.String(), arg.soltype.Name, arg.valueString())
+	}
+	return fmt.Sprintf("%v: %v", arg.soltype.Type.String(), arg.valueString())
 }
 
 // String implements stringer interface for decodedCallData
@@ -131,6 +137,12 @@ func parseCallData(calldata []byte, abidata string) (*decodedCallData, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid method signature (%s): %v", abidata, err)
 	}
+	return decodeCallData(sigdata, argdata, abispec)
+}
+
+// decodeCallData unpacks the arguments of the method identified by the 4byte
+// signature from an already parsed ABI.
+func decodeCallData(sigdata, argdata []byte, abispec abi.ABI) (*decodedCallData, error) {
 	method, err := abispec.MethodById(sigdata)
 	if err != nil {
 		return nil, err
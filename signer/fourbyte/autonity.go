@@ -0,0 +1,157 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fourbyte
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clearmatics/autonity/accounts/abi"
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/acdefault"
+	"github.com/clearmatics/autonity/crypto"
+	"github.com/clearmatics/autonity/rpc"
+	"github.com/clearmatics/autonity/signer/core"
+)
+
+// autonityContract is the Autonity system contract, whose calls are decoded with
+// its ABI instead of being looked up in the 4byte database.
+type autonityContract struct {
+	address common.Address
+	abi     abi.ABI
+	spec    string // ABI JSON the contract was parsed from
+}
+
+// newAutonityContract parses the ABI of the Autonity contract at the given address.
+func newAutonityContract(address common.Address, spec string) (*autonityContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(spec))
+	if err != nil {
+		return nil, fmt.Errorf("invalid autonity contract ABI: %v", err)
+	}
+	return &autonityContract{address: address, abi: parsed, spec: spec}, nil
+}
+
+// defaultAutonityContract returns the Autonity contract shipped with the genesis
+// configuration, deployed at the address derived from the default deployer.
+func defaultAutonityContract() (*autonityContract, error) {
+	return newAutonityContract(crypto.CreateAddress(acdefault.Deployer(), 0), acdefault.ABI())
+}
+
+// SetAutonityContract sets the address and ABI of the Autonity contract, whose
+// calls are then decoded with their named arguments.
+func (db *Database) SetAutonityContract(address common.Address, spec string) error {
+	contract, err := newAutonityContract(address, spec)
+	if err != nil {
+		return err
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.autonity = contract
+	return nil
+}
+
+// FollowAutonityContract loads the address and ABI of the Autonity contract from
+// a node. As the contract may be upgraded, its ABI is fetched again before each
+// call to the contract is decoded.
+func (db *Database) FollowAutonityContract(client *rpc.Client) error {
+	var address common.Address
+	if err := client.Call(&address, "tendermint_getContractAddress"); err != nil {
+		return err
+	}
+	if err := db.loadAutonityABI(client, address); err != nil {
+		return err
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.node = client
+	return nil
+}
+
+// loadAutonityABI fetches the current ABI of the Autonity contract from a node,
+// only parsing it if the contract was upgraded.
+func (db *Database) loadAutonityABI(client *rpc.Client, address common.Address) error {
+	var spec string
+	if err := client.Call(&spec, "tendermint_getContractABI"); err != nil {
+		return err
+	}
+	db.lock.RLock()
+	current := db.autonity
+	db.lock.RUnlock()
+
+	if current != nil && current.address == address && current.spec == spec {
+		return nil
+	}
+	return db.SetAutonityContract(address, spec)
+}
+
+// autonityContract returns the Autonity contract if it's deployed at the given
+// address, nil otherwise.
+func (db *Database) autonityContract(address common.Address, messages *core.ValidationMessages) *autonityContract {
+	db.lock.RLock()
+	contract, node := db.autonity, db.node
+	db.lock.RUnlock()
+
+	if contract == nil || contract.address != address {
+		return nil
+	}
+	if node != nil {
+		if err := db.loadAutonityABI(node, address); err != nil {
+			messages.Warn(fmt.Sprintf("Failed to retrieve the current Autonity contract ABI, decoding with the last known one: %v", err))
+		}
+		db.lock.RLock()
+		contract = db.autonity
+		db.lock.RUnlock()
+	}
+	return contract
+}
+
+// validateAutonityCall decodes a call to the Autonity contract with its ABI, and
+// attaches it to the messages for the UI and the rules to inspect.
+func validateAutonityCall(contract *autonityContract, data []byte, messages *core.ValidationMessages) {
+	if len(data) == 0 {
+		messages.Warn("Transaction sends value to the Autonity contract without calling it")
+		return
+	}
+	if len(data) < 4 {
+		messages.Warn("Transaction data is not valid ABI (missing the 4 byte call prefix)")
+		return
+	}
+	info, err := decodeCallData(data[:4], data[4:], contract.abi)
+	if err != nil {
+		messages.Warn(fmt.Sprintf("Transaction calls the Autonity contract, but the call could not be decoded: %v", err))
+		return
+	}
+	messages.Info(fmt.Sprintf("Autonity contract call: %v", info))
+
+	call := &core.ContractCall{
+		Contract:  "autonity",
+		Address:   contract.address,
+		Method:    info.name,
+		Signature: info.signature,
+		Args:      make([]core.ContractCallArg, len(info.inputs)),
+	}
+	for i, arg := range info.inputs {
+		call.Args[i] = core.ContractCallArg{
+			Name:  arg.soltype.Name,
+			Type:  arg.soltype.Type.String(),
+			Value: arg.valueString(),
+		}
+	}
+	messages.Call = call
+}
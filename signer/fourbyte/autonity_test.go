@@ -0,0 +1,117 @@
+package fourbyte
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/clearmatics/autonity/accounts/abi"
+	"github.com/clearmatics/autonity/common"
+	"github.com/clearmatics/autonity/common/hexutil"
+	"github.com/clearmatics/autonity/rpc"
+	"github.com/clearmatics/autonity/signer/core"
+)
+
+// autonityCallArgs returns the arguments of a transaction calling a contract.
+func autonityCallArgs(to common.Address, data []byte) *core.SendTxArgs {
+	mixed := common.NewMixedcaseAddress(to)
+	input := hexutil.Bytes(data)
+	return &core.SendTxArgs{
+		From:     common.NewMixedcaseAddress(common.Address{1}),
+		To:       &mixed,
+		Value:    hexutil.Big(*big.NewInt(0)),
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Gas:      hexutil.Uint64(100000),
+		Data:     &input,
+	}
+}
+
+// packCall encodes a method call with the given ABI.
+func packCall(t *testing.T, spec string, method string, args ...interface{}) []byte {
+	parsed, err := abi.JSON(strings.NewReader(spec))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+	data, err := parsed.Pack(method, args...)
+	if err != nil {
+		t.Fatalf("failed to pack %s: %v", method, err)
+	}
+	return data
+}
+
+func TestAutonityCallDecoding(t *testing.T) {
+	contract, err := defaultAutonityContract()
+	if err != nil {
+		t.Fatalf("failed to load default autonity contract: %v", err)
+	}
+	db := newEmpty()
+	db.autonity = contract
+
+	// Calls to the contract are decoded with their named arguments
+	account := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	data := packCall(t, contract.spec, "mintStake", account, big.NewInt(1000))
+
+	msgs, err := db.ValidateTransaction(nil, autonityCallArgs(contract.address, data))
+	if err != nil {
+		t.Fatalf("failed to validate call: %v", err)
+	}
+	call := msgs.Call
+	if call == nil || call.Contract != "autonity" || call.Method != "mintStake" || call.Address != contract.address {
+		t.Fatalf("call not decoded: %+v", call)
+	}
+	want := []core.ContractCallArg{
+		{Name: "_account", Type: "address", Value: account.Hex()},
+		{Name: "_amount", Type: "uint256", Value: "1000"},
+	}
+	if len(call.Args) != len(want) {
+		t.Fatalf("argument count mismatch: have %d, want %d", len(call.Args), len(want))
+	}
+	for i := range want {
+		if call.Args[i] != want[i] {
+			t.Errorf("argument %d mismatch: have %+v, want %+v", i, call.Args[i], want[i])
+		}
+	}
+	// Undecodable calls to the contract are warned about
+	if msgs, _ = db.ValidateTransaction(nil, autonityCallArgs(contract.address, []byte{1, 2, 3, 4})); msgs.Call != nil || len(msgs.Messages) != 1 || msgs.Messages[0].Typ != core.WARN {
+		t.Fatalf("undecodable call not warned about: %+v", msgs)
+	}
+	// Calls to other contracts are left to the 4byte database
+	if msgs, _ = db.ValidateTransaction(nil, autonityCallArgs(common.Address{2}, data)); msgs.Call != nil {
+		t.Fatalf("call to another contract decoded: %+v", msgs.Call)
+	}
+}
+
+// autonityService serves the Autonity contract address and ABI of a node.
+type autonityService struct {
+	address common.Address
+	abi     string
+}
+
+func (s *autonityService) GetContractAddress() common.Address { return s.address }
+func (s *autonityService) GetContractABI() string             { return s.abi }
+
+func TestFollowAutonityContract(t *testing.T) {
+	service := &autonityService{
+		address: common.Address{0xac},
+		abi:     `[{"type":"function","name":"foo","inputs":[{"name":"_value","type":"uint256"}]}]`,
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("tendermint", service); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	db := newEmpty()
+	if err := db.FollowAutonityContract(rpc.DialInProc(server)); err != nil {
+		t.Fatalf("failed to follow autonity contract: %v", err)
+	}
+	msgs, _ := db.ValidateTransaction(nil, autonityCallArgs(service.address, packCall(t, service.abi, "foo", big.NewInt(1))))
+	if msgs.Call == nil || msgs.Call.Method != "foo" {
+		t.Fatalf("call not decoded: %+v", msgs)
+	}
+	// Calls are decoded with the ABI of the upgraded contract
+	service.abi = `[{"type":"function","name":"bar","inputs":[{"name":"_value","type":"uint256"}]}]`
+	msgs, _ = db.ValidateTransaction(nil, autonityCallArgs(service.address, packCall(t, service.abi, "bar", big.NewInt(1))))
+	if msgs.Call == nil || msgs.Call.Method != "bar" {
+		t.Fatalf("call not decoded with the upgraded ABI: %+v", msgs)
+	}
+}
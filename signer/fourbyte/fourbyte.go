@@ -27,14 +27,22 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync"
+
+	"github.com/clearmatics/autonity/rpc"
 )
 
 // Database is a 4byte database with the possibility of maintaining an immutable
 // set (embedded) into the process and a mutable set (loaded and written to file).
+// Calls to the Autonity contract are decoded with its ABI instead.
 type Database struct {
 	embedded   map[string]string
 	custom     map[string]string
 	customPath string
+
+	autonity *autonityContract // Autonity contract, nil if calls aren't decoded with its ABI
+	node     *rpc.Client       // Node to retrieve the upgraded Autonity contract ABI from
+	lock     sync.RWMutex      // Protects the Autonity contract
 }
 
 // newEmpty exists for testing purposes.
@@ -72,10 +80,19 @@ func NewFromFile(path string) (*Database, error) {
 
 // NewWithFile loads both the standard signature database (embedded resource
 // file) as well as a custom database. The latter will be used to write new
-// values into if they are submitted via the API.
+// values into if they are submitted via the API. The Autonity contract shipped
+// with the genesis configuration is also loaded.
 func NewWithFile(path string) (*Database, error) {
-	db := &Database{make(map[string]string), make(map[string]string), path}
-	db.customPath = path
+	autonity, err := defaultAutonityContract()
+	if err != nil {
+		return nil, err
+	}
+	db := &Database{
+		embedded:   make(map[string]string),
+		custom:     make(map[string]string),
+		customPath: path,
+		autonity:   autonity,
+	}
 
 	blob, err := Asset("4byte.json")
 	if err != nil {
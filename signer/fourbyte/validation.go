@@ -74,6 +74,10 @@ func (db *Database) ValidateTransaction(selector *string, tx *core.SendTxArgs) (
 		messages.Crit("Transaction recipient is the zero address")
 	}
 	// Semantic fields validated, try to make heads or tails of the call data
+	if contract := db.autonityContract(tx.To.Address(), messages); contract != nil {
+		validateAutonityCall(contract, data, messages)
+		return messages, nil
+	}
 	db.validateCallData(selector, data, messages)
 	return messages, nil
 }
@@ -155,8 +155,22 @@ func (r *rulesetUI) checkApproval(jsfunc string, jsarg []byte, err error) (bool,
 	return false, fmt.Errorf("unknown response")
 }
 
+// ApproveTx evaluates the ApproveTx rule. Calls to the Autonity contract are
+// first evaluated by the ApproveAutonityCall rule, falling back to ApproveTx
+// unless it approves or rejects the call.
 func (r *rulesetUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
 	jsonreq, err := json.Marshal(request)
+	if err == nil && request != nil && request.Call != nil && request.Call.Contract == "autonity" {
+		approved, err := r.checkApproval("ApproveAutonityCall", jsonreq, nil)
+		switch {
+		case err != nil:
+			log.Debug("Autonity call rule not conclusive, going to transaction rule", "error", err)
+		case approved:
+			return core.SignTxResponse{Transaction: request.Transaction, Approved: true}, nil
+		default:
+			return core.SignTxResponse{Approved: false}, nil
+		}
+	}
 	approved, err := r.checkApproval("ApproveTx", jsonreq, err)
 	if err != nil {
 		log.Info("Rule-based approval error, going to manual", "error", err)
@@ -194,6 +194,43 @@ func TestSignTxRequest(t *testing.T) {
 	}
 }
 
+func TestAutonityCallRequest(t *testing.T) {
+	js := `
+	function ApproveAutonityCall(r){
+		if(r.contract_call.method=="upgradeContract" && r.transaction.from.toLowerCase()!="0x0000000000000000000000000000000000001337"){ return "Reject" }
+	}
+	function ApproveTx(r){ return "Approve" }`
+
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	operator, _ := mixAddr("0000000000000000000000000000000000001337")
+	other, _ := mixAddr("000000000000000000000000000000000000dead")
+
+	for i, tt := range []struct {
+		from     *common.MixedcaseAddress
+		method   string
+		approved bool
+	}{
+		{operator, "upgradeContract", true},
+		{other, "upgradeContract", false},
+		{other, "mintStake", true},
+	} {
+		resp, err := r.ApproveTx(&core.SignTxRequest{
+			Transaction: core.SendTxArgs{From: *tt.from},
+			Call:        &core.ContractCall{Contract: "autonity", Method: tt.method},
+			Meta:        core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+		})
+		if err != nil {
+			t.Errorf("test %d: unexpected error %v", i, err)
+		}
+		if resp.Approved != tt.approved {
+			t.Errorf("test %d: approval mismatch: have %v, want %v", i, resp.Approved, tt.approved)
+		}
+	}
+}
+
 type dummyUI struct {
 	calls []string
 }
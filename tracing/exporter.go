@@ -0,0 +1,359 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/clearmatics/autonity/log"
+)
+
+const (
+	queueSize     = 4096            // Spans waiting for export before new ones are dropped
+	batchSize     = 512             // Spans exported at once
+	flushInterval = 5 * time.Second // Maximum time a span waits for export
+	exportTimeout = 10 * time.Second
+)
+
+// Exporter sends batches of ended spans to their destination.
+type Exporter interface {
+	Export(resource []Attribute, spans []*Span) error
+	Close() error
+}
+
+// Config is the configuration of the tracing subsystem.
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+	File           string // File to append the spans to, one OTLP JSON request per line
+	Endpoint       string // OTLP/HTTP endpoint to post the spans to, like http://localhost:4318/v1/traces
+}
+
+// exporter is the running export loop, nil when tracing is disabled.
+type exporter struct {
+	resource  []Attribute
+	exporters []Exporter
+	queue     chan *Span
+	dropped   uint64
+	quit      chan chan struct{}
+}
+
+var (
+	running     *exporter
+	runningLock sync.Mutex
+)
+
+// Setup enables tracing, exporting the spans to the file and the endpoint of
+// the configuration.
+func Setup(config Config) error {
+	var exporters []Exporter
+	if config.File != "" {
+		file, err := NewFileExporter(config.File)
+		if err != nil {
+			return err
+		}
+		exporters = append(exporters, file)
+	}
+	if config.Endpoint != "" {
+		exporters = append(exporters, NewHTTPExporter(config.Endpoint))
+	}
+	if len(exporters) == 0 {
+		return errors.New("no tracing exporter configured")
+	}
+	resource := []Attribute{String("service.name", config.ServiceName)}
+	if config.ServiceVersion != "" {
+		resource = append(resource, String("service.version", config.ServiceVersion))
+	}
+	return StartExporters(resource, exporters...)
+}
+
+// StartExporters enables tracing, exporting the spans with the given exporters.
+// The resource attributes describe the node emitting the spans.
+func StartExporters(resource []Attribute, exporters ...Exporter) error {
+	runningLock.Lock()
+	defer runningLock.Unlock()
+
+	if running != nil {
+		return errors.New("tracing already started")
+	}
+	running = &exporter{
+		resource:  resource,
+		exporters: exporters,
+		queue:     make(chan *Span, queueSize),
+		quit:      make(chan chan struct{}),
+	}
+	go running.loop()
+	atomic.StoreInt32(&enabled, 1)
+	return nil
+}
+
+// Stop disables tracing, exporting the spans already ended.
+func Stop() {
+	runningLock.Lock()
+	defer runningLock.Unlock()
+
+	if running == nil {
+		return
+	}
+	atomic.StoreInt32(&enabled, 0)
+	done := make(chan struct{})
+	running.quit <- done
+	<-done
+	running = nil
+}
+
+// queue queues an ended span for export, dropping it if the queue is full.
+func queue(span *Span) {
+	runningLock.Lock()
+	e := running
+	runningLock.Unlock()
+
+	if e == nil {
+		return
+	}
+	select {
+	case e.queue <- span:
+	default:
+		if dropped := atomic.AddUint64(&e.dropped, 1); dropped%queueSize == 1 {
+			log.Warn("Tracing queue full, dropping spans", "dropped", dropped)
+		}
+	}
+}
+
+func (e *exporter) loop() {
+	var (
+		batch = make([]*Span, 0, batchSize)
+		flush = time.NewTicker(flushInterval)
+	)
+	defer flush.Stop()
+
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		for _, exp := range e.exporters {
+			if err := exp.Export(e.resource, batch); err != nil {
+				log.Warn("Failed to export tracing spans", "spans", len(batch), "err", err)
+			}
+		}
+		batch = make([]*Span, 0, batchSize)
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			if batch = append(batch, span); len(batch) == batchSize {
+				export()
+			}
+		case <-flush.C:
+			export()
+		case done := <-e.quit:
+			for len(e.queue) > 0 {
+				if batch = append(batch, <-e.queue); len(batch) == batchSize {
+					export()
+				}
+			}
+			export()
+			for _, exp := range e.exporters {
+				if err := exp.Close(); err != nil {
+					log.Warn("Failed to close tracing exporter", "err", err)
+				}
+			}
+			close(done)
+			return
+		}
+	}
+}
+
+// FileExporter appends the spans to a file, one OTLP JSON export request per
+// line, the format of the file exporter of the OpenTelemetry collector.
+type FileExporter struct {
+	file *os.File
+}
+
+// NewFileExporter opens the file to append spans to, creating it if needed.
+func NewFileExporter(path string) (*FileExporter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileExporter{file: file}, nil
+}
+
+// Export implements Exporter, appending the spans as a line of the file.
+func (f *FileExporter) Export(resource []Attribute, spans []*Span) error {
+	data, err := encodeOTLP(resource, spans)
+	if err != nil {
+		return err
+	}
+	_, err = f.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close implements Exporter, closing the file.
+func (f *FileExporter) Close() error {
+	return f.file.Close()
+}
+
+// HTTPExporter posts the spans to an OTLP/HTTP endpoint, in the JSON encoding.
+type HTTPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTPExporter creates an exporter posting to the endpoint.
+func NewHTTPExporter(endpoint string) *HTTPExporter {
+	return &HTTPExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: exportTimeout},
+	}
+}
+
+// Export implements Exporter, posting the spans to the endpoint.
+func (h *HTTPExporter) Export(resource []Attribute, spans []*Span) error {
+	data, err := encodeOTLP(resource, spans)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Post(h.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// Close implements Exporter.
+func (h *HTTPExporter) Close() error {
+	return nil
+}
+
+// OTLP JSON encoding of an export request, see
+// https://github.com/open-telemetry/opentelemetry-proto. Identifiers are hex
+// encoded and 64 bit integers are strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              SpanKind        `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 2 for errors
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+func encodeAttributes(attrs []Attribute) []otlpAttribute {
+	encoded := make([]otlpAttribute, 0, len(attrs))
+	for _, attr := range attrs {
+		var value otlpValue
+		switch v := attr.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case int64:
+			i := strconv.FormatInt(v, 10)
+			value.IntValue = &i
+		case bool:
+			value.BoolValue = &v
+		case float64:
+			value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		encoded = append(encoded, otlpAttribute{Key: attr.Key, Value: value})
+	}
+	return encoded
+}
+
+// encodeOTLP encodes the spans as an OTLP JSON export request.
+func encodeOTLP(resource []Attribute, spans []*Span) ([]byte, error) {
+	encoded := make([]otlpSpan, len(spans))
+	for i, span := range spans {
+		encoded[i] = otlpSpan{
+			TraceID:           span.TraceID.String(),
+			SpanID:            span.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        encodeAttributes(span.Attrs),
+		}
+		if span.ParentID != (SpanID{}) {
+			encoded[i].ParentSpanID = span.ParentID.String()
+		}
+		if span.Err != "" {
+			encoded[i].Status = otlpStatus{Code: 2, Message: span.Err}
+		}
+	}
+	return json.Marshal(&otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: encodeAttributes(resource)},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "github.com/clearmatics/autonity"},
+				Spans: encoded,
+			}},
+		}},
+	})
+}
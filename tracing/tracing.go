@@ -0,0 +1,230 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracing records spans of the work done by the node, like consensus
+// steps, block processing, system contract calls, message handling and RPC
+// calls, and exports them in the OpenTelemetry protocol (OTLP) JSON encoding.
+//
+// Tracing is disabled until Setup is called, the functions starting spans then
+// return nil spans whose methods do nothing, so that the call sites don't have
+// to check whether tracing is enabled.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace, the set of spans of an operation.
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanKind is the relationship of a span with its parent and children, as
+// defined by OpenTelemetry.
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1 // Internal operation of the node
+	KindServer   SpanKind = 2 // Handling of a remote request, like an RPC call
+	KindConsumer SpanKind = 5 // Handling of a message, like a p2p message
+)
+
+// Attribute is a key value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{} // string, int64, bool or float64
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+// Int64 returns an integer attribute.
+func Int64(key string, value int64) Attribute { return Attribute{key, value} }
+
+// Uint64 returns an integer attribute, values above the int64 range wrap.
+func Uint64(key string, value uint64) Attribute { return Attribute{key, int64(value)} }
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// Span is a timed operation of the node. It is exported once ended.
+type Span struct {
+	TraceID  TraceID
+	SpanID   SpanID
+	ParentID SpanID // Zero for the root spans of a trace
+	Name     string
+	Kind     SpanKind
+	Start    time.Time
+	End      time.Time
+	Attrs    []Attribute
+	Err      string // Error the operation failed with, if any
+
+	lock  sync.Mutex
+	ended bool
+}
+
+// SetAttributes adds attributes to the span, unless it ended.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.ended {
+		s.Attrs = append(s.Attrs, attrs...)
+	}
+}
+
+// SetError marks the span as failed, unless the error is nil or the span ended.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.ended {
+		s.Err = err.Error()
+	}
+}
+
+// Finish ends the span and queues it for export. Only the first call has an
+// effect.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended, s.End = true, time.Now()
+	s.lock.Unlock()
+
+	queue(s)
+}
+
+var enabled int32 // Set while an exporter is running
+
+// Enabled reports whether spans are recorded.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// newSpan creates a span of the trace, a random one if zero.
+func newSpan(trace TraceID, parent SpanID, name string, kind SpanKind, attrs []Attribute) *Span {
+	if trace == (TraceID{}) {
+		rand.Read(trace[:])
+	}
+	span := &Span{
+		TraceID:  trace,
+		ParentID: parent,
+		Name:     name,
+		Kind:     kind,
+		Start:    time.Now(),
+		Attrs:    attrs,
+	}
+	rand.Read(span.SpanID[:])
+	return span
+}
+
+// StartChild starts a span within the trace of the parent, or a new trace if
+// the parent is nil.
+func StartChild(parent *Span, name string, attrs ...Attribute) *Span {
+	if !Enabled() {
+		return nil
+	}
+	if parent == nil {
+		return newSpan(TraceID{}, SpanID{}, name, KindInternal, attrs)
+	}
+	return newSpan(parent.TraceID, parent.SpanID, name, KindInternal, attrs)
+}
+
+// StartKind starts a root span of a new trace of the given kind.
+func StartKind(kind SpanKind, name string, attrs ...Attribute) *Span {
+	if !Enabled() {
+		return nil
+	}
+	return newSpan(TraceID{}, SpanID{}, name, kind, attrs)
+}
+
+// HeightTrace returns the trace gathering the spans about a block height. The
+// consensus of the height, the verification, processing and insertion of its
+// block run in different parts of the node which don't share a parent span, so
+// they are correlated by this trace instead.
+func HeightTrace(number uint64) TraceID {
+	var seed [len("height") + 8]byte
+	copy(seed[:], "height")
+	binary.BigEndian.PutUint64(seed[len("height"):], number)
+
+	var trace TraceID
+	hash := sha256.Sum256(seed[:])
+	copy(trace[:], hash[:])
+	return trace
+}
+
+// StartHeight starts a root span within the trace of the block height.
+func StartHeight(number uint64, name string, attrs ...Attribute) *Span {
+	if !Enabled() {
+		return nil
+	}
+	return newSpan(HeightTrace(number), SpanID{}, name, KindInternal, append(attrs, Uint64("block.number", number)))
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of the context carrying the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// FromContext returns the span carried by the context, nil if none.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Start starts a span of the given kind, child of the span carried by the
+// context if any, and returns a copy of the context carrying the new span.
+func Start(ctx context.Context, kind SpanKind, name string, attrs ...Attribute) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+	var span *Span
+	if parent := FromContext(ctx); parent != nil {
+		span = newSpan(parent.TraceID, parent.SpanID, name, kind, attrs)
+	} else {
+		span = newSpan(TraceID{}, SpanID{}, name, kind, attrs)
+	}
+	return ContextWithSpan(ctx, span), span
+}
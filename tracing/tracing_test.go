@@ -0,0 +1,170 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// memoryExporter keeps the exported spans in memory.
+type memoryExporter struct {
+	spans  []*Span
+	closed bool
+	lock   sync.Mutex
+}
+
+func (m *memoryExporter) Export(resource []Attribute, spans []*Span) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.spans = append(m.spans, spans...)
+	return nil
+}
+
+func (m *memoryExporter) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestDisabled(t *testing.T) {
+	span := StartHeight(1, "height")
+	if span != nil {
+		t.Fatal("span started while tracing is disabled")
+	}
+	// Methods of nil spans do nothing
+	span.SetAttributes(String("key", "value"))
+	span.SetError(errors.New("failure"))
+	span.Finish()
+
+	ctx, span := Start(context.Background(), KindServer, "call")
+	if span != nil || FromContext(ctx) != nil {
+		t.Fatal("span started while tracing is disabled")
+	}
+}
+
+func TestSpans(t *testing.T) {
+	exporter := new(memoryExporter)
+	if err := StartExporters([]Attribute{String("service.name", "test")}, exporter); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	height := StartHeight(5, "height")
+	step := StartChild(height, "step", String("step", "propose"))
+	step.SetError(errors.New("timeout"))
+	step.Finish()
+	step.Finish() // ignored
+	height.Finish()
+
+	other := StartHeight(5, "insert")
+	other.Finish()
+
+	ctx, call := Start(context.Background(), KindServer, "eth_call")
+	_, child := Start(ctx, KindInternal, "execute")
+	child.Finish()
+	call.Finish()
+	Stop()
+
+	if Enabled() || !exporter.closed {
+		t.Fatal("tracing not stopped")
+	}
+	if len(exporter.spans) != 5 {
+		t.Fatalf("exported spans mismatch: have %d, want 5", len(exporter.spans))
+	}
+	if step.TraceID != height.TraceID || step.ParentID != height.SpanID || step.Err != "timeout" {
+		t.Errorf("step span mismatch: %+v", step)
+	}
+	if other.TraceID != height.TraceID || other.ParentID != (SpanID{}) {
+		t.Error("spans of the same height not in the same trace")
+	}
+	if child.TraceID != call.TraceID || child.ParentID != call.SpanID || call.TraceID == height.TraceID {
+		t.Error("context span not the parent")
+	}
+	if HeightTrace(5) == HeightTrace(6) {
+		t.Error("heights share a trace")
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "spans.json")
+	if err := Setup(Config{ServiceName: "autonity", File: path}); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	span := StartHeight(1, "height", Int64("round", 2), Bool("proposer", true))
+	span.SetError(errors.New("failure"))
+	span.Finish()
+	Stop()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		t.Fatal("no export request written")
+	}
+	var request otlpRequest
+	if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+		t.Fatalf("invalid export request: %v", err)
+	}
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("exported spans mismatch: have %d, want 1", len(spans))
+	}
+	have := spans[0]
+	if have.TraceID != HeightTrace(1).String() || have.Name != "height" || have.Status.Code != 2 || have.ParentSpanID != "" {
+		t.Errorf("exported span mismatch: %+v", have)
+	}
+	if len(have.Attributes) != 3 || *have.Attributes[0].Value.IntValue != "2" || !*have.Attributes[1].Value.BoolValue {
+		t.Errorf("exported attributes mismatch: %+v", have.Attributes)
+	}
+	if name := request.ResourceSpans[0].Resource.Attributes[0]; name.Key != "service.name" || *name.Value.StringValue != "autonity" {
+		t.Errorf("resource mismatch: %+v", name)
+	}
+}
+
+func TestHTTPExporter(t *testing.T) {
+	requests := make(chan otlpRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request otlpRequest
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- request
+	}))
+	defer server.Close()
+
+	exporter := NewHTTPExporter(server.URL)
+	span := &Span{Name: "call", Kind: KindServer}
+	if err := exporter.Export(nil, []*Span{span}); err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	request := <-requests
+	if have := request.ResourceSpans[0].ScopeSpans[0].Spans[0]; have.Name != "call" || have.Kind != KindServer {
+		t.Errorf("exported span mismatch: %+v", have)
+	}
+
+	failing := NewHTTPExporter("http://127.0.0.1:0")
+	if err := failing.Export(nil, []*Span{span}); err == nil {
+		t.Error("export to an unreachable endpoint succeeded")
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -221,3 +222,115 @@ func randBytes(n int) []byte {
 	crand.Read(r)
 	return r
 }
+
+// sortedEntries returns the leaves of a random trie in key order.
+func sortedEntries(n int) (*Trie, [][]byte, [][]byte) {
+	trie, vals := randomTrie(n)
+	var keys [][]byte
+	for k := range vals {
+		keys = append(keys, []byte(k))
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	values := make([][]byte, len(keys))
+	for i, k := range keys {
+		values[i] = vals[string(k)].v
+	}
+	return trie, keys, values
+}
+
+func TestRangeProof(t *testing.T) {
+	trie, keys, values := sortedEntries(4096)
+	for i := 0; i < 200; i++ {
+		start := mrand.Intn(len(keys))
+		end := start + mrand.Intn(len(keys)-start) + 1
+
+		proof := memorydb.New()
+		if err := trie.Prove(keys[start], 0, proof); err != nil {
+			t.Fatalf("failed to prove first key: %v", err)
+		}
+		if err := trie.Prove(keys[end-1], 0, proof); err != nil {
+			t.Fatalf("failed to prove last key: %v", err)
+		}
+		more, err := VerifyRangeProof(trie.Hash(), keys[start], keys[start:end], values[start:end], proof)
+		if err != nil {
+			t.Fatalf("range [%d, %d) rejected: %v", start, end, err)
+		}
+		if more != (end < len(keys)) {
+			t.Fatalf("range [%d, %d) continuation mismatch: have %v, want %v", start, end, more, end < len(keys))
+		}
+		// Dropping a leaf from within the range must be detected
+		if end-start > 2 {
+			drop := start + 1 + mrand.Intn(end-start-2)
+			k := append(append([][]byte{}, keys[start:drop]...), keys[drop+1:end]...)
+			v := append(append([][]byte{}, values[start:drop]...), values[drop+1:end]...)
+			if _, err := VerifyRangeProof(trie.Hash(), keys[start], k, v, proof); err == nil {
+				t.Fatalf("range [%d, %d) accepted without leaf %d", start, end, drop)
+			}
+		}
+	}
+}
+
+func TestRangeProofNonExistentOrigin(t *testing.T) {
+	trie, keys, values := sortedEntries(4096)
+	for i := 0; i < 100; i++ {
+		start := 1 + mrand.Intn(len(keys)-1)
+		end := start + mrand.Intn(len(keys)-start) + 1
+
+		// Pick an absent origin between the previous leaf and the first one
+		origin := common.CopyBytes(keys[start])
+		if origin[len(origin)-1] == 0 {
+			continue
+		}
+		origin[len(origin)-1]--
+		if bytes.Equal(origin, keys[start-1]) {
+			continue
+		}
+		proof := memorydb.New()
+		trie.Prove(origin, 0, proof)
+		trie.Prove(keys[end-1], 0, proof)
+		if _, err := VerifyRangeProof(trie.Hash(), origin, keys[start:end], values[start:end], proof); err != nil {
+			t.Fatalf("range [%d, %d) from absent origin rejected: %v", start, end, err)
+		}
+		// Omitting the first leaf must be detected
+		if end-start > 1 {
+			if _, err := VerifyRangeProof(trie.Hash(), origin, keys[start+1:end], values[start+1:end], proof); err == nil {
+				t.Fatalf("range [%d, %d) accepted without its first leaf", start, end)
+			}
+		}
+	}
+}
+
+func TestRangeProofEdges(t *testing.T) {
+	trie, keys, values := sortedEntries(256)
+
+	// The whole trie doesn't need any proof
+	if _, err := VerifyRangeProof(trie.Hash(), nil, keys, values, nil); err != nil {
+		t.Fatalf("whole trie rejected: %v", err)
+	}
+	if _, err := VerifyRangeProof(trie.Hash(), nil, keys[1:], values[1:], nil); err == nil {
+		t.Fatal("partial trie accepted as whole")
+	}
+	// An empty range past the last leaf proves the end of the trie
+	origin := common.CopyBytes(keys[len(keys)-1])
+	origin[len(origin)-1]++
+	proof := memorydb.New()
+	trie.Prove(origin, 0, proof)
+	if _, err := VerifyRangeProof(trie.Hash(), origin, nil, nil, proof); err != nil {
+		t.Fatalf("trie end rejected: %v", err)
+	}
+	// But not before it
+	proof = memorydb.New()
+	trie.Prove(keys[0], 0, proof)
+	if _, err := VerifyRangeProof(trie.Hash(), keys[0], nil, nil, proof); err == nil {
+		t.Fatal("empty range accepted before the trie end")
+	}
+	// Tampered values are rejected
+	proof = memorydb.New()
+	trie.Prove(keys[10], 0, proof)
+	trie.Prove(keys[20], 0, proof)
+	tampered := append([][]byte{}, values[10:21]...)
+	tampered[5] = []byte{0xde, 0xad}
+	if _, err := VerifyRangeProof(trie.Hash(), keys[10], keys[10:21], tampered, proof); err == nil {
+		t.Fatal("tampered range accepted")
+	}
+}